@@ -1,6 +1,12 @@
 package enum
 
-import "testing"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 var tests = []struct {
 	etos func(uint32) string
@@ -11,6 +17,8 @@ var tests = []struct {
 	{TypeString, GL_UNSIGNED_INT, "GL_UNSIGNED_INT"},
 	{TypeString, GL_UNSIGNED_SHORT_4_4_4_4, "GL_UNSIGNED_SHORT_4_4_4_4"},
 	{TypeString, GL_HALF_FLOAT, "GL_HALF_FLOAT"},
+	{TypeString, GL_UNSIGNED_INT_8_8_8_8, "GL_UNSIGNED_INT_8_8_8_8"},
+	{TypeString, GL_UNSIGNED_INT_10F_11F_11F_REV, "GL_UNSIGNED_INT_10F_11F_11F_REV"},
 	{TypeString, GL_RGBA, "Invalid type(0x1908)"},
 
 	{FormatString, GL_NONE, "GL_NONE"},
@@ -18,6 +26,25 @@ var tests = []struct {
 	{FormatString, GL_UNSIGNED_INT, "Invalid format(0x1405)"},
 
 	{FormatString, GL_ETC1_RGB8_OES, "GL_ETC1_RGB8_OES"},
+	{FormatString, GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, "GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT"},
+	{FormatString, GL_COMPRESSED_SIGNED_RG_RGTC2, "GL_COMPRESSED_SIGNED_RG_RGTC2"},
+	{FormatString, GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT, "GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT"},
+	{FormatString, GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG, "GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG"},
+	{FormatString, GL_LUMINANCE8, "GL_LUMINANCE8"},
+	{FormatString, GL_RGBA4, "GL_RGBA4"},
+	{FormatString, GL_RGBA_INTEGER, "GL_RGBA_INTEGER"},
+
+	// Aliases of extensions are named after the core enums
+	{InternalFormatString, GL_COMPRESSED_RGBA_BPTC_UNORM_ARB, "GL_COMPRESSED_RGBA_BPTC_UNORM"},
+	{InternalFormatString, GL_COMPRESSED_SIGNED_RED_GREEN_RGTC2_EXT, "GL_COMPRESSED_SIGNED_RG_RGTC2"},
+	{PixelFormatString, GL_BGRA_EXT, "GL_BGRA"},
+	{PixelFormatString, GL_RGBA8, "Invalid PixelFormat(0x8058)"},
+	{PixelTypeString, GL_HALF_FLOAT_OES, "GL_HALF_FLOAT_OES"},
+	{TextureTargetString, GL_TEXTURE_3D_OES, "GL_TEXTURE_3D"},
+	{TextureTargetString, GL_TEXTURE_CUBE_MAP_NEGATIVE_Z, "GL_TEXTURE_CUBE_MAP_NEGATIVE_Z"},
+	{TextureWrapModeString, GL_MIRRORED_REPEAT, "GL_MIRRORED_REPEAT"},
+	{TextureMinFilterString, GL_LINEAR_MIPMAP_NEAREST, "GL_LINEAR_MIPMAP_NEAREST"},
+	{TextureMagFilterString, GL_LINEAR_MIPMAP_NEAREST, "Invalid TextureMagFilter(0x2701)"},
 }
 
 func TestEnum(t *testing.T) {
@@ -28,3 +55,193 @@ func TestEnum(t *testing.T) {
 		}
 	}
 }
+
+func TestParseGroup(t *testing.T) {
+	tests := []struct {
+		parse func(string) (uint32, error)
+		s     string
+		e     uint32
+		err   string
+	}{
+		{ParsePixelType, "GL_UNSIGNED_SHORT_5_6_5", GL_UNSIGNED_SHORT_5_6_5, ""},
+		{ParsePixelFormat, "GL_RG_INTEGER", GL_RG_INTEGER, ""},
+		{ParseInternalFormat, "GL_COMPRESSED_RGBA_ASTC_6x6_KHR", GL_COMPRESSED_RGBA_ASTC_6x6_KHR, ""},
+		{ParseInternalFormat, "GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM_ARB", GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM, ""},
+		{ParseSizedInternalFormat, "GL_RGB9_E5", GL_RGB9_E5, ""},
+		{ParseTextureTarget, "GL_TEXTURE_2D_ARRAY", GL_TEXTURE_2D_ARRAY, ""},
+		{ParseSizedInternalFormat, "GL_RGBA", 0, "enum: invalid SizedInternalFormat name (GL_RGBA)"},
+		{ParsePixelType, "GL_RGBA", 0, "enum: invalid PixelType name (GL_RGBA)"},
+	}
+	for _, test := range tests {
+		e, err := test.parse(test.s)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+			}
+			continue
+		}
+		if err != nil || e != test.e {
+			t.Errorf("Expected (0x%X) for (%s), got (0x%X, %v)", test.e, test.s, e, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s   string
+		e   uint32
+		err string
+	}{
+		{"GL_COMPRESSED_RGBA_ASTC_6x6_KHR", GL_COMPRESSED_RGBA_ASTC_6x6_KHR, ""},
+		{"GL_UNSIGNED_INT_8_8_8_8", GL_UNSIGNED_INT_8_8_8_8, ""},
+		{"COMPRESSED_RGBA_ASTC_6x6_KHR", GL_COMPRESSED_RGBA_ASTC_6x6_KHR, ""},
+		{"RGBA_ASTC_6x6", GL_COMPRESSED_RGBA_ASTC_6x6_KHR, ""},
+		{"gl_compressed_rgba_astc_6X6_khr", GL_COMPRESSED_RGBA_ASTC_6x6_KHR, ""},
+		{"srgb8_alpha8_astc_12x12", GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x12_KHR, ""},
+		{"rgba_bptc_unorm", GL_COMPRESSED_RGBA_BPTC_UNORM, ""},
+		{"rgba_pvrtc_4bppv1", GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG, ""},
+		{"etc1_rgb8", GL_ETC1_RGB8_OES, ""},
+		// Complete names come before the short ones
+		{"rgb", GL_RGB, ""},
+		{"RGBA", GL_RGBA, ""},
+		{"GL_COMPRESSED_RGBA", GL_COMPRESSED_RGBA, ""},
+		{"GL_COMPRESED_RGBA_ASTC_6x6_KHR", 0, "enum: unknown name (GL_COMPRESED_RGBA_ASTC_6x6_KHR), did you mean GL_COMPRESSED_RGBA_ASTC_6x6_KHR?"},
+		{"RGBA_ASTC_66", 0, "did you mean GL_COMPRESSED_RGBA_ASTC_6x6?"},
+		{"GL_UNSINGED_BYTE", 0, "did you mean GL_UNSIGNED_BYTE?"},
+		{"GL_NOT_AN_ENUM", 0, "enum: unknown name (GL_NOT_AN_ENUM)"},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.s)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+			}
+			continue
+		}
+		if err != nil || e != test.e {
+			t.Errorf("Expected (0x%X) for (%s), got (0x%X, %v)", test.e, test.s, e, err)
+		}
+	}
+}
+
+func TestTypesString(t *testing.T) {
+	tests := []struct {
+		e fmt.Stringer
+		s string
+	}{
+		{Type(GL_UNSIGNED_INT_8_8_8_8), "GL_UNSIGNED_INT_8_8_8_8"},
+		{Type(GL_NONE), "GL_NONE"},
+		{Type(GL_RGBA), "Invalid type(0x1908)"},
+		{Format(GL_RGBA_INTEGER), "GL_RGBA_INTEGER"},
+		{Format(GL_NONE), "GL_NONE"},
+		{Format(GL_RGBA8), "Invalid format(0x8058)"},
+		{InternalFormat(GL_COMPRESSED_RGBA_ASTC_8x5_KHR), "GL_COMPRESSED_RGBA_ASTC_8x5_KHR"},
+		{InternalFormat(GL_COMPRESSED_RGBA_BPTC_UNORM_ARB), "GL_COMPRESSED_RGBA_BPTC_UNORM"},
+		{InternalFormat(GL_RGBA), "GL_RGBA"},
+		{InternalFormat(GL_UNSIGNED_BYTE), "Invalid internal format(0x1401)"},
+	}
+	for _, test := range tests {
+		if s := test.e.String(); s != test.s {
+			t.Errorf("Expected (%s), got (%s)", test.s, s)
+		}
+		if s := fmt.Sprintf("%v", test.e); s != test.s {
+			t.Errorf("Expected (%s) when formatted, got (%s)", test.s, s)
+		}
+	}
+}
+
+func TestTypesJSON(t *testing.T) {
+	type config struct {
+		Type           Type
+		Format         Format
+		InternalFormat InternalFormat
+	}
+	tests := []struct {
+		c    config
+		json string
+	}{
+		{config{GL_UNSIGNED_SHORT_5_6_5, GL_RGB, GL_RGB565}, `{"Type":"GL_UNSIGNED_SHORT_5_6_5","Format":"GL_RGB","InternalFormat":"GL_RGB565"}`},
+		{config{GL_NONE, GL_NONE, GL_COMPRESSED_RGBA_ASTC_6x6_KHR}, `{"Type":"GL_NONE","Format":"GL_NONE","InternalFormat":"GL_COMPRESSED_RGBA_ASTC_6x6_KHR"}`},
+		// Values without names are kept as numbers
+		{config{0x1234, GL_RGBA, 0xABCD}, `{"Type":"0x1234","Format":"GL_RGBA","InternalFormat":"0xABCD"}`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.c)
+		if err != nil || string(b) != test.json {
+			t.Errorf("Expected (%s), got (%s, %v)", test.json, b, err)
+		}
+		var c config
+		if err := json.Unmarshal([]byte(test.json), &c); err != nil || c != test.c {
+			t.Errorf("Expected (%+v) from (%s), got (%+v, %v)", test.c, test.json, c, err)
+		}
+	}
+
+	// Names are parsed as by Parse, but only within their groups
+	var c config
+	if err := json.Unmarshal([]byte(`{"Type":"unsigned_byte","Format":"rgba","InternalFormat":"RGBA_ASTC_6x6"}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (config{GL_UNSIGNED_BYTE, GL_RGBA, GL_COMPRESSED_RGBA_ASTC_6x6_KHR}); c != expected {
+		t.Errorf("Expected (%+v), got (%+v)", expected, c)
+	}
+	errorTests := []struct {
+		json string
+		err  string
+	}{
+		{`{"Type":"GL_RGBA"}`, "enum: invalid type (GL_RGBA)"},
+		{`{"Format":"GL_RGBA8"}`, "enum: invalid format (GL_RGBA8)"},
+		{`{"InternalFormat":"GL_FLOAT"}`, "enum: invalid internal format (GL_FLOAT)"},
+		{`{"InternalFormat":"0xZZ"}`, "enum: invalid internal format value (0xZZ)"},
+		{`{"InternalFormat":"GL_RGBA_ASTC_6x6_KHRR"}`, "did you mean"},
+	}
+	for _, test := range errorTests {
+		var c config
+		err := json.Unmarshal([]byte(test.json), &c)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
+
+func TestInternalFormatInfo(t *testing.T) {
+	tests := []struct {
+		f    InternalFormat
+		info FormatInfo
+	}{
+		{GL_COMPRESSED_RGBA_ASTC_8x5_KHR, FormatInfo{8, 5, 1, 16, 4, [4]int{8, 8, 8, 8}, true, false, false, false, false, GL_RGBA, nil}},
+		{GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x10_KHR, FormatInfo{12, 10, 1, 16, 4, [4]int{8, 8, 8, 8}, true, true, false, false, false, GL_RGBA, nil}},
+		{GL_COMPRESSED_RGBA_ASTC_5x4x4_OES, FormatInfo{5, 4, 4, 16, 4, [4]int{8, 8, 8, 8}, true, false, false, false, false, GL_RGBA, nil}},
+		{GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x6x6_OES, FormatInfo{6, 6, 6, 16, 4, [4]int{8, 8, 8, 8}, true, true, false, false, false, GL_RGBA, nil}},
+		{GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG, FormatInfo{8, 4, 1, 8, 3, [4]int{5, 5, 5}, true, false, false, false, false, GL_RGB, nil}},
+		{GL_COMPRESSED_SIGNED_RG11_EAC, FormatInfo{4, 4, 1, 16, 2, [4]int{11, 11}, true, false, true, false, false, GL_RG, nil}},
+		{GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT_ARB, FormatInfo{4, 4, 1, 16, 3, [4]int{16, 16, 16}, true, false, false, true, false, GL_RGB, nil}},
+		{GL_RGBA4, FormatInfo{1, 1, 1, 2, 4, [4]int{4, 4, 4, 4}, false, false, false, false, false, GL_RGBA,
+			[]TypeFormat{{GL_UNSIGNED_BYTE, GL_RGBA}, {GL_UNSIGNED_SHORT_4_4_4_4, GL_RGBA}}}},
+		{GL_SRGB8_ALPHA8, FormatInfo{1, 1, 1, 4, 4, [4]int{8, 8, 8, 8}, false, true, false, false, false, GL_RGBA,
+			[]TypeFormat{{GL_UNSIGNED_BYTE, GL_RGBA}}}},
+		{GL_RG16I, FormatInfo{1, 1, 1, 4, 2, [4]int{16, 16}, false, false, true, false, true, GL_RG,
+			[]TypeFormat{{GL_SHORT, GL_RG_INTEGER}}}},
+		{GL_RGB, FormatInfo{1, 1, 1, 0, 3, [4]int{}, false, false, false, false, false, GL_RGB,
+			[]TypeFormat{{GL_UNSIGNED_BYTE, GL_RGB}, {GL_UNSIGNED_SHORT_5_6_5, GL_RGB}}}},
+	}
+	for _, test := range tests {
+		info, ok := InternalFormatInfo(test.f)
+		if !ok {
+			t.Errorf("Expected information of %v", test.f)
+		} else if !reflect.DeepEqual(info, test.info) {
+			t.Errorf("Expected %v of %v, got %v", test.info, test.f, info)
+		}
+	}
+
+	for _, f := range []InternalFormat{GL_COMPRESSED_RGBA, GL_UNSIGNED_BYTE} {
+		if _, ok := InternalFormatInfo(f); ok {
+			t.Errorf("Expected no information of %v", f)
+		}
+	}
+	// Every ASTC format has information
+	for f := InternalFormat(0); f < 0x10000; f++ {
+		if _, ok := InternalFormatInfo(f); IsASTC(f) && !ok {
+			t.Errorf("Expected information of %v", f)
+		}
+	}
+}
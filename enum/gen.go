@@ -0,0 +1,293 @@
+//go:build ignore
+
+// gen generates gl.go from the Khronos registry gl.xml. It keeps the enums
+// required by OpenGL, OpenGL ES 2.0 and later, and their extensions, and
+// writes the constants and their names, then the names of the enums of the
+// kept groups with their string and parse functions.
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	input  = flag.String("input", "gl.xml", "registry to read")
+	output = flag.String("output", "gl.go", "file to write")
+)
+
+// APIs whose enums are kept, as named in the features and the extensions.
+var apis = map[string]bool{"gl": true, "glcore": true, "gles2": true}
+
+// Groups whose names and parse functions are generated, out of the hundreds
+// of the registry. Every enum of the kept APIs has a constant either way.
+var keptGroups = map[string]bool{
+	"InternalFormat":       true,
+	"PixelFormat":          true,
+	"PixelType":            true,
+	"SizedInternalFormat":  true,
+	"TextureMagFilter":     true,
+	"TextureMinFilter":     true,
+	"TextureParameterName": true,
+	"TextureTarget":        true,
+	"TextureWrapMode":      true,
+}
+
+type registry struct {
+	Enums      []enums     `xml:"enums"`
+	Groups     []group     `xml:"groups>group"`
+	Features   []feature   `xml:"feature"`
+	Extensions []extension `xml:"extensions>extension"`
+}
+
+type enums struct {
+	Enums []enum `xml:"enum"`
+}
+
+type enum struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	API   string `xml:"api,attr"`
+	Group string `xml:"group,attr"`
+}
+
+// group is the membership of the older registries, which list the enums
+// of each group instead of the groups of each enum.
+type group struct {
+	Name  string    `xml:"name,attr"`
+	Enums []nameRef `xml:"enum"`
+}
+
+type nameRef struct {
+	Name string `xml:"name,attr"`
+}
+
+type feature struct {
+	API     string    `xml:"api,attr"`
+	Require []require `xml:"require"`
+}
+
+type extension struct {
+	Supported string    `xml:"supported,attr"`
+	Require   []require `xml:"require"`
+}
+
+type require struct {
+	API   string    `xml:"api,attr"`
+	Enums []nameRef `xml:"enum"`
+}
+
+type constant struct {
+	Name  string
+	Value uint64
+}
+
+type groupData struct {
+	Name    string
+	Var     string
+	Strings []constant
+	Values  []constant
+}
+
+// requiredEnums adds the names of the enums of the requirements matching the
+// kept APIs to names.
+func requiredEnums(names map[string]bool, requires []require) {
+	for _, r := range requires {
+		if r.API != "" && !apis[r.API] {
+			continue
+		}
+		for _, e := range r.Enums {
+			names[e.Name] = true
+		}
+	}
+}
+
+func supported(s string) bool {
+	for _, api := range strings.Split(s, "|") {
+		if apis[api] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseValue parses the value of an enum. The few negative values, such as
+// the one of GL_NEXT_BUFFER_NV, are kept as their 32-bit two's complement,
+// as passed to a GLint parameter.
+func parseValue(s string) (uint64, error) {
+	if strings.HasPrefix(s, "-") {
+		v, err := strconv.ParseInt(s, 0, 32)
+		return uint64(uint32(v)), err
+	}
+	return strconv.ParseUint(s, 0, 64)
+}
+
+func generate(reg *registry) ([]byte, error) {
+	// Enums of the core versions, whose names are preferred over the ones
+	// of the extensions
+	core := make(map[string]bool)
+	for _, f := range reg.Features {
+		if apis[f.API] {
+			requiredEnums(core, f.Require)
+		}
+	}
+	required := make(map[string]bool)
+	for name := range core {
+		required[name] = true
+	}
+	for _, ext := range reg.Extensions {
+		if supported(ext.Supported) {
+			requiredEnums(required, ext.Require)
+		}
+	}
+
+	var constants []constant
+	seen := make(map[string]bool)
+	members := make(map[string][]constant)
+	for _, block := range reg.Enums {
+		for _, e := range block.Enums {
+			if (e.API != "" && !apis[e.API]) || !required[e.Name] || seen[e.Name] {
+				continue
+			}
+			v, err := parseValue(e.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s [%v]", e.Name, err)
+			}
+			seen[e.Name] = true
+			c := constant{e.Name, v}
+			constants = append(constants, c)
+			if e.Group == "" || v > math.MaxUint32 {
+				continue
+			}
+			for _, g := range strings.Split(e.Group, ",") {
+				if keptGroups[g] {
+					members[g] = append(members[g], c)
+				}
+			}
+		}
+	}
+	values := make(map[string]uint64)
+	for _, c := range constants {
+		values[c.Name] = c.Value
+	}
+	for _, g := range reg.Groups {
+		if !keptGroups[g.Name] {
+			continue
+		}
+		for _, e := range g.Enums {
+			if v, ok := values[e.Name]; ok && v <= math.MaxUint32 {
+				members[g.Name] = append(members[g.Name], constant{e.Name, v})
+			}
+		}
+	}
+
+	var groups []groupData
+	for name, cs := range members {
+		g := groupData{Name: name, Var: strings.ToLower(name[:1]) + name[1:]}
+		names := make(map[string]bool)
+		preferred := make(map[uint64]string)
+		for _, c := range cs {
+			if names[c.Name] {
+				continue
+			}
+			names[c.Name] = true
+			g.Values = append(g.Values, c)
+			if p, ok := preferred[c.Value]; !ok || (core[c.Name] && !core[p]) {
+				preferred[c.Value] = c.Name
+			}
+		}
+		for v, name := range preferred {
+			g.Strings = append(g.Strings, constant{name, v})
+		}
+		sort.Slice(g.Strings, func(i, j int) bool { return g.Strings[i].Value < g.Strings[j].Value })
+		sort.Slice(g.Values, func(i, j int) bool { return g.Values[i].Name < g.Values[j].Name })
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Constants []constant
+		Groups    []groupData
+	}{constants, groups})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+var tmpl = template.Must(template.New("gl").Parse(`// Code generated by gen.go from gl.xml; DO NOT EDIT.
+
+package enum
+
+import "fmt"
+
+const (
+{{- range .Constants}}
+	{{.Name}} = {{printf "0x%08X" .Value}}
+{{- end}}
+)
+
+var enumValues = map[string]uint32{
+{{- range .Constants}}{{if le .Value 0xFFFFFFFF}}
+	"{{.Name}}": {{.Name}},
+{{- end}}{{end}}
+}
+{{range .Groups}}
+var {{.Var}}Strings = map[uint32]string{
+{{- range .Strings}}
+	{{.Name}}: "{{.Name}}",
+{{- end}}
+}
+
+var {{.Var}}Values = map[string]uint32{
+{{- range .Values}}
+	"{{.Name}}": {{.Name}},
+{{- end}}
+}
+
+// {{.Name}}String returns the name of an enum of the {{.Name}} group.
+func {{.Name}}String(e uint32) string {
+	if s, ok := {{.Var}}Strings[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("Invalid {{.Name}}(0x%X)", e)
+}
+
+// Parse{{.Name}} returns the value of the enum of the {{.Name}} group named s.
+func Parse{{.Name}}(s string) (uint32, error) {
+	if e, ok := {{.Var}}Values[s]; ok {
+		return e, nil
+	}
+	return 0, fmt.Errorf("enum: invalid {{.Name}} name (%s)", s)
+}
+{{end}}`))
+
+func main() {
+	flag.Parse()
+	data, err := ioutil.ReadFile(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var reg registry
+	if err := xml.Unmarshal(data, &reg); err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(&reg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
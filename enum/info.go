@@ -0,0 +1,304 @@
+package enum
+
+// TypeFormat is a combination of pixel type and format valid to upload the
+// pixels of an internal format.
+type TypeFormat struct {
+	Type   Type
+	Format Format
+}
+
+// FormatInfo describes how the pixels of an internal format are stored.
+type FormatInfo struct {
+	// Footprint of the blocks in pixels, 1x1x1 for uncompressed formats
+	BlockWidth, BlockHeight, BlockDepth int
+	// BytesPerBlock is the size of the blocks, or of the pixels of
+	// uncompressed formats with their most compact type. It is 0 for unsized
+	// formats, whose size depends on the type.
+	BytesPerBlock int
+	Channels      int
+	// Bits of the channels in the order of the base format, 0 when unsized
+	Bits [4]int
+
+	Compressed bool
+	SRGB       bool
+	// Signed is set for the signed normalized, integer and float formats
+	Signed bool
+	Float  bool
+	// Integer is set for the formats read as integers by shaders
+	Integer bool
+
+	BaseInternalFormat InternalFormat
+	// Combinations of pixel type and format of uncompressed formats
+	TypeFormats []TypeFormat
+}
+
+// Flags of the formats in the table.
+const (
+	fSRGB = 1 << iota
+	fSigned
+	fFloat
+	fInteger
+)
+
+func (info *FormatInfo) setFlags(flags int) {
+	info.SRGB = flags&fSRGB != 0
+	info.Signed = flags&fSigned != 0
+	info.Float = flags&fFloat != 0
+	info.Integer = flags&fInteger != 0
+}
+
+func tf(t Type, f Format) TypeFormat {
+	return TypeFormat{t, f}
+}
+
+// uncompressed returns the information of an uncompressed format of the given
+// bytes per pixel, whose channels have the given bits.
+func uncompressed(base InternalFormat, channels, bytes int, bits [4]int, flags int, typeFormats ...TypeFormat) FormatInfo {
+	info := FormatInfo{BlockWidth: 1, BlockHeight: 1, BlockDepth: 1, BytesPerBlock: bytes, Channels: channels, Bits: bits}
+	info.setFlags(flags)
+	info.BaseInternalFormat = base
+	info.TypeFormats = typeFormats
+	return info
+}
+
+// compressed returns the information of a compressed format of blocks of
+// blockWidth x blockHeight pixels.
+func compressed(base InternalFormat, blockWidth, blockHeight, bytes int, channels int, bits [4]int, flags int) FormatInfo {
+	info := FormatInfo{BlockWidth: blockWidth, BlockHeight: blockHeight, BlockDepth: 1, BytesPerBlock: bytes, Channels: channels, Bits: bits}
+	info.setFlags(flags)
+	info.Compressed = true
+	info.BaseInternalFormat = base
+	return info
+}
+
+// astc returns the information of an ASTC format with the given footprint.
+func astc(blockWidth, blockHeight int, flags int) FormatInfo {
+	return compressed(GL_RGBA, blockWidth, blockHeight, 16, 4, [4]int{8, 8, 8, 8}, flags)
+}
+
+// astc3D returns the information of a 3D ASTC format with the given footprint.
+func astc3D(blockWidth, blockHeight, blockDepth int, flags int) FormatInfo {
+	info := astc(blockWidth, blockHeight, flags)
+	info.BlockDepth = blockDepth
+	return info
+}
+
+var (
+	bits8    = [4]int{8}
+	bits16   = [4]int{16}
+	bits32   = [4]int{32}
+	bits8x2  = [4]int{8, 8}
+	bits16x2 = [4]int{16, 16}
+	bits32x2 = [4]int{32, 32}
+	bits8x3  = [4]int{8, 8, 8}
+	bits16x3 = [4]int{16, 16, 16}
+	bits32x3 = [4]int{32, 32, 32}
+	bits8x4  = [4]int{8, 8, 8, 8}
+	bits16x4 = [4]int{16, 16, 16, 16}
+	bits32x4 = [4]int{32, 32, 32, 32}
+)
+
+var formatInfos = map[InternalFormat]FormatInfo{
+	// Unsized formats
+	GL_ALPHA:           uncompressed(GL_ALPHA, 1, 0, [4]int{}, 0, tf(GL_UNSIGNED_BYTE, GL_ALPHA)),
+	GL_LUMINANCE:       uncompressed(GL_LUMINANCE, 1, 0, [4]int{}, 0, tf(GL_UNSIGNED_BYTE, GL_LUMINANCE)),
+	GL_LUMINANCE_ALPHA: uncompressed(GL_LUMINANCE_ALPHA, 2, 0, [4]int{}, 0, tf(GL_UNSIGNED_BYTE, GL_LUMINANCE_ALPHA)),
+	GL_RED:             uncompressed(GL_RED, 1, 0, [4]int{}, 0, tf(GL_UNSIGNED_BYTE, GL_RED)),
+	GL_RG:              uncompressed(GL_RG, 2, 0, [4]int{}, 0, tf(GL_UNSIGNED_BYTE, GL_RG)),
+	GL_RGB: uncompressed(GL_RGB, 3, 0, [4]int{}, 0,
+		tf(GL_UNSIGNED_BYTE, GL_RGB), tf(GL_UNSIGNED_SHORT_5_6_5, GL_RGB)),
+	GL_RGBA: uncompressed(GL_RGBA, 4, 0, [4]int{}, 0,
+		tf(GL_UNSIGNED_BYTE, GL_RGBA), tf(GL_UNSIGNED_SHORT_4_4_4_4, GL_RGBA), tf(GL_UNSIGNED_SHORT_5_5_5_1, GL_RGBA)),
+	GL_SRGB:       uncompressed(GL_RGB, 3, 0, [4]int{}, fSRGB, tf(GL_UNSIGNED_BYTE, GL_RGB)),
+	GL_SRGB_ALPHA: uncompressed(GL_RGBA, 4, 0, [4]int{}, fSRGB, tf(GL_UNSIGNED_BYTE, GL_RGBA)),
+	GL_DEPTH_COMPONENT: uncompressed(GL_DEPTH_COMPONENT, 1, 0, [4]int{}, 0,
+		tf(GL_UNSIGNED_SHORT, GL_DEPTH_COMPONENT), tf(GL_UNSIGNED_INT, GL_DEPTH_COMPONENT)),
+	GL_DEPTH_STENCIL: uncompressed(GL_DEPTH_STENCIL, 2, 0, [4]int{}, 0, tf(GL_UNSIGNED_INT_24_8, GL_DEPTH_STENCIL)),
+
+	// Sized formats of 1 channel
+	GL_ALPHA8:     uncompressed(GL_ALPHA, 1, 1, bits8, 0, tf(GL_UNSIGNED_BYTE, GL_ALPHA)),
+	GL_LUMINANCE8: uncompressed(GL_LUMINANCE, 1, 1, bits8, 0, tf(GL_UNSIGNED_BYTE, GL_LUMINANCE)),
+	GL_R8:         uncompressed(GL_RED, 1, 1, bits8, 0, tf(GL_UNSIGNED_BYTE, GL_RED)),
+	GL_R8_SNORM:   uncompressed(GL_RED, 1, 1, bits8, fSigned, tf(GL_BYTE, GL_RED)),
+	GL_R16:        uncompressed(GL_RED, 1, 2, bits16, 0, tf(GL_UNSIGNED_SHORT, GL_RED)),
+	GL_R16_SNORM:  uncompressed(GL_RED, 1, 2, bits16, fSigned, tf(GL_SHORT, GL_RED)),
+	GL_R16F:       uncompressed(GL_RED, 1, 2, bits16, fSigned|fFloat, tf(GL_HALF_FLOAT, GL_RED), tf(GL_FLOAT, GL_RED)),
+	GL_R32F:       uncompressed(GL_RED, 1, 4, bits32, fSigned|fFloat, tf(GL_FLOAT, GL_RED)),
+	GL_R8UI:       uncompressed(GL_RED, 1, 1, bits8, fInteger, tf(GL_UNSIGNED_BYTE, GL_RED_INTEGER)),
+	GL_R8I:        uncompressed(GL_RED, 1, 1, bits8, fSigned|fInteger, tf(GL_BYTE, GL_RED_INTEGER)),
+	GL_R16UI:      uncompressed(GL_RED, 1, 2, bits16, fInteger, tf(GL_UNSIGNED_SHORT, GL_RED_INTEGER)),
+	GL_R16I:       uncompressed(GL_RED, 1, 2, bits16, fSigned|fInteger, tf(GL_SHORT, GL_RED_INTEGER)),
+	GL_R32UI:      uncompressed(GL_RED, 1, 4, bits32, fInteger, tf(GL_UNSIGNED_INT, GL_RED_INTEGER)),
+	GL_R32I:       uncompressed(GL_RED, 1, 4, bits32, fSigned|fInteger, tf(GL_INT, GL_RED_INTEGER)),
+
+	// Sized formats of 2 channels
+	GL_LUMINANCE8_ALPHA8: uncompressed(GL_LUMINANCE_ALPHA, 2, 2, bits8x2, 0, tf(GL_UNSIGNED_BYTE, GL_LUMINANCE_ALPHA)),
+	GL_RG8:               uncompressed(GL_RG, 2, 2, bits8x2, 0, tf(GL_UNSIGNED_BYTE, GL_RG)),
+	GL_RG8_SNORM:         uncompressed(GL_RG, 2, 2, bits8x2, fSigned, tf(GL_BYTE, GL_RG)),
+	GL_RG16:              uncompressed(GL_RG, 2, 4, bits16x2, 0, tf(GL_UNSIGNED_SHORT, GL_RG)),
+	GL_RG16_SNORM:        uncompressed(GL_RG, 2, 4, bits16x2, fSigned, tf(GL_SHORT, GL_RG)),
+	GL_RG16F:             uncompressed(GL_RG, 2, 4, bits16x2, fSigned|fFloat, tf(GL_HALF_FLOAT, GL_RG), tf(GL_FLOAT, GL_RG)),
+	GL_RG32F:             uncompressed(GL_RG, 2, 8, bits32x2, fSigned|fFloat, tf(GL_FLOAT, GL_RG)),
+	GL_RG8UI:             uncompressed(GL_RG, 2, 2, bits8x2, fInteger, tf(GL_UNSIGNED_BYTE, GL_RG_INTEGER)),
+	GL_RG8I:              uncompressed(GL_RG, 2, 2, bits8x2, fSigned|fInteger, tf(GL_BYTE, GL_RG_INTEGER)),
+	GL_RG16UI:            uncompressed(GL_RG, 2, 4, bits16x2, fInteger, tf(GL_UNSIGNED_SHORT, GL_RG_INTEGER)),
+	GL_RG16I:             uncompressed(GL_RG, 2, 4, bits16x2, fSigned|fInteger, tf(GL_SHORT, GL_RG_INTEGER)),
+	GL_RG32UI:            uncompressed(GL_RG, 2, 8, bits32x2, fInteger, tf(GL_UNSIGNED_INT, GL_RG_INTEGER)),
+	GL_RG32I:             uncompressed(GL_RG, 2, 8, bits32x2, fSigned|fInteger, tf(GL_INT, GL_RG_INTEGER)),
+
+	// Sized formats of 3 channels
+	GL_R3_G3_B2: uncompressed(GL_RGB, 3, 1, [4]int{3, 3, 2}, 0,
+		tf(GL_UNSIGNED_BYTE_3_3_2, GL_RGB), tf(GL_UNSIGNED_BYTE_2_3_3_REV, GL_RGB)),
+	GL_RGB565: uncompressed(GL_RGB, 3, 2, [4]int{5, 6, 5}, 0,
+		tf(GL_UNSIGNED_BYTE, GL_RGB), tf(GL_UNSIGNED_SHORT_5_6_5, GL_RGB)),
+	GL_RGB8:        uncompressed(GL_RGB, 3, 3, bits8x3, 0, tf(GL_UNSIGNED_BYTE, GL_RGB)),
+	GL_SRGB8:       uncompressed(GL_RGB, 3, 3, bits8x3, fSRGB, tf(GL_UNSIGNED_BYTE, GL_RGB)),
+	GL_RGB8_SNORM:  uncompressed(GL_RGB, 3, 3, bits8x3, fSigned, tf(GL_BYTE, GL_RGB)),
+	GL_RGB16:       uncompressed(GL_RGB, 3, 6, bits16x3, 0, tf(GL_UNSIGNED_SHORT, GL_RGB)),
+	GL_RGB16_SNORM: uncompressed(GL_RGB, 3, 6, bits16x3, fSigned, tf(GL_SHORT, GL_RGB)),
+	GL_R11F_G11F_B10F: uncompressed(GL_RGB, 3, 4, [4]int{11, 11, 10}, fFloat,
+		tf(GL_UNSIGNED_INT_10F_11F_11F_REV, GL_RGB), tf(GL_HALF_FLOAT, GL_RGB), tf(GL_FLOAT, GL_RGB)),
+	GL_RGB9_E5: uncompressed(GL_RGB, 3, 4, [4]int{9, 9, 9}, fFloat,
+		tf(GL_UNSIGNED_INT_5_9_9_9_REV, GL_RGB), tf(GL_HALF_FLOAT, GL_RGB), tf(GL_FLOAT, GL_RGB)),
+	GL_RGB16F:  uncompressed(GL_RGB, 3, 6, bits16x3, fSigned|fFloat, tf(GL_HALF_FLOAT, GL_RGB), tf(GL_FLOAT, GL_RGB)),
+	GL_RGB32F:  uncompressed(GL_RGB, 3, 12, bits32x3, fSigned|fFloat, tf(GL_FLOAT, GL_RGB)),
+	GL_RGB8UI:  uncompressed(GL_RGB, 3, 3, bits8x3, fInteger, tf(GL_UNSIGNED_BYTE, GL_RGB_INTEGER)),
+	GL_RGB8I:   uncompressed(GL_RGB, 3, 3, bits8x3, fSigned|fInteger, tf(GL_BYTE, GL_RGB_INTEGER)),
+	GL_RGB16UI: uncompressed(GL_RGB, 3, 6, bits16x3, fInteger, tf(GL_UNSIGNED_SHORT, GL_RGB_INTEGER)),
+	GL_RGB16I:  uncompressed(GL_RGB, 3, 6, bits16x3, fSigned|fInteger, tf(GL_SHORT, GL_RGB_INTEGER)),
+	GL_RGB32UI: uncompressed(GL_RGB, 3, 12, bits32x3, fInteger, tf(GL_UNSIGNED_INT, GL_RGB_INTEGER)),
+	GL_RGB32I:  uncompressed(GL_RGB, 3, 12, bits32x3, fSigned|fInteger, tf(GL_INT, GL_RGB_INTEGER)),
+
+	// Sized formats of 4 channels
+	GL_RGBA4: uncompressed(GL_RGBA, 4, 2, [4]int{4, 4, 4, 4}, 0,
+		tf(GL_UNSIGNED_BYTE, GL_RGBA), tf(GL_UNSIGNED_SHORT_4_4_4_4, GL_RGBA)),
+	GL_RGB5_A1: uncompressed(GL_RGBA, 4, 2, [4]int{5, 5, 5, 1}, 0,
+		tf(GL_UNSIGNED_BYTE, GL_RGBA), tf(GL_UNSIGNED_SHORT_5_5_5_1, GL_RGBA), tf(GL_UNSIGNED_INT_2_10_10_10_REV, GL_RGBA)),
+	GL_RGBA8:        uncompressed(GL_RGBA, 4, 4, bits8x4, 0, tf(GL_UNSIGNED_BYTE, GL_RGBA)),
+	GL_SRGB8_ALPHA8: uncompressed(GL_RGBA, 4, 4, bits8x4, fSRGB, tf(GL_UNSIGNED_BYTE, GL_RGBA)),
+	GL_RGBA8_SNORM:  uncompressed(GL_RGBA, 4, 4, bits8x4, fSigned, tf(GL_BYTE, GL_RGBA)),
+	GL_RGB10_A2:     uncompressed(GL_RGBA, 4, 4, [4]int{10, 10, 10, 2}, 0, tf(GL_UNSIGNED_INT_2_10_10_10_REV, GL_RGBA)),
+	GL_RGBA16:       uncompressed(GL_RGBA, 4, 8, bits16x4, 0, tf(GL_UNSIGNED_SHORT, GL_RGBA)),
+	GL_RGBA16_SNORM: uncompressed(GL_RGBA, 4, 8, bits16x4, fSigned, tf(GL_SHORT, GL_RGBA)),
+	GL_RGBA16F:      uncompressed(GL_RGBA, 4, 8, bits16x4, fSigned|fFloat, tf(GL_HALF_FLOAT, GL_RGBA), tf(GL_FLOAT, GL_RGBA)),
+	GL_RGBA32F:      uncompressed(GL_RGBA, 4, 16, bits32x4, fSigned|fFloat, tf(GL_FLOAT, GL_RGBA)),
+	GL_RGBA8UI:      uncompressed(GL_RGBA, 4, 4, bits8x4, fInteger, tf(GL_UNSIGNED_BYTE, GL_RGBA_INTEGER)),
+	GL_RGBA8I:       uncompressed(GL_RGBA, 4, 4, bits8x4, fSigned|fInteger, tf(GL_BYTE, GL_RGBA_INTEGER)),
+	GL_RGB10_A2UI:   uncompressed(GL_RGBA, 4, 4, [4]int{10, 10, 10, 2}, fInteger, tf(GL_UNSIGNED_INT_2_10_10_10_REV, GL_RGBA_INTEGER)),
+	GL_RGBA16UI:     uncompressed(GL_RGBA, 4, 8, bits16x4, fInteger, tf(GL_UNSIGNED_SHORT, GL_RGBA_INTEGER)),
+	GL_RGBA16I:      uncompressed(GL_RGBA, 4, 8, bits16x4, fSigned|fInteger, tf(GL_SHORT, GL_RGBA_INTEGER)),
+	GL_RGBA32UI:     uncompressed(GL_RGBA, 4, 16, bits32x4, fInteger, tf(GL_UNSIGNED_INT, GL_RGBA_INTEGER)),
+	GL_RGBA32I:      uncompressed(GL_RGBA, 4, 16, bits32x4, fSigned|fInteger, tf(GL_INT, GL_RGBA_INTEGER)),
+
+	// Depth and stencil formats
+	GL_DEPTH_COMPONENT16: uncompressed(GL_DEPTH_COMPONENT, 1, 2, bits16, 0,
+		tf(GL_UNSIGNED_SHORT, GL_DEPTH_COMPONENT), tf(GL_UNSIGNED_INT, GL_DEPTH_COMPONENT)),
+	GL_DEPTH_COMPONENT24:  uncompressed(GL_DEPTH_COMPONENT, 1, 4, [4]int{24}, 0, tf(GL_UNSIGNED_INT, GL_DEPTH_COMPONENT)),
+	GL_DEPTH_COMPONENT32:  uncompressed(GL_DEPTH_COMPONENT, 1, 4, bits32, 0, tf(GL_UNSIGNED_INT, GL_DEPTH_COMPONENT)),
+	GL_DEPTH_COMPONENT32F: uncompressed(GL_DEPTH_COMPONENT, 1, 4, bits32, fFloat, tf(GL_FLOAT, GL_DEPTH_COMPONENT)),
+	GL_DEPTH24_STENCIL8:   uncompressed(GL_DEPTH_STENCIL, 2, 4, [4]int{24, 8}, 0, tf(GL_UNSIGNED_INT_24_8, GL_DEPTH_STENCIL)),
+	GL_DEPTH32F_STENCIL8: uncompressed(GL_DEPTH_STENCIL, 2, 8, [4]int{32, 8}, fFloat,
+		tf(GL_FLOAT_32_UNSIGNED_INT_24_8_REV, GL_DEPTH_STENCIL)),
+	GL_STENCIL_INDEX8: uncompressed(GL_STENCIL_INDEX, 1, 1, bits8, fInteger, tf(GL_UNSIGNED_BYTE, GL_STENCIL_INDEX)),
+
+	// ETC and EAC
+	GL_ETC1_RGB8_OES:                             compressed(GL_RGB, 4, 4, 8, 3, bits8x3, 0),
+	GL_COMPRESSED_RGB8_ETC2:                      compressed(GL_RGB, 4, 4, 8, 3, bits8x3, 0),
+	GL_COMPRESSED_SRGB8_ETC2:                     compressed(GL_RGB, 4, 4, 8, 3, bits8x3, fSRGB),
+	GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2:  compressed(GL_RGBA, 4, 4, 8, 4, [4]int{8, 8, 8, 1}, 0),
+	GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2: compressed(GL_RGBA, 4, 4, 8, 4, [4]int{8, 8, 8, 1}, fSRGB),
+	GL_COMPRESSED_RGBA8_ETC2_EAC:                 compressed(GL_RGBA, 4, 4, 16, 4, bits8x4, 0),
+	GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC:          compressed(GL_RGBA, 4, 4, 16, 4, bits8x4, fSRGB),
+	GL_COMPRESSED_R11_EAC:                        compressed(GL_RED, 4, 4, 8, 1, [4]int{11}, 0),
+	GL_COMPRESSED_SIGNED_R11_EAC:                 compressed(GL_RED, 4, 4, 8, 1, [4]int{11}, fSigned),
+	GL_COMPRESSED_RG11_EAC:                       compressed(GL_RG, 4, 4, 16, 2, [4]int{11, 11}, 0),
+	GL_COMPRESSED_SIGNED_RG11_EAC:                compressed(GL_RG, 4, 4, 16, 2, [4]int{11, 11}, fSigned),
+
+	// S3TC, RGTC and BPTC
+	GL_COMPRESSED_RGB_S3TC_DXT1_EXT:        compressed(GL_RGB, 4, 4, 8, 3, [4]int{5, 6, 5}, 0),
+	GL_COMPRESSED_RGBA_S3TC_DXT1_EXT:       compressed(GL_RGBA, 4, 4, 8, 4, [4]int{5, 6, 5, 1}, 0),
+	GL_COMPRESSED_RGBA_S3TC_DXT3_EXT:       compressed(GL_RGBA, 4, 4, 16, 4, [4]int{5, 6, 5, 4}, 0),
+	GL_COMPRESSED_RGBA_S3TC_DXT5_EXT:       compressed(GL_RGBA, 4, 4, 16, 4, [4]int{5, 6, 5, 8}, 0),
+	GL_COMPRESSED_SRGB_S3TC_DXT1_EXT:       compressed(GL_RGB, 4, 4, 8, 3, [4]int{5, 6, 5}, fSRGB),
+	GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT: compressed(GL_RGBA, 4, 4, 8, 4, [4]int{5, 6, 5, 1}, fSRGB),
+	GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT: compressed(GL_RGBA, 4, 4, 16, 4, [4]int{5, 6, 5, 4}, fSRGB),
+	GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT: compressed(GL_RGBA, 4, 4, 16, 4, [4]int{5, 6, 5, 8}, fSRGB),
+	GL_COMPRESSED_RED_RGTC1:                compressed(GL_RED, 4, 4, 8, 1, bits8, 0),
+	GL_COMPRESSED_SIGNED_RED_RGTC1:         compressed(GL_RED, 4, 4, 8, 1, bits8, fSigned),
+	GL_COMPRESSED_RG_RGTC2:                 compressed(GL_RG, 4, 4, 16, 2, bits8x2, 0),
+	GL_COMPRESSED_SIGNED_RG_RGTC2:          compressed(GL_RG, 4, 4, 16, 2, bits8x2, fSigned),
+	GL_COMPRESSED_RGBA_BPTC_UNORM:          compressed(GL_RGBA, 4, 4, 16, 4, bits8x4, 0),
+	GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM:    compressed(GL_RGBA, 4, 4, 16, 4, bits8x4, fSRGB),
+	GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT:    compressed(GL_RGB, 4, 4, 16, 3, bits16x3, fSigned|fFloat),
+	GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT:  compressed(GL_RGB, 4, 4, 16, 3, bits16x3, fFloat),
+
+	// PVRTC, whose textures have at least 2x2 blocks
+	GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG:  compressed(GL_RGB, 4, 4, 8, 3, [4]int{5, 5, 5}, 0),
+	GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG:  compressed(GL_RGB, 8, 4, 8, 3, [4]int{5, 5, 5}, 0),
+	GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG: compressed(GL_RGBA, 4, 4, 8, 4, [4]int{5, 5, 5, 4}, 0),
+	GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG: compressed(GL_RGBA, 8, 4, 8, 4, [4]int{5, 5, 5, 4}, 0),
+
+	// ASTC
+	GL_COMPRESSED_RGBA_ASTC_4x4_KHR:           astc(4, 4, 0),
+	GL_COMPRESSED_RGBA_ASTC_5x4_KHR:           astc(5, 4, 0),
+	GL_COMPRESSED_RGBA_ASTC_5x5_KHR:           astc(5, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_6x5_KHR:           astc(6, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_6x6_KHR:           astc(6, 6, 0),
+	GL_COMPRESSED_RGBA_ASTC_8x5_KHR:           astc(8, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_8x6_KHR:           astc(8, 6, 0),
+	GL_COMPRESSED_RGBA_ASTC_8x8_KHR:           astc(8, 8, 0),
+	GL_COMPRESSED_RGBA_ASTC_10x5_KHR:          astc(10, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_10x6_KHR:          astc(10, 6, 0),
+	GL_COMPRESSED_RGBA_ASTC_10x8_KHR:          astc(10, 8, 0),
+	GL_COMPRESSED_RGBA_ASTC_10x10_KHR:         astc(10, 10, 0),
+	GL_COMPRESSED_RGBA_ASTC_12x10_KHR:         astc(12, 10, 0),
+	GL_COMPRESSED_RGBA_ASTC_12x12_KHR:         astc(12, 12, 0),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR:   astc(4, 4, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x4_KHR:   astc(5, 4, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x5_KHR:   astc(5, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x5_KHR:   astc(6, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x6_KHR:   astc(6, 6, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_8x5_KHR:   astc(8, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_8x6_KHR:   astc(8, 6, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_8x8_KHR:   astc(8, 8, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x5_KHR:  astc(10, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x6_KHR:  astc(10, 6, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x8_KHR:  astc(10, 8, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x10_KHR: astc(10, 10, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x10_KHR: astc(12, 10, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x12_KHR: astc(12, 12, fSRGB),
+	GL_COMPRESSED_RGBA_ASTC_3x3x3_OES:         astc3D(3, 3, 3, 0),
+	GL_COMPRESSED_RGBA_ASTC_4x3x3_OES:         astc3D(4, 3, 3, 0),
+	GL_COMPRESSED_RGBA_ASTC_4x4x3_OES:         astc3D(4, 4, 3, 0),
+	GL_COMPRESSED_RGBA_ASTC_4x4x4_OES:         astc3D(4, 4, 4, 0),
+	GL_COMPRESSED_RGBA_ASTC_5x4x4_OES:         astc3D(5, 4, 4, 0),
+	GL_COMPRESSED_RGBA_ASTC_5x5x4_OES:         astc3D(5, 5, 4, 0),
+	GL_COMPRESSED_RGBA_ASTC_5x5x5_OES:         astc3D(5, 5, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_6x5x5_OES:         astc3D(6, 5, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_6x6x5_OES:         astc3D(6, 6, 5, 0),
+	GL_COMPRESSED_RGBA_ASTC_6x6x6_OES:         astc3D(6, 6, 6, 0),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_3x3x3_OES: astc3D(3, 3, 3, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x3x3_OES: astc3D(4, 3, 3, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x4x3_OES: astc3D(4, 4, 3, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x4x4_OES: astc3D(4, 4, 4, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x4x4_OES: astc3D(5, 4, 4, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x5x4_OES: astc3D(5, 5, 4, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x5x5_OES: astc3D(5, 5, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x5x5_OES: astc3D(6, 5, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x6x5_OES: astc3D(6, 6, 5, fSRGB),
+	GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x6x6_OES: astc3D(6, 6, 6, fSRGB),
+}
+
+// InternalFormatInfo returns the information of the internal format f, or
+// false if f is not a known internal format with a defined storage, such as
+// the generic compressed formats.
+func InternalFormatInfo(f InternalFormat) (FormatInfo, bool) {
+	info, ok := formatInfos[f]
+	return info, ok
+}
+
+// IsASTC returns whether f is one of the 2D ASTC formats.
+func IsASTC(f InternalFormat) bool {
+	return (f >= GL_COMPRESSED_RGBA_ASTC_4x4_KHR && f <= GL_COMPRESSED_RGBA_ASTC_12x12_KHR) ||
+		(f >= GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR && f <= GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x12_KHR)
+}
@@ -0,0 +1,116 @@
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Vendor suffixes dropped from the short names of the enums.
+var vendorSuffixes = []string{"_KHR", "_ARB", "_EXT", "_OES", "_IMG", "_NV", "_AMD", "_APPLE", "_ANGLE", "_INTEL", "_QCOM", "_ARM"}
+
+// shortName returns the upper case name of an enum without the GL_ and the
+// COMPRESSED_ prefixes, and without the vendor suffix.
+func shortName(name string) string {
+	name = strings.TrimPrefix(strings.ToUpper(name), "GL_")
+	name = strings.TrimPrefix(name, "COMPRESSED_")
+	for _, suffix := range vendorSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+var (
+	// Names of the enums by their upper case names
+	upperNames = make(map[string]string)
+	// Names of the enums by their short names
+	shortNames = make(map[string][]string)
+	// Every name, in alphabetical order
+	sortedNames []string
+)
+
+func init() {
+	for name := range enumValues {
+		upperNames[strings.ToUpper(name)] = name
+		short := shortName(name)
+		shortNames[short] = append(shortNames[short], name)
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+	for _, names := range shortNames {
+		sort.Strings(names)
+	}
+}
+
+// Parse returns the value of the enum named s. The name may miss the GL_
+// prefix, or be shortened as well by the COMPRESSED_ prefix and the vendor
+// suffix, as in RGBA_ASTC_6x6, and is matched regardless of case. The error of
+// an unknown name suggests the closest one.
+func Parse(s string) (uint32, error) {
+	upper := strings.ToUpper(s)
+	if name, ok := upperNames[upper]; ok {
+		return enumValues[name], nil
+	}
+	if name, ok := upperNames["GL_"+upper]; ok {
+		return enumValues[name], nil
+	}
+	if names, ok := shortNames[shortName(upper)]; ok {
+		e := enumValues[names[0]]
+		for _, name := range names[1:] {
+			if enumValues[name] != e {
+				return 0, fmt.Errorf("enum: ambiguous name (%s) of %s", s, strings.Join(names, ", "))
+			}
+		}
+		return e, nil
+	}
+
+	if name := closestName(upper); name != "" {
+		return 0, fmt.Errorf("enum: unknown name (%s), did you mean %s?", s, name)
+	}
+	return 0, fmt.Errorf("enum: unknown name (%s)", s)
+}
+
+// closestName returns the name closest to s, in its complete or short form,
+// or an empty string if none is close enough to be a typo.
+func closestName(s string) string {
+	best, closest := len(s)/3+1, ""
+	short := shortName(s)
+	for _, name := range sortedNames {
+		d := editDistance(s, strings.ToUpper(name))
+		if ds := editDistance(short, shortName(name)); ds < d {
+			d = ds
+		}
+		if d < best {
+			best, closest = d, name
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			d := diagonal
+			if a[i-1] != b[j-1] {
+				d++
+			}
+			if row[j]+1 < d {
+				d = row[j] + 1
+			}
+			if row[j-1]+1 < d {
+				d = row[j-1] + 1
+			}
+			diagonal, row[j] = row[j], d
+		}
+	}
+	return row[len(b)]
+}
@@ -0,0 +1,109 @@
+package enum
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is a pixel type, such as GL_UNSIGNED_BYTE. GL_NONE is the type of
+// compressed textures.
+type Type uint32
+
+// Format is a pixel format, such as GL_RGBA. GL_NONE is the format of
+// compressed textures.
+type Format uint32
+
+// InternalFormat is an internal format, either unsized such as GL_RGBA,
+// sized such as GL_RGBA8, or compressed.
+type InternalFormat uint32
+
+func (t Type) String() string {
+	return TypeString(uint32(t))
+}
+
+func (t Type) MarshalText() ([]byte, error) {
+	return marshalEnum(uint32(t), pixelTypeStrings), nil
+}
+
+func (t *Type) UnmarshalText(text []byte) error {
+	e, err := unmarshalEnum(text, pixelTypeStrings, "type")
+	if err != nil {
+		return err
+	}
+	*t = Type(e)
+	return nil
+}
+
+func (f Format) String() string {
+	if _, ok := pixelFormatStrings[uint32(f)]; ok || f == GL_NONE {
+		return string(marshalEnum(uint32(f), pixelFormatStrings))
+	}
+	return fmt.Sprintf("Invalid format(0x%X)", uint32(f))
+}
+
+func (f Format) MarshalText() ([]byte, error) {
+	return marshalEnum(uint32(f), pixelFormatStrings), nil
+}
+
+func (f *Format) UnmarshalText(text []byte) error {
+	e, err := unmarshalEnum(text, pixelFormatStrings, "format")
+	if err != nil {
+		return err
+	}
+	*f = Format(e)
+	return nil
+}
+
+func (f InternalFormat) String() string {
+	if _, ok := internalFormatStrings[uint32(f)]; ok || f == GL_NONE {
+		return string(marshalEnum(uint32(f), internalFormatStrings))
+	}
+	return fmt.Sprintf("Invalid internal format(0x%X)", uint32(f))
+}
+
+func (f InternalFormat) MarshalText() ([]byte, error) {
+	return marshalEnum(uint32(f), internalFormatStrings), nil
+}
+
+func (f *InternalFormat) UnmarshalText(text []byte) error {
+	e, err := unmarshalEnum(text, internalFormatStrings, "internal format")
+	if err != nil {
+		return err
+	}
+	*f = InternalFormat(e)
+	return nil
+}
+
+// marshalEnum returns the name of e in a group, or its hexadecimal value if
+// it has none, so that any value read from a file can be written back.
+func marshalEnum(e uint32, names map[uint32]string) []byte {
+	if e == GL_NONE {
+		return []byte("GL_NONE")
+	}
+	if s, ok := names[e]; ok {
+		return []byte(s)
+	}
+	return []byte(fmt.Sprintf("0x%X", e))
+}
+
+// unmarshalEnum returns the value of the enum of a group named text, as
+// accepted by Parse, or written as a number.
+func unmarshalEnum(text []byte, names map[uint32]string, kind string) (uint32, error) {
+	s := string(text)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		e, err := strconv.ParseUint(s[2:], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("enum: invalid %s value (%s)", kind, s)
+		}
+		return uint32(e), nil
+	}
+	e, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := names[e]; !ok && e != GL_NONE {
+		return 0, fmt.Errorf("enum: invalid %s (%s)", kind, s)
+	}
+	return e, nil
+}
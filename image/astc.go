@@ -0,0 +1,989 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// How many bytes in an ASTC block, whatever its footprint
+const astcBlockSize = 16
+
+// Decoded colors of the blocks which break the rules of the format,
+// with the LDR and the HDR profiles
+var (
+	astcErrorColor    = color.NRGBA{0xFF, 0x00, 0xFF, 0xFF}
+	astcErrorColorHDR = glcolor.NRGBAF32{nan, nan, nan, nan}
+)
+
+var nan = float32(math.NaN())
+
+// ASTCProfile is the profile which decodes the blocks of an ASTC image.
+type ASTCProfile int
+
+const (
+	// ASTCProfileLDR decodes 8-bit colors, and rejects the HDR blocks
+	ASTCProfileLDR ASTCProfile = iota
+	// ASTCProfileHDR decodes float colors of both LDR and HDR blocks
+	ASTCProfileHDR
+)
+
+func (p ASTCProfile) String() string {
+	switch p {
+	case ASTCProfileLDR:
+		return "LDR"
+	case ASTCProfileHDR:
+		return "HDR"
+	}
+	return fmt.Sprintf("ASTCProfile(%d)", int(p))
+}
+
+// Footprints of the blocks of the 2D ASTC formats
+var astcFootprints = []image.Point{
+	{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6}, {8, 8},
+	{10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+}
+
+// IsASTCFootprint tells whether blocks of blockWidth x blockHeight pixels
+// are in one of the 2D ASTC formats.
+func IsASTCFootprint(blockWidth, blockHeight int) bool {
+	for _, f := range astcFootprints {
+		if f.X == blockWidth && f.Y == blockHeight {
+			return true
+		}
+	}
+	return false
+}
+
+// astcBlockMode is the layout of the weights of a block.
+type astcBlockMode struct {
+	weightWidth, weightHeight int
+	dualPlane                 bool
+	weightRange               iseRange
+}
+
+// weightCount returns how many weights a block stores, in both planes.
+func (m astcBlockMode) weightCount() int {
+	n := m.weightWidth * m.weightHeight
+	if m.dualPlane {
+		n *= 2
+	}
+	return n
+}
+
+// decodeBlockMode returns the layout of the weights stored in the 11 bits
+// of mode, or false for reserved modes.
+func decodeBlockMode(mode int) (m astcBlockMode, ok bool) {
+	high := mode>>9&1 != 0
+	m.dualPlane = mode>>10&1 != 0
+	a := mode >> 5 & 3
+	r := mode >> 4 & 1
+	if mode&3 != 0 {
+		r |= (mode & 3) << 1
+		b := mode >> 7 & 3
+		switch mode >> 2 & 3 {
+		case 0:
+			m.weightWidth, m.weightHeight = b+4, a+2
+		case 1:
+			m.weightWidth, m.weightHeight = b+8, a+2
+		case 2:
+			m.weightWidth, m.weightHeight = a+2, b+8
+		case 3:
+			if mode>>8&1 != 0 {
+				m.weightWidth, m.weightHeight = b&1+2, a+2
+			} else {
+				m.weightWidth, m.weightHeight = a+2, b&1+6
+			}
+		}
+	} else {
+		r |= (mode >> 2 & 3) << 1
+		if mode>>2&3 == 0 {
+			return m, false
+		}
+		b := mode >> 9 & 3
+		switch mode >> 7 & 3 {
+		case 0:
+			m.weightWidth, m.weightHeight = 12, a+2
+		case 1:
+			m.weightWidth, m.weightHeight = a+2, 12
+		case 2:
+			// No dual plane nor high precision in this mode
+			m.weightWidth, m.weightHeight = a+6, b+6
+			m.dualPlane, high = false, false
+		case 3:
+			switch a {
+			case 0:
+				m.weightWidth, m.weightHeight = 6, 10
+			case 1:
+				m.weightWidth, m.weightHeight = 10, 6
+			default:
+				return m, false
+			}
+		}
+	}
+
+	index := r - 2
+	if high {
+		index += 6
+	}
+	m.weightRange = iseRanges[index]
+	count := m.weightCount()
+	bits := m.weightRange.bitCount(count)
+	return m, count <= 64 && bits >= 24 && bits <= 96
+}
+
+// hash52 scrambles the seed of the partition patterns.
+func hash52(v uint32) uint32 {
+	v ^= v >> 15
+	v *= 0xEEDE0891
+	v ^= v >> 5
+	v += v << 16
+	v ^= v >> 7
+	v ^= v >> 3
+	v ^= v << 6
+	v ^= v >> 17
+	return v
+}
+
+// selectPartition returns the partition of the texel at x, y of a block,
+// in the pattern of the given index among the patterns of count partitions.
+func selectPartition(index, x, y, count int, smallBlock bool) int {
+	if smallBlock {
+		x, y = x<<1, y<<1
+	}
+	seed := uint32(index + (count-1)*1024)
+	rnum := hash52(seed)
+
+	var seeds [12]uint32
+	for i := 0; i < 8; i++ {
+		seeds[i] = rnum >> uint(i*4) & 0xF
+	}
+	seeds[8] = rnum >> 18 & 0xF
+	seeds[9] = rnum >> 22 & 0xF
+	seeds[10] = rnum >> 26 & 0xF
+	seeds[11] = (rnum>>30 | rnum<<2) & 0xF
+	for i := range seeds {
+		seeds[i] *= seeds[i]
+	}
+
+	var sh1, sh2 uint
+	if seed&1 != 0 {
+		sh1, sh2 = 5, 5
+		if seed&2 != 0 {
+			sh1 = 4
+		}
+		if count == 3 {
+			sh2 = 6
+		}
+	} else {
+		sh1, sh2 = 5, 5
+		if count == 3 {
+			sh1 = 6
+		}
+		if seed&2 != 0 {
+			sh2 = 4
+		}
+	}
+	sh3 := sh2
+	if seed&0x10 != 0 {
+		sh3 = sh1
+	}
+	for i := 0; i < 8; i += 2 {
+		seeds[i] >>= sh1
+		seeds[i+1] >>= sh2
+	}
+	for i := 8; i < 12; i++ {
+		seeds[i] >>= sh3
+	}
+
+	// The z coordinate of 3D blocks is always 0
+	ux, uy := uint32(x), uint32(y)
+	a := (seeds[0]*ux + seeds[1]*uy + rnum>>14) & 0x3F
+	b := (seeds[2]*ux + seeds[3]*uy + rnum>>10) & 0x3F
+	c := (seeds[4]*ux + seeds[5]*uy + rnum>>6) & 0x3F
+	d := (seeds[6]*ux + seeds[7]*uy + rnum>>2) & 0x3F
+	if count < 4 {
+		d = 0
+	}
+	if count < 3 {
+		c = 0
+	}
+
+	switch {
+	case a >= b && a >= c && a >= d:
+		return 0
+	case b >= c && b >= d:
+		return 1
+	case c >= d:
+		return 2
+	}
+	return 3
+}
+
+// bitTransferSigned moves the top bit of b to a, making b a signed
+// 6-bit offset and a an 8-bit base.
+func bitTransferSigned(a, b int) (int, int) {
+	a = a>>1 | b&0x80
+	b = b >> 1 & 0x3F
+	if b&0x20 != 0 {
+		b -= 0x40
+	}
+	return a, b
+}
+
+// blueContract moves red and green halfway towards blue.
+func blueContract(c [4]int) [4]int {
+	return [4]int{(c[0] + c[2]) >> 1, (c[1] + c[2]) >> 1, c[2], c[3]}
+}
+
+func clampColor(c [4]int) [4]int {
+	for i, v := range c {
+		if v < 0 {
+			c[i] = 0
+		} else if v > 0xFF {
+			c[i] = 0xFF
+		}
+	}
+	return c
+}
+
+// isHDREndpointMode tells whether the color endpoint mode needs the HDR profile.
+func isHDREndpointMode(mode int) bool {
+	switch mode {
+	case 2, 3, 7, 11, 14, 15:
+		return true
+	}
+	return false
+}
+
+// decodeEndpoints returns both 8-bit RGBA endpoints of the LDR color endpoint
+// mode from the unquantized values v.
+func decodeEndpoints(mode int, v []int) (e0, e1 [4]int) {
+	switch mode {
+	case 0:
+		// Luminance
+		e0 = [4]int{v[0], v[0], v[0], 0xFF}
+		e1 = [4]int{v[1], v[1], v[1], 0xFF}
+	case 1:
+		// Luminance with an offset
+		l0 := v[0]>>2 | v[1]&0xC0
+		l1 := l0 + v[1]&0x3F
+		if l1 > 0xFF {
+			l1 = 0xFF
+		}
+		e0 = [4]int{l0, l0, l0, 0xFF}
+		e1 = [4]int{l1, l1, l1, 0xFF}
+	case 4:
+		// Luminance and alpha
+		e0 = [4]int{v[0], v[0], v[0], v[2]}
+		e1 = [4]int{v[1], v[1], v[1], v[3]}
+	case 5:
+		// Luminance and alpha with offsets
+		l0, dl := bitTransferSigned(v[0], v[1])
+		a0, da := bitTransferSigned(v[2], v[3])
+		e0 = [4]int{l0, l0, l0, a0}
+		e1 = clampColor([4]int{l0 + dl, l0 + dl, l0 + dl, a0 + da})
+	case 6:
+		// RGB scaled down for the first endpoint
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, 0xFF}
+		e1 = [4]int{v[0], v[1], v[2], 0xFF}
+	case 8, 12:
+		// RGB and RGBA, swapped with blue contraction
+		c0 := [4]int{v[0], v[2], v[4], 0xFF}
+		c1 := [4]int{v[1], v[3], v[5], 0xFF}
+		if mode == 12 {
+			c0[3], c1[3] = v[6], v[7]
+		}
+		if c1[0]+c1[1]+c1[2] >= c0[0]+c0[1]+c0[2] {
+			e0, e1 = c0, c1
+		} else {
+			e0, e1 = blueContract(c1), blueContract(c0)
+		}
+	case 9, 13:
+		// RGB and RGBA with offsets, swapped with blue contraction
+		var c0, d [4]int
+		c0[3], d[3] = 0xFF, 0
+		components := 3
+		if mode == 13 {
+			components = 4
+		}
+		for i := 0; i < components; i++ {
+			c0[i], d[i] = bitTransferSigned(v[2*i], v[2*i+1])
+		}
+		c1 := [4]int{c0[0] + d[0], c0[1] + d[1], c0[2] + d[2], c0[3] + d[3]}
+		if d[0]+d[1]+d[2] >= 0 {
+			e0, e1 = c0, c1
+		} else {
+			e0, e1 = blueContract(c1), blueContract(c0)
+		}
+		e0, e1 = clampColor(e0), clampColor(e1)
+	case 10:
+		// RGB scaled down for the first endpoint, with two alphas
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, v[4]}
+		e1 = [4]int{v[0], v[1], v[2], v[5]}
+	}
+	return
+}
+
+// clamp12 clamps v to the 12 bits of the HDR endpoint components.
+func clamp12(v int) int {
+	if v < 0 {
+		return 0
+	} else if v > 0xFFF {
+		return 0xFFF
+	}
+	return v
+}
+
+// decodeHDREndpoints returns both 16-bit RGBA endpoints of the HDR color
+// endpoint mode from the unquantized values v, whose components are in the
+// logarithmic encoding of lnsToHalf. Alpha is a UNORM16 unless hdrAlpha.
+func decodeHDREndpoints(mode int, v []int) (e0, e1 [4]int, hdrAlpha bool) {
+	// The alpha of 1.0 in the logarithmic encoding
+	const one = 0x780
+	hdrAlpha = true
+	switch mode {
+	case 2:
+		// Luminance with a large range
+		y0, y1 := v[0]<<4, v[1]<<4
+		if v[1] < v[0] {
+			y0, y1 = v[1]<<4+8, v[0]<<4-8
+		}
+		e0 = [4]int{y0, y0, y0, one}
+		e1 = [4]int{y1, y1, y1, one}
+	case 3:
+		// Luminance with a small range
+		var y0, d int
+		if v[0]&0x80 != 0 {
+			y0 = (v[1]&0xE0)<<4 | (v[0]&0x7F)<<2
+			d = (v[1] & 0x1F) << 2
+		} else {
+			y0 = (v[1]&0xF0)<<4 | (v[0]&0x7F)<<1
+			d = (v[1] & 0x0F) << 1
+		}
+		y1 := clamp12(y0 + d)
+		e0 = [4]int{y0, y0, y0, one}
+		e1 = [4]int{y1, y1, y1, one}
+	case 7:
+		e0, e1 = decodeHDRScale(v)
+	case 11, 14, 15:
+		e0, e1 = decodeHDRDirect(v)
+		if mode == 15 {
+			e0[3], e1[3] = decodeHDRAlpha(v[6], v[7])
+		}
+	}
+	for ch := range e0 {
+		e0[ch], e1[ch] = e0[ch]<<4, e1[ch]<<4
+	}
+	if mode == 14 {
+		// LDR alpha
+		e0[3], e1[3], hdrAlpha = v[6]*0x101, v[7]*0x101, false
+	}
+	return
+}
+
+// decodeHDRScale returns the 12-bit endpoints of the HDR RGB mode with
+// a base and a scale, from 4 values.
+func decodeHDRScale(v []int) (e0, e1 [4]int) {
+	modeValue := (v[0]&0xC0)>>6 | (v[1]&0x80)>>5 | (v[2]&0x80)>>4
+	var major, mode int
+	switch {
+	case modeValue&0xC != 0xC:
+		major, mode = modeValue>>2, modeValue&3
+	case modeValue != 0xF:
+		major, mode = modeValue&3, 4
+	default:
+		major, mode = 0, 5
+	}
+
+	red, green, blue, scale := v[0]&0x3F, v[1]&0x1F, v[2]&0x1F, v[3]&0x1F
+	// Bits whose place depends on the mode
+	x0, x1 := v[1]>>6&1, v[1]>>5&1
+	x2, x3 := v[2]>>6&1, v[2]>>5&1
+	x4, x5, x6 := v[3]>>7&1, v[3]>>6&1, v[3]>>5&1
+	oneHot := 1 << uint(mode)
+	if oneHot&0x30 != 0 {
+		green |= x0 << 6
+		blue |= x2 << 6
+	}
+	if oneHot&0x3A != 0 {
+		green |= x1 << 5
+		blue |= x3 << 5
+	}
+	if oneHot&0x3D != 0 {
+		scale |= x6 << 5
+	}
+	if oneHot&0x2D != 0 {
+		scale |= x5 << 6
+	}
+	if oneHot&0x04 != 0 {
+		scale |= x4 << 7
+		red |= x3 << 6
+	}
+	if oneHot&0x3B != 0 {
+		red |= x4 << 6
+	}
+	if oneHot&0x10 != 0 {
+		red |= x5 << 7
+	}
+	if oneHot&0x0F != 0 {
+		red |= x2 << 7
+	}
+	if oneHot&0x05 != 0 {
+		red |= x1<<8 | x0<<9
+	}
+	if oneHot&0x0A != 0 {
+		red |= x0 << 8
+	}
+	if oneHot&0x02 != 0 {
+		red |= x6<<9 | x5<<10
+	}
+	if oneHot&0x01 != 0 {
+		red |= x3 << 10
+	}
+
+	shift := uint([6]int{1, 1, 2, 3, 4, 5}[mode])
+	red, green, blue, scale = red<<shift, green<<shift, blue<<shift, scale<<shift
+	// Green and blue are offsets from red but in the last mode
+	if mode != 5 {
+		green, blue = red-green, red-blue
+	}
+	switch major {
+	case 1:
+		red, green = green, red
+	case 2:
+		red, blue = blue, red
+	}
+	e0 = [4]int{clamp12(red - scale), clamp12(green - scale), clamp12(blue - scale), 0x780}
+	e1 = [4]int{clamp12(red), clamp12(green), clamp12(blue), 0x780}
+	return
+}
+
+// decodeHDRDirect returns the 12-bit endpoints of the HDR RGB mode,
+// from 6 values.
+func decodeHDRDirect(v []int) (e0, e1 [4]int) {
+	major := (v[4]&0x80)>>7 | (v[5]&0x80)>>6
+	if major == 3 {
+		e0 = [4]int{v[0] << 4, v[2] << 4, (v[4] & 0x7F) << 5, 0x780}
+		e1 = [4]int{v[1] << 4, v[3] << 4, (v[5] & 0x7F) << 5, 0x780}
+		return
+	}
+
+	mode := (v[1]&0x80)>>7 | (v[2]&0x80)>>6 | (v[3]&0x80)>>5
+	a := v[0] | (v[1]&0x40)<<2
+	b0, b1 := v[2]&0x3F, v[3]&0x3F
+	c := v[1] & 0x3F
+	d0, d1 := v[4]&0x7F, v[5]&0x7F
+	// Bits whose place depends on the mode
+	x0, x1, x2, x3 := v[2]>>6&1, v[3]>>6&1, v[4]>>6&1, v[5]>>6&1
+	x4, x5 := v[4]>>5&1, v[5]>>5&1
+	oneHot := 1 << uint(mode)
+	if oneHot&0xA4 != 0 {
+		a |= x0 << 9
+	}
+	if oneHot&0x08 != 0 {
+		a |= x2 << 9
+	}
+	if oneHot&0x50 != 0 {
+		a |= x4<<9 | x5<<10
+	}
+	if oneHot&0xA0 != 0 {
+		a |= x1 << 10
+	}
+	if oneHot&0xC0 != 0 {
+		a |= x2 << 11
+	}
+	if oneHot&0x04 != 0 {
+		c |= x1 << 6
+	}
+	if oneHot&0xE8 != 0 {
+		c |= x3 << 6
+	}
+	if oneHot&0x20 != 0 {
+		c |= x2 << 7
+	}
+	if oneHot&0x5B != 0 {
+		b0 |= x0 << 6
+		b1 |= x1 << 6
+	}
+	if oneHot&0x12 != 0 {
+		b0 |= x2 << 7
+		b1 |= x3 << 7
+	}
+	// The offsets d0 and d1 are signed, of fewer bits in some modes
+	bits := uint([8]int{7, 6, 7, 6, 5, 6, 5, 6}[mode])
+	d0, d1 = signExtend(d0, bits), signExtend(d1, bits)
+
+	shift := uint(mode>>1 ^ 3)
+	a, b0, b1, c, d0, d1 = a<<shift, b0<<shift, b1<<shift, c<<shift, d0<<shift, d1<<shift
+	e0 = [4]int{clamp12(a - c), clamp12(a - b0 - c - d0), clamp12(a - b1 - c - d1), 0x780}
+	e1 = [4]int{clamp12(a), clamp12(a - b0), clamp12(a - b1), 0x780}
+	switch major {
+	case 1:
+		e0[0], e0[1], e1[0], e1[1] = e0[1], e0[0], e1[1], e1[0]
+	case 2:
+		e0[0], e0[2], e1[0], e1[2] = e0[2], e0[0], e1[2], e1[0]
+	}
+	return
+}
+
+// decodeHDRAlpha returns both 12-bit alphas of the HDR alpha mode.
+func decodeHDRAlpha(v6, v7 int) (a0, a1 int) {
+	selector := v6>>7&1 | v7>>6&2
+	v6, v7 = v6&0x7F, v7&0x7F
+	if selector == 3 {
+		return v6 << 5, v7 << 5
+	}
+	s := uint(selector)
+	v6 |= v7 << (s + 1) & 0x780
+	v7 &= 0x3F >> s
+	v7 ^= 32 >> s
+	v7 -= 32 >> s
+	v6 <<= 4 - s
+	v7 <<= 4 - s
+	return v6, clamp12(v6 + v7)
+}
+
+// signExtend returns the signed value of the low bits of v.
+func signExtend(v int, bits uint) int {
+	v &= 1<<bits - 1
+	if v>>(bits-1) != 0 {
+		v -= 1 << bits
+	}
+	return v
+}
+
+// lnsToHalf converts a 16-bit HDR component from the logarithmic encoding
+// of the endpoints to a half float, with the largest finite half at most.
+func lnsToHalf(c int) uint16 {
+	e, m := c>>11, c&0x7FF
+	switch {
+	case m < 512:
+		m *= 3
+	case m < 1536:
+		m = 4*m - 512
+	default:
+		m = 5*m - 2048
+	}
+	h := e<<10 | m>>3
+	if h > 0x7BFF {
+		h = 0x7BFF
+	}
+	return uint16(h)
+}
+
+// halfToFloat converts the bits of a half float to a float32.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp, mant := uint32(h>>10&0x1F), uint32(h&0x3FF)
+	switch {
+	case exp == 0x1F:
+		// Infinities and NaNs
+		return math.Float32frombits(sign | 0x7F800000 | mant<<13)
+	case exp != 0:
+		return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+	case mant == 0:
+		return math.Float32frombits(sign)
+	}
+	// Subnormals are normalized
+	exp = 113
+	for mant&0x400 == 0 {
+		mant <<= 1
+		exp--
+	}
+	return math.Float32frombits(sign | exp<<23 | (mant&0x3FF)<<13)
+}
+
+// colorRangeFor returns the largest range of count color values which fits
+// in bits bits, or false if even the smallest one of 6 values does not.
+func colorRangeFor(count, bits int) (iseRange, bool) {
+	for i := len(iseRanges) - 1; i >= 4; i-- {
+		if iseRanges[i].bitCount(count) <= bits {
+			return iseRanges[i], true
+		}
+	}
+	return iseRange{}, false
+}
+
+// infillTexel returns the indices in the grid of weights of the 4 weights
+// interpolated at texel s, t of a block of the given footprint, with their
+// factors out of 16. Indices outside of the grid are -1.
+func infillTexel(s, t, gridWidth, gridHeight, width, height int) (indices, factors [4]int) {
+	ds := (1024 + width/2) / (width - 1)
+	dt := (1024 + height/2) / (height - 1)
+	gs := (ds*s*(gridWidth-1) + 32) >> 6
+	gt := (dt*t*(gridHeight-1) + 32) >> 6
+	js, fs := gs>>4, gs&0xF
+	jt, ft := gt>>4, gt&0xF
+
+	w11 := (fs*ft + 8) >> 4
+	factors = [4]int{16 - fs - ft + w11, fs - w11, ft - w11, w11}
+	for i, p := range [4]image.Point{{js, jt}, {js + 1, jt}, {js, jt + 1}, {js + 1, jt + 1}} {
+		indices[i] = -1
+		if p.X < gridWidth && p.Y < gridHeight {
+			indices[i] = p.Y*gridWidth + p.X
+		}
+	}
+	return
+}
+
+// infillWeights returns the weights of every texel of a block of the given
+// footprint, interpolated bilinearly from the grid of weights.
+func infillWeights(weights []int, gridWidth, gridHeight, width, height int) []int {
+	result := make([]int, width*height)
+	for t := 0; t < height; t++ {
+		for s := 0; s < width; s++ {
+			indices, factors := infillTexel(s, t, gridWidth, gridHeight, width, height)
+			w := 8
+			for i, index := range indices {
+				if index >= 0 {
+					w += weights[index] * factors[i]
+				}
+			}
+			result[t*width+s] = w >> 4
+		}
+	}
+	return result
+}
+
+// astcTexel16 is a decoded texel of 16-bit components, which are UNORM16
+// values or, where half is set, half floats of the HDR profile.
+type astcTexel16 struct {
+	c    [4]uint16
+	half [4]bool
+}
+
+// decodeVoidExtent decodes a block of a single color into dst, and returns
+// false if the block is invalid.
+func decodeVoidExtent(b astcBits, hdr bool, dst []astcTexel16) bool {
+	// HDR colors are not supported by the LDR profile
+	isHDR := b.get(9, 1) != 0
+	if (isHDR && !hdr) || b.get(10, 2) != 3 {
+		return false
+	}
+	// Coordinates of the extent are either all ones or increasing
+	sLow, sHigh, tLow, tHigh := b.get(12, 13), b.get(25, 13), b.get(38, 13), b.get(51, 13)
+	allOnes := sLow == 0x1FFF && sHigh == 0x1FFF && tLow == 0x1FFF && tHigh == 0x1FFF
+	if !allOnes && !(sLow < sHigh && tLow < tHigh) {
+		return false
+	}
+	var t astcTexel16
+	for ch := range t.c {
+		t.c[ch], t.half[ch] = uint16(b.get(64+16*ch, 16)), isHDR
+	}
+	for i := range dst {
+		dst[i] = t
+	}
+	return true
+}
+
+// decodeBlockASTC decodes the texels of an ASTC block of the given footprint
+// into dst, row by row, with the LDR profile. Colors are decoded for the sRGB
+// formats with srgb.
+func decodeBlockASTC(block []byte, width, height int, srgb bool, dst []color.NRGBA) {
+	texels := make([]astcTexel16, len(dst))
+	if !decodeBlockASTC16(block, width, height, srgb, false, texels) {
+		for i := range dst {
+			dst[i] = astcErrorColor
+		}
+		return
+	}
+	// Only the top 8 bits of the 16-bit components matter
+	for i, t := range texels {
+		dst[i] = color.NRGBA{uint8(t.c[0] >> 8), uint8(t.c[1] >> 8), uint8(t.c[2] >> 8), uint8(t.c[3] >> 8)}
+	}
+}
+
+// decodeBlockASTCHDR decodes the texels of an ASTC block of the given
+// footprint into dst, row by row, with the HDR profile.
+func decodeBlockASTCHDR(block []byte, width, height int, dst []glcolor.NRGBAF32) {
+	texels := make([]astcTexel16, len(dst))
+	if !decodeBlockASTC16(block, width, height, false, true, texels) {
+		for i := range dst {
+			dst[i] = astcErrorColorHDR
+		}
+		return
+	}
+	for i, t := range texels {
+		var f [4]float32
+		for ch, v := range t.c {
+			if t.half[ch] {
+				f[ch] = halfToFloat(v)
+			} else {
+				f[ch] = float32(v) / 0xFFFF
+			}
+		}
+		dst[i] = glcolor.NRGBAF32{f[0], f[1], f[2], f[3]}
+	}
+}
+
+// decodeBlockASTC16 decodes the texels of an ASTC block of the given
+// footprint into dst, row by row, and returns false if the block is invalid
+// in the profile, which is HDR with hdr.
+func decodeBlockASTC16(block []byte, width, height int, srgb, hdr bool, dst []astcTexel16) bool {
+	b := newASTCBits(block)
+	if b.get(0, 9) == 0x1FC {
+		return decodeVoidExtent(b, hdr, dst)
+	}
+	return decodeBlockASTCColors(b, width, height, srgb, hdr, dst)
+}
+
+// decodeBlockASTCColors decodes the texels of a block which is not a void
+// extent, and returns false if the block is invalid.
+func decodeBlockASTCColors(b astcBits, width, height int, srgb, hdr bool, dst []astcTexel16) bool {
+	mode, ok := decodeBlockMode(b.get(0, 11))
+	if !ok || mode.weightWidth > width || mode.weightHeight > height {
+		return false
+	}
+	partitions := b.get(11, 2) + 1
+	if partitions == 4 && mode.dualPlane {
+		return false
+	}
+
+	// Color endpoint modes of every partition
+	weightBits := mode.weightRange.bitCount(mode.weightCount())
+	belowWeights := 128 - weightBits
+	var endpointModes [4]int
+	var partitionIndex, colorStart int
+	if partitions == 1 {
+		endpointModes[0] = b.get(13, 4)
+		colorStart = 17
+	} else {
+		partitionIndex = b.get(13, 10)
+		colorStart = 29
+		cem := b.get(23, 6)
+		if cem&3 == 0 {
+			for i := 0; i < partitions; i++ {
+				endpointModes[i] = cem >> 2
+			}
+		} else {
+			// The classes and modes of the partitions continue below the weights
+			extraBits := 3*partitions - 4
+			belowWeights -= extraBits
+			cem |= b.get(belowWeights, extraBits) << 6
+			class := cem&3 - 1
+			for i := 0; i < partitions; i++ {
+				endpointModes[i] = (class + cem>>uint(2+i)&1) << 2
+				endpointModes[i] |= cem >> uint(2+partitions+2*i) & 3
+			}
+		}
+	}
+	var plane2Component int
+	if mode.dualPlane {
+		belowWeights -= 2
+		plane2Component = b.get(belowWeights, 2)
+	}
+
+	// Color endpoints, with the largest range which fits before the weights
+	colorCount := 0
+	for i := 0; i < partitions; i++ {
+		if isHDREndpointMode(endpointModes[i]) && !hdr {
+			return false
+		}
+		colorCount += (endpointModes[i]>>2 + 1) * 2
+	}
+	if colorCount > 18 {
+		return false
+	}
+	colorRange, ok := colorRangeFor(colorCount, belowWeights-colorStart)
+	if !ok {
+		return false
+	}
+	colors := make([]int, colorCount)
+	decodeISE(b, colorStart, colorRange, colors)
+	for i, v := range colors {
+		colors[i] = unquantizeColor(colorRange, v)
+	}
+	// Endpoints expanded to 16 bits, and whether their components are HDR
+	var endpoints [4][2][4]int
+	var halves [4][4]bool
+	for i, offset := 0, 0; i < partitions; i++ {
+		v := colors[offset:]
+		offset += (endpointModes[i]>>2 + 1) * 2
+		if isHDREndpointMode(endpointModes[i]) {
+			var hdrAlpha bool
+			endpoints[i][0], endpoints[i][1], hdrAlpha = decodeHDREndpoints(endpointModes[i], v)
+			halves[i] = [4]bool{true, true, true, hdrAlpha}
+			continue
+		}
+		e0, e1 := decodeEndpoints(endpointModes[i], v)
+		for ch := range e0 {
+			endpoints[i][0][ch], endpoints[i][1][ch] = e0[ch]*0x101, e1[ch]*0x101
+			if srgb && ch < 3 {
+				endpoints[i][0][ch], endpoints[i][1][ch] = e0[ch]<<8|0x80, e1[ch]<<8|0x80
+			}
+		}
+	}
+
+	// Weights, interleaved between both planes
+	weights := make([]int, mode.weightCount())
+	decodeISE(b.reversed(), 0, mode.weightRange, weights)
+	planes := 1
+	if mode.dualPlane {
+		planes = 2
+	}
+	var texelWeights [2][]int
+	gridSize := mode.weightWidth * mode.weightHeight
+	for p := 0; p < planes; p++ {
+		plane := make([]int, gridSize)
+		for i := range plane {
+			plane[i] = unquantizeWeight(mode.weightRange, weights[i*planes+p])
+		}
+		texelWeights[p] = infillWeights(plane, mode.weightWidth, mode.weightHeight, width, height)
+	}
+
+	smallBlock := width*height < 31
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			partition := 0
+			if partitions > 1 {
+				partition = selectPartition(partitionIndex, x, y, partitions, smallBlock)
+			}
+			e := endpoints[partition]
+			var t astcTexel16
+			for ch := range t.c {
+				w := texelWeights[0][i]
+				if mode.dualPlane && ch == plane2Component {
+					w = texelWeights[1][i]
+				}
+				c := (e[0][ch]*(64-w) + e[1][ch]*w + 32) >> 6
+				t.c[ch] = uint16(c)
+				if halves[partition][ch] {
+					t.c[ch], t.half[ch] = lnsToHalf(c), true
+				}
+			}
+			dst[i] = t
+		}
+	}
+	return true
+}
+
+// ASTC is an in-memory image of blocks in one of the ASTC formats.
+type ASTC struct {
+	Pix  []uint8
+	Rect image.Rectangle
+	// Footprint of the blocks in pixels
+	BlockWidth, BlockHeight int
+	// SRGB tells whether the blocks are in the sRGB format, whose colors
+	// are decoded with a different rounding
+	SRGB bool
+	// Profile decodes the blocks to color.NRGBA with the LDR profile,
+	// and to glcolor.NRGBAF32 with the HDR one
+	Profile ASTCProfile
+}
+
+func (p *ASTC) ColorModel() color.Model {
+	if p.Profile == ASTCProfileHDR {
+		return glcolor.NRGBAF32Model
+	}
+	return color.NRGBAModel
+}
+
+func (p *ASTC) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *ASTC) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		if p.Profile == ASTCProfileHDR {
+			return glcolor.NRGBAF32{}
+		}
+		return color.NRGBA{}
+	}
+	x, y = x-p.Rect.Min.X, y-p.Rect.Min.Y
+
+	xBlockDim, _ := p.BlockDimensions()
+	offset := ((y/p.BlockHeight)*xBlockDim + x/p.BlockWidth) * astcBlockSize
+	block := p.Pix[offset : offset+astcBlockSize]
+	i := (y%p.BlockHeight)*p.BlockWidth + x%p.BlockWidth
+	if p.Profile == ASTCProfileHDR {
+		pixels := make([]glcolor.NRGBAF32, p.BlockWidth*p.BlockHeight)
+		decodeBlockASTCHDR(block, p.BlockWidth, p.BlockHeight, pixels)
+		return pixels[i]
+	}
+	pixels := make([]color.NRGBA, p.BlockWidth*p.BlockHeight)
+	decodeBlockASTC(block, p.BlockWidth, p.BlockHeight, p.SRGB, pixels)
+	return pixels[i]
+}
+
+// BlockDimensions returns how many blocks of the footprint of p cover it
+// in width and height.
+func (p *ASTC) BlockDimensions() (x, y int) {
+	x = (p.Rect.Dx() + p.BlockWidth - 1) / p.BlockWidth
+	y = (p.Rect.Dy() + p.BlockHeight - 1) / p.BlockHeight
+	return
+}
+
+// Compress encodes im into p with the medium quality.
+func (p *ASTC) Compress(im image.Image) error {
+	return p.CompressQuality(im, ASTCMedium)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.NRGBA,
+// or in a *NRGBAF32 with the HDR profile.
+func (p *ASTC) Uncompress() (image.Image, error) {
+	xBlockDim, yBlockDim := p.BlockDimensions()
+	if len(p.Pix) < xBlockDim*yBlockDim*astcBlockSize {
+		return nil, fmt.Errorf("ASTC uncompress: not enough data [%v < %v]", len(p.Pix), xBlockDim*yBlockDim*astcBlockSize)
+	}
+
+	if p.Profile == ASTCProfileHDR {
+		m := NewNRGBAF32(p.Rect)
+		pixels := make([]glcolor.NRGBAF32, p.BlockWidth*p.BlockHeight)
+		p.uncompressBlocks(func(block []byte) {
+			decodeBlockASTCHDR(block, p.BlockWidth, p.BlockHeight, pixels)
+		}, func(x, y, i int) {
+			c := pixels[i]
+			j := m.PixOffset(x, y)
+			m.Pix[j], m.Pix[j+1], m.Pix[j+2], m.Pix[j+3] = c.R, c.G, c.B, c.A
+		})
+		return m, nil
+	}
+	m := image.NewNRGBA(p.Rect)
+	pixels := make([]color.NRGBA, p.BlockWidth*p.BlockHeight)
+	p.uncompressBlocks(func(block []byte) {
+		decodeBlockASTC(block, p.BlockWidth, p.BlockHeight, p.SRGB, pixels)
+	}, func(x, y, i int) {
+		c := pixels[i]
+		j := m.PixOffset(x, y)
+		m.Pix[j], m.Pix[j+1], m.Pix[j+2], m.Pix[j+3] = c.R, c.G, c.B, c.A
+	})
+	return m, nil
+}
+
+// uncompressBlocks calls decode with every block of p, then set with every
+// pixel of the block inside p and its index in the block.
+func (p *ASTC) uncompressBlocks(decode func(block []byte), set func(x, y, i int)) {
+	xBlockDim, yBlockDim := p.BlockDimensions()
+	w, h := p.Rect.Dx(), p.Rect.Dy()
+	for yBlock := 0; yBlock < yBlockDim; yBlock++ {
+		for xBlock := 0; xBlock < xBlockDim; xBlock++ {
+			offset := (yBlock*xBlockDim + xBlock) * astcBlockSize
+			decode(p.Pix[offset : offset+astcBlockSize])
+			// Pixels of edge blocks outside of the image are dropped
+			for y := 0; y < p.BlockHeight && yBlock*p.BlockHeight+y < h; y++ {
+				for x := 0; x < p.BlockWidth && xBlock*p.BlockWidth+x < w; x++ {
+					set(p.Rect.Min.X+xBlock*p.BlockWidth+x, p.Rect.Min.Y+yBlock*p.BlockHeight+y, y*p.BlockWidth+x)
+				}
+			}
+		}
+	}
+}
+
+func calculateSizeASTC(width, height, blockWidth, blockHeight int) int {
+	xBlocks := (width + blockWidth - 1) / blockWidth
+	yBlocks := (height + blockHeight - 1) / blockHeight
+	return xBlocks * yBlocks * astcBlockSize
+}
+
+// NewASTC returns an ASTC image of bounds r whose blocks have a footprint
+// of blockWidth x blockHeight pixels.
+func NewASTC(r image.Rectangle, blockWidth, blockHeight int) *ASTC {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSizeASTC(w, h, blockWidth, blockHeight))
+	return &ASTC{buf, r, blockWidth, blockHeight, false, ASTCProfileLDR}
+}
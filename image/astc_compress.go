@@ -0,0 +1,623 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// ASTCQuality selects how thoroughly the ASTC encoder searches the block
+// modes and partitions of every block.
+type ASTCQuality int
+
+const (
+	// ASTCFast only tries the largest grid of weights of every weight range,
+	// with a single plane and a single partition.
+	ASTCFast ASTCQuality = iota
+	// ASTCMedium tries every grid of weights which fits the footprint,
+	// with one or two planes of weights and a single partition.
+	ASTCMedium
+	// ASTCThorough also tries two partitions, and refines the weights
+	// one by one.
+	ASTCThorough
+)
+
+// astcBlockModes maps every layout of weights to the first block mode
+// which stores it.
+var astcBlockModes = make(map[astcBlockMode]int)
+
+func init() {
+	for mode := 2047; mode >= 0; mode-- {
+		if m, ok := decodeBlockMode(mode); ok {
+			astcBlockModes[m] = mode
+		}
+	}
+}
+
+// astcTexel is a pixel of the source image inside a block, with its
+// position in the block.
+type astcTexel struct {
+	x, y int
+	c    [4]int
+}
+
+// astcEncoding is the content of a block which is not a void extent,
+// before it is packed into bits.
+type astcEncoding struct {
+	mode           astcBlockMode
+	partitions     int
+	partitionIndex int
+	endpointMode   int
+	// plane2Component is the component of the second plane of weights
+	plane2Component int
+	// colors and weights are quantized to their ranges. Colors are in the
+	// order of the partitions, weights in the order of the grid.
+	colors  []int
+	weights []int
+}
+
+// pack stores the encoding into the 16 bytes of dst. Every partition has the
+// same color endpoint mode.
+func (e *astcEncoding) pack(dst []byte) {
+	var b astcBits
+	b.set(0, 11, astcBlockModes[e.mode])
+	b.set(11, 2, e.partitions-1)
+	colorStart := 17
+	if e.partitions == 1 {
+		b.set(13, 4, e.endpointMode)
+	} else {
+		b.set(13, 10, e.partitionIndex)
+		b.set(23, 6, e.endpointMode<<2)
+		colorStart = 29
+	}
+	belowWeights := 128 - e.mode.weightRange.bitCount(len(e.weights))
+	if e.mode.dualPlane {
+		belowWeights -= 2
+		b.set(belowWeights, 2, e.plane2Component)
+	}
+	colorRange, _ := colorRangeFor(len(e.colors), belowWeights-colorStart)
+	encodeISE(&b, colorStart, colorRange, e.colors)
+
+	var weights astcBits
+	encodeISE(&weights, 0, e.mode.weightRange, e.weights)
+	weights = weights.reversed()
+	b.lo |= weights.lo
+	b.hi |= weights.hi
+	b.write(dst)
+}
+
+// writeVoidExtent stores a block of a single color into dst.
+func writeVoidExtent(dst []byte, c [4]int) {
+	var b astcBits
+	b.set(0, 12, 0xDFC)
+	// Coordinates of the extent are all ones
+	for i := 0; i < 4; i++ {
+		b.set(12+13*i, 13, 0x1FFF)
+	}
+	for i, v := range c {
+		b.set(64+16*i, 16, v*0x101)
+	}
+	b.write(dst)
+}
+
+// nearestLevel returns the index of the value of levels which is
+// the nearest to v.
+func nearestLevel(levels []int, v float64) int {
+	best, bestDist := 0, math.Inf(1)
+	for i, l := range levels {
+		if d := math.Abs(float64(l) - v); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// astcLevels returns the unquantized values of the ranges.
+func astcLevels(ranges []iseRange, unquantize func(iseRange, int) int) map[iseRange][]int {
+	levels := make(map[iseRange][]int)
+	for _, r := range ranges {
+		l := make([]int, r.levels)
+		for v := range l {
+			l[v] = unquantize(r, v)
+		}
+		levels[r] = l
+	}
+	return levels
+}
+
+var (
+	// Colors have at least 6 levels, and weights at most 32
+	astcColorLevels  = astcLevels(iseRanges[4:], unquantizeColor)
+	astcWeightLevels = astcLevels(iseRanges[:12], unquantizeWeight)
+	// astcWeightOrder holds the quantized weights of every range in the
+	// order of their values, which differs for trits and quints
+	astcWeightOrder = make(map[iseRange][]int)
+)
+
+func init() {
+	for r, levels := range astcWeightLevels {
+		order := make([]int, len(levels))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool { return levels[order[i]] < levels[order[j]] })
+		astcWeightOrder[r] = order
+	}
+}
+
+// principalAxis returns the unit eigenvector of the largest eigenvalue of
+// the covariance matrix cov.
+func principalAxis(cov *[4][4]float64) (axis [4]float64) {
+	// Power iteration from the row of the largest variance, which unlike
+	// the diagonal is never orthogonal to anticorrelated components
+	k := 0
+	for i := range cov {
+		if cov[i][i] > cov[k][k] {
+			k = i
+		}
+	}
+	axis = cov[k]
+	for iter := 0; iter < 16; iter++ {
+		var next [4]float64
+		norm := 0.0
+		for i := range next {
+			for j := range axis {
+				next[i] += cov[i][j] * axis[j]
+			}
+			norm += next[i] * next[i]
+		}
+		if norm == 0 {
+			break
+		}
+		norm = math.Sqrt(norm)
+		for i := range next {
+			axis[i] = next[i] / norm
+		}
+	}
+	return
+}
+
+// principalLine returns the endpoints of the segment along the principal axis
+// of the colors of texels which covers all of them.
+func principalLine(texels []astcTexel) (e0, e1 [4]float64) {
+	if len(texels) == 0 {
+		return
+	}
+	var mean [4]float64
+	for _, t := range texels {
+		for c := range mean {
+			mean[c] += float64(t.c[c])
+		}
+	}
+	for c := range mean {
+		mean[c] /= float64(len(texels))
+	}
+	var cov [4][4]float64
+	for _, t := range texels {
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				cov[i][j] += (float64(t.c[i]) - mean[i]) * (float64(t.c[j]) - mean[j])
+			}
+		}
+	}
+
+	axis := principalAxis(&cov)
+	tMin, tMax := math.Inf(1), math.Inf(-1)
+	for _, t := range texels {
+		p := 0.0
+		for c := 0; c < 4; c++ {
+			p += (float64(t.c[c]) - mean[c]) * axis[c]
+		}
+		tMin, tMax = math.Min(tMin, p), math.Max(tMax, p)
+	}
+	for c := range e0 {
+		e0[c] = math.Max(0, math.Min(255, mean[c]+tMin*axis[c]))
+		e1[c] = math.Max(0, math.Min(255, mean[c]+tMax*axis[c]))
+	}
+	return
+}
+
+// dualPlaneLine returns the endpoints of the colors of texels whose component
+// is in a second plane of weights: the principal line of the other components,
+// and the range of the component.
+func dualPlaneLine(texels []astcTexel, component int) (e0, e1 [4]float64) {
+	others := make([]astcTexel, len(texels))
+	low, high := 0xFF, 0
+	for i, t := range texels {
+		others[i] = t
+		others[i].c[component] = 0
+		if t.c[component] < low {
+			low = t.c[component]
+		}
+		if t.c[component] > high {
+			high = t.c[component]
+		}
+	}
+	e0, e1 = principalLine(others)
+	e0[component], e1[component] = float64(low), float64(high)
+	return
+}
+
+// endpointValues returns the color values of the endpoint mode which store
+// the endpoints e0 and e1.
+func endpointValues(mode int, e0, e1 [4]float64) []float64 {
+	switch mode {
+	case 0:
+		return []float64{e0[0], e1[0]}
+	case 4:
+		return []float64{e0[0], e1[0], e0[3], e1[3]}
+	case 8:
+		return []float64{e0[0], e1[0], e0[1], e1[1], e0[2], e1[2]}
+	}
+	return []float64{e0[0], e1[0], e0[1], e1[1], e0[2], e1[2], e0[3], e1[3]}
+}
+
+// quantizeEndpoints quantizes the endpoints of a partition to the color range
+// r, and returns the quantized values with the endpoints they decode to.
+func quantizeEndpoints(mode int, e0, e1 [4]float64, r iseRange) (values []int, d0, d1 [4]int) {
+	levels := astcColorLevels[r]
+	ideal := endpointValues(mode, e0, e1)
+	values = make([]int, len(ideal))
+	unquantized := make([]int, len(ideal))
+	for i, v := range ideal {
+		values[i] = nearestLevel(levels, v)
+		unquantized[i] = levels[values[i]]
+	}
+	if mode == 8 || mode == 12 {
+		// Endpoints are swapped with blue contraction when the second one
+		// is darker, which keeps them in order
+		if unquantized[1]+unquantized[3]+unquantized[5] < unquantized[0]+unquantized[2]+unquantized[4] {
+			for i := 0; i < len(values); i += 2 {
+				values[i], values[i+1] = values[i+1], values[i]
+				unquantized[i], unquantized[i+1] = unquantized[i+1], unquantized[i]
+			}
+		}
+	}
+	d0, d1 = decodeEndpoints(mode, unquantized)
+	return
+}
+
+// astcConfig is a split of the texels of a block among partitions and planes.
+type astcConfig struct {
+	partitions, index int
+	// plane2Component is the component of the second plane of weights,
+	// or -1 with a single plane
+	plane2Component int
+}
+
+// astcEncoder encodes the blocks of one footprint.
+type astcEncoder struct {
+	width, height int
+	srgb          bool
+	quality       ASTCQuality
+	// modes and dualPlaneModes hold the layouts of weights to try,
+	// with one and two planes
+	modes, dualPlaneModes []astcBlockMode
+	decoded               []color.NRGBA
+}
+
+func newASTCEncoder(width, height int, srgb bool, quality ASTCQuality) *astcEncoder {
+	e := &astcEncoder{width: width, height: height, srgb: srgb, quality: quality}
+	e.decoded = make([]color.NRGBA, width*height)
+
+	// Largest grid of every weight range for the fast quality
+	largest := make(map[iseRange]int)
+	for mode := 0; mode < 2048; mode++ {
+		m, ok := decodeBlockMode(mode)
+		if !ok || m.weightWidth > width || m.weightHeight > height || astcBlockModes[m] != mode {
+			continue
+		}
+		if m.dualPlane {
+			if quality != ASTCFast {
+				e.dualPlaneModes = append(e.dualPlaneModes, m)
+			}
+			continue
+		}
+		if quality == ASTCFast {
+			if i, found := largest[m.weightRange]; found {
+				if e.modes[i].weightWidth*e.modes[i].weightHeight < m.weightWidth*m.weightHeight {
+					e.modes[i] = m
+				}
+				continue
+			}
+			largest[m.weightRange] = len(e.modes)
+		}
+		e.modes = append(e.modes, m)
+	}
+	return e
+}
+
+// blockError returns the sum of squared errors between the texels and
+// their decoded colors from block.
+func (e *astcEncoder) blockError(block []byte, texels []astcTexel) int {
+	decodeBlockASTC(block, e.width, e.height, e.srgb, e.decoded)
+	total := 0
+	for _, t := range texels {
+		c := e.decoded[t.y*e.width+t.x]
+		for i, v := range [4]uint8{c.R, c.G, c.B, c.A} {
+			d := int(v) - t.c[i]
+			total += d * d
+		}
+	}
+	return total
+}
+
+// partitionTexels splits the texels among the partitions of the pattern.
+func (e *astcEncoder) partitionTexels(texels []astcTexel, partitions, index int) [][]astcTexel {
+	result := make([][]astcTexel, partitions)
+	smallBlock := e.width*e.height < 31
+	for _, t := range texels {
+		p := 0
+		if partitions > 1 {
+			p = selectPartition(index, t.x, t.y, partitions, smallBlock)
+		}
+		result[p] = append(result[p], t)
+	}
+	return result
+}
+
+// encode returns the encoding of the texels with the layout of weights m,
+// or false if the color values do not fit in the block. The texels of every
+// partition lie on the lines between their endpoints.
+func (e *astcEncoder) encode(texels []astcTexel, m astcBlockMode, c astcConfig, endpointMode int, lines [][2][4]float64) (*astcEncoding, bool) {
+	gridSize := m.weightWidth * m.weightHeight
+	colorStart := 17
+	if c.partitions > 1 {
+		colorStart = 29
+	}
+	planes := 1
+	belowWeights := 128 - m.weightRange.bitCount(m.weightCount())
+	if m.dualPlane {
+		planes = 2
+		belowWeights -= 2
+	}
+	colorCount := c.partitions * (endpointMode>>2 + 1) * 2
+	colorRange, ok := colorRangeFor(colorCount, belowWeights-colorStart)
+	if !ok {
+		return nil, false
+	}
+
+	enc := &astcEncoding{m, c.partitions, c.index, endpointMode, c.plane2Component, nil, nil}
+	var endpoints [4][2][4]int
+	for p := 0; p < c.partitions; p++ {
+		values, d0, d1 := quantizeEndpoints(endpointMode, lines[p][0], lines[p][1], colorRange)
+		enc.colors = append(enc.colors, values...)
+		endpoints[p] = [2][4]int{d0, d1}
+	}
+
+	// Ideal weights of every texel, spread over the grid as the infill
+	// gathers them
+	smallBlock := e.width*e.height < 31
+	sums := make([]float64, gridSize*planes)
+	factorSums := make([]float64, gridSize*planes)
+	for _, t := range texels {
+		p := 0
+		if c.partitions > 1 {
+			p = selectPartition(c.index, t.x, t.y, c.partitions, smallBlock)
+		}
+		d0, d1 := endpoints[p][0], endpoints[p][1]
+		var dot, length [2]int
+		for ch := range d0 {
+			plane := 0
+			if m.dualPlane && ch == c.plane2Component {
+				plane = 1
+			}
+			dot[plane] += (t.c[ch] - d0[ch]) * (d1[ch] - d0[ch])
+			length[plane] += (d1[ch] - d0[ch]) * (d1[ch] - d0[ch])
+		}
+		indices, factors := infillTexel(t.x, t.y, m.weightWidth, m.weightHeight, e.width, e.height)
+		for plane := 0; plane < planes; plane++ {
+			w := 0.0
+			if length[plane] > 0 {
+				w = math.Max(0, math.Min(1, float64(dot[plane])/float64(length[plane]))) * 64
+			}
+			for i, index := range indices {
+				if index >= 0 {
+					sums[index*planes+plane] += w * float64(factors[i])
+					factorSums[index*planes+plane] += float64(factors[i])
+				}
+			}
+		}
+	}
+	levels := astcWeightLevels[m.weightRange]
+	enc.weights = make([]int, gridSize*planes)
+	for i := range enc.weights {
+		if factorSums[i] > 0 {
+			enc.weights[i] = nearestLevel(levels, sums[i]/factorSums[i])
+		}
+	}
+	return enc, true
+}
+
+// refineWeights moves every weight of enc to the next levels up or down
+// while it lowers the error, and stores the best block into block.
+func (e *astcEncoder) refineWeights(enc *astcEncoding, texels []astcTexel, block []byte, err int) {
+	order := astcWeightOrder[enc.mode.weightRange]
+	candidate := make([]byte, astcBlockSize)
+	for i := range enc.weights {
+		pos := 0
+		for order[pos] != enc.weights[i] {
+			pos++
+		}
+		for _, step := range []int{-1, 1} {
+			for next := pos + step; next >= 0 && next < len(order); next += step {
+				previous := enc.weights[i]
+				enc.weights[i] = order[next]
+				enc.pack(candidate)
+				candidateErr := e.blockError(candidate, texels)
+				if candidateErr >= err {
+					enc.weights[i] = previous
+					break
+				}
+				err, pos = candidateErr, next
+				copy(block, candidate)
+			}
+		}
+	}
+}
+
+// partitionPatterns returns the indices of the patterns of two partitions
+// whose colors are the closest to their principal lines, from the best one.
+func (e *astcEncoder) partitionPatterns(texels []astcTexel, count int) []int {
+	residuals := make([]float64, 1024)
+	indices := make([]int, 1024)
+	smallBlock := e.width*e.height < 31
+	for index := range indices {
+		indices[index] = index
+		var n [2]float64
+		var sums [2][4]float64
+		var products [2][4][4]float64
+		for _, t := range texels {
+			p := selectPartition(index, t.x, t.y, 2, smallBlock)
+			n[p]++
+			for i, v := range t.c {
+				sums[p][i] += float64(v)
+				for j, w := range t.c {
+					products[p][i][j] += float64(v * w)
+				}
+			}
+		}
+
+		// Variance of the colors which their principal axis leaves out
+		for p := range n {
+			if n[p] == 0 {
+				continue
+			}
+			var cov [4][4]float64
+			for i := range cov {
+				for j := range cov[i] {
+					cov[i][j] = products[p][i][j] - sums[p][i]*sums[p][j]/n[p]
+				}
+			}
+			axis := principalAxis(&cov)
+			for i := range cov {
+				residuals[index] += cov[i][i]
+				for j := range cov[i] {
+					residuals[index] -= axis[i] * cov[i][j] * axis[j]
+				}
+			}
+		}
+	}
+	sort.SliceStable(indices, func(i, j int) bool { return residuals[indices[i]] < residuals[indices[j]] })
+	return indices[:count]
+}
+
+// encodeBlock encodes the texels of one block into dst.
+func (e *astcEncoder) encodeBlock(dst []byte, texels []astcTexel) {
+	solid, gray, opaque := true, true, true
+	for _, t := range texels {
+		solid = solid && t.c == texels[0].c
+		gray = gray && t.c[0] == t.c[1] && t.c[1] == t.c[2]
+		opaque = opaque && t.c[3] == 0xFF
+	}
+	if len(texels) == 0 || solid {
+		var c [4]int
+		if len(texels) > 0 {
+			c = texels[0].c
+		}
+		writeVoidExtent(dst, c)
+		return
+	}
+
+	endpointModes := []int{12}
+	if opaque {
+		endpointModes = []int{8}
+	}
+	if gray && opaque {
+		endpointModes = append(endpointModes, 0)
+	} else if gray {
+		endpointModes = append(endpointModes, 4)
+	}
+	configs := []astcConfig{{1, 0, -1}}
+	if e.quality != ASTCFast {
+		components := 4
+		if opaque {
+			components = 3
+		}
+		for component := 0; component < components; component++ {
+			configs = append(configs, astcConfig{1, 0, component})
+		}
+	}
+	if e.quality == ASTCThorough {
+		for _, index := range e.partitionPatterns(texels, 2) {
+			configs = append(configs, astcConfig{2, index, -1})
+		}
+	}
+
+	bestErr := -1
+	var best *astcEncoding
+	block := make([]byte, astcBlockSize)
+	for _, c := range configs {
+		lines := make([][2][4]float64, c.partitions)
+		modes, modeCount := e.modes, len(endpointModes)
+		if c.plane2Component >= 0 {
+			lines[0][0], lines[0][1] = dualPlaneLine(texels, c.plane2Component)
+			modes, modeCount = e.dualPlaneModes, 1
+		} else {
+			for p, pt := range e.partitionTexels(texels, c.partitions, c.index) {
+				lines[p][0], lines[p][1] = principalLine(pt)
+			}
+			if c.partitions > 1 {
+				modeCount = 1
+			}
+		}
+		for _, endpointMode := range endpointModes[:modeCount] {
+			for _, m := range modes {
+				enc, ok := e.encode(texels, m, c, endpointMode, lines)
+				if !ok {
+					continue
+				}
+				enc.pack(block)
+				if err := e.blockError(block, texels); bestErr < 0 || err < bestErr {
+					bestErr, best = err, enc
+					copy(dst, block)
+				}
+			}
+		}
+	}
+	if best == nil {
+		// No layout fits, which does not happen with the standard footprints
+		writeVoidExtent(dst, texels[0].c)
+		return
+	}
+	if e.quality == ASTCThorough {
+		e.refineWeights(best, texels, dst, bestErr)
+	}
+}
+
+// CompressQuality encodes im into p with the given quality. Both images must
+// have the same size, which does not need to be a multiple of the footprint.
+func (p *ASTC) CompressQuality(im image.Image, quality ASTCQuality) error {
+	b := im.Bounds()
+	if b.Dx() != p.Rect.Dx() || b.Dy() != p.Rect.Dy() {
+		return fmt.Errorf("ASTC compress: wrong image size [%vx%v != %vx%v]", b.Dx(), b.Dy(), p.Rect.Dx(), p.Rect.Dy())
+	}
+	if !IsASTCFootprint(p.BlockWidth, p.BlockHeight) {
+		return fmt.Errorf("ASTC compress: unsupported block footprint [%vx%v]", p.BlockWidth, p.BlockHeight)
+	}
+
+	e := newASTCEncoder(p.BlockWidth, p.BlockHeight, p.SRGB, quality)
+	xBlocks, yBlocks := p.BlockDimensions()
+	texels := make([]astcTexel, 0, p.BlockWidth*p.BlockHeight)
+	for yBlock := 0; yBlock < yBlocks; yBlock++ {
+		for xBlock := 0; xBlock < xBlocks; xBlock++ {
+			// Pixels of edge blocks outside of the image are left out
+			texels = texels[:0]
+			for y := 0; y < p.BlockHeight; y++ {
+				for x := 0; x < p.BlockWidth; x++ {
+					px, py := xBlock*p.BlockWidth+x, yBlock*p.BlockHeight+y
+					if px >= b.Dx() || py >= b.Dy() {
+						continue
+					}
+					c := color.NRGBAModel.Convert(im.At(b.Min.X+px, b.Min.Y+py)).(color.NRGBA)
+					texels = append(texels, astcTexel{x, y, [4]int{int(c.R), int(c.G), int(c.B), int(c.A)}})
+				}
+			}
+			offset := (yBlock*xBlocks + xBlock) * astcBlockSize
+			e.encodeBlock(p.Pix[offset:offset+astcBlockSize], texels)
+		}
+	}
+	return nil
+}
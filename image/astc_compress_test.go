@@ -0,0 +1,235 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// astcCompressionError returns the sum of squared errors between im and
+// its ASTC encoding.
+func astcCompressionError(t *testing.T, im image.Image, p *ASTC) int {
+	uncompressed, err := p.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := im.Bounds()
+	total := 0
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := uncompressed.At(p.Rect.Min.X+x, p.Rect.Min.Y+y).(color.NRGBA)
+			expected := color.NRGBAModel.Convert(im.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			for i, v := range []uint8{c.R, c.G, c.B, c.A} {
+				d := int(v) - int([]uint8{expected.R, expected.G, expected.B, expected.A}[i])
+				total += d * d
+			}
+		}
+	}
+	return total
+}
+
+func TestEncodeISE(t *testing.T) {
+	// Every count of values leaves out a different part of the last group
+	for _, r := range iseRanges {
+		for n := 1; n <= 16 && 5+r.bitCount(n) <= 128; n++ {
+			values := make([]int, n)
+			for i := range values {
+				values[i] = (i*7 + n) % r.levels
+			}
+			var b astcBits
+			encodeISE(&b, 5, r, values)
+			// Bits past the sequence are left untouched
+			for pos := 5 + r.bitCount(n); pos < 128; pos++ {
+				if b.get(pos, 1) != 0 {
+					t.Errorf("Bit %v written past %v values of range %v", pos, n, r.levels)
+				}
+			}
+			decoded := make([]int, n)
+			decodeISE(b, 5, r, decoded)
+			for i := range values {
+				if decoded[i] != values[i] {
+					t.Errorf("Wrong value %v of %v in range %v : expected %v, got %v", i, n, r.levels, values[i], decoded[i])
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeBlockModes(t *testing.T) {
+	for m, mode := range astcBlockModes {
+		if decoded, ok := decodeBlockMode(mode); !ok || decoded != m {
+			t.Errorf("Wrong block mode %#x : expected %+v, got %+v", mode, m, decoded)
+		}
+	}
+}
+
+// Block mode 0x252 with a grid of 4x4 weights of 20 levels, one partition
+// with the RGB endpoints black and white, and the weights 0 to 15 from the
+// top left texel, row by row
+var astcQuintWeightsBlock = []byte{0x52, 0x02, 0x01, 0x01, 0x20, 0x00, 0x04, 0xF8, 0x5B, 0xD3, 0x25, 0xC3, 0x99, 0x0A, 0x26, 0x04}
+
+// Gray levels of the texels of astcQuintWeightsBlock, from the weights 0, 64,
+// 16, 48, 3, 61, 19, 45, 6, 58, 23, 41, 9, 55, 26 and 38 of the specification
+var astcQuintWeightsGrays = []int{0, 255, 64, 191, 12, 243, 76, 179, 24, 231, 92, 163, 36, 219, 104, 151}
+
+func TestCompressASTCReferenceBlock(t *testing.T) {
+	var texels []astcTexel
+	var pixels [16]color.NRGBA
+	decodeBlockASTC(astcQuintWeightsBlock, 4, 4, false, pixels[:])
+	for i, l := range astcQuintWeightsGrays {
+		expected := color.NRGBA{uint8(l), uint8(l), uint8(l), 0xFF}
+		if pixels[i] != expected {
+			t.Errorf("Wrong pixel at [%v %v] of the reference block : expected %v, got %v", i%4, i/4, expected, pixels[i])
+		}
+		texels = append(texels, astcTexel{i % 4, i / 4, [4]int{l, l, l, 0xFF}})
+	}
+
+	// The encoder picks the weights of the reference block for its texels,
+	// and finds no error in them
+	m := astcBlockMode{4, 4, false, iseRanges[9]}
+	e := newASTCEncoder(4, 4, false, ASTCFast)
+	lines := [][2][4]float64{{{0, 0, 0, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF}}}
+	enc, ok := e.encode(texels, m, astcConfig{1, 0, -1}, 8, lines)
+	if !ok {
+		t.Fatal("Expected an encoding of the reference block")
+	}
+	block := make([]byte, astcBlockSize)
+	enc.pack(block)
+	if !bytes.Equal(block, astcQuintWeightsBlock) {
+		t.Errorf("Wrong encoding of the reference block : expected %#v, got %#v", astcQuintWeightsBlock, block)
+	}
+	if err := e.blockError(block, texels); err != 0 {
+		t.Errorf("Wrong error of the reference block : expected 0, got %v", err)
+	}
+}
+
+func TestCompressASTCSolid(t *testing.T) {
+	// Partial blocks on the right and bottom edges with every footprint
+	r := image.Rect(0, 0, 13, 11)
+	for _, c := range []color.NRGBA{{0, 0, 0, 255}, {255, 255, 255, 255}, {12, 34, 56, 78}} {
+		im := image.NewNRGBA(r)
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				im.Set(x, y, c)
+			}
+		}
+		for _, f := range astcFootprints {
+			p := NewASTC(r, f.X, f.Y)
+			if err := p.CompressQuality(im, ASTCFast); err != nil {
+				t.Fatal(err)
+			}
+			if err := astcCompressionError(t, im, p); err != 0 {
+				t.Errorf("Wrong encoding of %v with footprint %vx%v : error %v", c, f.X, f.Y, err)
+			}
+		}
+	}
+}
+
+func TestCompressASTCQuality(t *testing.T) {
+	r := image.Rect(0, 0, 13, 9)
+	im := image.NewNRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			l := x*10 + y*7
+			im.Set(x, y, color.NRGBA{uint8(l + 20), uint8(l + 40), uint8(l/2 + 60), uint8(255 - y*10)})
+		}
+	}
+
+	// Largest mean squared errors per component with the fast and
+	// thorough qualities, larger footprints have less bits per pixel
+	tests := []struct {
+		footprint      image.Point
+		fast, thorough float64
+	}{
+		{image.Point{4, 4}, 20, 3},
+		{image.Point{6, 5}, 60, 8},
+		{image.Point{8, 8}, 200, 16},
+		{image.Point{12, 12}, 250, 45},
+	}
+	for _, test := range tests {
+		f := test.footprint
+		previous := -1
+		for _, quality := range []ASTCQuality{ASTCFast, ASTCMedium, ASTCThorough} {
+			p := NewASTC(r, f.X, f.Y)
+			if err := p.CompressQuality(im, quality); err != nil {
+				t.Fatal(err)
+			}
+			err := astcCompressionError(t, im, p)
+			mse := float64(err) / float64(r.Dx()*r.Dy()*4)
+			if (quality == ASTCFast && mse > test.fast) || (quality == ASTCThorough && mse > test.thorough) {
+				t.Errorf("Poor encoding with footprint %vx%v and quality %v : mean squared error %v", f.X, f.Y, quality, mse)
+			}
+			if previous >= 0 && err > previous {
+				t.Errorf("Worse encoding with footprint %vx%v and quality %v than the previous one : %v > %v", f.X, f.Y, quality, err, previous)
+			}
+			previous = err
+		}
+	}
+}
+
+func TestCompressASTCPartitions(t *testing.T) {
+	// A color in the first partition of a diagonal pattern, and a checker
+	// of two colors in the second one, which only the thorough quality
+	// encodes without error
+	r := image.Rect(0, 0, 6, 6)
+	im := image.NewNRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			c := color.NRGBA{0x00, 0xFF, 0x00, 0xFF}
+			if selectPartition(512, x, y, 2, false) == 1 {
+				c = color.NRGBA{0xFF, 0x00, 0x00, 0xFF}
+				if (x+y)%2 == 1 {
+					c = color.NRGBA{0x00, 0x00, 0xFF, 0xFF}
+				}
+			}
+			im.Set(x, y, c)
+		}
+	}
+	p := NewASTC(r, 6, 6)
+	if err := p.CompressQuality(im, ASTCThorough); err != nil {
+		t.Fatal(err)
+	}
+	if err := astcCompressionError(t, im, p); err != 0 {
+		t.Errorf("Wrong encoding of two partitions : error %v", err)
+	}
+	if partitions := newASTCBits(p.Pix).get(11, 2) + 1; partitions != 2 {
+		t.Errorf("Wrong number of partitions : expected 2, got %v", partitions)
+	}
+}
+
+func TestCompressASTCSRGB(t *testing.T) {
+	r := image.Rect(0, 0, 8, 8)
+	im := image.NewNRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			im.Set(x, y, color.NRGBA{uint8(x * 30), uint8(y * 30), 0x80, 0xFF})
+		}
+	}
+	p := NewASTC(r, 4, 4)
+	p.SRGB = true
+	if err := p.Compress(im); err != nil {
+		t.Fatal(err)
+	}
+	if mse := float64(astcCompressionError(t, im, p)) / float64(r.Dx()*r.Dy()*4); mse > 4 {
+		t.Errorf("Poor encoding of sRGB blocks : mean squared error %v", mse)
+	}
+}
+
+func TestCompressASTCError(t *testing.T) {
+	tests := []struct {
+		p   *ASTC
+		im  image.Image
+		err string
+	}{
+		{NewASTC(image.Rect(0, 0, 8, 8), 4, 4), image.NewNRGBA(image.Rect(0, 0, 4, 8)), "ASTC compress: wrong image size"},
+		{NewASTC(image.Rect(0, 0, 8, 8), 7, 7), image.NewNRGBA(image.Rect(0, 0, 8, 8)), "ASTC compress: unsupported block footprint"},
+	}
+	for _, test := range tests {
+		err := test.p.Compress(test.im)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package image
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// astcBits holds the 128 bits of an ASTC block, bit 0 being the lowest bit
+// of the first byte.
+type astcBits struct {
+	lo, hi uint64
+}
+
+func newASTCBits(block []byte) astcBits {
+	return astcBits{binary.LittleEndian.Uint64(block[0:8]), binary.LittleEndian.Uint64(block[8:16])}
+}
+
+// get returns n bits, at most 32, from position pos.
+func (b astcBits) get(pos, n int) int {
+	var v uint64
+	if pos >= 64 {
+		v = b.hi >> uint(pos-64)
+	} else {
+		v = b.lo >> uint(pos)
+		if pos > 0 {
+			v |= b.hi << uint(64-pos)
+		}
+	}
+	return int(v & (1<<uint(n) - 1))
+}
+
+// set stores the n lowest bits of v, at most 32, at position pos.
+func (b *astcBits) set(pos, n, v int) {
+	mask := uint64(1)<<uint(n) - 1
+	bits := uint64(v) & mask
+	if pos >= 64 {
+		b.hi |= bits << uint(pos-64)
+		return
+	}
+	b.lo |= bits << uint(pos)
+	if pos > 0 && pos+n > 64 {
+		b.hi |= bits >> uint(64-pos)
+	}
+}
+
+// write stores the 128 bits into the 16 bytes of block.
+func (b astcBits) write(block []byte) {
+	binary.LittleEndian.PutUint64(block[0:8], b.lo)
+	binary.LittleEndian.PutUint64(block[8:16], b.hi)
+}
+
+// reversed returns the bits in the reverse order, to read the weights which
+// are stored from the highest bit.
+func (b astcBits) reversed() astcBits {
+	return astcBits{bits.Reverse64(b.hi), bits.Reverse64(b.lo)}
+}
+
+// iseRange is a range of integers of the integer sequence encoding, whose
+// values are stored with a number of bits and optionally a trit or a quint.
+type iseRange struct {
+	levels int
+	bits   int
+	trits  bool
+	quints bool
+}
+
+// Ranges of the integer sequence encoding, from the smallest one
+var iseRanges = []iseRange{
+	{2, 1, false, false},
+	{3, 0, true, false},
+	{4, 2, false, false},
+	{5, 0, false, true},
+	{6, 1, true, false},
+	{8, 3, false, false},
+	{10, 1, false, true},
+	{12, 2, true, false},
+	{16, 4, false, false},
+	{20, 2, false, true},
+	{24, 3, true, false},
+	{32, 5, false, false},
+	{40, 3, false, true},
+	{48, 4, true, false},
+	{64, 6, false, false},
+	{80, 4, false, true},
+	{96, 5, true, false},
+	{128, 7, false, false},
+	{160, 5, false, true},
+	{192, 6, true, false},
+	{256, 8, false, false},
+}
+
+// bitCount returns how many bits store n values of the range.
+func (r iseRange) bitCount(n int) int {
+	count := n * r.bits
+	if r.trits {
+		count += (8*n + 4) / 5
+	} else if r.quints {
+		count += (7*n + 2) / 3
+	}
+	return count
+}
+
+// bitReader reads an integer sequence, the bits past its end being 0.
+type bitReader struct {
+	bits     astcBits
+	pos, end int
+}
+
+func (r *bitReader) read(n int) int {
+	if r.pos+n > r.end {
+		n = r.end - r.pos
+	}
+	if n <= 0 {
+		return 0
+	}
+	v := r.bits.get(r.pos, n)
+	r.pos += n
+	return v
+}
+
+// bitWriter writes an integer sequence, dropping the bits past its end.
+type bitWriter struct {
+	bits     *astcBits
+	pos, end int
+}
+
+func (w *bitWriter) write(n, v int) {
+	if w.pos+n > w.end {
+		n = w.end - w.pos
+	}
+	if n <= 0 {
+		return
+	}
+	w.bits.set(w.pos, n, v)
+	w.pos += n
+}
+
+// decodeTrits returns the 5 trits packed in the 8 bits of t.
+func decodeTrits(t int) (trits [5]int) {
+	bit := func(v int, i uint) int { return v >> i & 1 }
+	var c int
+	if t>>2&7 == 7 {
+		c = (t>>5&7)<<2 | t&3
+		trits[4], trits[3] = 2, 2
+	} else {
+		c = t & 0x1F
+		if t>>5&3 == 3 {
+			trits[4], trits[3] = 2, bit(t, 7)
+		} else {
+			trits[4], trits[3] = bit(t, 7), t>>5&3
+		}
+	}
+	if c&3 == 3 {
+		trits[2], trits[1] = 2, bit(c, 4)
+		trits[0] = bit(c, 3)<<1 | bit(c, 2)&^bit(c, 3)
+	} else if c>>2&3 == 3 {
+		trits[2], trits[1], trits[0] = 2, 2, c&3
+	} else {
+		trits[2], trits[1] = bit(c, 4), c>>2&3
+		trits[0] = bit(c, 1)<<1 | bit(c, 0)&^bit(c, 1)
+	}
+	return
+}
+
+// decodeQuints returns the 3 quints packed in the 7 bits of q.
+func decodeQuints(q int) (quints [3]int) {
+	bit := func(v int, i uint) int { return v >> i & 1 }
+	if q>>1&3 == 3 && q>>5&3 == 0 {
+		quints[2] = bit(q, 0)<<2 | (bit(q, 4)&^bit(q, 0))<<1 | bit(q, 3)&^bit(q, 0)
+		quints[1], quints[0] = 4, 4
+		return
+	}
+	var c int
+	if q>>1&3 == 3 {
+		quints[2] = 4
+		c = (q>>3&3)<<3 | (^q>>5&3)<<1 | q&1
+	} else {
+		quints[2] = q >> 5 & 3
+		c = q & 0x1F
+	}
+	if c&7 == 5 {
+		quints[1], quints[0] = 4, c>>3&3
+	} else {
+		quints[1], quints[0] = c>>3&3, c&7
+	}
+	return
+}
+
+// Smallest packed values of every sequence of trits and quints, whose bits
+// past the last nonzero value are 0, so that they can be left out
+var (
+	tritEncodings  = make(map[[5]int]int)
+	quintEncodings = make(map[[3]int]int)
+)
+
+func init() {
+	for t := 255; t >= 0; t-- {
+		tritEncodings[decodeTrits(t)] = t
+	}
+	for q := 127; q >= 0; q-- {
+		quintEncodings[decodeQuints(q)] = q
+	}
+}
+
+// decodeISE reads len(dst) values of the range r from position pos of b.
+func decodeISE(b astcBits, pos int, r iseRange, dst []int) {
+	n := len(dst)
+	reader := bitReader{b, pos, pos + r.bitCount(n)}
+	switch {
+	case r.trits:
+		// Blocks of 5 values, the bits of the trits between their bits
+		for i := 0; i < n; i += 5 {
+			var m [5]int
+			m[0] = reader.read(r.bits)
+			t := reader.read(2)
+			m[1] = reader.read(r.bits)
+			t |= reader.read(2) << 2
+			m[2] = reader.read(r.bits)
+			t |= reader.read(1) << 4
+			m[3] = reader.read(r.bits)
+			t |= reader.read(2) << 5
+			m[4] = reader.read(r.bits)
+			t |= reader.read(1) << 7
+			trits := decodeTrits(t)
+			for j := 0; j < 5 && i+j < n; j++ {
+				dst[i+j] = trits[j]<<uint(r.bits) | m[j]
+			}
+		}
+	case r.quints:
+		// Blocks of 3 values, the bits of the quints between their bits
+		for i := 0; i < n; i += 3 {
+			var m [3]int
+			m[0] = reader.read(r.bits)
+			q := reader.read(3)
+			m[1] = reader.read(r.bits)
+			q |= reader.read(2) << 3
+			m[2] = reader.read(r.bits)
+			q |= reader.read(2) << 5
+			quints := decodeQuints(q)
+			for j := 0; j < 3 && i+j < n; j++ {
+				dst[i+j] = quints[j]<<uint(r.bits) | m[j]
+			}
+		}
+	default:
+		for i := range dst {
+			dst[i] = reader.read(r.bits)
+		}
+	}
+}
+
+// encodeISE writes the values of the range r to position pos of b,
+// as decodeISE reads them.
+func encodeISE(b *astcBits, pos int, r iseRange, values []int) {
+	n := len(values)
+	writer := bitWriter{b, pos, pos + r.bitCount(n)}
+	mask := 1<<uint(r.bits) - 1
+	switch {
+	case r.trits:
+		for i := 0; i < n; i += 5 {
+			var trits [5]int
+			var m [5]int
+			for j := 0; j < 5 && i+j < n; j++ {
+				trits[j], m[j] = values[i+j]>>uint(r.bits), values[i+j]&mask
+			}
+			t := tritEncodings[trits]
+			writer.write(r.bits, m[0])
+			writer.write(2, t)
+			writer.write(r.bits, m[1])
+			writer.write(2, t>>2)
+			writer.write(r.bits, m[2])
+			writer.write(1, t>>4)
+			writer.write(r.bits, m[3])
+			writer.write(2, t>>5)
+			writer.write(r.bits, m[4])
+			writer.write(1, t>>7)
+		}
+	case r.quints:
+		for i := 0; i < n; i += 3 {
+			var quints [3]int
+			var m [3]int
+			for j := 0; j < 3 && i+j < n; j++ {
+				quints[j], m[j] = values[i+j]>>uint(r.bits), values[i+j]&mask
+			}
+			q := quintEncodings[quints]
+			writer.write(r.bits, m[0])
+			writer.write(3, q)
+			writer.write(r.bits, m[1])
+			writer.write(2, q>>3)
+			writer.write(r.bits, m[2])
+			writer.write(2, q>>5)
+		}
+	default:
+		for _, v := range values {
+			writer.write(r.bits, v)
+		}
+	}
+}
+
+// replicate repeats the n bits of v to fill m bits.
+func replicate(v, n, m int) int {
+	result := 0
+	for shift := m - n; shift > -n; shift -= n {
+		if shift >= 0 {
+			result |= v << uint(shift)
+		} else {
+			result |= v >> uint(-shift)
+		}
+	}
+	return result
+}
+
+// Masks of the bits b, c, d... of a value, from its second lowest bit,
+// which make the term B of the color unquantization, indexed by the number
+// of bits along with a trit or a quint
+var (
+	colorTritMasks = [][]int{
+		2: {0x116},
+		3: {0x085, 0x10A},
+		4: {0x041, 0x082, 0x104},
+		5: {0x020, 0x040, 0x081, 0x102},
+		6: {0x010, 0x020, 0x040, 0x080, 0x101},
+	}
+	colorQuintMasks = [][]int{
+		2: {0x10C},
+		3: {0x082, 0x105},
+		4: {0x040, 0x081, 0x102},
+		5: {0x020, 0x040, 0x080, 0x101},
+	}
+	weightTritMasks = [][]int{
+		2: {0x45},
+		3: {0x21, 0x42},
+	}
+	weightQuintMasks = [][]int{
+		2: {0x42},
+	}
+)
+
+// Multipliers of the trit or quint in the unquantization, indexed by
+// the number of bits
+var (
+	colorTritScales   = []int{1: 204, 2: 93, 3: 44, 4: 22, 5: 11, 6: 5}
+	colorQuintScales  = []int{1: 113, 2: 54, 3: 26, 4: 13, 5: 6}
+	weightTritScales  = []int{1: 50, 2: 23, 3: 11}
+	weightQuintScales = []int{1: 28, 2: 13}
+)
+
+// unquantize expands v with a trit or a quint to topBit*2 bits, scrambling
+// its bits as the specification does.
+func unquantize(v, bits, scale int, masks []int, topBit int) int {
+	a := 0
+	if v&1 != 0 {
+		a = topBit*4 - 1
+	}
+	b := 0
+	for i := 1; i < bits; i++ {
+		if v>>uint(i)&1 != 0 {
+			b |= masks[i-1]
+		}
+	}
+	t := (v>>uint(bits))*scale + b
+	t ^= a
+	return a&topBit | t>>2
+}
+
+// unquantizeColor returns the 8-bit value of the color endpoint v of range r.
+func unquantizeColor(r iseRange, v int) int {
+	switch {
+	case r.trits:
+		return unquantize(v, r.bits, colorTritScales[r.bits], colorTritMasks[r.bits], 0x80)
+	case r.quints:
+		return unquantize(v, r.bits, colorQuintScales[r.bits], colorQuintMasks[r.bits], 0x80)
+	}
+	return replicate(v, r.bits, 8)
+}
+
+// unquantizeWeight returns the value from 0 to 64 of the weight v of range r.
+func unquantizeWeight(r iseRange, v int) int {
+	var w int
+	switch {
+	case r.trits && r.bits == 0:
+		w = []int{0, 32, 63}[v]
+	case r.quints && r.bits == 0:
+		w = []int{0, 16, 32, 47, 63}[v]
+	case r.trits:
+		w = unquantize(v, r.bits, weightTritScales[r.bits], weightTritMasks[r.bits], 0x20)
+	case r.quints:
+		w = unquantize(v, r.bits, weightQuintScales[r.bits], weightQuintMasks[r.bits], 0x20)
+	default:
+		w = replicate(v, r.bits, 6)
+	}
+	if w > 32 {
+		w++
+	}
+	return w
+}
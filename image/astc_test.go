@@ -0,0 +1,348 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Block mode 0x42 with a grid of 4x4 weights of 2 bits, one partition with
+// the RGB endpoints (10, 20, 30) and (200, 150, 100) in 8 bits, and weights
+// 0 to 3 from the left column to the right one
+var astcGoldenBlock = []byte{0x42, 0x00, 0x15, 0x90, 0x29, 0x2C, 0x3D, 0xC8, 0x00, 0x00, 0x00, 0x00, 0x27, 0x27, 0x27, 0x27}
+
+// Colors of the columns of astcGoldenBlock
+var astcGoldenColumns = [4]color.NRGBA{
+	{10, 20, 30, 255},
+	{72, 62, 53, 255},
+	{138, 107, 77, 255},
+	{200, 150, 100, 255},
+}
+
+func TestDecodeBlockASTC(t *testing.T) {
+	// The color endpoint mode 2 needs the HDR profile
+	hdr := append([]byte{}, astcGoldenBlock...)
+	hdr[1], hdr[2] = 0x40, 0x14
+
+	solid := color.NRGBA{0x80, 0x40, 0x20, 0xFF}
+	tests := []struct {
+		name   string
+		block  []byte
+		pixels func(x, y int) color.NRGBA
+	}{
+		{"single partition", astcGoldenBlock, func(x, y int) color.NRGBA {
+			return astcGoldenColumns[x]
+		}},
+		{"void extent", []byte{0xFC, 0xFD, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x80, 0x00, 0x40, 0xAA, 0x20, 0xFF, 0xFF}, func(x, y int) color.NRGBA {
+			return solid
+		}},
+		{"void extent with empty extent", []byte{0xFC, 0x0D, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0x80, 0x00, 0x40, 0xAA, 0x20, 0xFF, 0xFF}, func(x, y int) color.NRGBA {
+			return astcErrorColor
+		}},
+		{"HDR void extent", []byte{0xFC, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x80, 0x00, 0x40, 0xAA, 0x20, 0xFF, 0xFF}, func(x, y int) color.NRGBA {
+			return astcErrorColor
+		}},
+		{"reserved block mode", make([]byte, astcBlockSize), func(x, y int) color.NRGBA {
+			return astcErrorColor
+		}},
+		{"HDR endpoints", hdr, func(x, y int) color.NRGBA {
+			return astcErrorColor
+		}},
+	}
+	for _, test := range tests {
+		var pixels [16]color.NRGBA
+		decodeBlockASTC(test.block, 4, 4, false, pixels[:])
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				if expected, c := test.pixels(x, y), pixels[y*4+x]; c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+			}
+		}
+	}
+}
+
+// astcColumnsBlock returns a block of the mode of astcGoldenBlock with
+// the given color endpoint mode and values of 256 levels.
+func astcColumnsBlock(endpointMode int, colors ...int) []byte {
+	mode, _ := decodeBlockMode(0x42)
+	weights := make([]int, 16)
+	for i := range weights {
+		weights[i] = i % 4
+	}
+	block := make([]byte, astcBlockSize)
+	e := astcEncoding{mode, 1, 0, endpointMode, 0, colors, weights}
+	e.pack(block)
+	return block
+}
+
+func TestDecodeBlockASTCHDR(t *testing.T) {
+	// Weights 0, 21, 43 and 64 from the left column to the right one,
+	// interpolating endpoints in the logarithmic encoding
+	column := func(c ...[4]float32) func(x, y int) glcolor.NRGBAF32 {
+		return func(x, y int) glcolor.NRGBAF32 {
+			return glcolor.NRGBAF32{c[0][x], c[1][x], c[2][x], c[3][x]}
+		}
+	}
+	ones := [4]float32{1, 1, 1, 1}
+	mode2 := [4]float32{0.0078125, 0.048828125, 0.328125, 2}
+	mode3 := [4]float32{0.1396484375, 0.1424560546875, 0.1455078125, 0.1484375}
+	mode7 := [4]float32{0.5, 0.6328125, 0.8046875, 1}
+	mode11 := [3][4]float32{
+		{1, 1.265625, 1.609375, 2},
+		{0.5, 0.990234375, 2.0234375, 4},
+		{1, 1.59375, 2.5625, 4},
+	}
+	// LDR endpoints are interpolated in 16 bits
+	unorm := func(e0, e1 int) (c [4]float32) {
+		for x, w := range []int{0, 21, 43, 64} {
+			c[x] = float32((e0*0x101*(64-w)+e1*0x101*w+32)>>6) / 0xFFFF
+		}
+		return
+	}
+	// Alphas of the LDR and the HDR modes 14 and 15
+	alpha14 := unorm(0, 0xFF)
+	alpha15 := [4]float32{1, 0.205078125, 0.03857421875, 0.0078125}
+
+	tests := []struct {
+		name   string
+		block  []byte
+		pixels func(x, y int) glcolor.NRGBAF32
+	}{
+		{"LDR", astcGoldenBlock, column(unorm(10, 200), unorm(20, 150), unorm(30, 100), ones)},
+		{"void extent", []byte{0xFC, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x3C, 0x00, 0x40, 0x00, 0x38, 0x00, 0x3C}, func(x, y int) glcolor.NRGBAF32 {
+			return glcolor.NRGBAF32{1, 2, 0.5, 1}
+		}},
+		{"luminance with a large range", astcColumnsBlock(2, 0x40, 0x80), column(mode2, mode2, mode2, ones)},
+		{"luminance with a small range", astcColumnsBlock(3, 0x85, 0x63), column(mode3, mode3, mode3, ones)},
+		{"RGB with a scale", astcColumnsBlock(7, 0xFC, 0xBC, 0xBC, 0x04), column(mode7, mode7, mode7, ones)},
+		{"RGB", astcColumnsBlock(11, 0x78, 0x80, 0x70, 0x88, 0xBC, 0xC4), column(mode11[0], mode11[1], mode11[2], ones)},
+		{"RGB with LDR alpha", astcColumnsBlock(14, 0x78, 0x80, 0x70, 0x88, 0xBC, 0xC4, 0x00, 0xFF), column(mode11[0], mode11[1], mode11[2], alpha14)},
+		{"RGBA", astcColumnsBlock(15, 0x78, 0x80, 0x70, 0x88, 0xBC, 0xC4, 0xBC, 0xA0), column(mode11[0], mode11[1], mode11[2], alpha15)},
+	}
+	for _, test := range tests {
+		var pixels [16]glcolor.NRGBAF32
+		decodeBlockASTCHDR(test.block, 4, 4, pixels[:])
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				if expected, c := test.pixels(x, y), pixels[y*4+x]; c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+			}
+		}
+	}
+
+	// Invalid blocks decode to NaNs, and HDR blocks to errors with the LDR profile
+	var pixels [16]glcolor.NRGBAF32
+	decodeBlockASTCHDR(make([]byte, astcBlockSize), 4, 4, pixels[:])
+	if c := pixels[5]; !math.IsNaN(float64(c.R)) || !math.IsNaN(float64(c.A)) {
+		t.Errorf("Wrong pixel of reserved block mode : expected NaNs, got %v", c)
+	}
+	var ldrPixels [16]color.NRGBA
+	decodeBlockASTC(astcColumnsBlock(11, 0x78, 0x80, 0x70, 0x88, 0xBC, 0xC4), 4, 4, false, ldrPixels[:])
+	if c := ldrPixels[5]; c != astcErrorColor {
+		t.Errorf("Wrong pixel of HDR block with the LDR profile : expected %v, got %v", astcErrorColor, c)
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	tests := []struct {
+		h uint16
+		f float32
+	}{
+		{0x0000, 0},
+		{0x3C00, 1},
+		{0xC000, -2},
+		{0x7BFF, 65504},
+		// Smallest subnormal
+		{0x0001, float32(math.Ldexp(1, -24))},
+		{0x0300, float32(math.Ldexp(0.75, -14))},
+	}
+	for _, test := range tests {
+		if f := halfToFloat(test.h); f != test.f {
+			t.Errorf("Wrong float of half %#04x : expected %v, got %v", test.h, test.f, f)
+		}
+	}
+	if f := halfToFloat(0x7C00); !math.IsInf(float64(f), 1) {
+		t.Errorf("Wrong float of infinite half : got %v", f)
+	}
+}
+
+func TestDecodeBlockModes(t *testing.T) {
+	tests := []struct {
+		mode                      int
+		ok                        bool
+		weightWidth, weightHeight int
+		dualPlane                 bool
+		levels                    int
+	}{
+		{0x042, true, 4, 4, false, 4},
+		{0x253, true, 4, 4, false, 32},
+		{0x4C2, true, 5, 4, true, 4},
+		{0x108, true, 6, 6, false, 4},
+		{0x000, false, 0, 0, false, 0},
+		// Weights of both planes need too many bits
+		{0x653, false, 0, 0, false, 0},
+	}
+	for _, test := range tests {
+		m, ok := decodeBlockMode(test.mode)
+		if ok != test.ok {
+			t.Errorf("Wrong validity of block mode %#x : expected %v, got %v", test.mode, test.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if m.weightWidth != test.weightWidth || m.weightHeight != test.weightHeight || m.dualPlane != test.dualPlane || m.weightRange.levels != test.levels {
+			t.Errorf("Wrong block mode %#x : expected %vx%v weights, dual plane %v and %v levels, got %vx%v, %v and %v", test.mode,
+				test.weightWidth, test.weightHeight, test.dualPlane, test.levels,
+				m.weightWidth, m.weightHeight, m.dualPlane, m.weightRange.levels)
+		}
+	}
+}
+
+func TestDecodeTritsAndQuints(t *testing.T) {
+	trits := make(map[[5]int]bool)
+	for i := 0; i < 256; i++ {
+		trits[decodeTrits(i)] = true
+	}
+	if len(trits) != 243 {
+		t.Errorf("Wrong number of trit sequences : expected 243, got %v", len(trits))
+	}
+	quints := make(map[[3]int]bool)
+	for i := 0; i < 128; i++ {
+		quints[decodeQuints(i)] = true
+	}
+	if len(quints) != 125 {
+		t.Errorf("Wrong number of quint sequences : expected 125, got %v", len(quints))
+	}
+}
+
+func TestUnquantize(t *testing.T) {
+	// Colors have at least 6 levels
+	for _, r := range iseRanges[4:] {
+		colors := make(map[int]bool)
+		for v := 0; v < r.levels; v++ {
+			colors[unquantizeColor(r, v)] = true
+		}
+		if len(colors) != r.levels || !colors[0] || !colors[255] {
+			t.Errorf("Wrong color values of range %v : got %v", r.levels, colors)
+		}
+	}
+	// Weights of every range with at most 32 levels, in the order of their
+	// encoded values, as tabulated by the specification
+	weights := [][]int{
+		{0, 64},
+		{0, 32, 64},
+		{0, 21, 43, 64},
+		{0, 16, 32, 48, 64},
+		{0, 64, 12, 52, 25, 39},
+		{0, 9, 18, 27, 37, 46, 55, 64},
+		{0, 64, 7, 57, 14, 50, 21, 43, 28, 36},
+		{0, 64, 17, 47, 5, 59, 23, 41, 11, 53, 28, 36},
+		{0, 4, 8, 12, 17, 21, 25, 29, 35, 39, 43, 47, 52, 56, 60, 64},
+		{0, 64, 16, 48, 3, 61, 19, 45, 6, 58, 23, 41, 9, 55, 26, 38, 13, 51, 29, 35},
+		{0, 64, 8, 56, 16, 48, 24, 40, 2, 62, 11, 53, 19, 45, 27, 37, 5, 59, 13, 51, 22, 42, 30, 34},
+		{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30,
+			34, 36, 38, 40, 42, 44, 46, 48, 50, 52, 54, 56, 58, 60, 62, 64},
+	}
+	for i, r := range iseRanges[:12] {
+		for v := 0; v < r.levels; v++ {
+			if w := unquantizeWeight(r, v); w != weights[i][v] {
+				t.Errorf("Wrong weight %v of range %v : expected %v, got %v", v, r.levels, weights[i][v], w)
+			}
+		}
+	}
+}
+
+func TestSelectPartition(t *testing.T) {
+	for count := 2; count <= 4; count++ {
+		used := make(map[int]bool)
+		for index := 0; index < 1024; index++ {
+			for y := 0; y < 12; y++ {
+				for x := 0; x < 12; x++ {
+					p := selectPartition(index, x, y, count, false)
+					if p >= count {
+						t.Fatalf("Wrong partition of texel [%v %v] in pattern %v of %v partitions : got %v", x, y, index, count, p)
+					}
+					used[p] = true
+				}
+			}
+		}
+		if len(used) != count {
+			t.Errorf("Wrong number of partitions used by the patterns of %v partitions : got %v", count, len(used))
+		}
+	}
+}
+
+func TestDecodeASTCImage(t *testing.T) {
+	// Blocks of 10x8 pixels, 3x3 of them cropped to 25x17
+	r := image.Rect(2, 1, 27, 18)
+	m := NewASTC(r, 10, 8)
+	if x, y := m.BlockDimensions(); x != 3 || y != 3 {
+		t.Fatalf("Wrong block dimensions : expected [3 3], got [%v %v]", x, y)
+	}
+	if len(m.Pix) != 9*astcBlockSize {
+		t.Fatalf("Wrong size of pixel data : expected %v, got %v", 9*astcBlockSize, len(m.Pix))
+	}
+	voidExtent := []byte{0xFC, 0xFD, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x80, 0x00, 0x40, 0xAA, 0x20, 0xFF, 0xFF}
+	for i := 0; i < 9; i++ {
+		copy(m.Pix[i*astcBlockSize:], voidExtent)
+	}
+	uncompressed, err := m.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := uncompressed.(*image.NRGBA); !ok {
+		t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+	}
+	if uncompressed.Bounds() != r {
+		t.Errorf("Wrong bounds of uncompressed image : expected %v, got %v", r, uncompressed.Bounds())
+	}
+	expected := color.NRGBA{0x80, 0x40, 0x20, 0xFF}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if c := m.At(x, y); c != expected {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+			if c := uncompressed.At(x, y); c != expected {
+				t.Errorf("Wrong uncompressed pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+		}
+	}
+
+	// The HDR profile decodes to floats
+	m.Profile = ASTCProfileHDR
+	if uncompressed, err = m.Uncompress(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := uncompressed.(*NRGBAF32); !ok {
+		t.Fatalf("Wrong type of uncompressed HDR image : got %T", uncompressed)
+	}
+	if m.ColorModel() != glcolor.NRGBAF32Model {
+		t.Errorf("Wrong color model of HDR image")
+	}
+	expectedHDR := glcolor.NRGBAF32{float32(0x80FF) / 0xFFFF, float32(0x4000) / 0xFFFF, float32(0x20AA) / 0xFFFF, 1}
+	for _, p := range []image.Point{r.Min, r.Max.Sub(image.Point{1, 1})} {
+		if c := m.At(p.X, p.Y); c != expectedHDR {
+			t.Errorf("Wrong HDR pixel at %v : expected %v, got %v", p, expectedHDR, c)
+		}
+		if c := uncompressed.At(p.X, p.Y); c != expectedHDR {
+			t.Errorf("Wrong uncompressed HDR pixel at %v : expected %v, got %v", p, expectedHDR, c)
+		}
+	}
+
+	// The golden block in a 4x4 footprint
+	m = NewASTC(image.Rect(0, 0, 4, 4), 4, 4)
+	copy(m.Pix, astcGoldenBlock)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if expected, c := astcGoldenColumns[x], m.At(x, y); c != expected {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package astcfile reads and writes the .astc files of ASTC encoders, which
+// store the blocks of a single image after a 16-byte header.
+package astcfile
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+// 0x5CA1AB13 in little endian
+const magic = "\x13\xAB\xA1\x5C"
+
+const headerSize = 16
+
+// Header holds the fields of a .astc file header which follow the magic.
+type Header struct {
+	// Footprint of the blocks in pixels
+	BlockWidth, BlockHeight, BlockDepth uint8
+	// Size of the image in pixels, stored in 24 bits
+	Width, Height, Depth uint32
+}
+
+// decodeUint24 returns the little endian 24-bit value at the beginning of buf.
+func decodeUint24(buf []byte) uint32 {
+	return uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16
+}
+
+type decoder struct {
+	r      io.Reader
+	header Header
+	im     *glimage.ASTC
+}
+
+func (d *decoder) decode(r io.Reader, configOnly bool) error {
+	d.r = r
+	if err := d.decodeHeader(); err != nil {
+		return err
+	}
+	if configOnly {
+		return nil
+	}
+	return d.decodeImage()
+}
+
+func (d *decoder) decodeHeader() error {
+	var buf [headerSize]byte
+	if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+		return err
+	}
+	if magic != string(buf[:4]) {
+		return fmt.Errorf("ASTC reader: invalid identifier [%v]", buf[:4])
+	}
+
+	h := &d.header
+	h.BlockWidth, h.BlockHeight, h.BlockDepth = buf[4], buf[5], buf[6]
+	h.Width, h.Height, h.Depth = decodeUint24(buf[7:]), decodeUint24(buf[10:]), decodeUint24(buf[13:])
+	if h.BlockDepth > 1 || h.Depth > 1 {
+		return fmt.Errorf("ASTC reader: 3D textures are not supported [depth=%v, block depth=%v]", h.Depth, h.BlockDepth)
+	}
+	if !glimage.IsASTCFootprint(int(h.BlockWidth), int(h.BlockHeight)) {
+		return fmt.Errorf("ASTC reader: unsupported block footprint [%vx%v]", h.BlockWidth, h.BlockHeight)
+	}
+	return nil
+}
+
+func (d *decoder) decodeImage() error {
+	h := &d.header
+	r := image.Rect(0, 0, int(h.Width), int(h.Height))
+	d.im = glimage.NewASTC(r, int(h.BlockWidth), int(h.BlockHeight))
+	if _, err := io.ReadFull(d.r, d.im.Pix); err != nil {
+		return fmt.Errorf("ASTC reader: not enough image data [%v]", err)
+	}
+	return nil
+}
+
+// Decode reads a .astc file from r and returns the image as a *glimage.ASTC.
+// The file does not tell whether the blocks are sRGB nor their profile,
+// so the image is linear with the LDR profile.
+func Decode(r io.Reader) (image.Image, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.im, nil
+}
+
+// DecodeHeader reads the header of a .astc file from r without decoding
+// the blocks.
+func DecodeHeader(r io.Reader) (Header, error) {
+	var d decoder
+	if err := d.decode(r, true); err != nil {
+		return Header{}, err
+	}
+	return d.header, nil
+}
+
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	var d decoder
+	err := d.decode(r, true)
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      int(d.header.Width),
+		Height:     int(d.header.Height),
+	}, err
+}
+
+func init() {
+	image.RegisterFormat("astc", magic, Decode, DecodeConfig)
+}
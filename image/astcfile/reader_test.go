@@ -0,0 +1,94 @@
+package astcfile
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+// Block with a grid of 4x4 weights, the RGB endpoints (10, 20, 30) and
+// (200, 150, 100) and weights 0 to 3 from the left column to the right one
+var goldenBlock = []byte{0x42, 0x00, 0x15, 0x90, 0x29, 0x2C, 0x3D, 0xC8, 0x00, 0x00, 0x00, 0x00, 0x27, 0x27, 0x27, 0x27}
+
+// astcFile builds a .astc file with the given header fields and blocks.
+func astcFile(blockWidth, blockHeight, blockDepth uint8, width, height, depth uint32, blocks ...[]byte) []byte {
+	buf := []byte(magic)
+	buf = append(buf, blockWidth, blockHeight, blockDepth)
+	for _, v := range []uint32{width, height, depth} {
+		buf = append(buf, byte(v), byte(v>>8), byte(v>>16))
+	}
+	for _, b := range blocks {
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+func TestDecode(t *testing.T) {
+	// Two blocks of 4x4 pixels cropped to 6x3
+	input := astcFile(4, 4, 1, 6, 3, 1, goldenBlock, goldenBlock)
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "astc" || config.ColorModel != color.NRGBAModel || config.Width != 6 || config.Height != 3 {
+		t.Errorf("Wrong config : got %v %+v", format, config)
+	}
+	header, err := DecodeHeader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Header{4, 4, 1, 6, 3, 1}); header != expected {
+		t.Errorf("Wrong header : expected %+v, got %+v", expected, header)
+	}
+
+	m, _, err := image.Decode(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	astc, ok := m.(*glimage.ASTC)
+	if !ok {
+		t.Fatalf("Wrong image type : got %T", m)
+	}
+	if astc.Bounds() != image.Rect(0, 0, 6, 3) || astc.BlockWidth != 4 || astc.BlockHeight != 4 {
+		t.Errorf("Wrong bounds or footprint : got %v %vx%v", astc.Bounds(), astc.BlockWidth, astc.BlockHeight)
+	}
+	tests := []struct {
+		x, y int
+		c    color.Color
+	}{
+		{0, 0, color.NRGBA{10, 20, 30, 255}},
+		{3, 2, color.NRGBA{200, 150, 100, 255}},
+		{4, 1, color.NRGBA{10, 20, 30, 255}},
+	}
+	for _, test := range tests {
+		if c := m.At(test.x, test.y); c != test.c {
+			t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", test.x, test.y, test.c, c)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	badMagic := astcFile(4, 4, 1, 4, 4, 1, goldenBlock)
+	badMagic[0] = 0
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{badMagic, "ASTC reader: invalid identifier"},
+		{astcFile(4, 4, 4, 4, 4, 4, goldenBlock), "ASTC reader: 3D textures are not supported"},
+		{astcFile(7, 7, 1, 4, 4, 1, goldenBlock), "ASTC reader: unsupported block footprint"},
+		{astcFile(4, 4, 1, 8, 4, 1, goldenBlock), "ASTC reader: not enough image data"},
+		{astcFile(4, 4, 1, 4, 4, 1)[:10], "unexpected EOF"},
+	}
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package astcfile
+
+import (
+	"fmt"
+	"image"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+// Options are the encoding parameters of the images which are not ASTC yet.
+type Options struct {
+	// Footprint of the blocks in pixels, 4x4 if zero
+	BlockWidth, BlockHeight int
+	// Quality of the ASTC encoder
+	Quality glimage.ASTCQuality
+}
+
+// How many bytes in an ASTC block, whatever its footprint
+const blockSize = 16
+
+// Largest width and height which fit in the 24 bits of the header
+const maxSize = 1<<24 - 1
+
+// putUint24 stores v in little endian in the first 3 bytes of buf.
+func putUint24(buf []byte, v uint32) {
+	buf[0], buf[1], buf[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// Encode writes the image m to w in .astc format. A *glimage.ASTC is written
+// as is, other images are compressed with the footprint and the quality in
+// opts, or into 4x4 blocks with the medium quality if opts is nil.
+func Encode(w io.Writer, m image.Image, opts *Options) error {
+	astc, ok := m.(*glimage.ASTC)
+	if !ok {
+		blockWidth, blockHeight, quality := 4, 4, glimage.ASTCMedium
+		if opts != nil {
+			quality = opts.Quality
+			if opts.BlockWidth != 0 || opts.BlockHeight != 0 {
+				blockWidth, blockHeight = opts.BlockWidth, opts.BlockHeight
+			}
+		}
+		astc = glimage.NewASTC(m.Bounds(), blockWidth, blockHeight)
+		if err := astc.CompressQuality(m, quality); err != nil {
+			return err
+		}
+	}
+
+	b := astc.Bounds()
+	if b.Dx() > maxSize || b.Dy() > maxSize {
+		return fmt.Errorf("ASTC writer: image too large [%vx%v]", b.Dx(), b.Dy())
+	}
+	if !glimage.IsASTCFootprint(astc.BlockWidth, astc.BlockHeight) {
+		return fmt.Errorf("ASTC writer: unsupported block footprint [%vx%v]", astc.BlockWidth, astc.BlockHeight)
+	}
+	xBlocks, yBlocks := astc.BlockDimensions()
+	size := xBlocks * yBlocks * blockSize
+	if len(astc.Pix) < size {
+		return fmt.Errorf("ASTC writer: not enough image data [%v < %v]", len(astc.Pix), size)
+	}
+
+	var header [headerSize]byte
+	copy(header[:], magic)
+	header[4], header[5], header[6] = uint8(astc.BlockWidth), uint8(astc.BlockHeight), 1
+	putUint24(header[7:], uint32(b.Dx()))
+	putUint24(header[10:], uint32(b.Dy()))
+	putUint24(header[13:], 1)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(astc.Pix[:size])
+	return err
+}
@@ -0,0 +1,73 @@
+package astcfile
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+func TestEncode(t *testing.T) {
+	// ASTC images are written as is
+	m := glimage.NewASTC(image.Rect(0, 0, 6, 3), 4, 4)
+	copy(m.Pix, goldenBlock)
+	copy(m.Pix[16:], goldenBlock)
+	var buf bytes.Buffer
+	if err := Encode(&buf, m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := astcFile(4, 4, 1, 6, 3, 1, goldenBlock, goldenBlock); !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Wrong file : expected %v, got %v", expected, buf.Bytes())
+	}
+
+	// Other images are compressed with the footprint of the options
+	r := image.Rect(0, 0, 13, 7)
+	nrgba := image.NewNRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			nrgba.Set(x, y, color.NRGBA{0x40, 0x80, 0xC0, 0xFF})
+		}
+	}
+	buf.Reset()
+	if err := Encode(&buf, nrgba, &Options{6, 5, glimage.ASTCFast}); err != nil {
+		t.Fatal(err)
+	}
+	if size := headerSize + 3*2*16; buf.Len() != size {
+		t.Errorf("Wrong file size : expected %v, got %v", size, buf.Len())
+	}
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if astc := decoded.(*glimage.ASTC); astc.Bounds() != r || astc.BlockWidth != 6 || astc.BlockHeight != 5 {
+		t.Errorf("Wrong bounds or footprint : got %v %vx%v", astc.Bounds(), astc.BlockWidth, astc.BlockHeight)
+	}
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			if c := decoded.At(x, y); c != nrgba.At(x, y) {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, nrgba.At(x, y), c)
+			}
+		}
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	tests := []struct {
+		m    image.Image
+		opts *Options
+		err  string
+	}{
+		{glimage.NewASTC(image.Rect(0, 0, 8, 8), 7, 7), nil, "ASTC writer: unsupported block footprint"},
+		{&glimage.ASTC{Rect: image.Rect(0, 0, 8, 8), BlockWidth: 4, BlockHeight: 4}, nil, "ASTC writer: not enough image data"},
+		{image.NewNRGBA(image.Rect(0, 0, 8, 8)), &Options{3, 3, glimage.ASTCFast}, "ASTC compress: unsupported block footprint"},
+	}
+	for _, test := range tests {
+		err := Encode(&bytes.Buffer{}, test.m, test.opts)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package image
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+)
+
+// How many bytes in a block of BC2 or BC3, with the alpha block first
+const blockSizeBC3 = 16
+
+// rgb565ToNRGBA extends the RGB565 color v to 8 bits per component.
+func rgb565ToNRGBA(v uint16) color.NRGBA {
+	return color.NRGBA{extend5to8Bits(uint8(v >> 11)), extend6to8Bits(uint8(v >> 5)), extend5to8Bits(uint8(v)), 0xFF}
+}
+
+// mix returns the weighted average of a and b, rounded to the nearest.
+func mix(a, b uint8, wa, wb int) uint8 {
+	return uint8((int(a)*wa + int(b)*wb + (wa+wb)/2) / (wa + wb))
+}
+
+// bc1Palette returns the 4 colors of a BC1 color block. The block is in
+// 3-color mode when the first endpoint is not greater than the second one,
+// unless fourColors is set as for the color blocks of BC2 and BC3.
+func bc1Palette(block []byte, fourColors bool) (palette [4]color.NRGBA) {
+	v0, v1 := binary.LittleEndian.Uint16(block), binary.LittleEndian.Uint16(block[2:])
+	c0, c1 := rgb565ToNRGBA(v0), rgb565ToNRGBA(v1)
+	palette[0], palette[1] = c0, c1
+	if fourColors || v0 > v1 {
+		palette[2] = color.NRGBA{mix(c0.R, c1.R, 2, 1), mix(c0.G, c1.G, 2, 1), mix(c0.B, c1.B, 2, 1), 0xFF}
+		palette[3] = color.NRGBA{mix(c0.R, c1.R, 1, 2), mix(c0.G, c1.G, 1, 2), mix(c0.B, c1.B, 1, 2), 0xFF}
+	} else {
+		// The fourth color is transparent black
+		palette[2] = color.NRGBA{mix(c0.R, c1.R, 1, 1), mix(c0.G, c1.G, 1, 1), mix(c0.B, c1.B, 1, 1), 0xFF}
+	}
+	return
+}
+
+// decodeColorBC1 decodes a BC1 color block into dst, row by row. The 2-bit
+// indices of the pixels are stored from the least significant bits.
+func decodeColorBC1(block []byte, fourColors bool, dst *[blockWidth * blockWidth]color.NRGBA) {
+	palette := bc1Palette(block, fourColors)
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for i := range dst {
+		dst[i] = palette[indices>>uint(2*i)&0x03]
+	}
+}
+
+// decodeBlockBC1 decodes the pixels of a BC1 block into dst, row by row.
+func decodeBlockBC1(block []byte, dst *[blockWidth * blockWidth]color.NRGBA) {
+	decodeColorBC1(block, false, dst)
+}
+
+// decodeBlockBC2 decodes the pixels of a BC2 block into dst, row by row.
+// The 4-bit alpha values are stored explicitly before the color block.
+func decodeBlockBC2(block []byte, dst *[blockWidth * blockWidth]color.NRGBA) {
+	decodeColorBC1(block[blockSize:], true, dst)
+	alpha := binary.LittleEndian.Uint64(block)
+	for i := range dst {
+		dst[i].A = extend4to8Bits(uint8(alpha >> uint(4*i)))
+	}
+}
+
+// bc3AlphaPalette returns the 8 values of a BC3 alpha block. The block
+// interpolates 6 values between its endpoints when the first one is greater,
+// and 4 values followed by 0 and 255 otherwise.
+func bc3AlphaPalette(a0, a1 uint8) (palette [8]uint8) {
+	palette[0], palette[1] = a0, a1
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = mix(a0, a1, 7-i, i)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = mix(a0, a1, 5-i, i)
+		}
+		palette[6], palette[7] = 0, 0xFF
+	}
+	return
+}
+
+// bc3AlphaIndices returns the 3-bit indices of a BC3 alpha block, stored from
+// the least significant bits of bytes 2 to 7.
+func bc3AlphaIndices(block []byte) uint64 {
+	var indices uint64
+	for i := 7; i >= 2; i-- {
+		indices = indices<<8 | uint64(block[i])
+	}
+	return indices
+}
+
+// decodeAlphaBC3 decodes the 8-bit values of a BC3 alpha block into dst, row by row.
+func decodeAlphaBC3(block []byte, dst *[blockWidth * blockWidth]uint8) {
+	palette := bc3AlphaPalette(block[0], block[1])
+	indices := bc3AlphaIndices(block)
+	for i := range dst {
+		dst[i] = palette[indices>>uint(3*i)&0x07]
+	}
+}
+
+// decodeBlockBC3 decodes the pixels of a BC3 block into dst, row by row.
+func decodeBlockBC3(block []byte, dst *[blockWidth * blockWidth]color.NRGBA) {
+	decodeColorBC1(block[blockSize:], true, dst)
+	var alpha [blockWidth * blockWidth]uint8
+	decodeAlphaBC3(block, &alpha)
+	for i := range dst {
+		dst[i].A = alpha[i]
+	}
+}
+
+// BC1 is an in-memory image of blocks in the BC1 format, also known as DXT1.
+// Blocks in 3-color mode may have transparent black pixels.
+type BC1 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC1) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *BC1) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC1) At(x, y int) color.Color {
+	return nrgbaBlockAt(p.Rect, p.Pix, blockSize, x, y, decodeBlockBC1)
+}
+
+func (p *BC1) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.NRGBA.
+func (p *BC1) Uncompress() (image.Image, error) {
+	return uncompressNRGBA("BC1", p.Rect, p.Pix, blockSize, decodeBlockBC1)
+}
+
+func NewBC1(r image.Rectangle) *BC1 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGBA_S3TC_DXT1_EXT, w, h))
+	return &BC1{buf, r}
+}
+
+// BC2 is an in-memory image of blocks in the BC2 format, also known as DXT3,
+// made of 4-bit alpha values followed by a BC1 color block.
+type BC2 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC2) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *BC2) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC2) At(x, y int) color.Color {
+	return nrgbaBlockAt(p.Rect, p.Pix, blockSizeBC3, x, y, decodeBlockBC2)
+}
+
+func (p *BC2) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.NRGBA.
+func (p *BC2) Uncompress() (image.Image, error) {
+	return uncompressNRGBA("BC2", p.Rect, p.Pix, blockSizeBC3, decodeBlockBC2)
+}
+
+func NewBC2(r image.Rectangle) *BC2 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGBA_S3TC_DXT3_EXT, w, h))
+	return &BC2{buf, r}
+}
+
+// BC3 is an in-memory image of blocks in the BC3 format, also known as DXT5,
+// made of an interpolated alpha block followed by a BC1 color block.
+type BC3 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC3) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *BC3) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC3) At(x, y int) color.Color {
+	return nrgbaBlockAt(p.Rect, p.Pix, blockSizeBC3, x, y, decodeBlockBC3)
+}
+
+func (p *BC3) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.NRGBA.
+func (p *BC3) Uncompress() (image.Image, error) {
+	return uncompressNRGBA("BC3", p.Rect, p.Pix, blockSizeBC3, decodeBlockBC3)
+}
+
+func NewBC3(r image.Rectangle) *BC3 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGBA_S3TC_DXT5_EXT, w, h))
+	return &BC3{buf, r}
+}
@@ -0,0 +1,352 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Components and endpoints of the fields of a BC6H block. The endpoints
+// w and x are the first subset, y and z the second one.
+const (
+	bc6hR = iota
+	bc6hG
+	bc6hB
+)
+
+const (
+	bc6hW = iota
+	bc6hX
+	bc6hY
+	bc6hZ
+)
+
+// bc6hField holds count bits of a component of an endpoint, from its bit first.
+type bc6hField struct {
+	component, endpoint int
+	first, count        int
+}
+
+// bc6hMode describes the fields of a BC6H block in one of the 14 modes.
+type bc6hMode struct {
+	regions int
+	// The endpoints other than w are deltas from w in transformed modes
+	transformed  bool
+	endpointBits int
+	deltaBits    [3]int
+	fields       []bc6hField
+}
+
+// BC6H modes by their 2-bit or 5-bit mode field, the other values
+// being reserved
+var bc6hModes = map[int]bc6hMode{
+	// Mode 1, 10.5.5.5
+	0x00: {2, true, 10, [3]int{5, 5, 5}, []bc6hField{
+		{bc6hG, bc6hY, 4, 1}, {bc6hB, bc6hY, 4, 1}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 5}, {bc6hG, bc6hZ, 4, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 5}, {bc6hB, bc6hZ, 0, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 5}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 5}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 5}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 2, 7.6.6.6
+	0x01: {2, true, 7, [3]int{6, 6, 6}, []bc6hField{
+		{bc6hG, bc6hY, 5, 1}, {bc6hG, bc6hZ, 4, 1}, {bc6hG, bc6hZ, 5, 1},
+		{bc6hR, bc6hW, 0, 7}, {bc6hB, bc6hZ, 0, 1}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 4, 1},
+		{bc6hG, bc6hW, 0, 7}, {bc6hB, bc6hY, 5, 1}, {bc6hB, bc6hZ, 2, 1}, {bc6hG, bc6hY, 4, 1},
+		{bc6hB, bc6hW, 0, 7}, {bc6hB, bc6hZ, 3, 1}, {bc6hB, bc6hZ, 5, 1}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hX, 0, 6}, {bc6hG, bc6hY, 0, 4}, {bc6hG, bc6hX, 0, 6}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 6}, {bc6hB, bc6hY, 0, 4}, {bc6hR, bc6hY, 0, 6}, {bc6hR, bc6hZ, 0, 6},
+	}},
+	// Mode 3, 11.5.4.4
+	0x02: {2, true, 11, [3]int{5, 4, 4}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 5}, {bc6hR, bc6hW, 10, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 4}, {bc6hG, bc6hW, 10, 1}, {bc6hB, bc6hZ, 0, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 4}, {bc6hB, bc6hW, 10, 1}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 5}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 5}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 4, 11.4.5.4
+	0x06: {2, true, 11, [3]int{4, 5, 4}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 4}, {bc6hR, bc6hW, 10, 1}, {bc6hG, bc6hZ, 4, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 5}, {bc6hG, bc6hW, 10, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 4}, {bc6hB, bc6hW, 10, 1}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 4}, {bc6hB, bc6hZ, 0, 1}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 4},
+		{bc6hG, bc6hY, 4, 1}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 5, 11.4.4.5
+	0x0A: {2, true, 11, [3]int{4, 4, 5}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 4}, {bc6hR, bc6hW, 10, 1}, {bc6hB, bc6hY, 4, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 4}, {bc6hG, bc6hW, 10, 1}, {bc6hB, bc6hZ, 0, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 5}, {bc6hB, bc6hW, 10, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 4}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 4},
+		{bc6hB, bc6hZ, 4, 1}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 6, 9.5.5.5
+	0x0E: {2, true, 9, [3]int{5, 5, 5}, []bc6hField{
+		{bc6hR, bc6hW, 0, 9}, {bc6hB, bc6hY, 4, 1}, {bc6hG, bc6hW, 0, 9}, {bc6hG, bc6hY, 4, 1},
+		{bc6hB, bc6hW, 0, 9}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hX, 0, 5}, {bc6hG, bc6hZ, 4, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 5}, {bc6hB, bc6hZ, 0, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 5}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 5}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 5}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 7, 8.6.5.5
+	0x12: {2, true, 8, [3]int{6, 5, 5}, []bc6hField{
+		{bc6hR, bc6hW, 0, 8}, {bc6hG, bc6hZ, 4, 1}, {bc6hB, bc6hY, 4, 1},
+		{bc6hG, bc6hW, 0, 8}, {bc6hB, bc6hZ, 2, 1}, {bc6hG, bc6hY, 4, 1},
+		{bc6hB, bc6hW, 0, 8}, {bc6hB, bc6hZ, 3, 1}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hX, 0, 6}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 5}, {bc6hB, bc6hZ, 0, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 5}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 6}, {bc6hR, bc6hZ, 0, 6},
+	}},
+	// Mode 8, 8.5.6.5
+	0x16: {2, true, 8, [3]int{5, 6, 5}, []bc6hField{
+		{bc6hR, bc6hW, 0, 8}, {bc6hB, bc6hZ, 0, 1}, {bc6hB, bc6hY, 4, 1},
+		{bc6hG, bc6hW, 0, 8}, {bc6hG, bc6hY, 5, 1}, {bc6hG, bc6hY, 4, 1},
+		{bc6hB, bc6hW, 0, 8}, {bc6hG, bc6hZ, 5, 1}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hX, 0, 5}, {bc6hG, bc6hZ, 4, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 6}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 5}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 5}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 5}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 9, 8.5.5.6
+	0x1A: {2, true, 8, [3]int{5, 5, 6}, []bc6hField{
+		{bc6hR, bc6hW, 0, 8}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 4, 1},
+		{bc6hG, bc6hW, 0, 8}, {bc6hB, bc6hY, 5, 1}, {bc6hG, bc6hY, 4, 1},
+		{bc6hB, bc6hW, 0, 8}, {bc6hB, bc6hZ, 5, 1}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hX, 0, 5}, {bc6hG, bc6hZ, 4, 1}, {bc6hG, bc6hY, 0, 4},
+		{bc6hG, bc6hX, 0, 5}, {bc6hB, bc6hZ, 0, 1}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 6}, {bc6hB, bc6hY, 0, 4},
+		{bc6hR, bc6hY, 0, 5}, {bc6hB, bc6hZ, 2, 1}, {bc6hR, bc6hZ, 0, 5}, {bc6hB, bc6hZ, 3, 1},
+	}},
+	// Mode 10, 6.6.6.6
+	0x1E: {2, false, 6, [3]int{6, 6, 6}, []bc6hField{
+		{bc6hR, bc6hW, 0, 6}, {bc6hG, bc6hZ, 4, 1}, {bc6hB, bc6hZ, 0, 1}, {bc6hB, bc6hZ, 1, 1}, {bc6hB, bc6hY, 4, 1},
+		{bc6hG, bc6hW, 0, 6}, {bc6hG, bc6hY, 5, 1}, {bc6hB, bc6hY, 5, 1}, {bc6hB, bc6hZ, 2, 1}, {bc6hG, bc6hY, 4, 1},
+		{bc6hB, bc6hW, 0, 6}, {bc6hG, bc6hZ, 5, 1}, {bc6hB, bc6hZ, 3, 1}, {bc6hB, bc6hZ, 5, 1}, {bc6hB, bc6hZ, 4, 1},
+		{bc6hR, bc6hX, 0, 6}, {bc6hG, bc6hY, 0, 4}, {bc6hG, bc6hX, 0, 6}, {bc6hG, bc6hZ, 0, 4},
+		{bc6hB, bc6hX, 0, 6}, {bc6hB, bc6hY, 0, 4}, {bc6hR, bc6hY, 0, 6}, {bc6hR, bc6hZ, 0, 6},
+	}},
+	// Mode 11, 10.10
+	0x03: {1, false, 10, [3]int{10, 10, 10}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 10}, {bc6hG, bc6hX, 0, 10}, {bc6hB, bc6hX, 0, 10},
+	}},
+	// Mode 12, 11.9
+	0x07: {1, true, 11, [3]int{9, 9, 9}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 9}, {bc6hR, bc6hW, 10, 1},
+		{bc6hG, bc6hX, 0, 9}, {bc6hG, bc6hW, 10, 1},
+		{bc6hB, bc6hX, 0, 9}, {bc6hB, bc6hW, 10, 1},
+	}},
+	// Mode 13, 12.8, with the high bits of w in reverse order
+	0x0B: {1, true, 12, [3]int{8, 8, 8}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 8}, {bc6hR, bc6hW, 11, 1}, {bc6hR, bc6hW, 10, 1},
+		{bc6hG, bc6hX, 0, 8}, {bc6hG, bc6hW, 11, 1}, {bc6hG, bc6hW, 10, 1},
+		{bc6hB, bc6hX, 0, 8}, {bc6hB, bc6hW, 11, 1}, {bc6hB, bc6hW, 10, 1},
+	}},
+	// Mode 14, 16.4, with the high bits of w in reverse order
+	0x0F: {1, true, 16, [3]int{4, 4, 4}, []bc6hField{
+		{bc6hR, bc6hW, 0, 10}, {bc6hG, bc6hW, 0, 10}, {bc6hB, bc6hW, 0, 10},
+		{bc6hR, bc6hX, 0, 4}, {bc6hR, bc6hW, 15, 1}, {bc6hR, bc6hW, 14, 1}, {bc6hR, bc6hW, 13, 1},
+		{bc6hR, bc6hW, 12, 1}, {bc6hR, bc6hW, 11, 1}, {bc6hR, bc6hW, 10, 1},
+		{bc6hG, bc6hX, 0, 4}, {bc6hG, bc6hW, 15, 1}, {bc6hG, bc6hW, 14, 1}, {bc6hG, bc6hW, 13, 1},
+		{bc6hG, bc6hW, 12, 1}, {bc6hG, bc6hW, 11, 1}, {bc6hG, bc6hW, 10, 1},
+		{bc6hB, bc6hX, 0, 4}, {bc6hB, bc6hW, 15, 1}, {bc6hB, bc6hW, 14, 1}, {bc6hB, bc6hW, 13, 1},
+		{bc6hB, bc6hW, 12, 1}, {bc6hB, bc6hW, 11, 1}, {bc6hB, bc6hW, 10, 1},
+	}},
+}
+
+// bc6hUnquantize extends the endpoint component v of the given bits
+// to 16 bits, or to 15 bits and a sign.
+func bc6hUnquantize(v, bits int, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15:
+			return v
+		case v == 0:
+			return 0
+		case v == 1<<uint(bits)-1:
+			return 0xFFFF
+		}
+		return (v<<16 + 0x8000) >> uint(bits)
+	}
+
+	if bits >= 16 {
+		return v
+	}
+	negative := v < 0
+	if negative {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<uint(bits-1)-1:
+		v = 0x7FFF
+	default:
+		v = (v<<15 + 0x4000) >> uint(bits-1)
+	}
+	if negative {
+		return -v
+	}
+	return v
+}
+
+// bc6hHalf scales an interpolated component to the bits of a half float.
+func bc6hHalf(v int, signed bool) uint16 {
+	if !signed {
+		return uint16(v * 31 >> 6)
+	}
+	if v < 0 {
+		return 0x8000 | uint16(-v*31>>5)
+	}
+	return uint16(v * 31 >> 5)
+}
+
+// decodeBlockBC6H decodes the pixels of a BC6H block into dst, row by row.
+// Blocks of the reserved modes are black.
+func decodeBlockBC6H(block []byte, signed bool, dst *[blockWidth * blockWidth]glcolor.NRGBAF32) {
+	r := bptcReader{bits: newASTCBits(block)}
+	code := r.read(2)
+	if code > 1 {
+		code |= r.read(3) << 2
+	}
+	m, ok := bc6hModes[code]
+	if !ok {
+		for i := range dst {
+			dst[i] = glcolor.NRGBAF32{0, 0, 0, 1}
+		}
+		return
+	}
+
+	var endpoints [4][3]int
+	for _, f := range m.fields {
+		endpoints[f.endpoint][f.component] |= r.read(f.count) << uint(f.first)
+	}
+	partition := 0
+	if m.regions == 2 {
+		partition = r.read(5)
+	}
+
+	count := 2 * m.regions
+	for c := 0; c < 3; c++ {
+		if signed {
+			endpoints[0][c] = signExtend(endpoints[0][c], uint(m.endpointBits))
+		}
+		for i := 1; i < count; i++ {
+			if m.transformed || signed {
+				endpoints[i][c] = signExtend(endpoints[i][c], uint(m.deltaBits[c]))
+			}
+			if m.transformed {
+				endpoints[i][c] = (endpoints[0][c] + endpoints[i][c]) & (1<<uint(m.endpointBits) - 1)
+				if signed {
+					endpoints[i][c] = signExtend(endpoints[i][c], uint(m.endpointBits))
+				}
+			}
+		}
+	}
+	for i := 0; i < count; i++ {
+		for c := 0; c < 3; c++ {
+			endpoints[i][c] = bc6hUnquantize(endpoints[i][c], m.endpointBits, signed)
+		}
+	}
+
+	// Blocks of 2 regions have 3-bit indices, and blocks of 1 region 4-bit
+	// indices, with one bit less for anchors
+	subsets, indexBits := m.regions, 5-m.regions
+	for i := range dst {
+		n := indexBits
+		if bptcIsAnchor(subsets, partition, i) {
+			n--
+		}
+		weight := bptcWeights[indexBits][r.read(n)]
+		s := bptcSubset(subsets, partition, i)
+		var c [3]float32
+		for j := range c {
+			v := bptcInterpolate(endpoints[2*s][j], endpoints[2*s+1][j], weight)
+			c[j] = halfToFloat(bc6hHalf(v, signed))
+		}
+		dst[i] = glcolor.NRGBAF32{c[0], c[1], c[2], 1}
+	}
+}
+
+// BC6H is an in-memory image of blocks in the BC6H format, of half float
+// colors which are either unsigned or signed.
+type BC6H struct {
+	Pix    []uint8
+	Rect   image.Rectangle
+	Signed bool
+}
+
+func (p *BC6H) ColorModel() color.Model {
+	return glcolor.NRGBAF32Model
+}
+
+func (p *BC6H) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC6H) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return glcolor.NRGBAF32{}
+	}
+	x, y = x-p.Rect.Min.X, y-p.Rect.Min.Y
+
+	xBlockDim, _ := p.BlockDimensions()
+	offset := ((y/blockWidth)*xBlockDim + x/blockWidth) * blockSizeBPTC
+	var pixels [blockWidth * blockWidth]glcolor.NRGBAF32
+	decodeBlockBC6H(p.Pix[offset:offset+blockSizeBPTC], p.Signed, &pixels)
+	return pixels[(y%blockWidth)*blockWidth+x%blockWidth]
+}
+
+func (p *BC6H) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *BC6H) Compress(im image.Image) error {
+	return fmt.Errorf("BC6H compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *NRGBAF32.
+func (p *BC6H) Uncompress() (image.Image, error) {
+	xBlockDim, yBlockDim := p.BlockDimensions()
+	if len(p.Pix) < xBlockDim*yBlockDim*blockSizeBPTC {
+		return nil, fmt.Errorf("BC6H uncompress: not enough data [%v < %v]", len(p.Pix), xBlockDim*yBlockDim*blockSizeBPTC)
+	}
+
+	m := NewNRGBAF32(p.Rect)
+	w, h := p.Rect.Dx(), p.Rect.Dy()
+	var pixels [blockWidth * blockWidth]glcolor.NRGBAF32
+	for yBlock := 0; yBlock < yBlockDim; yBlock++ {
+		for xBlock := 0; xBlock < xBlockDim; xBlock++ {
+			offset := (yBlock*xBlockDim + xBlock) * blockSizeBPTC
+			decodeBlockBC6H(p.Pix[offset:offset+blockSizeBPTC], p.Signed, &pixels)
+			// Pixels of edge blocks outside of the image are dropped
+			for y := 0; y < blockWidth && yBlock*blockWidth+y < h; y++ {
+				for x := 0; x < blockWidth && xBlock*blockWidth+x < w; x++ {
+					c := pixels[y*blockWidth+x]
+					i := m.PixOffset(p.Rect.Min.X+xBlock*blockWidth+x, p.Rect.Min.Y+yBlock*blockWidth+y)
+					m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// NewBC6H returns a BC6H image of bounds r, whose colors are unsigned
+// unless Signed is set.
+func NewBC6H(r image.Rectangle) *BC6H {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, w, h))
+	return &BC6H{buf, r, false}
+}
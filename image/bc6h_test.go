@@ -0,0 +1,157 @@
+package image
+
+import (
+	"image"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// bc6hBlock sets the fields of position, bits and value in a block.
+func bc6hBlock(fields ...[3]int) []byte {
+	var b astcBits
+	for _, f := range fields {
+		b.set(f[0], f[1], f[2])
+	}
+	block := make([]byte, blockSizeBPTC)
+	for i := 0; i < 8; i++ {
+		block[i] = uint8(b.lo >> uint(8*i))
+		block[8+i] = uint8(b.hi >> uint(8*i))
+	}
+	return block
+}
+
+// bc6hIndices returns the fields of 1-region indices from the bit 65, the
+// index of every pixel being its position.
+func bc6hIndices() [][3]int {
+	fields := [][3]int{{65, 3, 0}}
+	for i := 1; i < 16; i++ {
+		fields = append(fields, [3]int{64 + 4*i, 4, i})
+	}
+	return fields
+}
+
+func TestBC6HModes(t *testing.T) {
+	for code, m := range bc6hModes {
+		var covered [4][3]int
+		bits := 2
+		if code&3 > 1 {
+			bits = 5
+		}
+		for _, f := range m.fields {
+			for i := f.first; i < f.first+f.count; i++ {
+				if covered[f.endpoint][f.component]&(1<<uint(i)) != 0 {
+					t.Errorf("Bit %v of the endpoint %v, component %v is set twice in the BC6H mode %#x", i, f.endpoint, f.component, code)
+				}
+				covered[f.endpoint][f.component] |= 1 << uint(i)
+			}
+			bits += f.count
+		}
+		for e := 0; e < 2*m.regions; e++ {
+			for c := 0; c < 3; c++ {
+				n := m.endpointBits
+				if e > 0 && m.transformed {
+					n = m.deltaBits[c]
+				}
+				if covered[e][c] != 1<<uint(n)-1 {
+					t.Errorf("Wrong bits %#x of the endpoint %v, component %v in the BC6H mode %#x", covered[e][c], e, c, code)
+				}
+			}
+		}
+		expected := 65
+		if m.regions == 2 {
+			expected = 82 - 5
+		}
+		if bits != expected {
+			t.Errorf("Wrong size of the header of the BC6H mode %#x : expected %v, got %v", code, expected, bits)
+		}
+	}
+}
+
+func TestDecodeBC6H(t *testing.T) {
+	// Mode 11 from red 0 to 1023, and green 512
+	mode11 := bc6hBlock(append([][3]int{{0, 5, 0x03}, {15, 10, 512}, {35, 10, 1023}, {45, 10, 512}}, bc6hIndices()...)...)
+	// Mode 14 from red 0x739D, whose high bits are reversed, to a delta of 7
+	mode14 := bc6hBlock(append([][3]int{{0, 5, 0x0F}, {5, 10, 0x39D}, {35, 4, 7}, {39, 6, 14}}, bc6hIndices()...)...)
+	// Signed mode 1 with the partition 13, from red -256 to deltas of -1,
+	// 15 and -16, the pixel 15 having the index 3
+	mode1 := bc6hBlock([3]int{0, 2, 0x00}, [3]int{5, 10, 768}, [3]int{35, 5, 0x1F},
+		[3]int{65, 5, 15}, [3]int{71, 5, 0x10}, [3]int{77, 5, 13}, [3]int{126, 2, 3})
+
+	tests := []struct {
+		name   string
+		block  []byte
+		signed bool
+		pixels map[int]glcolor.NRGBAF32
+	}{
+		{"mode 11", mode11, false, map[int]glcolor.NRGBAF32{
+			0:  {0, 1.5146484375, 0, 1},
+			8:  {2.935546875, 1.5146484375, 0, 1},
+			15: {65504, 1.5146484375, 0, 1},
+		}},
+		{"mode 14", mode14, false, map[int]glcolor.NRGBAF32{
+			0:  {0.5, 0, 0, 1},
+			15: {0.50146484375, 0, 0, 1},
+		}},
+		{"signed mode 1", mode1, true, map[int]glcolor.NRGBAF32{
+			0:  {-1.5302734375, 0, 0, 1},
+			7:  {-1.5302734375, 0, 0, 1},
+			8:  {-0.81103515625, 0, 0, 1},
+			15: {-1.4130859375, 0, 0, 1},
+		}},
+		{"reserved mode", bc6hBlock([3]int{0, 5, 0x13}), false, map[int]glcolor.NRGBAF32{
+			0:  {0, 0, 0, 1},
+			15: {0, 0, 0, 1},
+		}},
+	}
+	for _, test := range tests {
+		var pixels [blockWidth * blockWidth]glcolor.NRGBAF32
+		decodeBlockBC6H(test.block, test.signed, &pixels)
+		for i, expected := range test.pixels {
+			if pixels[i] != expected {
+				t.Errorf("Wrong pixel %v of the %s block : expected %v, got %v", i, test.name, expected, pixels[i])
+			}
+		}
+	}
+}
+
+func TestDecodeBC6HImage(t *testing.T) {
+	r := image.Rect(0, 0, 3, 3)
+	m := NewBC6H(r)
+	if len(m.Pix) != blockSizeBPTC {
+		t.Fatalf("Wrong size of pixel data : expected %v, got %v", blockSizeBPTC, len(m.Pix))
+	}
+	if m.ColorModel() != glcolor.NRGBAF32Model {
+		t.Error("Wrong color model of BC6H")
+	}
+	// Signed mode 11 of red -1 and -1
+	m.Signed = true
+	copy(m.Pix, bc6hBlock([3]int{0, 5, 0x03}, [3]int{5, 10, 0x3FF}, [3]int{35, 10, 0x3FF}))
+
+	uncompressed, err := m.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := uncompressed.(*NRGBAF32); !ok {
+		t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+	}
+	// -1 is the 16-bit value -96, and the half float 0x805D
+	expected := glcolor.NRGBAF32{halfToFloat(0x805D), 0, 0, 1}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			if c := m.At(x, y); c != expected {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+			if c := uncompressed.At(x, y); c != expected {
+				t.Errorf("Wrong uncompressed pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+		}
+	}
+
+	if err := m.Compress(image.NewNRGBA(r)); err == nil {
+		t.Error("Expected an error for the compression")
+	}
+	if _, err := (&BC6H{make([]byte, 8), r, false}).Uncompress(); err == nil {
+		t.Error("Expected an error for missing data")
+	}
+}
@@ -0,0 +1,300 @@
+package image
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Alpha below which BC1 pixels are encoded as transparent
+const bc1AlphaThreshold = 128
+
+// quantize565 returns the RGB565 color nearest to the 8-bit components of c.
+func quantize565(c [3]float64) uint16 {
+	q := func(v float64, max int) uint16 {
+		return uint16(math.Floor(math.Max(0, math.Min(255, v))*float64(max)/255 + 0.5))
+	}
+	return q(c[0], 31)<<11 | q(c[1], 63)<<5 | q(c[2], 31)
+}
+
+// bc1Weights holds the position of the colors of the palette between the
+// endpoints, in 4-color and in 3-color mode.
+var bc1Weights = [2][4]float64{
+	{0, 1, 1.0 / 3, 2.0 / 3},
+	{0, 1, 0.5, 0},
+}
+
+// fitEndpoints returns the endpoints which encode the texels with the least
+// squared error for the given palette indices, and false if they are not
+// determined by the indices.
+func fitEndpoints(texels []astcTexel, indices []int, weights [4]float64) (e0, e1 [3]float64, ok bool) {
+	var aa, bb, ab float64
+	var ax, bx [3]float64
+	for i, t := range texels {
+		w := weights[indices[i]]
+		aa += (1 - w) * (1 - w)
+		bb += w * w
+		ab += w * (1 - w)
+		for c := range ax {
+			ax[c] += (1 - w) * float64(t.c[c])
+			bx[c] += w * float64(t.c[c])
+		}
+	}
+	det := aa*bb - ab*ab
+	if math.Abs(det) < 1e-9 {
+		return e0, e1, false
+	}
+	for c := range e0 {
+		e0[c] = (ax[c]*bb - bx[c]*ab) / det
+		e1[c] = (bx[c]*aa - ax[c]*ab) / det
+	}
+	return e0, e1, true
+}
+
+// bc1Candidate writes the color block of the endpoints e0 and e1 into dst,
+// in 3-color mode if threeColors is set, and returns its squared error over
+// the opaque texels with their palette indices.
+func bc1Candidate(dst []byte, e0, e1 [3]float64, threeColors, fourColors bool, opaque, transparent []astcTexel) (int, []int) {
+	v0, v1 := quantize565(e0), quantize565(e1)
+	if (threeColors && v0 > v1) || (!threeColors && v0 < v1) {
+		v0, v1 = v1, v0
+	}
+	binary.LittleEndian.PutUint16(dst, v0)
+	binary.LittleEndian.PutUint16(dst[2:], v1)
+	palette := bc1Palette(dst, fourColors)
+	// Equal endpoints of BC1 are in 3-color mode whatever was asked
+	usable := 4
+	if !fourColors && v0 <= v1 {
+		usable = 3
+	}
+
+	var indices uint32
+	total := 0
+	chosen := make([]int, len(opaque))
+	for i, t := range opaque {
+		best, bestErr := 0, -1
+		for j := 0; j < usable; j++ {
+			c := palette[j]
+			d := [3]int{int(c.R) - t.c[0], int(c.G) - t.c[1], int(c.B) - t.c[2]}
+			if err := d[0]*d[0] + d[1]*d[1] + d[2]*d[2]; bestErr < 0 || err < bestErr {
+				best, bestErr = j, err
+			}
+		}
+		chosen[i] = best
+		total += bestErr
+		indices |= uint32(best) << uint(2*(t.y*blockWidth+t.x))
+	}
+	for _, t := range transparent {
+		indices |= 3 << uint(2*(t.y*blockWidth+t.x))
+	}
+	binary.LittleEndian.PutUint32(dst[4:], indices)
+	return total, chosen
+}
+
+// encodeColorBC1 encodes the colors of the texels into the BC1 color block dst.
+// The colors of BC2 and BC3 are always in 4-color mode, while BC1 uses the
+// 3-color mode for its transparent pixels or when it is more accurate.
+func encodeColorBC1(dst []byte, texels []astcTexel, fourColors bool) {
+	var opaque, transparent []astcTexel
+	for _, t := range texels {
+		if !fourColors && t.c[3] < bc1AlphaThreshold {
+			transparent = append(transparent, t)
+		} else {
+			// Only the colors matter from now on
+			t.c[3] = 0
+			opaque = append(opaque, t)
+		}
+	}
+	if len(opaque) == 0 {
+		// Equal endpoints select the 3-color mode, every index is transparent
+		binary.LittleEndian.PutUint32(dst, 0)
+		binary.LittleEndian.PutUint32(dst[4:], 0xFFFFFFFF)
+		return
+	}
+
+	l0, l1 := principalLine(opaque)
+	start0, start1 := [3]float64{l0[0], l0[1], l0[2]}, [3]float64{l1[0], l1[1], l1[2]}
+	var modes []bool
+	switch {
+	case len(transparent) > 0:
+		modes = []bool{true}
+	case fourColors:
+		modes = []bool{false}
+	default:
+		modes = []bool{false, true}
+	}
+
+	var best, candidate [blockSize]byte
+	bestErr := -1
+	for _, threeColors := range modes {
+		weights := bc1Weights[0]
+		if threeColors {
+			weights = bc1Weights[1]
+		}
+		e0, e1 := start0, start1
+		// Refine the endpoints by least squares from the indices of the previous ones
+		for iter := 0; iter < 3; iter++ {
+			err, indices := bc1Candidate(candidate[:], e0, e1, threeColors, fourColors, opaque, transparent)
+			if bestErr < 0 || err < bestErr {
+				best, bestErr = candidate, err
+			}
+			if err == 0 {
+				break
+			}
+			// Indices of swapped endpoints are swapped too
+			v0, v1 := binary.LittleEndian.Uint16(candidate[:]), binary.LittleEndian.Uint16(candidate[2:])
+			var ok bool
+			if e0, e1, ok = fitEndpoints(opaque, indices, weights); !ok {
+				break
+			}
+			if quantize565(e0) == v0 && quantize565(e1) == v1 || quantize565(e0) == v1 && quantize565(e1) == v0 {
+				break
+			}
+		}
+	}
+	copy(dst, best[:])
+}
+
+// encodeAlphaBC2 encodes the alpha of the texels into the 4-bit values of dst.
+func encodeAlphaBC2(dst []byte, texels []astcTexel) {
+	var alpha uint64
+	for _, t := range texels {
+		a := uint64((t.c[3]*15 + 127) / 255)
+		alpha |= a << uint(4*(t.y*blockWidth+t.x))
+	}
+	binary.LittleEndian.PutUint64(dst, alpha)
+}
+
+// bc3AlphaCandidate writes the alpha block of the endpoints a0 and a1 into
+// dst, and returns its squared error over the given component of the texels.
+func bc3AlphaCandidate(dst []byte, a0, a1 uint8, texels []astcTexel, component int) int {
+	palette := bc3AlphaPalette(a0, a1)
+	var indices uint64
+	total := 0
+	for _, t := range texels {
+		best, bestErr := 0, -1
+		for j, v := range palette {
+			d := int(v) - t.c[component]
+			if bestErr < 0 || d*d < bestErr {
+				best, bestErr = j, d*d
+			}
+		}
+		total += bestErr
+		indices |= uint64(best) << uint(3*(t.y*blockWidth+t.x))
+	}
+	dst[0], dst[1] = a0, a1
+	for i := 2; i < 8; i++ {
+		dst[i] = uint8(indices >> uint(8*(i-2)))
+	}
+	return total
+}
+
+// encodeAlphaBC3 encodes the given component of the texels into the BC3 alpha
+// block dst, interpolating either between the extreme values, or between
+// the extreme values other than 0 and 255 which the block holds exactly.
+func encodeAlphaBC3(dst []byte, texels []astcTexel, component int) {
+	min, max := 255, 0
+	innerMin, innerMax := 255, 0
+	for _, t := range texels {
+		v := t.c[component]
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+		if v != 0 && v != 255 {
+			if v < innerMin {
+				innerMin = v
+			}
+			if v > innerMax {
+				innerMax = v
+			}
+		}
+	}
+	if min > max {
+		min, max = 0, 0
+	}
+	if innerMin > innerMax {
+		innerMin, innerMax = min, min
+	}
+
+	var candidate [blockSize]byte
+	err := bc3AlphaCandidate(dst, uint8(max), uint8(min), texels, component)
+	if err == 0 {
+		return
+	}
+	if bc3AlphaCandidate(candidate[:], uint8(innerMin), uint8(innerMax), texels, component) < err {
+		copy(dst, candidate[:])
+	}
+}
+
+// compressBC encodes every block of im into pix, which holds blocks of size
+// bytes for an image of bounds r, with the 8-bit components of the texels.
+func compressBC(name string, r image.Rectangle, pix []byte, size int, im image.Image, encode func(dst []byte, texels []astcTexel)) error {
+	return compressBlocks(name, r, pix, size, im, func(c color.Color) [4]int {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		return [4]int{int(n.R), int(n.G), int(n.B), int(n.A)}
+	}, encode)
+}
+
+// compressBlocks encodes every block of im into pix, which holds blocks
+// of size bytes for an image of bounds r. The components of the texels are
+// given by convert, and pixels of edge blocks outside of the image are left
+// out of the texels.
+func compressBlocks(name string, r image.Rectangle, pix []byte, size int, im image.Image,
+	convert func(c color.Color) [4]int, encode func(dst []byte, texels []astcTexel)) error {
+	b := im.Bounds()
+	if b.Dx() != r.Dx() || b.Dy() != r.Dy() {
+		return fmt.Errorf("%s compress: wrong image size [%vx%v != %vx%v]", name, b.Dx(), b.Dy(), r.Dx(), r.Dy())
+	}
+
+	xBlocks, yBlocks := blockDimensions(r)
+	texels := make([]astcTexel, 0, blockWidth*blockWidth)
+	for yBlock := 0; yBlock < yBlocks; yBlock++ {
+		for xBlock := 0; xBlock < xBlocks; xBlock++ {
+			texels = texels[:0]
+			for y := 0; y < blockWidth; y++ {
+				for x := 0; x < blockWidth; x++ {
+					px, py := xBlock*blockWidth+x, yBlock*blockWidth+y
+					if px >= b.Dx() || py >= b.Dy() {
+						continue
+					}
+					texels = append(texels, astcTexel{x, y, convert(im.At(b.Min.X+px, b.Min.Y+py))})
+				}
+			}
+			offset := (yBlock*xBlocks + xBlock) * size
+			encode(pix[offset:offset+size], texels)
+		}
+	}
+	return nil
+}
+
+// Compress encodes im into p. Both images must have the same size, which does
+// not need to be a multiple of 4. Pixels whose alpha is below 128 become
+// transparent black.
+func (p *BC1) Compress(im image.Image) error {
+	return compressBC("BC1", p.Rect, p.Pix, blockSize, im, func(dst []byte, texels []astcTexel) {
+		encodeColorBC1(dst, texels, false)
+	})
+}
+
+// Compress encodes im into p. Both images must have the same size, which does
+// not need to be a multiple of 4.
+func (p *BC2) Compress(im image.Image) error {
+	return compressBC("BC2", p.Rect, p.Pix, blockSizeBC3, im, func(dst []byte, texels []astcTexel) {
+		encodeAlphaBC2(dst, texels)
+		encodeColorBC1(dst[blockSize:], texels, true)
+	})
+}
+
+// Compress encodes im into p. Both images must have the same size, which does
+// not need to be a multiple of 4.
+func (p *BC3) Compress(im image.Image) error {
+	return compressBC("BC3", p.Rect, p.Pix, blockSizeBC3, im, func(dst []byte, texels []astcTexel) {
+		encodeAlphaBC3(dst, texels, 3)
+		encodeColorBC1(dst[blockSize:], texels, true)
+	})
+}
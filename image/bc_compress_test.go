@@ -0,0 +1,151 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// bcError returns the sum of squared errors of every component between im
+// and the uncompressed p, and the largest error of a single component.
+func bcError(t *testing.T, im image.Image, p BlockCompressedImage) (total, max int) {
+	uncompressed, err := p.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := im.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c0 := color.NRGBAModel.Convert(im.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			c1 := uncompressed.At(p.Bounds().Min.X+x, p.Bounds().Min.Y+y).(color.NRGBA)
+			for i, v := range []uint8{c0.R, c0.G, c0.B, c0.A} {
+				d := int([]uint8{c1.R, c1.G, c1.B, c1.A}[i]) - int(v)
+				total += d * d
+				if d < 0 {
+					d = -d
+				}
+				if d > max {
+					max = d
+				}
+			}
+		}
+	}
+	return
+}
+
+func fillNRGBA(r image.Rectangle, f func(x, y int) color.NRGBA) *image.NRGBA {
+	im := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			im.Set(x, y, f(x, y))
+		}
+	}
+	return im
+}
+
+func TestCompressBCSolid(t *testing.T) {
+	// 10x10 has partial blocks on the right and bottom edges, and the colors
+	// are exact in RGB565
+	r := image.Rect(0, 0, 10, 10)
+	for _, c := range []color.NRGBA{{0, 0, 0, 255}, {255, 255, 255, 255}, {255, 0, 0, 255}, {132, 130, 255, 255}} {
+		im := fillNRGBA(r, func(x, y int) color.NRGBA { return c })
+		for _, p := range []BlockCompressedImage{NewBC1(r), NewBC2(r), NewBC3(r)} {
+			if err := p.Compress(im); err != nil {
+				t.Fatal(err)
+			}
+			if total, _ := bcError(t, im, p); total != 0 {
+				t.Errorf("Wrong %T encoding of %v : error %v", p, c, total)
+			}
+		}
+	}
+}
+
+func TestCompressBCGradient(t *testing.T) {
+	r := image.Rect(0, 0, 8, 8)
+	// Colors vary along a line in every block, which BC encodes closely
+	im := fillNRGBA(r, func(x, y int) color.NRGBA {
+		return color.NRGBA{uint8(x * 32), uint8(x * 16), uint8(255 - x*24), uint8(y * 36)}
+	})
+	tests := []struct {
+		p        BlockCompressedImage
+		maxError int
+	}{
+		// Alpha is opaque in BC1, whose error is measured on colors only
+		{NewBC1(r), 16},
+		{NewBC2(r), 16},
+		{NewBC3(r), 16},
+	}
+	for _, test := range tests {
+		src := image.Image(im)
+		if _, ok := test.p.(*BC1); ok {
+			src = fillNRGBA(r, func(x, y int) color.NRGBA {
+				c := im.NRGBAAt(x, y)
+				c.A = 255
+				return c
+			})
+		}
+		if err := test.p.Compress(src); err != nil {
+			t.Fatal(err)
+		}
+		if _, max := bcError(t, src, test.p); max > test.maxError {
+			t.Errorf("Wrong %T encoding of the gradient : largest error %v > %v", test.p, max, test.maxError)
+		}
+	}
+}
+
+func TestCompressBC1Transparent(t *testing.T) {
+	r := image.Rect(0, 0, 4, 4)
+	im := fillNRGBA(r, func(x, y int) color.NRGBA {
+		if x == y {
+			return color.NRGBA{0x80, 0x80, 0x80, 0x20}
+		}
+		return color.NRGBA{uint8(x * 80), 0, 0xFF, 0xFF}
+	})
+	p := NewBC1(r)
+	if err := p.Compress(im); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			c := p.At(x, y).(color.NRGBA)
+			if x == y && c != (color.NRGBA{}) {
+				t.Errorf("Expected transparent black at [%v %v], got %v", x, y, c)
+			} else if x != y && c.A != 0xFF {
+				t.Errorf("Expected opaque pixel at [%v %v], got %v", x, y, c)
+			}
+		}
+	}
+
+	// A fully transparent block
+	im = fillNRGBA(r, func(x, y int) color.NRGBA { return color.NRGBA{} })
+	if err := p.Compress(im); err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := bcError(t, im, p); total != 0 {
+		t.Errorf("Wrong encoding of a transparent block : error %v", total)
+	}
+}
+
+func TestCompressBC3Alpha(t *testing.T) {
+	// 0 and 255 are kept by the mode with 6 interpolated values
+	r := image.Rect(0, 0, 4, 4)
+	im := fillNRGBA(r, func(x, y int) color.NRGBA {
+		return color.NRGBA{0xFF, 0xFF, 0xFF, []uint8{0, 100, 255, 100}[x]}
+	})
+	p := NewBC3(r)
+	if err := p.Compress(im); err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := bcError(t, im, p); total != 0 {
+		t.Errorf("Wrong encoding of the alpha values : error %v", total)
+	}
+}
+
+func TestCompressBCError(t *testing.T) {
+	p := NewBC1(image.Rect(0, 0, 4, 4))
+	err := p.Compress(image.NewNRGBA(image.Rect(0, 0, 5, 4)))
+	if err == nil || !strings.Contains(err.Error(), "BC1 compress: wrong image size") {
+		t.Errorf("Expected pattern of error message (BC1 compress: wrong image size), got (%v)", err)
+	}
+}
@@ -0,0 +1,120 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Red and blue endpoints with the pixel indices 0 to 3 in every row
+var (
+	bc1FourColorBlock  = []byte{0x00, 0xF8, 0x1F, 0x00, 0xE4, 0xE4, 0xE4, 0xE4}
+	bc1ThreeColorBlock = []byte{0x1F, 0x00, 0x00, 0xF8, 0xE4, 0xE4, 0xE4, 0xE4}
+)
+
+// rows returns the pixels of a block whose rows are all the given colors.
+func rows(c0, c1, c2, c3 color.NRGBA) (pixels [4][4]color.NRGBA) {
+	for y := range pixels {
+		pixels[y] = [4]color.NRGBA{c0, c1, c2, c3}
+	}
+	return
+}
+
+// bc3AlphaBlock returns a BC3 alpha block whose pixel i has the index i%8.
+func bc3AlphaBlock(a0, a1 uint8) []byte {
+	var indices uint64
+	for i := 0; i < 16; i++ {
+		indices |= uint64(i%8) << uint(3*i)
+	}
+	block := []byte{a0, a1}
+	for i := 0; i < 6; i++ {
+		block = append(block, uint8(indices>>uint(8*i)))
+	}
+	return block
+}
+
+func TestDecodeBC(t *testing.T) {
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	bc2Alpha := []byte{0x10, 0x32, 0x54, 0x76, 0x98, 0xBA, 0xDC, 0xFE}
+	withAlpha := func(pixels [4][4]color.NRGBA, alpha func(i int) uint8) [4][4]color.NRGBA {
+		for y := range pixels {
+			for x := range pixels[y] {
+				pixels[y][x].A = alpha(y*4 + x)
+			}
+		}
+		return pixels
+	}
+	alpha8 := []uint8{255, 0, 219, 182, 146, 109, 73, 36}
+	alpha6 := []uint8{0, 255, 51, 102, 153, 204, 0, 255}
+
+	tests := []struct {
+		name   string
+		image  BlockCompressedImage
+		block  []byte
+		pixels [4][4]color.NRGBA
+	}{
+		{"BC1 4-color", NewBC1(image.Rect(0, 0, 4, 4)), bc1FourColorBlock,
+			rows(red, blue, color.NRGBA{170, 0, 85, 255}, color.NRGBA{85, 0, 170, 255})},
+		{"BC1 3-color", NewBC1(image.Rect(0, 0, 4, 4)), bc1ThreeColorBlock,
+			rows(blue, red, color.NRGBA{128, 0, 128, 255}, color.NRGBA{})},
+		// The color blocks of BC2 and BC3 always have 4 colors
+		{"BC2", NewBC2(image.Rect(0, 0, 4, 4)), append(bc2Alpha, bc1ThreeColorBlock...),
+			withAlpha(rows(blue, red, color.NRGBA{85, 0, 170, 255}, color.NRGBA{170, 0, 85, 255}), func(i int) uint8 { return uint8(i * 17) })},
+		{"BC3 8-alpha", NewBC3(image.Rect(0, 0, 4, 4)), append(bc3AlphaBlock(255, 0), bc1FourColorBlock...),
+			withAlpha(rows(red, blue, color.NRGBA{170, 0, 85, 255}, color.NRGBA{85, 0, 170, 255}), func(i int) uint8 { return alpha8[i%8] })},
+		{"BC3 6-alpha", NewBC3(image.Rect(0, 0, 4, 4)), append(bc3AlphaBlock(0, 255), bc1FourColorBlock...),
+			withAlpha(rows(red, blue, color.NRGBA{170, 0, 85, 255}, color.NRGBA{85, 0, 170, 255}), func(i int) uint8 { return alpha6[i%8] })},
+	}
+	for _, test := range tests {
+		switch m := test.image.(type) {
+		case *BC1:
+			copy(m.Pix, test.block)
+		case *BC2:
+			copy(m.Pix, test.block)
+		case *BC3:
+			copy(m.Pix, test.block)
+		}
+		uncompressed, err := test.image.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				expected := test.pixels[y][x]
+				if c := test.image.At(x, y); c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+				if c := uncompressed.At(x, y); c != expected {
+					t.Errorf("Wrong uncompressed pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeBCEdgeBlocks(t *testing.T) {
+	// Two blocks side by side, cropped to 5x3
+	r := image.Rect(2, 1, 7, 4)
+	m := NewBC1(r)
+	if len(m.Pix) != 16 {
+		t.Fatalf("Wrong size of pixel data : expected 16, got %v", len(m.Pix))
+	}
+	copy(m.Pix, bc1FourColorBlock)
+	copy(m.Pix[8:], bc1ThreeColorBlock)
+	uncompressed, err := m.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []color.NRGBA{{255, 0, 0, 255}, {0, 0, 255, 255}, {170, 0, 85, 255}, {85, 0, 170, 255}, {0, 0, 255, 255}}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if c := uncompressed.At(x, y); c != expected[x-r.Min.X] {
+				t.Errorf("Wrong uncompressed pixel at [%v %v] : expected %v, got %v", x, y, expected[x-r.Min.X], c)
+			}
+		}
+	}
+
+	if _, err := (&BC3{make([]byte, 8), image.Rect(0, 0, 4, 4)}).Uncompress(); err == nil {
+		t.Error("Expected an error for missing data")
+	}
+}
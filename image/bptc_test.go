@@ -0,0 +1,155 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// bptcBlock packs the fields of value and bits into a block, from its least
+// significant bit.
+func bptcBlock(fields ...[2]int) []byte {
+	var b astcBits
+	pos := 0
+	for _, f := range fields {
+		b.set(pos, f[1], f[0])
+		pos += f[1]
+	}
+	block := make([]byte, blockSizeBPTC)
+	for i := 0; i < 8; i++ {
+		block[i] = uint8(b.lo >> uint(8*i))
+		block[8+i] = uint8(b.hi >> uint(8*i))
+	}
+	return block
+}
+
+// repeatField returns n fields of the given value and bits.
+func repeatField(value, bits, n int) [][2]int {
+	fields := make([][2]int, n)
+	for i := range fields {
+		fields[i] = [2]int{value, bits}
+	}
+	return fields
+}
+
+func TestBPTCPartitions(t *testing.T) {
+	for p := 0; p < 64; p++ {
+		if s := bptcPartitions2[p][bptcAnchors2[p]]; s != 1 {
+			t.Errorf("Anchor %v of the partition %v of 2 subsets is in subset %v", bptcAnchors2[p], p, s)
+		}
+		for i, anchors := range bptcAnchors3 {
+			if s := bptcPartitions3[p][anchors[p]]; int(s) != i+1 {
+				t.Errorf("Anchor %v of the partition %v of 3 subsets is in subset %v, expected %v", anchors[p], p, s, i+1)
+			}
+		}
+	}
+}
+
+func TestBC7Modes(t *testing.T) {
+	for mode, m := range bc7Modes {
+		bits := mode + 1 + m.partitionBits + m.rotationBits + m.indexSelectionBits
+		bits += 2 * m.subsets * (3*m.colorBits + m.alphaBits)
+		if m.endpointPBits {
+			bits += 2 * m.subsets
+		} else if m.sharedPBits {
+			bits += m.subsets
+		}
+		bits += 16*m.indexBits - m.subsets
+		if m.indexBits2 > 0 {
+			bits += 16*m.indexBits2 - 1
+		}
+		if bits != 128 {
+			t.Errorf("Wrong size of the BC7 mode %v : %v bits", mode, bits)
+		}
+	}
+}
+
+func TestDecodeBC7(t *testing.T) {
+	var fields [][2]int
+
+	// Mode 6 with unique P-bits, and the index of every pixel
+	// being its position
+	fields = [][2]int{{1 << 6, 7}, {0, 7}, {127, 7}, {64, 7}, {64, 7}, {0, 7}, {0, 7}, {127, 7}, {127, 7}, {0, 1}, {1, 1}, {0, 3}}
+	for i := 1; i < 16; i++ {
+		fields = append(fields, [2]int{i, 4})
+	}
+	mode6 := bptcBlock(fields...)
+
+	// Mode 1 with the partition 13, of the top and bottom halves, and shared
+	// P-bits. The anchor of the bottom half is the last pixel.
+	fields = [][2]int{{1 << 1, 2}, {13, 6}, {63, 6}, {63, 6}, {0, 6}, {0, 6}, {0, 6}, {0, 6}, {0, 6}, {63, 6}}
+	fields = append(fields, repeatField(0, 6, 4)...)
+	fields = append(fields, [2]int{1, 1}, [2]int{0, 1}, [2]int{0, 2})
+	fields = append(fields, repeatField(0, 3, 7)...)
+	fields = append(fields, repeatField(7, 3, 7)...)
+	fields = append(fields, [2]int{3, 2})
+	mode1 := bptcBlock(fields...)
+
+	// Mode 4 with alpha swapped with red, and the 3-bit indices for colors
+	fields = [][2]int{{1 << 4, 5}, {1, 2}, {1, 1}, {0, 5}, {31, 5}, {0, 5}, {0, 5}, {0, 5}, {0, 5}, {0, 6}, {63, 6}, {1, 1}}
+	fields = append(fields, repeatField(1, 2, 15)...)
+	fields = append(fields, [2]int{3, 2})
+	fields = append(fields, repeatField(3, 3, 15)...)
+	mode4 := bptcBlock(fields...)
+
+	tests := []struct {
+		name   string
+		block  []byte
+		pixels map[int]color.NRGBA
+	}{
+		{"mode 6", mode6, map[int]color.NRGBA{0: {0, 128, 0, 254}, 5: {84, 128, 0, 254}, 15: {255, 129, 1, 255}}},
+		{"mode 1", mode1, map[int]color.NRGBA{0: {255, 2, 2, 255}, 7: {255, 2, 2, 255}, 8: {0, 253, 0, 255}, 14: {0, 253, 0, 255}, 15: {0, 107, 0, 255}}},
+		{"mode 4", mode4, map[int]color.NRGBA{0: {84, 0, 0, 108}, 9: {84, 0, 0, 108}}},
+		{"reserved mode", make([]byte, blockSizeBPTC), map[int]color.NRGBA{0: {}, 15: {}}},
+	}
+	for _, test := range tests {
+		var pixels [blockWidth * blockWidth]color.NRGBA
+		decodeBlockBC7(test.block, &pixels)
+		for i, expected := range test.pixels {
+			if pixels[i] != expected {
+				t.Errorf("Wrong pixel %v of the %s block : expected %v, got %v", i, test.name, expected, pixels[i])
+			}
+		}
+	}
+}
+
+func TestDecodeBC7Image(t *testing.T) {
+	// Two blocks side by side, cropped to 6x3
+	r := image.Rect(1, 2, 7, 5)
+	m := NewBC7(r)
+	if len(m.Pix) != 2*blockSizeBPTC {
+		t.Fatalf("Wrong size of pixel data : expected %v, got %v", 2*blockSizeBPTC, len(m.Pix))
+	}
+	// Opaque red and green blocks of mode 5
+	copy(m.Pix, bptcBlock([2]int{1 << 5, 6}, [2]int{0, 2}, [2]int{127, 7}, [2]int{127, 7}, [2]int{0, 28}, [2]int{255, 8}, [2]int{255, 8}))
+	copy(m.Pix[blockSizeBPTC:], bptcBlock([2]int{1 << 5, 6}, [2]int{0, 2}, [2]int{0, 14}, [2]int{127, 7}, [2]int{127, 7}, [2]int{0, 14}, [2]int{255, 8}, [2]int{255, 8}))
+
+	uncompressed, err := m.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := uncompressed.(*image.NRGBA); !ok {
+		t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			expected := color.NRGBA{255, 0, 0, 255}
+			if x-r.Min.X >= 4 {
+				expected = color.NRGBA{0, 255, 0, 255}
+			}
+			if c := m.At(x, y); c != expected {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+			if c := uncompressed.At(x, y); c != expected {
+				t.Errorf("Wrong uncompressed pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+		}
+	}
+
+	if err := m.Compress(image.NewNRGBA(r)); err == nil {
+		t.Error("Expected an error for the compression")
+	}
+	if _, err := (&BC7{make([]byte, 8), image.Rect(0, 0, 4, 4)}).Uncompress(); err == nil {
+		t.Error("Expected an error for missing data")
+	}
+}
@@ -1,6 +1,9 @@
 package color
 
-import "image/color"
+import (
+	"image/color"
+	"math"
+)
 
 // NGrayAlpha represents a 16-bit non-alpha-premultiplied color,
 // having 8 bits for each of grayscale and alpha.
@@ -30,6 +33,75 @@ func (c R8) RGBA() (r, g, b, a uint32) {
 	return
 }
 
+// R16 represents a 16-bit opaque color with only red channel
+type R16 struct {
+	R uint16
+}
+
+func (c R16) RGBA() (r, g, b, a uint32) {
+	r = uint32(c.R)
+	g = 0x0000
+	b = 0x0000
+	a = 0xFFFF
+	return
+}
+
+// RG16 represents a 32-bit opaque color,
+// having 16 bits for each of red and green.
+type RG16 struct {
+	R, G uint16
+}
+
+func (c RG16) RGBA() (r, g, b, a uint32) {
+	r = uint32(c.R)
+	g = uint32(c.G)
+	b = 0x0000
+	a = 0xFFFF
+	return
+}
+
+// SignedR16 represents a 16-bit opaque color with only red channel,
+// which is signed normalized: -32767 and 32767 map to -1 and 1.
+type SignedR16 struct {
+	R int16
+}
+
+func (c SignedR16) RGBA() (r, g, b, a uint32) {
+	r = signedToUnsigned(c.R)
+	g = 0x0000
+	b = 0x0000
+	a = 0xFFFF
+	return
+}
+
+// SignedRG16 represents a 32-bit opaque color, having 16 bits for each
+// of red and green, which are signed normalized like SignedR16.
+type SignedRG16 struct {
+	R, G int16
+}
+
+func (c SignedRG16) RGBA() (r, g, b, a uint32) {
+	r = signedToUnsigned(c.R)
+	g = signedToUnsigned(c.G)
+	b = 0x0000
+	a = 0xFFFF
+	return
+}
+
+// signedToUnsigned maps the signed normalized v from [-1, 1] to [0, 0xFFFF].
+// Both -32768 and -32767 map to -1.
+func signedToUnsigned(v int16) uint32 {
+	if v < -0x7FFF {
+		v = -0x7FFF
+	}
+	return (uint32(int32(v)+0x7FFF)*0xFFFF + 0x7FFF) / 0xFFFE
+}
+
+// unsignedToSigned is the inverse of signedToUnsigned.
+func unsignedToSigned(v uint32) int16 {
+	return int16(int32((v*0xFFFE+0x7FFF)/0xFFFF) - 0x7FFF)
+}
+
 // RGB represents a 24-bit opaque color,
 // having 8 bits for each of red, green and blue
 type RGB struct {
@@ -93,27 +165,58 @@ func (c NRGBA5551) RGBA() (r, g, b, a uint32) {
 // NBGRA8888 represents a 64-bit non-alpha-premultiplied color,
 // having 8 bits for each of red, green, blue and alpha.
 type NBGRA8888 struct {
-	b, g, r, a uint8
+	B, G, R, A uint8
 }
 
 func (c NBGRA8888) RGBA() (r, g, b, a uint32) {
-	a8 := uint32(c.a)
-	r = uint32(c.r) * 0x101 * a8 / 0xFF
-	g = uint32(c.g) * 0x101 * a8 / 0xFF
-	b = uint32(c.b) * 0x101 * a8 / 0xFF
+	a8 := uint32(c.A)
+	r = uint32(c.R) * 0x101 * a8 / 0xFF
+	g = uint32(c.G) * 0x101 * a8 / 0xFF
+	b = uint32(c.B) * 0x101 * a8 / 0xFF
 	a = a8 * 0x101
 	return
 }
 
+// NRGBAF32 represents a non-alpha-premultiplied color of 32-bit floats
+// for each of red, green, blue and alpha. HDR colors exceed 1, and
+// are clamped by the RGBA method.
+type NRGBAF32 struct {
+	R, G, B, A float32
+}
+
+func (c NRGBAF32) RGBA() (r, g, b, a uint32) {
+	a = floatToUnsigned(c.A)
+	r = floatToUnsigned(c.R) * a / 0xFFFF
+	g = floatToUnsigned(c.G) * a / 0xFFFF
+	b = floatToUnsigned(c.B) * a / 0xFFFF
+	return
+}
+
+// floatToUnsigned maps v from [0, 1] to [0, 0xFFFF], clamping the values
+// outside of the range. NaN maps to 0.
+func floatToUnsigned(v float32) uint32 {
+	if !(v > 0) {
+		return 0
+	} else if v >= 1 {
+		return 0xFFFF
+	}
+	return uint32(math.Floor(float64(v)*0xFFFF + 0.5))
+}
+
 // Models for GL color types
 var (
 	NGrayAlphaModel color.Model = color.ModelFunc(nGrayAlphaModel)
 	R8Model         color.Model = color.ModelFunc(r8Model)
+	R16Model        color.Model = color.ModelFunc(r16Model)
+	RG16Model       color.Model = color.ModelFunc(rg16Model)
+	SignedR16Model  color.Model = color.ModelFunc(signedR16Model)
+	SignedRG16Model color.Model = color.ModelFunc(signedRG16Model)
 	RGBModel        color.Model = color.ModelFunc(rgbModel)
 	RGB565Model     color.Model = color.ModelFunc(rgb565Model)
 	NRGBA4444Model  color.Model = color.ModelFunc(nRGBA4444Model)
 	NRGBA5551Model  color.Model = color.ModelFunc(nRGBA5551Model)
 	NBGRA8888Model  color.Model = color.ModelFunc(nBGRA8888Model)
+	NRGBAF32Model   color.Model = color.ModelFunc(nRGBAF32Model)
 )
 
 func nGrayAlphaModel(c color.Color) color.Color {
@@ -134,6 +237,42 @@ func r8Model(c color.Color) color.Color {
 	return R8{uint8(r & 0xFF)}
 }
 
+func r16Model(c color.Color) color.Color {
+	if _, ok := c.(R16); ok {
+		return c
+	}
+
+	r, _, _, _ := c.RGBA()
+	return R16{uint16(r)}
+}
+
+func rg16Model(c color.Color) color.Color {
+	if _, ok := c.(RG16); ok {
+		return c
+	}
+
+	r, g, _, _ := c.RGBA()
+	return RG16{uint16(r), uint16(g)}
+}
+
+func signedR16Model(c color.Color) color.Color {
+	if _, ok := c.(SignedR16); ok {
+		return c
+	}
+
+	r, _, _, _ := c.RGBA()
+	return SignedR16{unsignedToSigned(r)}
+}
+
+func signedRG16Model(c color.Color) color.Color {
+	if _, ok := c.(SignedRG16); ok {
+		return c
+	}
+
+	r, g, _, _ := c.RGBA()
+	return SignedRG16{unsignedToSigned(r), unsignedToSigned(g)}
+}
+
 func rgbModel(c color.Color) color.Color {
 	if _, ok := c.(RGB); ok {
 		return c
@@ -189,5 +328,22 @@ func nBGRA8888Model(c color.Color) color.Color {
 	}
 
 	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return NBGRA8888{}
+	}
 	return NBGRA8888{uint8((b * 0xFFFF / a) >> 8), uint8((g * 0xFFFF / a) >> 8), uint8((r * 0xFFFF / a) >> 8), uint8(a >> 8)}
 }
+
+func nRGBAF32Model(c color.Color) color.Color {
+	if _, ok := c.(NRGBAF32); ok {
+		return c
+	}
+
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return NRGBAF32{}
+	}
+	// Unpremultiplied components stay in [0, 1]
+	fa := float32(a)
+	return NRGBAF32{float32(r) / fa, float32(g) / fa, float32(b) / fa, fa / 0xFFFF}
+}
@@ -39,6 +39,97 @@ func TestR8(t *testing.T) {
 	}
 }
 
+func TestR16(t *testing.T) {
+	var c R16
+	if r, g, b, a := c.RGBA(); r != 0 || g != 0 || b != 0 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	c = R16{0x5A3C}
+	if r, g, b, a := c.RGBA(); r != 0x5A3C || g != 0x0000 || b != 0x0000 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	c = R16Model.Convert(color.RGBA64{0x5151, 0xB6B6, 0x5151, 0xFFFF}).(R16)
+	if cnew := (R16{0x5151}); c != cnew {
+		t.Error()
+	}
+}
+
+func TestRG16(t *testing.T) {
+	var c RG16
+	if r, g, b, a := c.RGBA(); r != 0 || g != 0 || b != 0 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	c = RG16{0x5A3C, 0xC3A5}
+	if r, g, b, a := c.RGBA(); r != 0x5A3C || g != 0xC3A5 || b != 0x0000 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	c = RG16Model.Convert(color.RGBA64{0x5151, 0xB6B6, 0x5151, 0xFFFF}).(RG16)
+	if cnew := (RG16{0x5151, 0xB6B6}); c != cnew {
+		t.Error()
+	}
+}
+
+func TestSignedR16(t *testing.T) {
+	var c SignedR16
+	if r, g, b, a := c.RGBA(); r != 0x8000 || g != 0 || b != 0 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	// -32768 and -32767 both map to -1
+	for _, v := range []int16{-0x8000, -0x7FFF} {
+		c = SignedR16{v}
+		if r, g, b, a := c.RGBA(); r != 0x0000 || g != 0x0000 || b != 0x0000 || a != 0xFFFF {
+			t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+		}
+	}
+	c = SignedR16{0x7FFF}
+	if r, g, b, a := c.RGBA(); r != 0xFFFF || g != 0x0000 || b != 0x0000 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	// Every value but -32768 survives the conversion
+	for v := -0x7FFF; v <= 0x7FFF; v++ {
+		c = SignedR16Model.Convert(SignedRG16{int16(v), 0}).(SignedR16)
+		if cnew := (SignedR16{int16(v)}); c != cnew {
+			t.Fatalf("Expected %v, got %v", cnew, c)
+		}
+	}
+}
+
+func TestSignedRG16(t *testing.T) {
+	c := SignedRG16{-0x7FFF, 0x7FFF}
+	if r, g, b, a := c.RGBA(); r != 0x0000 || g != 0xFFFF || b != 0x0000 || a != 0xFFFF {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	c = SignedRG16Model.Convert(color.RGBA64{0x0000, 0xFFFF, 0x5151, 0xFFFF}).(SignedRG16)
+	if cnew := (SignedRG16{-0x7FFF, 0x7FFF}); c != cnew {
+		t.Error()
+	}
+}
+
+func TestNRGBAF32(t *testing.T) {
+	var c NRGBAF32
+	if r, g, b, a := c.RGBA(); r != 0 || g != 0 || b != 0 || a != 0 {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	// HDR components are clamped
+	c = NRGBAF32{0.5, 4.0, -1.0, 0.5}
+	if r, g, b, a := c.RGBA(); r != 0x4000 || g != 0x8000 || b != 0 || a != 0x8000 {
+		t.Errorf("r=0x%X g=0x%X b=0x%X a=0x%X", r, g, b, a)
+	}
+
+	c = NRGBAF32Model.Convert(color.RGBA64{0x3333, 0x6666, 0x0000, 0xCCCC}).(NRGBAF32)
+	if cnew := (NRGBAF32{0.25, 0.5, 0, 0.8}); c != cnew {
+		t.Errorf("Wrong converted color : got %v", c)
+	}
+}
+
 func TestRGB(t *testing.T) {
 	var c RGB
 	if r, g, b, a := c.RGBA(); r != 0 || g != 0 || b != 0 || a != 0xFFFF {
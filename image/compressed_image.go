@@ -1,6 +1,13 @@
 package image
 
-import "image"
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+	glcolor "github.com/hantempo/glu/image/color"
+)
 
 type BlockCompressedImage interface {
 	image.Image
@@ -8,3 +15,112 @@ type BlockCompressedImage interface {
 	Uncompress() (image.Image, error)
 	BlockDimensions() (int, int)
 }
+
+// calculateSize returns how many bytes hold the blocks of the compressed
+// internal format f covering an image of width x height pixels.
+func calculateSize(f enum.InternalFormat, width, height int) int {
+	size, err := ImageSize(f, width, height, 1)
+	if err != nil {
+		panic(err)
+	}
+	return size
+}
+
+// rgbBlockDecoder decodes the pixels of a 4x4 block into dst, row by row.
+type rgbBlockDecoder func(block []byte, dst *[blockWidth * blockWidth]glcolor.RGB)
+
+// blockDimensions returns how many 4x4 blocks cover r in width and height.
+func blockDimensions(r image.Rectangle) (x, y int) {
+	x = (r.Dx() + blockWidth - 1) / blockWidth
+	y = (r.Dy() + blockWidth - 1) / blockWidth
+	return
+}
+
+// rgbBlockAt returns the pixel at x, y of an image of bounds r whose blocks
+// of size bytes are stored row by row in pix.
+func rgbBlockAt(r image.Rectangle, pix []byte, size, x, y int, decode rgbBlockDecoder) color.Color {
+	if !(image.Point{x, y}.In(r)) {
+		return glcolor.RGB{}
+	}
+	x, y = x-r.Min.X, y-r.Min.Y
+
+	xBlockDim, _ := blockDimensions(r)
+	offset := ((y/blockWidth)*xBlockDim + x/blockWidth) * size
+	var pixels [blockWidth * blockWidth]glcolor.RGB
+	decode(pix[offset:offset+size], &pixels)
+	return pixels[(y%blockWidth)*blockWidth+x%blockWidth]
+}
+
+// uncompressRGB decodes every block of an image of bounds r whose blocks
+// of size bytes are stored row by row in pix.
+func uncompressRGB(name string, r image.Rectangle, pix []byte, size int, decode rgbBlockDecoder) (*RGB, error) {
+	xBlockDim, yBlockDim := blockDimensions(r)
+	if len(pix) < xBlockDim*yBlockDim*size {
+		return nil, fmt.Errorf("%s uncompress: not enough data [%v < %v]", name, len(pix), xBlockDim*yBlockDim*size)
+	}
+
+	m := NewRGB(r)
+	w, h := r.Dx(), r.Dy()
+	var pixels [blockWidth * blockWidth]glcolor.RGB
+	for yBlock := 0; yBlock < yBlockDim; yBlock++ {
+		for xBlock := 0; xBlock < xBlockDim; xBlock++ {
+			offset := (yBlock*xBlockDim + xBlock) * size
+			decode(pix[offset:offset+size], &pixels)
+			// Pixels of edge blocks outside of the image are dropped
+			for y := 0; y < blockWidth && yBlock*blockWidth+y < h; y++ {
+				for x := 0; x < blockWidth && xBlock*blockWidth+x < w; x++ {
+					c := pixels[y*blockWidth+x]
+					i := m.PixOffset(r.Min.X+xBlock*blockWidth+x, r.Min.Y+yBlock*blockWidth+y)
+					m.Pix[i], m.Pix[i+1], m.Pix[i+2] = c.R, c.G, c.B
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// nrgbaBlockDecoder decodes the pixels of a 4x4 block into dst, row by row.
+type nrgbaBlockDecoder func(block []byte, dst *[blockWidth * blockWidth]color.NRGBA)
+
+// nrgbaBlockAt returns the pixel at x, y of an image of bounds r whose blocks
+// of size bytes are stored row by row in pix.
+func nrgbaBlockAt(r image.Rectangle, pix []byte, size, x, y int, decode nrgbaBlockDecoder) color.Color {
+	if !(image.Point{x, y}.In(r)) {
+		return color.NRGBA{}
+	}
+	x, y = x-r.Min.X, y-r.Min.Y
+
+	xBlockDim, _ := blockDimensions(r)
+	offset := ((y/blockWidth)*xBlockDim + x/blockWidth) * size
+	var pixels [blockWidth * blockWidth]color.NRGBA
+	decode(pix[offset:offset+size], &pixels)
+	return pixels[(y%blockWidth)*blockWidth+x%blockWidth]
+}
+
+// uncompressNRGBA decodes every block of an image of bounds r whose blocks
+// of size bytes are stored row by row in pix.
+func uncompressNRGBA(name string, r image.Rectangle, pix []byte, size int, decode nrgbaBlockDecoder) (*image.NRGBA, error) {
+	xBlockDim, yBlockDim := blockDimensions(r)
+	if len(pix) < xBlockDim*yBlockDim*size {
+		return nil, fmt.Errorf("%s uncompress: not enough data [%v < %v]", name, len(pix), xBlockDim*yBlockDim*size)
+	}
+
+	m := image.NewNRGBA(r)
+	w, h := r.Dx(), r.Dy()
+	var pixels [blockWidth * blockWidth]color.NRGBA
+	for yBlock := 0; yBlock < yBlockDim; yBlock++ {
+		for xBlock := 0; xBlock < xBlockDim; xBlock++ {
+			offset := (yBlock*xBlockDim + xBlock) * size
+			decode(pix[offset:offset+size], &pixels)
+			// Pixels of edge blocks outside of the image are dropped
+			for y := 0; y < blockWidth && yBlock*blockWidth+y < h; y++ {
+				for x := 0; x < blockWidth && xBlock*blockWidth+x < w; x++ {
+					c := pixels[y*blockWidth+x]
+					i := m.PixOffset(r.Min.X+xBlock*blockWidth+x, r.Min.Y+yBlock*blockWidth+y)
+					m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+	}
+	return m, nil
+}
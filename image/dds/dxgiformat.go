@@ -0,0 +1,116 @@
+package dds
+
+// DXGI formats which are decoded to glimage types
+const (
+	DXGI_FORMAT_UNKNOWN             = 0
+	DXGI_FORMAT_R8G8B8A8_UNORM      = 28
+	DXGI_FORMAT_R8G8B8A8_UNORM_SRGB = 29
+	DXGI_FORMAT_R8_UNORM            = 61
+	DXGI_FORMAT_BC1_UNORM           = 71
+	DXGI_FORMAT_BC1_UNORM_SRGB      = 72
+	DXGI_FORMAT_BC2_UNORM           = 74
+	DXGI_FORMAT_BC2_UNORM_SRGB      = 75
+	DXGI_FORMAT_BC3_UNORM           = 77
+	DXGI_FORMAT_BC3_UNORM_SRGB      = 78
+	DXGI_FORMAT_B5G6R5_UNORM        = 85
+	DXGI_FORMAT_B5G5R5A1_UNORM      = 86
+	DXGI_FORMAT_B8G8R8A8_UNORM      = 87
+	DXGI_FORMAT_B8G8R8A8_UNORM_SRGB = 91
+	DXGI_FORMAT_BC6H_UF16           = 95
+	DXGI_FORMAT_BC6H_SF16           = 96
+	DXGI_FORMAT_BC7_UNORM           = 98
+	DXGI_FORMAT_BC7_UNORM_SRGB      = 99
+	DXGI_FORMAT_B4G4R4A4_UNORM      = 115
+)
+
+// Flags of DDS_PIXELFORMAT
+const (
+	PixelFormatAlphaPixels = 0x1
+	PixelFormatAlpha       = 0x2
+	PixelFormatFourCC      = 0x4
+	PixelFormatRGB         = 0x40
+	PixelFormatLuminance   = 0x20000
+)
+
+// fourCC returns the little endian code of the 4 characters in s.
+func fourCC(s string) uint32 {
+	return uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+}
+
+// legacyFormat is a DDS_PIXELFORMAT without a FourCC code
+// and its DXGI equivalent.
+type legacyFormat struct {
+	flags, bitCount, rMask, gMask, bMask, aMask uint32
+	dxgiFormat                                  uint32
+}
+
+// D3DFMT_A8R8G8B8, D3DFMT_A8B8G8R8, D3DFMT_R5G6B5, D3DFMT_A1R5G5B5,
+// D3DFMT_A4R4G4B4 and D3DFMT_L8
+var legacyFormats = []legacyFormat{
+	{PixelFormatRGB | PixelFormatAlphaPixels, 32, 0x00FF0000, 0x0000FF00, 0x000000FF, 0xFF000000, DXGI_FORMAT_B8G8R8A8_UNORM},
+	{PixelFormatRGB | PixelFormatAlphaPixels, 32, 0x000000FF, 0x0000FF00, 0x00FF0000, 0xFF000000, DXGI_FORMAT_R8G8B8A8_UNORM},
+	{PixelFormatRGB, 16, 0xF800, 0x07E0, 0x001F, 0, DXGI_FORMAT_B5G6R5_UNORM},
+	{PixelFormatRGB | PixelFormatAlphaPixels, 16, 0x7C00, 0x03E0, 0x001F, 0x8000, DXGI_FORMAT_B5G5R5A1_UNORM},
+	{PixelFormatRGB | PixelFormatAlphaPixels, 16, 0x0F00, 0x00F0, 0x000F, 0xF000, DXGI_FORMAT_B4G4R4A4_UNORM},
+	{PixelFormatLuminance, 8, 0xFF, 0, 0, 0, DXGI_FORMAT_R8_UNORM},
+}
+
+// Block-compressed formats identified by the FourCC code of the pixel format.
+// BC6H and BC7 have no FourCC code, and always need the DX10 header.
+var fourCCFormats = map[uint32]uint32{
+	fourCC("DXT1"): DXGI_FORMAT_BC1_UNORM,
+	fourCC("DXT3"): DXGI_FORMAT_BC2_UNORM,
+	fourCC("DXT5"): DXGI_FORMAT_BC3_UNORM,
+}
+
+// How many bytes in a 4x4 block of the block-compressed formats
+var blockSizes = map[uint32]int{
+	DXGI_FORMAT_BC1_UNORM:      8,
+	DXGI_FORMAT_BC1_UNORM_SRGB: 8,
+	DXGI_FORMAT_BC2_UNORM:      16,
+	DXGI_FORMAT_BC2_UNORM_SRGB: 16,
+	DXGI_FORMAT_BC3_UNORM:      16,
+	DXGI_FORMAT_BC3_UNORM_SRGB: 16,
+	DXGI_FORMAT_BC6H_UF16:      16,
+	DXGI_FORMAT_BC6H_SF16:      16,
+	DXGI_FORMAT_BC7_UNORM:      16,
+	DXGI_FORMAT_BC7_UNORM_SRGB: 16,
+}
+
+// DXGIFormat returns the DXGI format equivalent to a pixel format
+// of the DDS header which has no DX10 header.
+func DXGIFormat(pf PixelFormat) (uint32, bool) {
+	if pf.Flags&PixelFormatFourCC != 0 {
+		f, ok := fourCCFormats[pf.FourCC]
+		return f, ok
+	}
+	// The alpha mask is only meaningful with PixelFormatAlphaPixels
+	aMask := pf.ABitMask
+	if pf.Flags&PixelFormatAlphaPixels == 0 {
+		aMask = 0
+	}
+	kind := pf.Flags &^ PixelFormatAlphaPixels
+	for _, f := range legacyFormats {
+		if f.flags&^PixelFormatAlphaPixels == kind && f.bitCount == pf.RGBBitCount &&
+			f.rMask == pf.RBitMask && f.gMask == pf.GBitMask && f.bMask == pf.BBitMask && f.aMask == aMask {
+			return f.dxgiFormat, true
+		}
+	}
+	return DXGI_FORMAT_UNKNOWN, false
+}
+
+// PixelFormatOf returns the pixel format describing the given DXGI format
+// without a DX10 header, if there is one.
+func PixelFormatOf(dxgiFormat uint32) (PixelFormat, bool) {
+	for code, f := range fourCCFormats {
+		if f == dxgiFormat {
+			return PixelFormat{Size: pixelFormatSize, Flags: PixelFormatFourCC, FourCC: code}, true
+		}
+	}
+	for _, f := range legacyFormats {
+		if f.dxgiFormat == dxgiFormat {
+			return PixelFormat{pixelFormatSize, f.flags, 0, f.bitCount, f.rMask, f.gMask, f.bMask, f.aMask}, true
+		}
+	}
+	return PixelFormat{}, false
+}
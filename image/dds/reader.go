@@ -0,0 +1,441 @@
+// Package dds reads and writes DirectDraw Surface files, including the
+// DX10 header extension for DXGI formats and texture arrays.
+package dds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+const magic = "DDS "
+
+const (
+	headerSize      = 124
+	pixelFormatSize = 32
+	// Largest array of 2D textures of Direct3D 11 and 12
+	maxArraySize = 2048
+)
+
+// Flags of DDS_HEADER
+const (
+	FlagCaps        = 0x1
+	FlagHeight      = 0x2
+	FlagWidth       = 0x4
+	FlagPitch       = 0x8
+	FlagPixelFormat = 0x1000
+	FlagMipMapCount = 0x20000
+	FlagLinearSize  = 0x80000
+	FlagDepth       = 0x800000
+)
+
+// Capabilities in the Caps and Caps2 fields of DDS_HEADER
+const (
+	CapsComplex = 0x8
+	CapsTexture = 0x1000
+	CapsMipMap  = 0x400000
+
+	Caps2Cubemap         = 0x200
+	Caps2CubemapAllFaces = 0xFC00
+	Caps2Volume          = 0x200000
+)
+
+// Fields of DDS_HEADER_DXT10
+const (
+	ResourceDimensionTexture1D = 2
+	ResourceDimensionTexture2D = 3
+	ResourceDimensionTexture3D = 4
+
+	MiscFlagTextureCube = 0x4
+)
+
+// PixelFormat holds the fields of DDS_PIXELFORMAT.
+type PixelFormat struct {
+	Size        uint32
+	Flags       uint32
+	FourCC      uint32
+	RGBBitCount uint32
+	RBitMask    uint32
+	GBitMask    uint32
+	BBitMask    uint32
+	ABitMask    uint32
+}
+
+// Header holds the fields of DDS_HEADER which follow the magic.
+type Header struct {
+	Size              uint32
+	Flags             uint32
+	Height            uint32
+	Width             uint32
+	PitchOrLinearSize uint32
+	Depth             uint32
+	MipMapCount       uint32
+	Reserved1         [11]uint32
+	PixelFormat       PixelFormat
+	Caps              uint32
+	Caps2             uint32
+	Caps3             uint32
+	Caps4             uint32
+	Reserved2         uint32
+}
+
+// HeaderDX10 holds the fields of DDS_HEADER_DXT10, which follows the header
+// when the FourCC code of the pixel format is "DX10".
+type HeaderDX10 struct {
+	DXGIFormat        uint32
+	ResourceDimension uint32
+	MiscFlag          uint32
+	ArraySize         uint32
+	MiscFlags2        uint32
+}
+
+// Config holds the headers of a DDS file.
+type Config struct {
+	Header
+	// DX10 is nil if the file has no DX10 header.
+	DX10 *HeaderDX10
+}
+
+// DXGIFormat returns the format of the images, from the DX10 header if any
+// or from the pixel format otherwise.
+func (c *Config) DXGIFormat() (uint32, bool) {
+	if c.DX10 != nil {
+		return c.DX10.DXGIFormat, true
+	}
+	return DXGIFormat(c.PixelFormat)
+}
+
+// Levels returns the number of mipmap levels stored in the file.
+func (c *Config) Levels() int {
+	if c.Flags&FlagMipMapCount == 0 || c.MipMapCount == 0 {
+		return 1
+	}
+	return int(c.MipMapCount)
+}
+
+// Layers returns the number of array layers stored in the file,
+// which is 1 for non-array textures.
+func (c *Config) Layers() int {
+	if c.DX10 == nil || c.DX10.ArraySize == 0 {
+		return 1
+	}
+	return int(c.DX10.ArraySize)
+}
+
+// Faces returns the number of faces stored in the file,
+// which is 6 for cubemaps and 1 otherwise.
+func (c *Config) Faces() int {
+	if c.DX10 != nil && c.DX10.MiscFlag&MiscFlagTextureCube != 0 {
+		return 6
+	}
+	if c.Caps2&Caps2Cubemap != 0 {
+		return 6
+	}
+	return 1
+}
+
+// LevelSize returns the width and height of the given mipmap level.
+func (c *Config) LevelSize(level int) (width, height int) {
+	width = int(c.Width) >> uint(level)
+	height = int(c.Height) >> uint(level)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return
+}
+
+// Texture holds every image stored in a DDS file.
+type Texture struct {
+	Config
+	// Images is indexed by mipmap level, array layer and cube face,
+	// in this order.
+	Images [][][]image.Image
+}
+
+// Image returns the image of the given mipmap level, array layer and cube face.
+func (t *Texture) Image(level, layer, face int) image.Image {
+	return t.Images[level][layer][face]
+}
+
+type format struct {
+	model color.Model
+	// newImage allocates an image and returns it with its pixel buffer.
+	newImage func(r image.Rectangle) (image.Image, []byte)
+	// fromFile and toFile convert the 16-bit pixels between the layouts of
+	// the file and of the image. They are nil if both layouts are the same.
+	fromFile, toFile func(v uint16) uint16
+}
+
+var formats = map[uint32]format{
+	DXGI_FORMAT_R8G8B8A8_UNORM:      {color.NRGBAModel, newNRGBA, nil, nil},
+	DXGI_FORMAT_R8G8B8A8_UNORM_SRGB: {color.NRGBAModel, newNRGBA, nil, nil},
+	DXGI_FORMAT_R8_UNORM: {color.GrayModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := image.NewGray(r)
+		return m, m.Pix
+	}, nil, nil},
+	DXGI_FORMAT_BC1_UNORM:      {color.NRGBAModel, newBC1, nil, nil},
+	DXGI_FORMAT_BC1_UNORM_SRGB: {color.NRGBAModel, newBC1, nil, nil},
+	DXGI_FORMAT_BC2_UNORM:      {color.NRGBAModel, newBC2, nil, nil},
+	DXGI_FORMAT_BC2_UNORM_SRGB: {color.NRGBAModel, newBC2, nil, nil},
+	DXGI_FORMAT_BC3_UNORM:      {color.NRGBAModel, newBC3, nil, nil},
+	DXGI_FORMAT_BC3_UNORM_SRGB: {color.NRGBAModel, newBC3, nil, nil},
+	DXGI_FORMAT_B5G6R5_UNORM: {glcolor.RGB565Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewRGB565(r)
+		return m, m.Pix
+	}, nil, nil},
+	DXGI_FORMAT_B5G5R5A1_UNORM: {glcolor.NRGBA5551Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewNRGBA5551(r)
+		return m, m.Pix
+	}, bgra5551ToRGBA5551, rgba5551ToBGRA5551},
+	DXGI_FORMAT_B8G8R8A8_UNORM:      {glcolor.NBGRA8888Model, newNBGRA8888, nil, nil},
+	DXGI_FORMAT_B8G8R8A8_UNORM_SRGB: {glcolor.NBGRA8888Model, newNBGRA8888, nil, nil},
+	DXGI_FORMAT_BC6H_UF16: {glcolor.NRGBAF32Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC6H(r)
+		return m, m.Pix
+	}, nil, nil},
+	DXGI_FORMAT_BC6H_SF16: {glcolor.NRGBAF32Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC6H(r)
+		m.Signed = true
+		return m, m.Pix
+	}, nil, nil},
+	DXGI_FORMAT_BC7_UNORM:      {color.NRGBAModel, newBC7, nil, nil},
+	DXGI_FORMAT_BC7_UNORM_SRGB: {color.NRGBAModel, newBC7, nil, nil},
+	DXGI_FORMAT_B4G4R4A4_UNORM: {glcolor.NRGBA4444Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewNRGBA4444(r)
+		return m, m.Pix
+	}, bgra4444ToRGBA4444, rgba4444ToBGRA4444},
+}
+
+func newNRGBA(r image.Rectangle) (image.Image, []byte) {
+	m := image.NewNRGBA(r)
+	return m, m.Pix
+}
+
+func newNBGRA8888(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewNBGRA8888(r)
+	return m, m.Pix
+}
+
+func newBC1(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC1(r)
+	return m, m.Pix
+}
+
+func newBC2(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC2(r)
+	return m, m.Pix
+}
+
+func newBC3(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC3(r)
+	return m, m.Pix
+}
+
+func newBC7(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC7(r)
+	return m, m.Pix
+}
+
+// DXGI stores blue in the lowest bits and alpha in the highest ones,
+// while glcolor follows GL with red in the highest bits and alpha in the lowest.
+
+func bgra4444ToRGBA4444(v uint16) uint16 {
+	return v<<4 | v>>12
+}
+
+func rgba4444ToBGRA4444(v uint16) uint16 {
+	return v>>4 | v<<12
+}
+
+func bgra5551ToRGBA5551(v uint16) uint16 {
+	return (v>>10&0x1F)<<11 | (v>>5&0x1F)<<6 | (v&0x1F)<<1 | v>>15
+}
+
+func rgba5551ToBGRA5551(v uint16) uint16 {
+	return (v>>11&0x1F)<<10 | (v>>6&0x1F)<<5 | v>>1&0x1F | (v&1)<<15
+}
+
+// swizzle applies f to every little endian 16-bit pixel of pix.
+func swizzle(pix []byte, f func(v uint16) uint16) {
+	if f == nil {
+		return
+	}
+	for i := 0; i+2 <= len(pix); i += 2 {
+		binary.LittleEndian.PutUint16(pix[i:], f(binary.LittleEndian.Uint16(pix[i:])))
+	}
+}
+
+type decoder struct {
+	r      io.Reader
+	config Config
+	format format
+	tex    *Texture
+}
+
+func (d *decoder) decode(r io.Reader, configOnly bool) error {
+	d.r = r
+	if err := d.decodeHeader(); err != nil {
+		return err
+	}
+	if err := d.checkFormat(); err != nil {
+		return err
+	}
+	if err := d.checkLayout(); err != nil {
+		return err
+	}
+	if configOnly {
+		return nil
+	}
+	return d.decodeImages()
+}
+
+func (d *decoder) decodeHeader() error {
+	var buf [4 + headerSize]byte
+	if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+		return err
+	}
+	if magic != string(buf[:4]) {
+		return fmt.Errorf("DDS reader: invalid identifier [%v]", buf[:4])
+	}
+
+	h := &d.config.Header
+	if err := binary.Read(bytes.NewReader(buf[4:]), binary.LittleEndian, h); err != nil {
+		return err
+	}
+	if h.Size != headerSize {
+		return fmt.Errorf("DDS reader: invalid header size [%v]", h.Size)
+	}
+	if h.PixelFormat.Size != pixelFormatSize {
+		return fmt.Errorf("DDS reader: invalid pixel format size [%v]", h.PixelFormat.Size)
+	}
+
+	if h.PixelFormat.Flags&PixelFormatFourCC != 0 && h.PixelFormat.FourCC == fourCC("DX10") {
+		d.config.DX10 = &HeaderDX10{}
+		if err := binary.Read(d.r, binary.LittleEndian, d.config.DX10); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFormat makes sure the format of the images is supported.
+func (d *decoder) checkFormat() error {
+	dxgiFormat, ok := d.config.DXGIFormat()
+	if !ok {
+		return fmt.Errorf("DDS reader: unsupported pixel format [%+v]", d.config.PixelFormat)
+	}
+	f, ok := formats[dxgiFormat]
+	if !ok {
+		return fmt.Errorf("DDS reader: unsupported DXGI format [%v]", dxgiFormat)
+	}
+	d.format = f
+	return nil
+}
+
+// checkLayout makes sure the layers, faces and slices can be decoded.
+func (d *decoder) checkLayout() error {
+	c := &d.config
+	if c.Caps2&Caps2Volume != 0 || (c.Flags&FlagDepth != 0 && c.Depth > 1) {
+		return fmt.Errorf("DDS reader: 3D textures are not supported [depth=%v]", c.Depth)
+	}
+	if c.DX10 != nil {
+		dim := c.DX10.ResourceDimension
+		if dim != ResourceDimensionTexture1D && dim != ResourceDimensionTexture2D {
+			return fmt.Errorf("DDS reader: unsupported resource dimension [%v]", dim)
+		}
+	} else if c.Caps2&Caps2Cubemap != 0 && c.Caps2&Caps2CubemapAllFaces != Caps2CubemapAllFaces {
+		return fmt.Errorf("DDS reader: cubemaps with missing faces are not supported [%#x]", c.Caps2)
+	}
+	if full := glimage.MipLevels(int(c.Width), int(c.Height)); c.Levels() > full {
+		return fmt.Errorf("DDS reader: too many levels [%v > %v]", c.MipMapCount, full)
+	}
+	if c.Layers() > maxArraySize {
+		return fmt.Errorf("DDS reader: too many array elements [%v]", c.DX10.ArraySize)
+	}
+	return nil
+}
+
+func (d *decoder) decodeImages() error {
+	c := &d.config
+	levels, layers, faces := c.Levels(), c.Layers(), c.Faces()
+
+	d.tex = &Texture{Config: *c, Images: make([][][]image.Image, levels)}
+	for level := range d.tex.Images {
+		d.tex.Images[level] = make([][]image.Image, 0, 1)
+	}
+
+	// Every face stores its whole mipmap chain before the next one. The
+	// images of a layer are only allocated once the file has supplied the
+	// previous layers, whatever the array size in the header.
+	for layer := 0; layer < layers; layer++ {
+		for level := range d.tex.Images {
+			d.tex.Images[level] = append(d.tex.Images[level], make([]image.Image, faces))
+		}
+		for face := 0; face < faces; face++ {
+			for level := 0; level < levels; level++ {
+				width, height := c.LevelSize(level)
+				im, pix := d.format.newImage(image.Rect(0, 0, width, height))
+				if _, err := io.ReadFull(d.r, pix); err != nil {
+					return fmt.Errorf("DDS reader: not enough image data [%v]", err)
+				}
+				swizzle(pix, d.format.fromFile)
+				d.tex.Images[level][layer][face] = im
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeTexture reads a DDS file from r and returns every mipmap level,
+// array layer and cube face stored in it.
+func DecodeTexture(r io.Reader) (*Texture, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex, nil
+}
+
+// Decode reads a DDS file from r and returns the first face of
+// the first array layer in the base mipmap level.
+func Decode(r io.Reader) (image.Image, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex.Image(0, 0, 0), nil
+}
+
+// DecodeTextureConfig reads the headers of a DDS file from r
+// without decoding any image.
+func DecodeTextureConfig(r io.Reader) (Config, error) {
+	var d decoder
+	if err := d.decode(r, true); err != nil {
+		return Config{}, err
+	}
+	return d.config, nil
+}
+
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	var d decoder
+	err := d.decode(r, true)
+	width, height := d.config.LevelSize(0)
+	return image.Config{
+		ColorModel: d.format.model,
+		Width:      width,
+		Height:     height,
+	}, err
+}
+
+func init() {
+	image.RegisterFormat("dds", magic, Decode, DecodeConfig)
+}
@@ -0,0 +1,222 @@
+package dds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// ddsFile builds a DDS file with the given header, an optional DX10 header
+// and the data of the images in file order.
+func ddsFile(h Header, dx10 *HeaderDX10, data ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	h.Size = headerSize
+	h.PixelFormat.Size = pixelFormatSize
+	binary.Write(&buf, binary.LittleEndian, h)
+	if dx10 != nil {
+		binary.Write(&buf, binary.LittleEndian, dx10)
+	}
+	for _, d := range data {
+		buf.Write(d)
+	}
+	return buf.Bytes()
+}
+
+// legacyHeader returns the header of a 2D texture in the given DXGI format
+// without a DX10 header.
+func legacyHeader(dxgiFormat uint32, width, height, levels uint32) Header {
+	pf, _ := PixelFormatOf(dxgiFormat)
+	h := Header{Flags: FlagCaps | FlagHeight | FlagWidth | FlagPixelFormat, Width: width, Height: height, PixelFormat: pf, Caps: CapsTexture}
+	if levels > 1 {
+		h.Flags |= FlagMipMapCount
+		h.MipMapCount = levels
+	}
+	return h
+}
+
+// dx10Header returns the headers of a 2D texture in the given DXGI format.
+func dx10Header(dxgiFormat uint32, width, height, layers uint32, miscFlag uint32) (Header, *HeaderDX10) {
+	h := Header{Flags: FlagCaps | FlagHeight | FlagWidth | FlagPixelFormat, Width: width, Height: height, Caps: CapsTexture}
+	h.PixelFormat = PixelFormat{Flags: PixelFormatFourCC, FourCC: fourCC("DX10")}
+	return h, &HeaderDX10{dxgiFormat, ResourceDimensionTexture2D, miscFlag, layers, 0}
+}
+
+func TestDecode(t *testing.T) {
+	// A8R8G8B8 stores blue, green, red and alpha bytes
+	input := ddsFile(legacyHeader(DXGI_FORMAT_B8G8R8A8_UNORM, 2, 1, 0), nil, []byte{0x11, 0x22, 0x33, 0xFF, 0x44, 0x55, 0x66, 0x80})
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "dds" || config.ColorModel != glcolor.NBGRA8888Model || config.Width != 2 || config.Height != 1 {
+		t.Errorf("Wrong config : got %v %+v", format, config)
+	}
+
+	m, _, err := image.Decode(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bgra, ok := m.(*glimage.NBGRA8888)
+	if !ok {
+		t.Fatalf("Wrong image type : got %T", m)
+	}
+	if c := bgra.At(0, 0); c != (glcolor.NBGRA8888{0x11, 0x22, 0x33, 0xFF}) {
+		t.Errorf("Wrong color at (0, 0) : got %v", c)
+	}
+	if c := bgra.At(1, 0); c != (glcolor.NBGRA8888{0x44, 0x55, 0x66, 0x80}) {
+		t.Errorf("Wrong color at (1, 0) : got %v", c)
+	}
+}
+
+func TestDecodeFormats(t *testing.T) {
+	tests := []struct {
+		dxgiFormat uint32
+		data       []byte
+		expected   color.Color
+	}{
+		{DXGI_FORMAT_R8_UNORM, []byte{0x80}, color.Gray{0x80}},
+		{DXGI_FORMAT_R8G8B8A8_UNORM, []byte{0x11, 0x22, 0x33, 0x44}, color.NRGBA{0x11, 0x22, 0x33, 0x44}},
+		{DXGI_FORMAT_B5G6R5_UNORM, []byte{0x34, 0x12}, glcolor.RGB565{0x1234}},
+		// Blue 0x1, green 0x2, red 0x3 and alpha 0x4
+		{DXGI_FORMAT_B4G4R4A4_UNORM, []byte{0x21, 0x43}, glcolor.NRGBA4444{0x3214}},
+		// Blue 0x01, green 0x02, red 0x03 and alpha 1
+		{DXGI_FORMAT_B5G5R5A1_UNORM, []byte{0x41, 0x8C}, glcolor.NRGBA5551{0x1883}},
+		// Red endpoints, read from the DXT1, DXT3 and DXT5 FourCC codes
+		{DXGI_FORMAT_BC1_UNORM, []byte{0x00, 0xF8, 0x00, 0xF8, 0, 0, 0, 0}, color.NRGBA{0xFF, 0, 0, 0xFF}},
+		{DXGI_FORMAT_BC2_UNORM, []byte{0x08, 0, 0, 0, 0, 0, 0, 0, 0x00, 0xF8, 0x00, 0xF8, 0, 0, 0, 0}, color.NRGBA{0xFF, 0, 0, 0x88}},
+		{DXGI_FORMAT_BC3_UNORM, []byte{0x80, 0x80, 0, 0, 0, 0, 0, 0, 0x00, 0xF8, 0x00, 0xF8, 0, 0, 0, 0}, color.NRGBA{0xFF, 0, 0, 0x80}},
+		// Red of the BC7 mode 5, and red -256 of the signed BC6H mode 11, only
+		// with the DX10 header
+		{DXGI_FORMAT_BC7_UNORM, []byte{0x20, 0xFF, 0x3F, 0, 0, 0, 0xFC, 0xFF, 0x03, 0, 0, 0, 0, 0, 0, 0}, color.NRGBA{0xFF, 0, 0, 0xFF}},
+		{DXGI_FORMAT_BC6H_SF16, []byte{0x03, 0x60, 0, 0, 0, 0x18, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, glcolor.NRGBAF32{-1.5302734375, 0, 0, 1}},
+	}
+	for _, test := range tests {
+		for _, dx10 := range []bool{false, true} {
+			if _, ok := PixelFormatOf(test.dxgiFormat); !ok && !dx10 {
+				continue
+			}
+			var input []byte
+			if dx10 {
+				h, h10 := dx10Header(test.dxgiFormat, 1, 1, 1, 0)
+				input = ddsFile(h, h10, test.data)
+			} else {
+				input = ddsFile(legacyHeader(test.dxgiFormat, 1, 1, 0), nil, test.data)
+			}
+			m, err := Decode(bytes.NewReader(input))
+			if err != nil {
+				t.Errorf("Format %v, DX10 %v : %v", test.dxgiFormat, dx10, err)
+				continue
+			}
+			if c := m.At(0, 0); c != test.expected {
+				t.Errorf("Format %v, DX10 %v : expected %v, got %v", test.dxgiFormat, dx10, test.expected, c)
+			}
+		}
+	}
+}
+
+func TestDecodeTexture(t *testing.T) {
+	// Mipmapped cubemap, every face storing its 2x2 and 1x1 levels in turn
+	h := legacyHeader(DXGI_FORMAT_R8_UNORM, 2, 2, 2)
+	h.Caps |= CapsComplex | CapsMipMap
+	h.Caps2 = Caps2Cubemap | Caps2CubemapAllFaces
+	var data [][]byte
+	for face := 0; face < 6; face++ {
+		v := uint8(face * 2)
+		data = append(data, []byte{v, v, v, v}, []byte{v + 1})
+	}
+	tex, err := DecodeTexture(bytes.NewReader(ddsFile(h, nil, data...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tex.Images) != 2 || len(tex.Images[0]) != 1 || len(tex.Images[0][0]) != 6 {
+		t.Fatalf("Wrong layout : got %vx%vx%v", len(tex.Images), len(tex.Images[0]), len(tex.Images[0][0]))
+	}
+	for face := 0; face < 6; face++ {
+		for level := 0; level < 2; level++ {
+			m := tex.Image(level, 0, face).(*image.Gray)
+			if expected := uint8(face*2 + level); m.Pix[0] != expected {
+				t.Errorf("Level %v of face %v : expected %v, got %v", level, face, expected, m.Pix[0])
+			}
+		}
+	}
+
+	// Array of 2 cubemaps
+	h, h10 := dx10Header(DXGI_FORMAT_R8_UNORM, 1, 1, 2, MiscFlagTextureCube)
+	data = nil
+	for i := 0; i < 12; i++ {
+		data = append(data, []byte{uint8(i)})
+	}
+	input := ddsFile(h, h10, data...)
+	config, err := DecodeTextureConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Levels() != 1 || config.Layers() != 2 || config.Faces() != 6 {
+		t.Errorf("Wrong config layout : got %v %v %v", config.Levels(), config.Layers(), config.Faces())
+	}
+	if f, ok := config.DXGIFormat(); !ok || f != DXGI_FORMAT_R8_UNORM {
+		t.Errorf("Wrong DXGI format : got %v", f)
+	}
+	if tex, err = DecodeTexture(bytes.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	for layer := 0; layer < 2; layer++ {
+		for face := 0; face < 6; face++ {
+			m := tex.Image(0, layer, face).(*image.Gray)
+			if expected := uint8(layer*6 + face); m.Pix[0] != expected {
+				t.Errorf("Face %v of layer %v : expected %v, got %v", face, layer, expected, m.Pix[0])
+			}
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	rgb565 := legacyHeader(DXGI_FORMAT_B5G6R5_UNORM, 2, 2, 0)
+	unknownMasks := rgb565
+	unknownMasks.PixelFormat.RBitMask = 0x001F
+	volume := rgb565
+	volume.Caps2 = Caps2Volume
+	partialCubemap := rgb565
+	partialCubemap.Caps2 = Caps2Cubemap | 0x400
+	dx10, unknownFormat := dx10Header(2, 2, 2, 1, 0)
+	_, texture3D := dx10Header(DXGI_FORMAT_B5G6R5_UNORM, 2, 2, 1, 0)
+	texture3D.ResourceDimension = ResourceDimensionTexture3D
+	tooManyLevels := legacyHeader(DXGI_FORMAT_B5G6R5_UNORM, 2, 2, 0x7FFFFFFF)
+	hugeArray, hugeArrayDX10 := dx10Header(DXGI_FORMAT_B5G6R5_UNORM, 2, 2, 0x7FFFFFFF, 0)
+	bigArray, bigArrayDX10 := dx10Header(DXGI_FORMAT_B5G6R5_UNORM, 2, 2, maxArraySize, 0)
+
+	input := ddsFile(rgb565, nil)
+	binary.LittleEndian.PutUint32(input[4:], 100)
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{[]byte("KTX 1234"), "unexpected EOF"},
+		{append([]byte("DDX "), ddsFile(rgb565, nil)[4:]...), "DDS reader: invalid identifier"},
+		{input, "DDS reader: invalid header size"},
+		{ddsFile(unknownMasks, nil), "DDS reader: unsupported pixel format"},
+		{ddsFile(dx10, unknownFormat), "DDS reader: unsupported DXGI format"},
+		{ddsFile(volume, nil), "DDS reader: 3D textures are not supported"},
+		{ddsFile(dx10, texture3D), "DDS reader: unsupported resource dimension"},
+		{ddsFile(partialCubemap, nil), "DDS reader: cubemaps with missing faces are not supported"},
+		{ddsFile(rgb565, nil, make([]byte, 7)), "DDS reader: not enough image data"},
+		{ddsFile(tooManyLevels, nil), "DDS reader: too many levels [2147483647 > 2]"},
+		{ddsFile(hugeArray, hugeArrayDX10), "DDS reader: too many array elements [2147483647]"},
+		// Only the data of the first layer
+		{ddsFile(bigArray, bigArrayDX10, make([]byte, 8)), "DDS reader: not enough image data"},
+	}
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
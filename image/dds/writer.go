@@ -0,0 +1,216 @@
+package dds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+// Options are the encoding parameters.
+type Options struct {
+	// Mipmaps holds the images of the mipmap levels following the base level.
+	// It is only used by Encode.
+	Mipmaps []image.Image
+	// DX10 forces the DX10 header, which is otherwise only written for
+	// texture arrays.
+	DX10 bool
+}
+
+type encoder struct {
+	w    io.Writer
+	tex  *Texture
+	opts Options
+	err  error
+}
+
+func (e *encoder) write(v interface{}) {
+	if e.err != nil {
+		return
+	}
+	e.err = binary.Write(e.w, binary.LittleEndian, v)
+}
+
+// convert returns im if its type can be written as is, or a copy of it
+// as a *glimage.NBGRA8888 otherwise.
+func convert(im image.Image) image.Image {
+	switch im.(type) {
+	case *image.Gray, *image.NRGBA, *glimage.RGB565, *glimage.NRGBA4444, *glimage.NRGBA5551, *glimage.NBGRA8888,
+		*glimage.BC1, *glimage.BC2, *glimage.BC3, *glimage.BC6H, *glimage.BC7:
+		return im
+	}
+	b := im.Bounds()
+	m := glimage.NewNBGRA8888(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			m.Set(x-b.Min.X, y-b.Min.Y, im.At(x, y))
+		}
+	}
+	return m
+}
+
+// imageData returns the DXGI format of im, which must be returned by convert,
+// and its pixels as stored in a DDS file.
+func imageData(im image.Image) (uint32, []byte) {
+	var (
+		dxgiFormat uint32
+		pix        []byte
+		stride     int
+		rowLen     int
+	)
+	b := im.Bounds()
+	switch m := im.(type) {
+	case *image.Gray:
+		dxgiFormat, pix, stride, rowLen = DXGI_FORMAT_R8_UNORM, m.Pix, m.Stride, b.Dx()
+	case *image.NRGBA:
+		dxgiFormat, pix, stride, rowLen = DXGI_FORMAT_R8G8B8A8_UNORM, m.Pix, m.Stride, b.Dx()*4
+	case *glimage.RGB565:
+		dxgiFormat, pix, stride, rowLen = DXGI_FORMAT_B5G6R5_UNORM, m.Pix, m.Stride, b.Dx()*2
+	case *glimage.NRGBA4444:
+		dxgiFormat, pix, stride, rowLen = DXGI_FORMAT_B4G4R4A4_UNORM, m.Pix, m.Stride, b.Dx()*2
+	case *glimage.NRGBA5551:
+		dxgiFormat, pix, stride, rowLen = DXGI_FORMAT_B5G5R5A1_UNORM, m.Pix, m.Stride, b.Dx()*2
+	case *glimage.NBGRA8888:
+		dxgiFormat, pix, stride, rowLen = DXGI_FORMAT_B8G8R8A8_UNORM, m.Pix, m.Stride, b.Dx()*4
+	case *glimage.BC1:
+		return DXGI_FORMAT_BC1_UNORM, m.Pix
+	case *glimage.BC2:
+		return DXGI_FORMAT_BC2_UNORM, m.Pix
+	case *glimage.BC3:
+		return DXGI_FORMAT_BC3_UNORM, m.Pix
+	case *glimage.BC6H:
+		if m.Signed {
+			return DXGI_FORMAT_BC6H_SF16, m.Pix
+		}
+		return DXGI_FORMAT_BC6H_UF16, m.Pix
+	case *glimage.BC7:
+		return DXGI_FORMAT_BC7_UNORM, m.Pix
+	}
+
+	// Rows are not padded in DDS files
+	data := make([]byte, rowLen*b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		copy(data[y*rowLen:(y+1)*rowLen], pix[y*stride:])
+	}
+	swizzle(data, formats[dxgiFormat].toFile)
+	return dxgiFormat, data
+}
+
+func (e *encoder) encode() error {
+	t := e.tex
+	if len(t.Images) == 0 || len(t.Images[0]) == 0 || len(t.Images[0][0]) == 0 {
+		return fmt.Errorf("DDS writer: no image to write")
+	}
+	levels, layers, faces := len(t.Images), len(t.Images[0]), len(t.Images[0][0])
+	if faces != 1 && faces != 6 {
+		return fmt.Errorf("DDS writer: invalid number of faces [%v]", faces)
+	}
+	base := t.Images[0][0][0].Bounds()
+	if base.Empty() {
+		return fmt.Errorf("DDS writer: empty image [%vx%v]", base.Dx(), base.Dy())
+	}
+
+	var c Config
+	c.Width, c.Height = uint32(base.Dx()), uint32(base.Dy())
+
+	// Gather the data of every face before writing anything,
+	// each face storing its whole mipmap chain
+	var (
+		dxgiFormat uint32
+		faceData   [][]byte
+	)
+	for layer := 0; layer < layers; layer++ {
+		for face := 0; face < faces; face++ {
+			for level := 0; level < levels; level++ {
+				if len(t.Images[level]) != layers {
+					return fmt.Errorf("DDS writer: wrong number of layers in level %v [%v != %v]", level, len(t.Images[level]), layers)
+				}
+				if len(t.Images[level][layer]) != faces {
+					return fmt.Errorf("DDS writer: wrong number of faces in level %v [%v != %v]", level, len(t.Images[level][layer]), faces)
+				}
+				im := t.Images[level][layer][face]
+				width, height := c.LevelSize(level)
+				if b := im.Bounds(); b.Dx() != width || b.Dy() != height {
+					return fmt.Errorf("DDS writer: wrong image size in level %v [%vx%v != %vx%v]", level, b.Dx(), b.Dy(), width, height)
+				}
+				f, data := imageData(convert(im))
+				if len(faceData) == 0 {
+					dxgiFormat = f
+				} else if f != dxgiFormat {
+					return fmt.Errorf("DDS writer: mixed image types [%T %T]", im, t.Images[0][0][0])
+				}
+				faceData = append(faceData, data)
+			}
+		}
+	}
+
+	h := &c.Header
+	h.Size = headerSize
+	h.Flags = FlagCaps | FlagHeight | FlagWidth | FlagPixelFormat
+	if _, ok := blockSizes[dxgiFormat]; ok {
+		// Compressed images give the size of the base level instead of a row
+		h.Flags |= FlagLinearSize
+		h.PitchOrLinearSize = uint32(len(faceData[0]))
+	} else {
+		h.Flags |= FlagPitch
+		h.PitchOrLinearSize = uint32(len(faceData[0]) / base.Dy())
+	}
+	h.Caps = CapsTexture
+	if levels > 1 {
+		h.Flags |= FlagMipMapCount
+		h.MipMapCount = uint32(levels)
+		h.Caps |= CapsComplex | CapsMipMap
+	}
+	if faces == 6 {
+		h.Caps |= CapsComplex
+		h.Caps2 = Caps2Cubemap | Caps2CubemapAllFaces
+	}
+
+	pf, ok := PixelFormatOf(dxgiFormat)
+	if layers > 1 || e.opts.DX10 || !ok {
+		pf = PixelFormat{Size: pixelFormatSize, Flags: PixelFormatFourCC, FourCC: fourCC("DX10")}
+		c.DX10 = &HeaderDX10{dxgiFormat, ResourceDimensionTexture2D, 0, uint32(layers), 0}
+		if faces == 6 {
+			c.DX10.MiscFlag = MiscFlagTextureCube
+		}
+	}
+	h.PixelFormat = pf
+
+	e.write([]byte(magic))
+	e.write(h)
+	if c.DX10 != nil {
+		e.write(c.DX10)
+	}
+	for _, data := range faceData {
+		e.write(data)
+	}
+	return e.err
+}
+
+// EncodeTexture writes every image of tex to w in DDS format. The format
+// is decided by the type of the images, see Encode.
+func EncodeTexture(w io.Writer, tex *Texture, opts *Options) error {
+	e := &encoder{w: w, tex: tex}
+	if opts != nil {
+		e.opts = *opts
+	}
+	return e.encode()
+}
+
+// Encode writes the image m to w in DDS format, followed by the mipmap levels
+// in opts. An *image.Gray, *image.NRGBA, *glimage.RGB565, *glimage.NRGBA4444,
+// *glimage.NRGBA5551, *glimage.NBGRA8888, *glimage.BC1, *glimage.BC2,
+// *glimage.BC3, *glimage.BC6H or *glimage.BC7 is written in the equivalent
+// DXGI format, and any other image is converted to B8G8R8A8. BC6H and BC7
+// are always written with the DX10 header.
+func Encode(w io.Writer, m image.Image, opts *Options) error {
+	tex := &Texture{Images: [][][]image.Image{{{m}}}}
+	if opts != nil {
+		for _, mipmap := range opts.Mipmaps {
+			tex.Images = append(tex.Images, [][]image.Image{{mipmap}})
+		}
+	}
+	return EncodeTexture(w, tex, opts)
+}
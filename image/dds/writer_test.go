@@ -0,0 +1,164 @@
+package dds
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+	"github.com/hantempo/glu/image/internal/imagetest"
+)
+
+// compressImage returns m compressed in p.
+func compressImage(p glimage.BlockCompressedImage, m image.Image) glimage.BlockCompressedImage {
+	if err := p.Compress(m); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestEncode(t *testing.T) {
+	r := image.Rect(0, 0, 3, 2)
+	bc6h := glimage.NewBC6H(r)
+	bc6h.Signed = true
+	tests := []struct {
+		m          image.Image
+		dxgiFormat uint32
+		pitch      uint32
+	}{
+		{imagetest.FillImageAlpha(image.NewGray(r)), DXGI_FORMAT_R8_UNORM, 3},
+		{imagetest.FillImageAlpha(image.NewNRGBA(r)), DXGI_FORMAT_R8G8B8A8_UNORM, 12},
+		{imagetest.FillImageAlpha(glimage.NewRGB565(r)), DXGI_FORMAT_B5G6R5_UNORM, 6},
+		{imagetest.FillImageAlpha(glimage.NewNRGBA4444(r)), DXGI_FORMAT_B4G4R4A4_UNORM, 6},
+		{imagetest.FillImageAlpha(glimage.NewNRGBA5551(r)), DXGI_FORMAT_B5G5R5A1_UNORM, 6},
+		{imagetest.FillImageAlpha(glimage.NewNBGRA8888(r)), DXGI_FORMAT_B8G8R8A8_UNORM, 12},
+		// The linear size of the whole level for compressed images
+		{compressImage(glimage.NewBC1(r), imagetest.FillImageAlpha(image.NewNRGBA(r))), DXGI_FORMAT_BC1_UNORM, 8},
+		{compressImage(glimage.NewBC2(r), imagetest.FillImageAlpha(image.NewNRGBA(r))), DXGI_FORMAT_BC2_UNORM, 16},
+		{compressImage(glimage.NewBC3(r), imagetest.FillImageAlpha(image.NewNRGBA(r))), DXGI_FORMAT_BC3_UNORM, 16},
+		{bc6h, DXGI_FORMAT_BC6H_SF16, 16},
+		{glimage.NewBC7(r), DXGI_FORMAT_BC7_UNORM, 16},
+	}
+	for _, test := range tests {
+		for _, dx10 := range []bool{false, true} {
+			var buf bytes.Buffer
+			if err := Encode(&buf, test.m, &Options{DX10: dx10}); err != nil {
+				t.Fatal(err)
+			}
+			config, err := DecodeTextureConfig(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Formats without a pixel format always have the DX10 header
+			_, legacy := PixelFormatOf(test.dxgiFormat)
+			if f, ok := config.DXGIFormat(); !ok || f != test.dxgiFormat || (config.DX10 != nil) != (dx10 || !legacy) {
+				t.Errorf("%T, DX10 %v : wrong DXGI format %v", test.m, dx10, f)
+			}
+			if config.PitchOrLinearSize != test.pitch {
+				t.Errorf("%T : expected pitch %v, got %v", test.m, test.pitch, config.PitchOrLinearSize)
+			}
+			m, err := Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.TypeOf(m) != reflect.TypeOf(test.m) || !imagetest.SameImage(m, test.m) {
+				t.Errorf("%T, DX10 %v : decoded image differs", test.m, dx10)
+			}
+		}
+	}
+}
+
+func TestEncodeConvert(t *testing.T) {
+	// Images without a DXGI equivalent are written as B8G8R8A8
+	m := imagetest.FillImageAlpha(image.NewRGBA(image.Rect(1, 1, 4, 3)))
+	var buf bytes.Buffer
+	if err := Encode(&buf, m, nil); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := glimage.NewNBGRA8888(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			expected.Set(x, y, m.At(x+1, y+1))
+		}
+	}
+	if _, ok := decoded.(*glimage.NBGRA8888); !ok || !imagetest.SameImage(decoded, expected) {
+		t.Errorf("Wrong decoded image : got %T", decoded)
+	}
+}
+
+func TestEncodeTexture(t *testing.T) {
+	newFace := func(size int, v uint8) image.Image {
+		im := image.NewGray(image.Rect(0, 0, size, size))
+		for i := range im.Pix {
+			im.Pix[i] = v
+		}
+		return im
+	}
+	cubemap := &Texture{Images: [][][]image.Image{
+		{{newFace(2, 1), newFace(2, 2), newFace(2, 3), newFace(2, 4), newFace(2, 5), newFace(2, 6)}},
+		{{newFace(1, 7), newFace(1, 8), newFace(1, 9), newFace(1, 10), newFace(1, 11), newFace(1, 12)}},
+	}}
+	array := &Texture{Images: [][][]image.Image{
+		{{newFace(2, 1)}, {newFace(2, 2)}, {newFace(2, 3)}},
+	}}
+
+	for _, tex := range []*Texture{cubemap, array} {
+		var buf bytes.Buffer
+		if err := EncodeTexture(&buf, tex, nil); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeTexture(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decoded.Images) != len(tex.Images) || len(decoded.Images[0]) != len(tex.Images[0]) || len(decoded.Images[0][0]) != len(tex.Images[0][0]) {
+			t.Fatalf("Wrong layout : expected %vx%vx%v, got %vx%vx%v", len(tex.Images), len(tex.Images[0]), len(tex.Images[0][0]),
+				len(decoded.Images), len(decoded.Images[0]), len(decoded.Images[0][0]))
+		}
+		for level := range tex.Images {
+			for layer := range tex.Images[level] {
+				for face := range tex.Images[level][layer] {
+					if !imagetest.SameImage(tex.Image(level, layer, face), decoded.Image(level, layer, face)) {
+						t.Errorf("Image [%v %v %v] differs", level, layer, face)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	tests := []struct {
+		tex *Texture
+		err string
+	}{
+		{&Texture{}, "DDS writer: no image to write"},
+		{&Texture{Images: [][][]image.Image{{{image.NewGray(image.Rect(0, 0, 4, 0))}}}}, "DDS writer: empty image [4x0]"},
+		{&Texture{Images: [][][]image.Image{
+			{{image.NewGray(image.Rect(0, 0, 2, 2))}},
+			{{image.NewGray(image.Rect(0, 0, 2, 2))}},
+		}}, "DDS writer: wrong image size in level 1"},
+		{&Texture{Images: [][][]image.Image{
+			{{image.NewGray(image.Rect(0, 0, 1, 1)), image.NewGray(image.Rect(0, 0, 1, 1))}},
+		}}, "DDS writer: invalid number of faces"},
+		{&Texture{Images: [][][]image.Image{
+			{{image.NewGray(image.Rect(0, 0, 2, 2))}},
+			{{glimage.NewRGB565(image.Rect(0, 0, 1, 1))}},
+		}}, "DDS writer: mixed image types"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := EncodeTexture(&buf, test.tex, nil)
+		if err == nil {
+			t.Errorf("Expected pattern of error message (%s), got no error", test.err)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%s)", test.err, err.Error())
+		}
+	}
+}
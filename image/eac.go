@@ -0,0 +1,351 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Modifiers of the EAC blocks, indexed by table and by pixel index
+var eacModifierTable = [][]int16{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+// eacIndices returns the 3-bit pixel indices of an EAC block, stored column
+// by column from the most significant bits of bytes 2 to 7.
+func eacIndices(block []byte) uint64 {
+	var indices uint64
+	for _, b := range block[2:8] {
+		indices = indices<<8 | uint64(b)
+	}
+	return indices
+}
+
+// decodeAlphaEAC decodes the 8-bit values of an EAC alpha block into dst, row by row.
+func decodeAlphaEAC(block []byte, dst *[blockWidth * blockWidth]uint8) {
+	base := block[0]
+	multiplier := int16(block[1] >> 4)
+	modifiers := eacModifierTable[block[1]&0x0F]
+	indices := eacIndices(block)
+	for y := 0; y < blockWidth; y++ {
+		for x := 0; x < blockWidth; x++ {
+			shift := uint(45 - (x*blockWidth+y)*3)
+			dst[y*blockWidth+x] = clamp(base, modifiers[indices>>shift&0x07]*multiplier)
+		}
+	}
+}
+
+// decodeEAC11 decodes the 11-bit values of an EAC R11 block into dst, row by
+// row, extended to 16 bits. Signed values range from -32767 to 32767.
+func decodeEAC11(block []byte, signed bool, dst *[blockWidth * blockWidth]int32) {
+	base := int32(block[0])*8 + 4
+	min, max := int32(0), int32(2047)
+	if signed {
+		// -128 is a duplicate of -127
+		b := int32(int8(block[0]))
+		if b == -128 {
+			b = -127
+		}
+		base = b * 8
+		min, max = -1023, 1023
+	}
+	multiplier := int32(block[1] >> 4)
+	modifiers := eacModifierTable[block[1]&0x0F]
+	indices := eacIndices(block)
+	for y := 0; y < blockWidth; y++ {
+		for x := 0; x < blockWidth; x++ {
+			shift := uint(45 - (x*blockWidth+y)*3)
+			modifier := int32(modifiers[indices>>shift&0x07])
+			// A multiplier of 0 still allows small steps from the base value
+			v := base + modifier
+			if multiplier != 0 {
+				v = base + modifier*multiplier*8
+			}
+			if v < min {
+				v = min
+			} else if v > max {
+				v = max
+			}
+
+			i := y*blockWidth + x
+			switch {
+			case !signed:
+				dst[i] = v<<5 | v>>6
+			case v < 0:
+				dst[i] = -(-v<<5 | -v>>5)
+			default:
+				dst[i] = v<<5 | v>>5
+			}
+		}
+	}
+}
+
+// eacFormat describes the images of one or two EAC R11 channels, whose blocks
+// of every channel follow each other.
+type eacFormat struct {
+	name     string
+	channels int
+	signed   bool
+}
+
+var (
+	eacR11        = eacFormat{"EAC R11", 1, false}
+	eacSignedR11  = eacFormat{"EAC signed R11", 1, true}
+	eacRG11       = eacFormat{"EAC RG11", 2, false}
+	eacSignedRG11 = eacFormat{"EAC signed RG11", 2, true}
+)
+
+func (f eacFormat) blockSize() int {
+	return f.channels * blockSize
+}
+
+func (f eacFormat) model() color.Model {
+	switch {
+	case f.channels == 1 && f.signed:
+		return glcolor.SignedR16Model
+	case f.channels == 1:
+		return glcolor.R16Model
+	case f.signed:
+		return glcolor.SignedRG16Model
+	}
+	return glcolor.RG16Model
+}
+
+// color returns the color of the 16-bit values of every channel.
+func (f eacFormat) color(v [2]int32) color.Color {
+	switch {
+	case f.channels == 1 && f.signed:
+		return glcolor.SignedR16{int16(v[0])}
+	case f.channels == 1:
+		return glcolor.R16{uint16(v[0])}
+	case f.signed:
+		return glcolor.SignedRG16{int16(v[0]), int16(v[1])}
+	}
+	return glcolor.RG16{uint16(v[0]), uint16(v[1])}
+}
+
+// decodeBlock decodes the pixels of a block into dst, row by row.
+func (f eacFormat) decodeBlock(block []byte, dst *[blockWidth * blockWidth]color.Color) {
+	var values [2][blockWidth * blockWidth]int32
+	for c := 0; c < f.channels; c++ {
+		decodeEAC11(block[c*blockSize:], f.signed, &values[c])
+	}
+	for i := range dst {
+		dst[i] = f.color([2]int32{values[0][i], values[1][i]})
+	}
+}
+
+// at returns the pixel at x, y of an image of bounds r whose blocks
+// are stored row by row in pix.
+func (f eacFormat) at(r image.Rectangle, pix []byte, x, y int) color.Color {
+	if !(image.Point{x, y}.In(r)) {
+		return f.color([2]int32{})
+	}
+	x, y = x-r.Min.X, y-r.Min.Y
+
+	xBlockDim, _ := blockDimensions(r)
+	offset := ((y/blockWidth)*xBlockDim + x/blockWidth) * f.blockSize()
+	var pixels [blockWidth * blockWidth]color.Color
+	f.decodeBlock(pix[offset:offset+f.blockSize()], &pixels)
+	return pixels[(y%blockWidth)*blockWidth+x%blockWidth]
+}
+
+// uncompress decodes every block of an image of bounds r whose blocks
+// are stored row by row in pix, keeping 16 bits per channel.
+func (f eacFormat) uncompress(r image.Rectangle, pix []byte) (*image.RGBA64, error) {
+	xBlockDim, yBlockDim := blockDimensions(r)
+	if len(pix) < xBlockDim*yBlockDim*f.blockSize() {
+		return nil, fmt.Errorf("%s uncompress: not enough data [%v < %v]", f.name, len(pix), xBlockDim*yBlockDim*f.blockSize())
+	}
+
+	m := image.NewRGBA64(r)
+	w, h := r.Dx(), r.Dy()
+	var pixels [blockWidth * blockWidth]color.Color
+	for yBlock := 0; yBlock < yBlockDim; yBlock++ {
+		for xBlock := 0; xBlock < xBlockDim; xBlock++ {
+			offset := (yBlock*xBlockDim + xBlock) * f.blockSize()
+			f.decodeBlock(pix[offset:offset+f.blockSize()], &pixels)
+			// Pixels of edge blocks outside of the image are dropped
+			for y := 0; y < blockWidth && yBlock*blockWidth+y < h; y++ {
+				for x := 0; x < blockWidth && xBlock*blockWidth+x < w; x++ {
+					m.Set(r.Min.X+xBlock*blockWidth+x, r.Min.Y+yBlock*blockWidth+y, pixels[y*blockWidth+x])
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// EACR11 is an in-memory image of blocks in the EAC R11 format,
+// with an unsigned red channel of 11 bits.
+type EACR11 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *EACR11) ColorModel() color.Model {
+	return eacR11.model()
+}
+
+func (p *EACR11) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *EACR11) At(x, y int) color.Color {
+	return eacR11.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *EACR11) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *EACR11) Compress(im image.Image) error {
+	return fmt.Errorf("EAC R11 compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *EACR11) Uncompress() (image.Image, error) {
+	return eacR11.uncompress(p.Rect, p.Pix)
+}
+
+func NewEACR11(r image.Rectangle) *EACR11 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_R11_EAC, w, h))
+	return &EACR11{buf, r}
+}
+
+// EACSignedR11 is an in-memory image of blocks in the EAC signed R11 format,
+// with a signed red channel of 11 bits.
+type EACSignedR11 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *EACSignedR11) ColorModel() color.Model {
+	return eacSignedR11.model()
+}
+
+func (p *EACSignedR11) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *EACSignedR11) At(x, y int) color.Color {
+	return eacSignedR11.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *EACSignedR11) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *EACSignedR11) Compress(im image.Image) error {
+	return fmt.Errorf("EAC signed R11 compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *EACSignedR11) Uncompress() (image.Image, error) {
+	return eacSignedR11.uncompress(p.Rect, p.Pix)
+}
+
+func NewEACSignedR11(r image.Rectangle) *EACSignedR11 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_SIGNED_R11_EAC, w, h))
+	return &EACSignedR11{buf, r}
+}
+
+// EACRG11 is an in-memory image of blocks in the EAC RG11 format,
+// with unsigned red and green channels of 11 bits.
+type EACRG11 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *EACRG11) ColorModel() color.Model {
+	return eacRG11.model()
+}
+
+func (p *EACRG11) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *EACRG11) At(x, y int) color.Color {
+	return eacRG11.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *EACRG11) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *EACRG11) Compress(im image.Image) error {
+	return fmt.Errorf("EAC RG11 compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *EACRG11) Uncompress() (image.Image, error) {
+	return eacRG11.uncompress(p.Rect, p.Pix)
+}
+
+func NewEACRG11(r image.Rectangle) *EACRG11 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RG11_EAC, w, h))
+	return &EACRG11{buf, r}
+}
+
+// EACSignedRG11 is an in-memory image of blocks in the EAC signed RG11 format,
+// with signed red and green channels of 11 bits.
+type EACSignedRG11 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *EACSignedRG11) ColorModel() color.Model {
+	return eacSignedRG11.model()
+}
+
+func (p *EACSignedRG11) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *EACSignedRG11) At(x, y int) color.Color {
+	return eacSignedRG11.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *EACSignedRG11) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *EACSignedRG11) Compress(im image.Image) error {
+	return fmt.Errorf("EAC signed RG11 compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *EACSignedRG11) Uncompress() (image.Image, error) {
+	return eacSignedRG11.uncompress(p.Rect, p.Pix)
+}
+
+func NewEACSignedRG11(r image.Rectangle) *EACSignedRG11 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_SIGNED_RG11_EAC, w, h))
+	return &EACSignedRG11{buf, r}
+}
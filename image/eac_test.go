@@ -0,0 +1,133 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Pixel indices 0 to 7 in every two columns
+var eacIndexPattern = []byte{0x05, 0x39, 0x77, 0x05, 0x39, 0x77}
+
+// Known EAC R11 blocks with their 16-bit values, indexed by pixel index
+var eacGoldenBlocks = []struct {
+	name   string
+	block  []byte
+	signed bool
+	values [8]int32
+}{
+	{
+		// Base 128, multiplier 2, table 13
+		"unsigned",
+		append([]byte{0x80, 0x2D}, eacIndexPattern...),
+		false,
+		[8]int32{32399, 31887, 31375, 27789, 32912, 33424, 33936, 37522},
+	},
+	{
+		// Base 64, multiplier 0, table 13
+		"signed without multiplier",
+		append([]byte{0x40, 0x0D}, eacIndexPattern...),
+		true,
+		[8]int32{16367, 16335, 16303, 16079, 16400, 16432, 16464, 16688},
+	},
+	{
+		// Base -128 read as -127, multiplier 1, table 13, clamped to -1023
+		"signed",
+		append([]byte{0x80, 0x1D}, eacIndexPattern...),
+		true,
+		[8]int32{-32767, -32767, -32767, -32767, -32543, -32287, -32031, -30237},
+	},
+}
+
+// eacValue returns the value of the pixel at x, y of a block
+// with eacIndexPattern.
+func eacValue(values [8]int32, x, y int) int32 {
+	return values[(x%2)*4+y]
+}
+
+func TestDecodeEAC11(t *testing.T) {
+	for _, test := range eacGoldenBlocks {
+		var values [blockWidth * blockWidth]int32
+		decodeEAC11(test.block, test.signed, &values)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				if expected, v := eacValue(test.values, x, y), values[y*4+x]; v != expected {
+					t.Errorf("Wrong value at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, v)
+				}
+			}
+		}
+	}
+
+	// Values are clamped to 11 bits before being extended
+	var values [blockWidth * blockWidth]int32
+	decodeEAC11([]byte{0xFF, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, false, &values)
+	if values[0] != 0xFFFF {
+		t.Errorf("Wrong clamped value : expected %v, got %v", 0xFFFF, values[0])
+	}
+}
+
+func TestDecodeEACImages(t *testing.T) {
+	unsigned, signedSmall, signed := eacGoldenBlocks[0], eacGoldenBlocks[1], eacGoldenBlocks[2]
+	tests := []struct {
+		im    BlockCompressedImage
+		pix   []byte
+		model color.Model
+		color func(x, y int) color.Color
+	}{
+		{NewEACR11(image.Rect(0, 0, 4, 4)), unsigned.block, glcolor.R16Model, func(x, y int) color.Color {
+			return glcolor.R16{uint16(eacValue(unsigned.values, x, y))}
+		}},
+		{NewEACSignedR11(image.Rect(0, 0, 4, 4)), signed.block, glcolor.SignedR16Model, func(x, y int) color.Color {
+			return glcolor.SignedR16{int16(eacValue(signed.values, x, y))}
+		}},
+		{NewEACRG11(image.Rect(0, 0, 4, 4)), append(unsigned.block, unsigned.block...), glcolor.RG16Model, func(x, y int) color.Color {
+			v := uint16(eacValue(unsigned.values, x, y))
+			return glcolor.RG16{v, v}
+		}},
+		{NewEACSignedRG11(image.Rect(0, 0, 4, 4)), append(signed.block, signedSmall.block...), glcolor.SignedRG16Model, func(x, y int) color.Color {
+			return glcolor.SignedRG16{int16(eacValue(signed.values, x, y)), int16(eacValue(signedSmall.values, x, y))}
+		}},
+	}
+	for _, test := range tests {
+		var pix []byte
+		switch m := test.im.(type) {
+		case *EACR11:
+			pix = m.Pix
+		case *EACSignedR11:
+			pix = m.Pix
+		case *EACRG11:
+			pix = m.Pix
+		case *EACSignedRG11:
+			pix = m.Pix
+		}
+		if len(pix) != len(test.pix) {
+			t.Fatalf("Wrong size of pixel data of %T : expected %v, got %v", test.im, len(test.pix), len(pix))
+		}
+		copy(pix, test.pix)
+		if test.im.ColorModel() != test.model {
+			t.Errorf("Wrong color model of %T", test.im)
+		}
+
+		uncompressed, err := test.im.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := uncompressed.(*image.RGBA64); !ok {
+			t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				expected := test.color(x, y)
+				if c := test.im.At(x, y); c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %T : expected %v, got %v", x, y, test.im, expected, c)
+				}
+				// Uncompressed pixels keep the 16 bits of every channel
+				if c := uncompressed.At(x, y); c != color.RGBA64Model.Convert(expected) {
+					t.Errorf("Wrong uncompressed pixel at [%v %v] of %T : expected %v, got %v", x, y, test.im, expected, c)
+				}
+			}
+		}
+	}
+}
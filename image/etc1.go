@@ -1,10 +1,10 @@
 package image
 
 import (
-	"fmt"
 	"image"
 	"image/color"
 
+	"github.com/hantempo/glu/enum"
 	glcolor "github.com/hantempo/glu/image/color"
 )
 
@@ -51,12 +51,6 @@ func clamp(v uint8, modifier int16) uint8 {
 	}
 }
 
-func calculateSizeETC1(width, height int) int {
-	blockSizeWidth := (width + blockWidth - 1) / blockWidth
-	blockSizeHeight := (height + blockWidth - 1) / blockWidth
-	return blockSizeWidth * blockSizeHeight * blockSize
-}
-
 type ETC1 struct {
 	Pix  []uint8
 	Rect image.Rectangle
@@ -71,91 +65,81 @@ func (p *ETC1) Bounds() image.Rectangle {
 }
 
 func (p *ETC1) At(x, y int) color.Color {
-	if !(image.Point{x, y}.In(p.Rect)) {
-		return glcolor.RGB{}
-	}
-
-	_, yBlockDim := p.BlockDimensions()
-
-	xBlock := x / blockWidth
-	yBlock := y / blockWidth
-
-	blockOffset := yBlock*yBlockDim + xBlock
-	byteOffset := blockOffset * blockSize
-
-	blockData := p.Pix[byteOffset : byteOffset+8]
-	diffBit := blockData[3]&0x02 == 0x01
-	flipBit := blockData[3]&0x01 == 0x01
+	return rgbBlockAt(p.Rect, p.Pix, blockSize, x, y, decodeBlockETC1)
+}
 
-	// pixel offset in this block
-	pixelOffsetX, pixelOffsetY := x%blockWidth, y%blockWidth
-	pixelOffst := uint(pixelOffsetX*blockWidth + pixelOffsetY)
-	var pixelIndex uint8
-	if pixelOffst >= 8 {
-		pixelIndex = ((blockData[5]>>pixelOffst)&0x01)<<1 + (blockData[7]>>pixelOffst)&0x01
-	} else {
-		pixelOffst -= 8
-		pixelIndex = ((blockData[4]>>pixelOffst)&0x01)<<1 + (blockData[6]>>pixelOffst)&0x01
-	}
-	fmt.Println(pixelIndex)
+// decodeBlockETC1 decodes the pixels of an ETC1 block into dst, row by row.
+func decodeBlockETC1(block []byte, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	decodeSubblocks(block, block[3]&0x02 != 0, true, dst)
+}
 
-	inFirstBlock := true
-	if flipBit {
-		inFirstBlock = pixelOffsetY >= 2
-	} else {
-		inFirstBlock = pixelOffsetX >= 2
-	}
+// decodeSubblocks decodes the pixels of a block in individual or differential
+// mode into dst, row by row. Without opaque, pixel indices 0 and 2 do not
+// modify the base color, as in the ETC2 blocks with punch-through alpha.
+func decodeSubblocks(block []byte, diffBit, opaque bool, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	flipBit := block[3]&0x01 != 0
 
-	var r, g, b, codeWordIndex uint8
-	if inFirstBlock {
+	// Base colors of both sub-blocks
+	var base [2][3]uint8
+	for c := 0; c < 3; c++ {
 		if diffBit {
-			r = extend5to8Bits((blockData[0] >> 3) & 0x1F)
-			g = extend5to8Bits((blockData[1] >> 3) & 0x1F)
-			b = extend5to8Bits((blockData[2] >> 3) & 0x1F)
+			v := block[c] >> 3
+			// The 3-bit delta of the second sub-block is signed
+			delta := int8(block[c]<<5) >> 5
+			base[0][c] = extend5to8Bits(v)
+			base[1][c] = extend5to8Bits(uint8(int8(v) + delta))
 		} else {
-			r = extend4to8Bits((blockData[0] >> 4) & 0x0F)
-			g = extend4to8Bits((blockData[1] >> 4) & 0x0F)
-			b = extend4to8Bits((blockData[2] >> 4) & 0x0F)
+			base[0][c] = extend4to8Bits(block[c] >> 4)
+			base[1][c] = extend4to8Bits(block[c])
 		}
-		codeWordIndex = (blockData[3] >> 5) & 0x07
-	} else {
-		if diffBit {
-			r = extend5to8Bits((blockData[0]>>3)&0x1F + blockData[0]&0x07)
-			b = extend5to8Bits((blockData[1]>>3)&0x1F + blockData[1]&0x07)
-			g = extend5to8Bits((blockData[2]>>3)&0x1F + blockData[2]&0x07)
-		} else {
-			r = extend4to8Bits(blockData[0] & 0x0F)
-			g = extend4to8Bits(blockData[1] & 0x0F)
-			b = extend4to8Bits(blockData[2] & 0x0F)
-		}
-		codeWordIndex = (blockData[3] >> 2) & 0x07
 	}
-	codeWord := codeWordTable[codeWordIndex]
-	modifier := codeWord[modifierTableIndex[pixelIndex]]
+	codeWords := [2][]int16{
+		codeWordTable[(block[3]>>5)&0x07],
+		codeWordTable[(block[3]>>2)&0x07],
+	}
 
-	return glcolor.RGB{
-		clamp(r, modifier),
-		clamp(g, modifier),
-		clamp(b, modifier),
+	// Pixel indices are stored column by column, the most significant bits
+	// in bytes 4 and 5, the least significant bits in bytes 6 and 7
+	msb := uint16(block[4])<<8 | uint16(block[5])
+	lsb := uint16(block[6])<<8 | uint16(block[7])
+	for y := 0; y < blockWidth; y++ {
+		for x := 0; x < blockWidth; x++ {
+			subblock := 0
+			if (flipBit && y >= 2) || (!flipBit && x >= 2) {
+				subblock = 1
+			}
+			bit := uint(x*blockWidth + y)
+			pixelIndex := (msb>>bit&0x01)<<1 | lsb>>bit&0x01
+			var modifier int16
+			if opaque || pixelIndex&0x01 != 0 {
+				modifier = codeWords[subblock][modifierTableIndex[pixelIndex]]
+			}
+			c := base[subblock]
+			dst[y*blockWidth+x] = glcolor.RGB{
+				clamp(c[0], modifier),
+				clamp(c[1], modifier),
+				clamp(c[2], modifier),
+			}
+		}
 	}
 }
 
 func (p *ETC1) BlockDimensions() (x, y int) {
-	x = (p.Rect.Dx() + blockWidth - 1) / blockWidth
-	y = (p.Rect.Dy() + blockWidth - 1) / blockWidth
-	return
+	return blockDimensions(p.Rect)
 }
 
+// Compress encodes im into p with the medium quality.
 func (p *ETC1) Compress(im image.Image) error {
-	return nil
+	return p.CompressQuality(im, ETC1Medium)
 }
 
+// Uncompress decodes every block of p, and returns the pixels in a *RGB.
 func (p *ETC1) Uncompress() (image.Image, error) {
-	return p, nil
+	return uncompressRGB("ETC1", p.Rect, p.Pix, blockSize, decodeBlockETC1)
 }
 
 func NewETC1(r image.Rectangle) *ETC1 {
 	w, h := r.Dx(), r.Dy()
-	buf := make([]byte, calculateSizeETC1(w, h))
+	buf := make([]byte, calculateSize(enum.GL_ETC1_RGB8_OES, w, h))
 	return &ETC1{buf, r}
 }
@@ -0,0 +1,292 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// ETC1Quality selects how thoroughly the ETC1 encoder searches
+// the base colors of every block.
+type ETC1Quality int
+
+const (
+	// ETC1Fast only tries the average color of every sub-block.
+	ETC1Fast ETC1Quality = iota
+	// ETC1Medium also tries the base colors one step lighter and darker
+	// than the average color.
+	ETC1Medium
+	// ETC1Slow tries every base color one step away from the average color
+	// in any component.
+	ETC1Slow
+)
+
+// baseOffsets returns the offsets from the quantized average color
+// of the base colors to try.
+func (q ETC1Quality) baseOffsets() [][3]int {
+	switch q {
+	case ETC1Fast:
+		return [][3]int{{0, 0, 0}}
+	case ETC1Medium:
+		return [][3]int{{0, 0, 0}, {-1, -1, -1}, {1, 1, 1}}
+	}
+	var offsets [][3]int
+	for r := -1; r <= 1; r++ {
+		for g := -1; g <= 1; g++ {
+			for b := -1; b <= 1; b++ {
+				offsets = append(offsets, [3]int{r, g, b})
+			}
+		}
+	}
+	return offsets
+}
+
+// blockPixel is a pixel of the source image inside a block, with its
+// position in the block.
+type blockPixel struct {
+	x, y int
+	c    [3]int
+}
+
+// subblockEncoding is a base color of a sub-block with its codeword table
+// and the modifier of every pixel.
+type subblockEncoding struct {
+	// base holds the quantized components of 4 or 5 bits.
+	base  [3]uint8
+	table uint8
+	// modifiers holds the index in the codeword table of every pixel,
+	// indexed like the pixels of the sub-block.
+	modifiers [8]uint8
+	err       int
+}
+
+// quantize returns the value of v on bits bits which is the nearest to v.
+func quantize(v, bits int) int {
+	max := 1<<uint(bits) - 1
+	return (v*max + 127) / 255
+}
+
+// extend returns the 8-bit value of the quantized component v of bits bits.
+func extend(v uint8, bits int) uint8 {
+	if bits == 4 {
+		return extend4to8Bits(v)
+	}
+	return extend5to8Bits(v)
+}
+
+// encodeSubblock returns the codeword table and modifiers which encode
+// the pixels with the least error from the given base color.
+func encodeSubblock(pixels []blockPixel, base [3]uint8, bits int) subblockEncoding {
+	best := subblockEncoding{base: base, err: -1}
+	var color [3]int
+	for i := range color {
+		color[i] = int(extend(base[i], bits))
+	}
+	for table, codeWord := range codeWordTable {
+		// Colors of the sub-block with every modifier of the table
+		var palette [4][3]int
+		for m, modifier := range codeWord {
+			for c := range color {
+				palette[m][c] = int(clamp(uint8(color[c]), modifier))
+			}
+		}
+
+		e := subblockEncoding{base: base, table: uint8(table)}
+		for i, p := range pixels {
+			bestModifier, bestErr := 0, -1
+			for m := range palette {
+				dr := palette[m][0] - p.c[0]
+				dg := palette[m][1] - p.c[1]
+				db := palette[m][2] - p.c[2]
+				err := dr*dr + dg*dg + db*db
+				if bestErr < 0 || err < bestErr {
+					bestModifier, bestErr = m, err
+				}
+			}
+			e.modifiers[i] = uint8(bestModifier)
+			e.err += bestErr
+			if best.err >= 0 && e.err >= best.err {
+				break
+			}
+		}
+		if best.err < 0 || e.err < best.err {
+			best = e
+		}
+	}
+	return best
+}
+
+// subblockEncodings returns the encodings of the pixels with base colors
+// at the given offsets from their average color, from the one with
+// the least error.
+func subblockEncodings(pixels []blockPixel, bits int, offsets [][3]int) []subblockEncoding {
+	var center [3]int
+	if len(pixels) > 0 {
+		for _, p := range pixels {
+			for c := range center {
+				center[c] += p.c[c]
+			}
+		}
+		for c := range center {
+			center[c] = quantize((center[c]+len(pixels)/2)/len(pixels), bits)
+		}
+	}
+
+	max := 1<<uint(bits) - 1
+	var encodings []subblockEncoding
+	for _, offset := range offsets {
+		var base [3]uint8
+		valid := true
+		for c := range base {
+			v := center[c] + offset[c]
+			if v < 0 || v > max {
+				valid = false
+				break
+			}
+			base[c] = uint8(v)
+		}
+		if valid {
+			encodings = append(encodings, encodeSubblock(pixels, base, bits))
+		}
+	}
+	sort.SliceStable(encodings, func(i, j int) bool { return encodings[i].err < encodings[j].err })
+	return encodings
+}
+
+// differentialPair returns the pair of 5-bit encodings of both sub-blocks
+// with the least error whose base colors differ by -4 to 3 in every component.
+func differentialPair(e1, e2 []subblockEncoding) (subblockEncoding, subblockEncoding, bool) {
+	var best1, best2 subblockEncoding
+	found := false
+	for _, c1 := range e1 {
+		// Encodings are sorted, no better pair can follow
+		if found && c1.err+e2[0].err >= best1.err+best2.err {
+			break
+		}
+		for _, c2 := range e2 {
+			if found && c1.err+c2.err >= best1.err+best2.err {
+				break
+			}
+			fits := true
+			for c := range c1.base {
+				d := int(c2.base[c]) - int(c1.base[c])
+				if d < -4 || d > 3 {
+					fits = false
+					break
+				}
+			}
+			if fits {
+				best1, best2, found = c1, c2, true
+				break
+			}
+		}
+	}
+	return best1, best2, found
+}
+
+// pixelIndexValue maps the index in the codeword table to the pixel index
+// value stored in a block, the inverse of modifierTableIndex.
+var pixelIndexValue = []uint8{3, 2, 0, 1}
+
+// writeBlock stores both sub-block encodings into dst.
+func writeBlock(dst []byte, diff, flip bool, e1, e2 subblockEncoding, sub1, sub2 []blockPixel) {
+	for c := 0; c < 3; c++ {
+		if diff {
+			d := int8(e2.base[c]) - int8(e1.base[c])
+			dst[c] = e1.base[c]<<3 | uint8(d)&0x07
+		} else {
+			dst[c] = e1.base[c]<<4 | e2.base[c]
+		}
+	}
+	dst[3] = e1.table<<5 | e2.table<<2
+	if diff {
+		dst[3] |= 0x02
+	}
+	if flip {
+		dst[3] |= 0x01
+	}
+
+	var msb, lsb uint16
+	for _, s := range []struct {
+		pixels []blockPixel
+		e      subblockEncoding
+	}{{sub1, e1}, {sub2, e2}} {
+		for i, p := range s.pixels {
+			v := pixelIndexValue[s.e.modifiers[i]]
+			bit := uint(p.x*blockWidth + p.y)
+			msb |= uint16(v>>1) << bit
+			lsb |= uint16(v&1) << bit
+		}
+	}
+	dst[4], dst[5] = uint8(msb>>8), uint8(msb)
+	dst[6], dst[7] = uint8(lsb>>8), uint8(lsb)
+}
+
+// compressBlock encodes the pixels of one block into dst, trying both
+// orientations of the sub-blocks in individual and differential modes.
+func compressBlock(dst []byte, pixels []blockPixel, quality ETC1Quality) {
+	offsets := quality.baseOffsets()
+	bestErr := -1
+	for _, flip := range []bool{false, true} {
+		var sub1, sub2 []blockPixel
+		for _, p := range pixels {
+			if (!flip && p.x < 2) || (flip && p.y < 2) {
+				sub1 = append(sub1, p)
+			} else {
+				sub2 = append(sub2, p)
+			}
+		}
+
+		// Individual mode, 4 bits per component
+		i1 := subblockEncodings(sub1, 4, offsets)[0]
+		i2 := subblockEncodings(sub2, 4, offsets)[0]
+		if bestErr < 0 || i1.err+i2.err < bestErr {
+			bestErr = i1.err + i2.err
+			writeBlock(dst, false, flip, i1, i2, sub1, sub2)
+		}
+
+		// Differential mode, 5 bits per component
+		e1, e2 := subblockEncodings(sub1, 5, offsets), subblockEncodings(sub2, 5, offsets)
+		if len(sub2) == 0 {
+			// The second sub-block is outside of the image, any base color
+			// close to the first one does
+			e2 = []subblockEncoding{{base: e1[0].base}}
+		}
+		d1, d2, ok := differentialPair(e1, e2)
+		if ok && d1.err+d2.err < bestErr {
+			bestErr = d1.err + d2.err
+			writeBlock(dst, true, flip, d1, d2, sub1, sub2)
+		}
+	}
+}
+
+// CompressQuality encodes im into p with the given quality. Both images must
+// have the same size, which does not need to be a multiple of 4.
+func (p *ETC1) CompressQuality(im image.Image, quality ETC1Quality) error {
+	b := im.Bounds()
+	if b.Dx() != p.Rect.Dx() || b.Dy() != p.Rect.Dy() {
+		return fmt.Errorf("ETC1 compress: wrong image size [%vx%v != %vx%v]", b.Dx(), b.Dy(), p.Rect.Dx(), p.Rect.Dy())
+	}
+
+	xBlocks, yBlocks := p.BlockDimensions()
+	pixels := make([]blockPixel, 0, blockWidth*blockWidth)
+	for yBlock := 0; yBlock < yBlocks; yBlock++ {
+		for xBlock := 0; xBlock < xBlocks; xBlock++ {
+			// Pixels of edge blocks outside of the image are left out
+			pixels = pixels[:0]
+			for y := 0; y < blockWidth; y++ {
+				for x := 0; x < blockWidth; x++ {
+					px, py := xBlock*blockWidth+x, yBlock*blockWidth+y
+					if px >= b.Dx() || py >= b.Dy() {
+						continue
+					}
+					r, g, bl, _ := im.At(b.Min.X+px, b.Min.Y+py).RGBA()
+					pixels = append(pixels, blockPixel{x, y, [3]int{int(r >> 8), int(g >> 8), int(bl >> 8)}})
+				}
+			}
+			offset := (yBlock*xBlocks + xBlock) * blockSize
+			compressBlock(p.Pix[offset:offset+blockSize], pixels, quality)
+		}
+	}
+	return nil
+}
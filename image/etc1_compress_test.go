@@ -0,0 +1,139 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// compressionError returns the sum of squared errors between im and
+// its ETC1 encoding.
+func compressionError(t *testing.T, im image.Image, p *ETC1) int {
+	uncompressed, err := p.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := im.Bounds()
+	total := 0
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := uncompressed.At(p.Rect.Min.X+x, p.Rect.Min.Y+y).(glcolor.RGB)
+			r, g, bl, _ := im.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			for i, v := range []uint32{r, g, bl} {
+				d := int([]uint8{c.R, c.G, c.B}[i]) - int(v>>8)
+				total += d * d
+			}
+		}
+	}
+	return total
+}
+
+func TestCompressETC1Solid(t *testing.T) {
+	// 10x10 has partial blocks on the right and bottom edges
+	r := image.Rect(0, 0, 10, 10)
+	for _, c := range []color.Color{color.Black, color.White} {
+		im := image.NewRGBA(r)
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 10; x++ {
+				im.Set(x, y, c)
+			}
+		}
+		for _, quality := range []ETC1Quality{ETC1Fast, ETC1Medium, ETC1Slow} {
+			p := NewETC1(r)
+			if err := p.CompressQuality(im, quality); err != nil {
+				t.Fatal(err)
+			}
+			if err := compressionError(t, im, p); err != 0 {
+				t.Errorf("Wrong encoding of %v with quality %v : error %v", c, quality, err)
+			}
+		}
+	}
+}
+
+func TestCompressETC1Modes(t *testing.T) {
+	tests := []struct {
+		name       string
+		color      func(x, y int) color.RGBA
+		diff, flip bool
+	}{
+		// Grays too far apart for the differential mode,
+		// which no single sub-block encodes exactly
+		{"left and right", func(x, y int) color.RGBA {
+			if x < 2 {
+				return color.RGBA{0x15, 0x15, 0x15, 0xFF}
+			}
+			return color.RGBA{0x79, 0x79, 0x79, 0xFF}
+		}, false, false},
+		{"top and bottom", func(x, y int) color.RGBA {
+			if y < 2 {
+				return color.RGBA{0x79, 0x79, 0x79, 0xFF}
+			}
+			return color.RGBA{0x15, 0x15, 0x15, 0xFF}
+		}, false, true},
+		// Close grays which only the differential mode encodes exactly
+		{"close top and bottom", func(x, y int) color.RGBA {
+			if y < 2 {
+				return color.RGBA{0x3E, 0x3E, 0x3E, 0xFF}
+			}
+			return color.RGBA{0x41, 0x41, 0x41, 0xFF}
+		}, true, true},
+	}
+	for _, test := range tests {
+		im := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				im.Set(x, y, test.color(x, y))
+			}
+		}
+		p := NewETC1(im.Bounds())
+		if err := p.Compress(im); err != nil {
+			t.Fatal(err)
+		}
+		if diff, flip := p.Pix[3]&0x02 != 0, p.Pix[3]&0x01 != 0; diff != test.diff || flip != test.flip {
+			t.Errorf("Wrong mode of %s : expected diff=%v flip=%v, got diff=%v flip=%v", test.name, test.diff, test.flip, diff, flip)
+		}
+		if err := compressionError(t, im, p); err != 0 {
+			t.Errorf("Wrong encoding of %s : error %v", test.name, err)
+		}
+	}
+}
+
+func TestCompressETC1Quality(t *testing.T) {
+	r := image.Rect(0, 0, 13, 7)
+	im := image.NewRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			l := x*12 + y*9
+			im.Set(x, y, color.RGBA{uint8(l + 20), uint8(l + 40), uint8(l/2 + 60), 0xFF})
+		}
+	}
+
+	previous := -1
+	for _, quality := range []ETC1Quality{ETC1Fast, ETC1Medium, ETC1Slow} {
+		p := NewETC1(r)
+		if err := p.CompressQuality(im, quality); err != nil {
+			t.Fatal(err)
+		}
+		err := compressionError(t, im, p)
+		// Mean squared error per component
+		mse := float64(err) / float64(r.Dx()*r.Dy()*3)
+		if mse > 25 {
+			t.Errorf("Poor encoding with quality %v : mean squared error %v", quality, mse)
+		}
+		if previous >= 0 && err > previous {
+			t.Errorf("Worse encoding with quality %v than the previous one : %v > %v", quality, err, previous)
+		}
+		previous = err
+	}
+}
+
+func TestCompressETC1Error(t *testing.T) {
+	p := NewETC1(image.Rect(0, 0, 8, 8))
+	err := p.Compress(image.NewRGBA(image.Rect(0, 0, 4, 8)))
+	if err == nil || !strings.Contains(err.Error(), "ETC1 compress: wrong image size") {
+		t.Errorf("Expected pattern of error message (ETC1 compress: wrong image size), got (%v)", err)
+	}
+}
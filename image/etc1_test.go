@@ -0,0 +1,103 @@
+package image
+
+import (
+	"image"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Known ETC1 blocks with their pixels, row by row
+var etc1GoldenBlocks = []struct {
+	name   string
+	block  []byte
+	pixels [4][4]glcolor.RGB
+}{
+	{
+		// Differential mode, vertical split, no delta, table 0
+		"differential",
+		[]byte{0xE8, 0xA0, 0x18, 0x03, 0x00, 0x0F, 0x00, 0x00},
+		[4][4]glcolor.RGB{
+			{{237, 163, 22}, {241, 167, 26}, {241, 167, 26}, {241, 167, 26}},
+			{{237, 163, 22}, {241, 167, 26}, {241, 167, 26}, {241, 167, 26}},
+			{{237, 163, 22}, {241, 167, 26}, {241, 167, 26}, {241, 167, 26}},
+			{{237, 163, 22}, {241, 167, 26}, {241, 167, 26}, {241, 167, 26}},
+		},
+	},
+	{
+		// Individual mode, side by side sub-blocks with tables 1 and 7
+		"individual",
+		[]byte{0xF0, 0x0F, 0x80, 0x3C, 0x00, 0xF0, 0x0F, 0x0F},
+		[4][4]glcolor.RGB{
+			{{255, 17, 153}, {250, 0, 131}, {183, 255, 183}, {47, 255, 47}},
+			{{255, 17, 153}, {250, 0, 131}, {183, 255, 183}, {47, 255, 47}},
+			{{255, 17, 153}, {250, 0, 131}, {183, 255, 183}, {47, 255, 47}},
+			{{255, 17, 153}, {250, 0, 131}, {183, 255, 183}, {47, 255, 47}},
+		},
+	},
+	{
+		// Differential mode, stacked sub-blocks with tables 2 and 4,
+		// deltas of -3, +3 and -4
+		"flipped differential",
+		[]byte{0x55, 0xA3, 0xFC, 0x53, 0xFF, 0xFF, 0x55, 0x55},
+		[4][4]glcolor.RGB{
+			{{53, 136, 226}, {53, 136, 226}, {53, 136, 226}, {53, 136, 226}},
+			{{73, 156, 246}, {73, 156, 246}, {73, 156, 246}, {73, 156, 246}},
+			{{0, 129, 162}, {0, 129, 162}, {0, 129, 162}, {0, 129, 162}},
+			{{39, 171, 204}, {39, 171, 204}, {39, 171, 204}, {39, 171, 204}},
+		},
+	},
+}
+
+func TestDecodeETC1(t *testing.T) {
+	for _, test := range etc1GoldenBlocks {
+		m := NewETC1(image.Rect(0, 0, 4, 4))
+		copy(m.Pix, test.block)
+		uncompressed, err := m.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := uncompressed.(*RGB); !ok {
+			t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				expected := test.pixels[y][x]
+				if c := m.At(x, y); c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+				if c := uncompressed.At(x, y); c != expected {
+					t.Errorf("Wrong uncompressed pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeETC1Layout(t *testing.T) {
+	// Two rows of three blocks, cropped to 10x6 and moved away from the origin
+	r := image.Rect(3, 5, 13, 11)
+	m := NewETC1(r)
+	for i := 0; i < 6; i++ {
+		copy(m.Pix[i*blockSize:], etc1GoldenBlocks[i%3].block)
+	}
+	uncompressed, err := m.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uncompressed.Bounds() != r {
+		t.Errorf("Wrong bounds of uncompressed image : expected %v, got %v", r, uncompressed.Bounds())
+	}
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			block := (y/4*3 + x/4) % 3
+			expected := etc1GoldenBlocks[block].pixels[y%4][x%4]
+			if c := m.At(r.Min.X+x, r.Min.Y+y); c != expected {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+			if c := uncompressed.At(r.Min.X+x, r.Min.Y+y); c != expected {
+				t.Errorf("Wrong uncompressed pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+		}
+	}
+}
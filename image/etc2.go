@@ -0,0 +1,186 @@
+package image
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Distances between the paint colors of the T and H modes
+var distanceTable = []int16{
+	3, 6, 11, 16, 23, 32, 41, 64,
+}
+
+// overflows tells whether the 5-bit base color plus the 3-bit signed delta
+// stored in v falls outside of 0 to 31. It selects the modes added by ETC2.
+func overflows(v uint8) bool {
+	sum := int8(v>>3) + int8(v<<5)>>5
+	return sum < 0 || sum > 31
+}
+
+// extend6to8Bits extends the 6-bit value v to 8 bits.
+func extend6to8Bits(v uint8) uint8 {
+	v = v & 0x3F
+	return (v << 2) | (v >> 4)
+}
+
+// extend7to8Bits extends the 7-bit value v to 8 bits.
+func extend7to8Bits(v uint8) uint8 {
+	v = v & 0x7F
+	return (v << 1) | (v >> 6)
+}
+
+// decodeBlockETC2 decodes the pixels of an ETC2 RGB block into dst, row by row.
+func decodeBlockETC2(block []byte, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	diffBit := block[3]&0x02 != 0
+	if diffBit {
+		if overflows(block[0]) {
+			decodeTMode(block, dst)
+			return
+		}
+		if overflows(block[1]) {
+			decodeHMode(block, dst)
+			return
+		}
+		if overflows(block[2]) {
+			decodePlanarMode(block, dst)
+			return
+		}
+	}
+	// Individual and differential modes are the ones of ETC1
+	decodeBlockETC1(block, dst)
+}
+
+// decodePaintColors sets every pixel of dst to the paint color
+// selected by its index.
+func decodePaintColors(block []byte, paint *[4]glcolor.RGB, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	for y := 0; y < blockWidth; y++ {
+		for x := 0; x < blockWidth; x++ {
+			dst[y*blockWidth+x] = paint[pixelIndex(block, x, y)]
+		}
+	}
+}
+
+// pixelIndex returns the 2-bit index of the pixel at x, y of an ETC block.
+func pixelIndex(block []byte, x, y int) uint16 {
+	msb := uint16(block[4])<<8 | uint16(block[5])
+	lsb := uint16(block[6])<<8 | uint16(block[7])
+	bit := uint(x*blockWidth + y)
+	return (msb>>bit&0x01)<<1 | lsb>>bit&0x01
+}
+
+// shift returns c with d added to every component.
+func shift(c glcolor.RGB, d int16) glcolor.RGB {
+	return glcolor.RGB{clamp(c.R, d), clamp(c.G, d), clamp(c.B, d)}
+}
+
+// decodeTMode decodes a block whose red component overflows.
+func decodeTMode(block []byte, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	c1 := glcolor.RGB{
+		extend4to8Bits((block[0]>>3)&0x03<<2 | block[0]&0x03),
+		extend4to8Bits(block[1] >> 4),
+		extend4to8Bits(block[1]),
+	}
+	c2 := glcolor.RGB{
+		extend4to8Bits(block[2] >> 4),
+		extend4to8Bits(block[2]),
+		extend4to8Bits(block[3] >> 4),
+	}
+	d := distanceTable[(block[3]>>2)&0x03<<1|block[3]&0x01]
+	paint := [4]glcolor.RGB{c1, shift(c2, d), c2, shift(c2, -d)}
+	decodePaintColors(block, &paint, dst)
+}
+
+// decodeHMode decodes a block whose green component overflows.
+func decodeHMode(block []byte, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	r1 := (block[0] >> 3) & 0x0F
+	g1 := (block[0]&0x07)<<1 | (block[1]>>4)&0x01
+	b1 := block[1]&0x08 | (block[1]&0x03)<<1 | block[2]>>7
+	r2 := (block[2] >> 3) & 0x0F
+	g2 := (block[2]&0x07)<<1 | block[3]>>7
+	b2 := (block[3] >> 3) & 0x0F
+
+	// The lowest bit of the distance index is the order of the base colors
+	index := (block[3]>>2)&0x01<<2 | (block[3]&0x01)<<1
+	if uint(r1)<<8|uint(g1)<<4|uint(b1) >= uint(r2)<<8|uint(g2)<<4|uint(b2) {
+		index |= 0x01
+	}
+	d := distanceTable[index]
+
+	c1 := glcolor.RGB{extend4to8Bits(r1), extend4to8Bits(g1), extend4to8Bits(b1)}
+	c2 := glcolor.RGB{extend4to8Bits(r2), extend4to8Bits(g2), extend4to8Bits(b2)}
+	paint := [4]glcolor.RGB{shift(c1, d), shift(c1, -d), shift(c2, d), shift(c2, -d)}
+	decodePaintColors(block, &paint, dst)
+}
+
+// decodePlanarMode decodes a block whose blue component overflows, which
+// interpolates the colors at its origin, horizontal and vertical corners.
+func decodePlanarMode(block []byte, dst *[blockWidth * blockWidth]glcolor.RGB) {
+	o := [3]int{
+		int(extend6to8Bits(block[0] >> 1)),
+		int(extend7to8Bits((block[0]&0x01)<<6 | (block[1]>>1)&0x3F)),
+		int(extend6to8Bits((block[1]&0x01)<<5 | (block[2]>>3)&0x03<<3 | (block[2]&0x03)<<1 | block[3]>>7)),
+	}
+	h := [3]int{
+		int(extend6to8Bits((block[3]>>2)&0x1F<<1 | block[3]&0x01)),
+		int(extend7to8Bits(block[4] >> 1)),
+		int(extend6to8Bits((block[4]&0x01)<<5 | block[5]>>3)),
+	}
+	v := [3]int{
+		int(extend6to8Bits((block[5]&0x07)<<3 | block[6]>>5)),
+		int(extend7to8Bits((block[6]&0x1F)<<2 | block[7]>>6)),
+		int(extend6to8Bits(block[7])),
+	}
+	for y := 0; y < blockWidth; y++ {
+		for x := 0; x < blockWidth; x++ {
+			var c [3]uint8
+			for i := range c {
+				c[i] = clamp(0, int16((x*(h[i]-o[i])+y*(v[i]-o[i])+4*o[i]+2)>>2))
+			}
+			dst[y*blockWidth+x] = glcolor.RGB{c[0], c[1], c[2]}
+		}
+	}
+}
+
+// ETC2RGB8 is an in-memory image of blocks in the ETC2 RGB8 format,
+// a superset of ETC1 with the T, H and planar modes.
+type ETC2RGB8 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *ETC2RGB8) ColorModel() color.Model {
+	return glcolor.RGBModel
+}
+
+func (p *ETC2RGB8) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *ETC2RGB8) At(x, y int) color.Color {
+	return rgbBlockAt(p.Rect, p.Pix, blockSize, x, y, decodeBlockETC2)
+}
+
+func (p *ETC2RGB8) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress encodes im into p with the medium quality. Only the modes of ETC1
+// are used, which every ETC2 decoder supports.
+func (p *ETC2RGB8) Compress(im image.Image) error {
+	etc1 := ETC1{p.Pix, p.Rect}
+	return etc1.CompressQuality(im, ETC1Medium)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *RGB.
+func (p *ETC2RGB8) Uncompress() (image.Image, error) {
+	return uncompressRGB("ETC2", p.Rect, p.Pix, blockSize, decodeBlockETC2)
+}
+
+func NewETC2RGB8(r image.Rectangle) *ETC2RGB8 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGB8_ETC2, w, h))
+	return &ETC2RGB8{buf, r}
+}
@@ -0,0 +1,134 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// How many bytes in a block of ETC2 RGBA8, with the EAC alpha block first
+const blockSizeRGBA8 = 16
+
+// decodeBlockETC2A1 decodes the pixels of an ETC2 block with punch-through
+// alpha into dst, row by row.
+func decodeBlockETC2A1(block []byte, dst *[blockWidth * blockWidth]color.NRGBA) {
+	// The bit of the differential mode tells whether the block is opaque,
+	// there is no individual mode
+	opaque := block[3]&0x02 != 0
+	var pixels [blockWidth * blockWidth]glcolor.RGB
+	switch {
+	case overflows(block[0]):
+		decodeTMode(block, &pixels)
+	case overflows(block[1]):
+		decodeHMode(block, &pixels)
+	case overflows(block[2]):
+		// Planar blocks are always opaque
+		decodePlanarMode(block, &pixels)
+		opaque = true
+	default:
+		decodeSubblocks(block, true, opaque, &pixels)
+	}
+
+	for y := 0; y < blockWidth; y++ {
+		for x := 0; x < blockWidth; x++ {
+			i := y*blockWidth + x
+			if !opaque && pixelIndex(block, x, y) == 2 {
+				dst[i] = color.NRGBA{}
+			} else {
+				dst[i] = color.NRGBA{pixels[i].R, pixels[i].G, pixels[i].B, 0xFF}
+			}
+		}
+	}
+}
+
+// decodeBlockETC2RGBA8 decodes the pixels of an ETC2 RGBA8 block into dst, row by row.
+func decodeBlockETC2RGBA8(block []byte, dst *[blockWidth * blockWidth]color.NRGBA) {
+	var alpha [blockWidth * blockWidth]uint8
+	decodeAlphaEAC(block[:blockSize], &alpha)
+	var pixels [blockWidth * blockWidth]glcolor.RGB
+	decodeBlockETC2(block[blockSize:], &pixels)
+	for i, c := range pixels {
+		dst[i] = color.NRGBA{c.R, c.G, c.B, alpha[i]}
+	}
+}
+
+// ETC2RGB8A1 is an in-memory image of blocks in the ETC2 RGB8 format
+// with punch-through alpha, whose pixels are either opaque or transparent black.
+type ETC2RGB8A1 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *ETC2RGB8A1) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *ETC2RGB8A1) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *ETC2RGB8A1) At(x, y int) color.Color {
+	return nrgbaBlockAt(p.Rect, p.Pix, blockSize, x, y, decodeBlockETC2A1)
+}
+
+func (p *ETC2RGB8A1) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *ETC2RGB8A1) Compress(im image.Image) error {
+	return fmt.Errorf("ETC2 RGB8A1 compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.NRGBA.
+func (p *ETC2RGB8A1) Uncompress() (image.Image, error) {
+	return uncompressNRGBA("ETC2 RGB8A1", p.Rect, p.Pix, blockSize, decodeBlockETC2A1)
+}
+
+func NewETC2RGB8A1(r image.Rectangle) *ETC2RGB8A1 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, w, h))
+	return &ETC2RGB8A1{buf, r}
+}
+
+// ETC2RGBA8 is an in-memory image of blocks in the ETC2 RGBA8 format,
+// made of an EAC alpha block followed by an ETC2 RGB8 block.
+type ETC2RGBA8 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *ETC2RGBA8) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *ETC2RGBA8) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *ETC2RGBA8) At(x, y int) color.Color {
+	return nrgbaBlockAt(p.Rect, p.Pix, blockSizeRGBA8, x, y, decodeBlockETC2RGBA8)
+}
+
+func (p *ETC2RGBA8) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Compress is not supported yet.
+func (p *ETC2RGBA8) Compress(im image.Image) error {
+	return fmt.Errorf("ETC2 RGBA8 compress: not supported")
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.NRGBA.
+func (p *ETC2RGBA8) Uncompress() (image.Image, error) {
+	return uncompressNRGBA("ETC2 RGBA8", p.Rect, p.Pix, blockSizeRGBA8, decodeBlockETC2RGBA8)
+}
+
+func NewETC2RGBA8(r image.Rectangle) *ETC2RGBA8 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RGBA8_ETC2_EAC, w, h))
+	return &ETC2RGBA8{buf, r}
+}
@@ -0,0 +1,150 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// opaque returns the pixels of a golden block with an opaque alpha.
+func opaque(pixels [4][4]glcolor.RGB) (nrgba [4][4]color.NRGBA) {
+	for y := range pixels {
+		for x, c := range pixels[y] {
+			nrgba[y][x] = color.NRGBA{c.R, c.G, c.B, 0xFF}
+		}
+	}
+	return
+}
+
+func TestDecodeETC2RGB8A1(t *testing.T) {
+	transparent := color.NRGBA{}
+	tests := []struct {
+		name   string
+		block  []byte
+		pixels [4][4]color.NRGBA
+	}{
+		{
+			"opaque differential",
+			[]byte{0xE8, 0xA0, 0x18, 0x03, 0x00, 0x0F, 0x00, 0x00},
+			opaque(etc1GoldenBlocks[0].pixels),
+		},
+		{
+			// Pixel index 2 in the first column is transparent,
+			// pixel index 0 in the others keeps the base color
+			"transparent differential",
+			[]byte{0xE8, 0xA0, 0x18, 0x01, 0x00, 0x0F, 0x00, 0x00},
+			[4][4]color.NRGBA{
+				{transparent, {239, 165, 24, 255}, {239, 165, 24, 255}, {239, 165, 24, 255}},
+				{transparent, {239, 165, 24, 255}, {239, 165, 24, 255}, {239, 165, 24, 255}},
+				{transparent, {239, 165, 24, 255}, {239, 165, 24, 255}, {239, 165, 24, 255}},
+				{transparent, {239, 165, 24, 255}, {239, 165, 24, 255}, {239, 165, 24, 255}},
+			},
+		},
+		{
+			"opaque T mode",
+			etc2GoldenBlocks[0].block,
+			opaque(etc2GoldenBlocks[0].pixels),
+		},
+		{
+			// Pixel index 2 in the third column is transparent
+			"transparent T mode",
+			[]byte{0x1C, 0x48, 0x26, 0xA5, 0xFF, 0x00, 0xF0, 0xF0},
+			[4][4]color.NRGBA{
+				{{204, 68, 136, 255}, {50, 118, 186, 255}, transparent, {18, 86, 154, 255}},
+				{{204, 68, 136, 255}, {50, 118, 186, 255}, transparent, {18, 86, 154, 255}},
+				{{204, 68, 136, 255}, {50, 118, 186, 255}, transparent, {18, 86, 154, 255}},
+				{{204, 68, 136, 255}, {50, 118, 186, 255}, transparent, {18, 86, 154, 255}},
+			},
+		},
+		{
+			// Planar blocks ignore the opaque bit
+			"planar mode",
+			[]byte{0x40, 0x40, 0x04, 0xE0, 0x41, 0x02, 0x10, 0x00},
+			opaque(etc2GoldenBlocks[2].pixels),
+		},
+		{
+			"planar mode with bit 55",
+			[]byte{0x40, 0xC0, 0x04, 0xE0, 0x41, 0x02, 0x10, 0x00},
+			opaque(etc2GoldenBlocks[3].pixels),
+		},
+	}
+	for _, test := range tests {
+		m := NewETC2RGB8A1(image.Rect(0, 0, 4, 4))
+		copy(m.Pix, test.block)
+		uncompressed, err := m.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				expected := test.pixels[y][x]
+				if c := m.At(x, y); c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+				if c := uncompressed.At(x, y); c != expected {
+					t.Errorf("Wrong uncompressed pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeETC2RGBA8(t *testing.T) {
+	// Base 128, multiplier 2, table 13, pixel indices 0 to 7 in every
+	// two columns, followed by the H mode block
+	block := []byte{0x80, 0x2D, 0x05, 0x39, 0x77, 0x05, 0x39, 0x77}
+	block = append(block, etc2GoldenBlocks[1].block...)
+	alpha := [4][4]uint8{
+		{126, 128, 126, 128},
+		{124, 130, 124, 130},
+		{122, 132, 122, 132},
+		{108, 146, 108, 146},
+	}
+
+	// Two blocks side by side, cropped to 7x3
+	r := image.Rect(1, 2, 8, 5)
+	m := NewETC2RGBA8(r)
+	if len(m.Pix) != 32 {
+		t.Fatalf("Wrong size of pixel data : expected 32, got %v", len(m.Pix))
+	}
+	copy(m.Pix, block)
+	copy(m.Pix[16:], block)
+	uncompressed, err := m.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			c := etc2GoldenBlocks[1].pixels[y][x%4]
+			expected := color.NRGBA{c.R, c.G, c.B, alpha[y][x%4]}
+			if c := m.At(r.Min.X+x, r.Min.Y+y); c != expected {
+				t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+			if c := uncompressed.At(r.Min.X+x, r.Min.Y+y); c != expected {
+				t.Errorf("Wrong uncompressed pixel at [%v %v] : expected %v, got %v", x, y, expected, c)
+			}
+		}
+	}
+}
+
+func TestDecodeAlphaEACClamp(t *testing.T) {
+	// Base 250 and 5, multiplier 15, table 0, every pixel index 7 then 3
+	tests := []struct {
+		block    []byte
+		expected uint8
+	}{
+		{[]byte{0xFA, 0xF0, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, 255},
+		{[]byte{0x05, 0xF0, 0x6D, 0xB6, 0xDB, 0x6D, 0xB6, 0xDB}, 0},
+	}
+	for _, test := range tests {
+		var alpha [blockWidth * blockWidth]uint8
+		decodeAlphaEAC(test.block, &alpha)
+		for i, a := range alpha {
+			if a != test.expected {
+				t.Errorf("Wrong alpha of pixel %v in block %x : expected %v, got %v", i, test.block, test.expected, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// Known ETC2 blocks of the modes missing from ETC1, with their pixels, row by row
+var etc2GoldenBlocks = []struct {
+	name   string
+	block  []byte
+	pixels [4][4]glcolor.RGB
+}{
+	{
+		// Red overflows, distance 16, paint colors 0 to 3 from left to right
+		"T mode",
+		[]byte{0x1C, 0x48, 0x26, 0xA7, 0xFF, 0x00, 0xF0, 0xF0},
+		[4][4]glcolor.RGB{
+			{{204, 68, 136}, {50, 118, 186}, {34, 102, 170}, {18, 86, 154}},
+			{{204, 68, 136}, {50, 118, 186}, {34, 102, 170}, {18, 86, 154}},
+			{{204, 68, 136}, {50, 118, 186}, {34, 102, 170}, {18, 86, 154}},
+			{{204, 68, 136}, {50, 118, 186}, {34, 102, 170}, {18, 86, 154}},
+		},
+	},
+	{
+		// Green overflows, distance 32 with the first base color greater,
+		// paint colors 0 to 3 from top to bottom
+		"H mode",
+		[]byte{0x53, 0x1C, 0x9A, 0xE6, 0xCC, 0xCC, 0xAA, 0xAA},
+		[4][4]glcolor.RGB{
+			{{202, 151, 185}, {202, 151, 185}, {202, 151, 185}, {202, 151, 185}},
+			{{138, 87, 121}, {138, 87, 121}, {138, 87, 121}, {138, 87, 121}},
+			{{83, 117, 236}, {83, 117, 236}, {83, 117, 236}, {83, 117, 236}},
+			{{19, 53, 172}, {19, 53, 172}, {19, 53, 172}, {19, 53, 172}},
+		},
+	},
+	{
+		// Blue overflows, origin (130,64,4), horizontal (195,64,130)
+		// and vertical (65,129,0) colors
+		"planar mode",
+		[]byte{0x40, 0x40, 0x04, 0xE2, 0x41, 0x02, 0x10, 0x00},
+		[4][4]glcolor.RGB{
+			{{130, 64, 4}, {146, 64, 36}, {163, 64, 67}, {179, 64, 99}},
+			{{114, 80, 3}, {130, 80, 35}, {146, 80, 66}, {163, 80, 98}},
+			{{98, 97, 2}, {114, 97, 34}, {130, 97, 65}, {146, 97, 97}},
+			{{81, 113, 1}, {98, 113, 33}, {114, 113, 64}, {130, 113, 96}},
+		},
+	},
+	{
+		// The same block with the bit 55 between the green components of
+		// the origin set, which only counts in the differential mode
+		"planar mode with bit 55",
+		[]byte{0x40, 0xC0, 0x04, 0xE2, 0x41, 0x02, 0x10, 0x00},
+		[4][4]glcolor.RGB{
+			{{130, 64, 4}, {146, 64, 36}, {163, 64, 67}, {179, 64, 99}},
+			{{114, 80, 3}, {130, 80, 35}, {146, 80, 66}, {163, 80, 98}},
+			{{98, 97, 2}, {114, 97, 34}, {130, 97, 65}, {146, 97, 97}},
+			{{81, 113, 1}, {98, 113, 33}, {114, 113, 64}, {130, 113, 96}},
+		},
+	},
+}
+
+func TestDecodeETC2RGB8(t *testing.T) {
+	// ETC1 blocks decode the same in ETC2
+	for _, test := range append(etc2GoldenBlocks, etc1GoldenBlocks...) {
+		m := NewETC2RGB8(image.Rect(0, 0, 4, 4))
+		copy(m.Pix, test.block)
+		uncompressed, err := m.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				expected := test.pixels[y][x]
+				if c := m.At(x, y); c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+				if c := uncompressed.At(x, y); c != expected {
+					t.Errorf("Wrong uncompressed pixel at [%v %v] of %s block : expected %v, got %v", x, y, test.name, expected, c)
+				}
+			}
+		}
+	}
+}
+
+func TestCompressETC2RGB8(t *testing.T) {
+	r := image.Rect(0, 0, 6, 5)
+	im := image.NewRGBA(r)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			im.Set(x, y, color.RGBA{uint8(x * 40), uint8(y * 50), 0x80, 0xFF})
+		}
+	}
+	p := NewETC2RGB8(r)
+	if err := p.Compress(im); err != nil {
+		t.Fatal(err)
+	}
+
+	// The encoding only uses the modes of ETC1
+	etc1 := NewETC1(r)
+	if err := etc1.Compress(im); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p.Pix, etc1.Pix) {
+		t.Errorf("Wrong encoding : expected %x, got %x", etc1.Pix, p.Pix)
+	}
+}
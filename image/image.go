@@ -135,3 +135,131 @@ func NewNRGBA4444(r image.Rectangle) *NRGBA4444 {
 	buf := make([]byte, w*h*2)
 	return &NRGBA4444{buf, w * 2, r}
 }
+
+// NRGBA5551 is an in-memory image whose At method returns color.NRGBA5551 values.
+type NRGBA5551 struct {
+	Pix    []byte
+	Stride int
+	Rect   image.Rectangle
+}
+
+func (p *NRGBA5551) ColorModel() color.Model {
+	return glcolor.NRGBA5551Model
+}
+
+func (p *NRGBA5551) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *NRGBA5551) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return glcolor.NRGBA5551{}
+	}
+	i := p.PixOffset(x, y)
+	return glcolor.NRGBA5551{binary.LittleEndian.Uint16(p.Pix[i : i+2])}
+}
+
+func (p *NRGBA5551) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*2
+}
+
+func (p *NRGBA5551) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	c1 := glcolor.NRGBA5551Model.Convert(c).(glcolor.NRGBA5551)
+	binary.LittleEndian.PutUint16(p.Pix[i:i+2], c1.Value)
+}
+
+func NewNRGBA5551(r image.Rectangle) *NRGBA5551 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, w*h*2)
+	return &NRGBA5551{buf, w * 2, r}
+}
+
+// NBGRA8888 is an in-memory image whose At method returns color.NBGRA8888 values.
+// Pix holds the blue, green, red and alpha bytes of each pixel in that order.
+type NBGRA8888 struct {
+	Pix    []byte
+	Stride int
+	Rect   image.Rectangle
+}
+
+func (p *NBGRA8888) ColorModel() color.Model {
+	return glcolor.NBGRA8888Model
+}
+
+func (p *NBGRA8888) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *NBGRA8888) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return glcolor.NBGRA8888{}
+	}
+	i := p.PixOffset(x, y)
+	return glcolor.NBGRA8888{p.Pix[i], p.Pix[i+1], p.Pix[i+2], p.Pix[i+3]}
+}
+
+func (p *NBGRA8888) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+func (p *NBGRA8888) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	c1 := glcolor.NBGRA8888Model.Convert(c).(glcolor.NBGRA8888)
+	p.Pix[i], p.Pix[i+1], p.Pix[i+2], p.Pix[i+3] = c1.B, c1.G, c1.R, c1.A
+}
+
+func NewNBGRA8888(r image.Rectangle) *NBGRA8888 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, w*h*4)
+	return &NBGRA8888{buf, w * 4, r}
+}
+
+// NRGBAF32 is an in-memory image whose At method returns color.NRGBAF32 values.
+// Pix holds 4 floats per pixel, and Stride counts floats.
+type NRGBAF32 struct {
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+func (p *NRGBAF32) ColorModel() color.Model {
+	return glcolor.NRGBAF32Model
+}
+
+func (p *NRGBAF32) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *NRGBAF32) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return glcolor.NRGBAF32{}
+	}
+	i := p.PixOffset(x, y)
+	return glcolor.NRGBAF32{p.Pix[i], p.Pix[i+1], p.Pix[i+2], p.Pix[i+3]}
+}
+
+func (p *NRGBAF32) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+func (p *NRGBAF32) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	c1 := glcolor.NRGBAF32Model.Convert(c).(glcolor.NRGBAF32)
+	p.Pix[i], p.Pix[i+1], p.Pix[i+2], p.Pix[i+3] = c1.R, c1.G, c1.B, c1.A
+}
+
+func NewNRGBAF32(r image.Rectangle) *NRGBAF32 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]float32, w*h*4)
+	return &NRGBAF32{buf, w * 4, r}
+}
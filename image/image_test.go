@@ -26,6 +26,9 @@ func TestImage(t *testing.T) {
 		NewRGB565(image.Rect(0, 0, 10, 10)),
 		NewRGB(image.Rect(0, 0, 10, 10)),
 		NewNRGBA4444(image.Rect(0, 0, 10, 10)),
+		NewNRGBA5551(image.Rect(0, 0, 10, 10)),
+		NewNBGRA8888(image.Rect(0, 0, 10, 10)),
+		NewNRGBAF32(image.Rect(0, 0, 10, 10)),
 	}
 	for _, m := range testImage {
 		if !image.Rect(0, 0, 10, 10).Eq(m.Bounds()) {
@@ -65,57 +68,57 @@ func TestCompressedImage(t *testing.T) {
 			continue
 		}
 
-		//// Compress and uncompress a black image
-		//blackImage := image.NewRGBA(dims)
-		//for j := 0; j < 10; j++ {
-		//for i := 0; i < 10; i++ {
-		//blackImage.Set(i, j, color.Black)
-		//}
-		//}
-		//{
-		//err := m.Compress(blackImage)
-		//if err != nil {
-		//t.Error(err)
-		//}
-		//uncomImage, err := m.Uncompress()
-		//if err != nil {
-		//t.Error(err)
-		//}
-		//for j := 0; j < 10; j++ {
-		//for i := 0; i < 10; i++ {
-		//if !cmp(color.RGBAModel, color.Black, uncomImage.At(i, j)) {
-		//t.Errorf("%T: at (%v, %v), want a black color, got %v", m, i, j, uncomImage.At(i, j))
-		//continue
-		//}
-		//}
-		//}
-		//}
+		// Compress and uncompress a black image
+		blackImage := image.NewRGBA(dims)
+		for j := 0; j < 10; j++ {
+			for i := 0; i < 10; i++ {
+				blackImage.Set(i, j, color.Black)
+			}
+		}
+		{
+			err := m.Compress(blackImage)
+			if err != nil {
+				t.Error(err)
+			}
+			uncomImage, err := m.Uncompress()
+			if err != nil {
+				t.Error(err)
+			}
+			for j := 0; j < 10; j++ {
+				for i := 0; i < 10; i++ {
+					if !cmp(color.RGBAModel, color.Black, uncomImage.At(i, j)) {
+						t.Errorf("%T: at (%v, %v), want a black color, got %v", m, i, j, uncomImage.At(i, j))
+						continue
+					}
+				}
+			}
+		}
 
-		//// Compress and uncompress a white image
-		//whiteImage := image.NewRGBA(dims)
-		//for j := 0; j < 10; j++ {
-		//for i := 0; i < 10; i++ {
-		//whiteImage.Set(i, j, color.White)
-		//}
-		//}
-		//{
-		//err := m.Compress(whiteImage)
-		//if err != nil {
-		//t.Error(err)
-		//}
-		//uncomImage, err := m.Uncompress()
-		//if err != nil {
-		//t.Error(err)
-		//}
-		//for j := 0; j < 10; j++ {
-		//for i := 0; i < 10; i++ {
-		//if !cmp(color.RGBAModel, color.White, uncomImage.At(i, j)) {
-		//t.Errorf("%T: at (%v, %v), want a white color, got %v", m, i, j, uncomImage.At(i, j))
-		//continue
-		//}
-		//}
-		//}
-		//}
+		// Compress and uncompress a white image
+		whiteImage := image.NewRGBA(dims)
+		for j := 0; j < 10; j++ {
+			for i := 0; i < 10; i++ {
+				whiteImage.Set(i, j, color.White)
+			}
+		}
+		{
+			err := m.Compress(whiteImage)
+			if err != nil {
+				t.Error(err)
+			}
+			uncomImage, err := m.Uncompress()
+			if err != nil {
+				t.Error(err)
+			}
+			for j := 0; j < 10; j++ {
+				for i := 0; i < 10; i++ {
+					if !cmp(color.RGBAModel, color.White, uncomImage.At(i, j)) {
+						t.Errorf("%T: at (%v, %v), want a white color, got %v", m, i, j, uncomImage.At(i, j))
+						continue
+					}
+				}
+			}
+		}
 	}
 
 	im := NewRGB(image.Rect(0, 0, 2, 1))
@@ -0,0 +1,55 @@
+// Package imagetest provides the helpers shared by the tests of the image
+// file formats.
+package imagetest
+
+import (
+	"image"
+	"image/color"
+)
+
+// Image is an image whose pixels can be set.
+type Image interface {
+	image.Image
+	Set(int, int, color.Color)
+}
+
+// FillImage sets every pixel of m to an opaque color depending on its
+// position, and returns m.
+func FillImage(m Image) Image {
+	return fill(m, func(x, y int) uint8 { return 0xFF })
+}
+
+// FillImageAlpha sets every pixel of m to a color whose alpha decreases
+// from left to right, and returns m.
+func FillImageAlpha(m Image) Image {
+	return fill(m, func(x, y int) uint8 { return uint8(0xFF - x*30) })
+}
+
+func fill(m Image, alpha func(x, y int) uint8) Image {
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			m.Set(x, y, color.NRGBA{uint8(x * 40), uint8(y * 40), uint8(x*20 + y*20), alpha(x, y)})
+		}
+	}
+	return m
+}
+
+// SameImage returns whether m0 and m1 have the same bounds and the same
+// colors once converted to 16-bit RGBA.
+func SameImage(m0, m1 image.Image) bool {
+	if m0.Bounds() != m1.Bounds() {
+		return false
+	}
+	b := m0.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r0, g0, b0, a0 := m0.At(x, y).RGBA()
+			r1, g1, b1, a1 := m1.At(x, y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				return false
+			}
+		}
+	}
+	return true
+}
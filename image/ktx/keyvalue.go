@@ -0,0 +1,134 @@
+package ktx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Standard keys of the key/value data defined by the KTX specification.
+const (
+	KeyOrientation = "KTXorientation"
+	KeyWriter      = "KTXwriter"
+)
+
+// KeyValues holds the key/value pairs stored in a KTX file. Values are kept
+// as they are in the file, including the NUL terminator of string values.
+type KeyValues map[string][]byte
+
+// String returns the value of key as a string, without its NUL terminator.
+func (kv KeyValues) String(key string) (string, bool) {
+	v, ok := kv[key]
+	if !ok {
+		return "", false
+	}
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		v = v[:i]
+	}
+	return string(v), true
+}
+
+// SetString sets the value of key to s followed by a NUL terminator.
+func (kv KeyValues) SetString(key, s string) {
+	kv[key] = append([]byte(s), 0)
+}
+
+// Writer returns the name of the tool which wrote the file.
+func (kv KeyValues) Writer() (string, bool) {
+	return kv.String(KeyWriter)
+}
+
+// Orientation returns the logical orientation of the texture.
+func (kv KeyValues) Orientation() (Orientation, bool) {
+	s, ok := kv.String(KeyOrientation)
+	if !ok {
+		return Orientation{}, false
+	}
+	o, err := ParseOrientation(s)
+	if err != nil {
+		return Orientation{}, false
+	}
+	return o, true
+}
+
+// SetOrientation sets the logical orientation of the texture.
+func (kv KeyValues) SetOrientation(o Orientation) {
+	kv.SetString(KeyOrientation, o.String())
+}
+
+// Orientation describes in which direction the texture coordinates increase
+// when the pixels are viewed. S is 'r' (right) or 'l' (left), T is 'd' (down)
+// or 'u' (up) and R is 'o' (out) or 'i' (in). R is 0 when unspecified.
+type Orientation struct {
+	S, T, R byte
+}
+
+// ParseOrientation parses the value of KTXorientation, like "S=r,T=d".
+func ParseOrientation(s string) (Orientation, error) {
+	var o Orientation
+	for _, field := range strings.Split(s, ",") {
+		if len(field) != 3 || field[1] != '=' {
+			return Orientation{}, fmt.Errorf("KTX reader: invalid orientation [%s]", s)
+		}
+		switch v := field[2]; field[0] {
+		case 'S':
+			if v != 'r' && v != 'l' {
+				return Orientation{}, fmt.Errorf("KTX reader: invalid orientation [%s]", s)
+			}
+			o.S = v
+		case 'T':
+			if v != 'd' && v != 'u' {
+				return Orientation{}, fmt.Errorf("KTX reader: invalid orientation [%s]", s)
+			}
+			o.T = v
+		case 'R':
+			if v != 'o' && v != 'i' {
+				return Orientation{}, fmt.Errorf("KTX reader: invalid orientation [%s]", s)
+			}
+			o.R = v
+		default:
+			return Orientation{}, fmt.Errorf("KTX reader: invalid orientation [%s]", s)
+		}
+	}
+	if o.S == 0 || o.T == 0 {
+		return Orientation{}, fmt.Errorf("KTX reader: invalid orientation [%s]", s)
+	}
+	return o, nil
+}
+
+func (o Orientation) String() string {
+	s := fmt.Sprintf("S=%c,T=%c", o.S, o.T)
+	if o.R != 0 {
+		s += fmt.Sprintf(",R=%c", o.R)
+	}
+	return s
+}
+
+// decodeKeyValues parses the key/value data of a KTX file.
+func decodeKeyValues(data []byte, isLittleEndianness bool) (KeyValues, error) {
+	kv := make(KeyValues)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("KTX reader: truncated key/value data")
+		}
+		size := int(decodeUint32(data, isLittleEndianness))
+		data = data[4:]
+		if size > len(data) {
+			return nil, fmt.Errorf("KTX reader: key/value pair too large [%v > %v]", size, len(data))
+		}
+		pair := data[:size]
+		i := bytes.IndexByte(pair, 0)
+		if i < 0 {
+			return nil, fmt.Errorf("KTX reader: key without NUL terminator [%q]", pair)
+		}
+		kv[string(pair[:i])] = append([]byte(nil), pair[i+1:]...)
+
+		// valuePadding
+		size += padding(size)
+		if size > len(data) {
+			size = len(data)
+		}
+		data = data[size:]
+	}
+	return kv, nil
+}
@@ -0,0 +1,55 @@
+package ktx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOrientation(t *testing.T) {
+	tests := []struct {
+		s string
+		o Orientation
+	}{
+		{"S=r,T=d", Orientation{'r', 'd', 0}},
+		{"S=l,T=u", Orientation{'l', 'u', 0}},
+		{"S=r,T=u,R=i", Orientation{'r', 'u', 'i'}},
+	}
+	for _, test := range tests {
+		o, err := ParseOrientation(test.s)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if o != test.o {
+			t.Errorf("Wrong orientation of (%s) : expected %v, got %v", test.s, test.o, o)
+		}
+		if o.String() != test.s {
+			t.Errorf("Wrong string : expected (%s), got (%s)", test.s, o.String())
+		}
+	}
+
+	for _, s := range []string{"", "S=r", "T=d", "S=x,T=d", "S=r,T=d,Q=o", "S:r,T=d"} {
+		if _, err := ParseOrientation(s); err == nil {
+			t.Errorf("Expected error for orientation (%s)", s)
+		}
+	}
+}
+
+func TestDecodeKeyValuesError(t *testing.T) {
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{[]byte{1, 0}, "truncated key/value data"},
+		{[]byte{8, 0, 0, 0, 'k', 0}, "key/value pair too large"},
+		{[]byte{2, 0, 0, 0, 'k', 'v', 0, 0}, "key without NUL terminator"},
+	}
+	for _, test := range tests {
+		_, err := decodeKeyValues(test.input, true)
+		if err == nil {
+			t.Errorf("Expected pattern of error message (%s), got no error", test.err)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%s)", test.err, err.Error())
+		}
+	}
+}
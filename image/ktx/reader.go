@@ -1,12 +1,13 @@
 package ktx
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
-	"log"
+	"math"
 
 	"github.com/hantempo/glu/enum"
 	glimage "github.com/hantempo/glu/image"
@@ -21,21 +22,154 @@ func decodeUint32(buf []byte, isLittleEndianness bool) uint32 {
 	}
 }
 
+// Header holds the fields of a KTX file header which follow the identifier
+// and the endianness.
+type Header struct {
+	GLType                enum.Type
+	GLTypeSize            uint32
+	GLFormat              enum.Format
+	GLInternalFormat      enum.InternalFormat
+	GLBaseInternalFormat  enum.InternalFormat
+	PixelWidth            uint32
+	PixelHeight           uint32
+	PixelDepth            uint32
+	NumberOfArrayElements uint32
+	NumberOfFaces         uint32
+	NumberOfMipmapLevels  uint32
+	BytesOfKeyValueData   uint32
+}
+
+// Levels returns the number of mipmap levels stored in the file.
+func (h *Header) Levels() int {
+	if h.NumberOfMipmapLevels == 0 {
+		return 1
+	}
+	return int(h.NumberOfMipmapLevels)
+}
+
+// Layers returns the number of array layers stored in the file,
+// which is 1 for non-array textures.
+func (h *Header) Layers() int {
+	if h.NumberOfArrayElements == 0 {
+		return 1
+	}
+	return int(h.NumberOfArrayElements)
+}
+
+// Faces returns the number of faces stored in the file,
+// which is 6 for cubemaps and 1 otherwise.
+func (h *Header) Faces() int {
+	return int(h.NumberOfFaces)
+}
+
+// LevelSize returns the width and height of the given mipmap level.
+func (h *Header) LevelSize(level int) (width, height int) {
+	width = int(h.PixelWidth) >> uint(level)
+	height = int(h.PixelHeight) >> uint(level)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return
+}
+
+// Config holds the header and the key/value data of a KTX file.
+type Config struct {
+	Header
+	KeyValues KeyValues
+}
+
+// Texture holds every image stored in a KTX file.
+type Texture struct {
+	Config
+	// Images is indexed by mipmap level, array layer and cube face,
+	// in this order.
+	Images [][][]image.Image
+}
+
+// Image returns the image of the given mipmap level, array layer and cube face.
+func (t *Texture) Image(level, layer, face int) image.Image {
+	return t.Images[level][layer][face]
+}
+
 type decoder struct {
-	im            image.Image
-	model         color.Model
+	r                  io.Reader
+	isLittleEndianness bool
+	header             Header
+	keyValues          KeyValues
+	tex                *Texture
+	model              color.Model
+	// layoutFormat is the sized or compressed internal format
+	// which decides the size of the images in the file
+	layoutFormat enum.InternalFormat
+	// chain is the layout of the mipmap levels in the file
+	chain         []glimage.MipLevel
 	width, height int
 }
 
 const magic = "\xAB\x4B\x54\x58\x20\x31\x31\xBB\x0D\x0A\x1A\x0A"
 
+// padding returns how many bytes are needed to align n to 4 bytes.
+func padding(n int) int {
+	return 3 - ((n + 3) % 4)
+}
+
 func (d *decoder) decode(r io.Reader, configOnly bool) error {
+	d.r = r
+	if err := d.decodeHeader(); err != nil {
+		return err
+	}
+	if err := d.checkFormat(); err != nil {
+		return err
+	}
+	if err := d.checkLayout(); err != nil {
+		return err
+	}
+	if err := d.decodeKeyValues(); err != nil {
+		return err
+	}
+	if configOnly {
+		return nil
+	}
+	return d.decodeImages()
+}
+
+// readData reads the next n bytes of the file. The buffer grows as the data
+// arrives, so that a size field of a crafted file cannot allocate more than
+// the file holds.
+func (d *decoder) readData(n int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, n); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *decoder) decodeKeyValues() error {
+	data, err := d.readData(int64(d.header.BytesOfKeyValueData))
+	if err != nil {
+		return err
+	}
+	kv, err := decodeKeyValues(data, d.isLittleEndianness)
+	if err != nil {
+		return err
+	}
+	d.keyValues = kv
+	return nil
+}
+
+func (d *decoder) decodeHeader() error {
 	// Enough to hold the header identifier (12 bytes)
 	var tmp [256]byte
 
 	// Read and check the identifier
 	buf := tmp[:12]
-	if _, err := io.ReadFull(r, buf); err != nil {
+	if _, err := io.ReadFull(d.r, buf); err != nil {
 		return err
 	}
 	if magic != string(buf) {
@@ -44,96 +178,359 @@ func (d *decoder) decode(r io.Reader, configOnly bool) error {
 
 	// Read and decide endianness
 	buf = tmp[:4]
-	if _, err := io.ReadFull(r, buf); err != nil {
+	if _, err := io.ReadFull(d.r, buf); err != nil {
 		return err
 	}
-	isLittleEndianness := true
 	endianness := decodeUint32(buf, true)
 	if endianness == 0x04030201 {
-		isLittleEndianness = true
+		d.isLittleEndianness = true
 	} else if endianness == 0x01020304 {
-		isLittleEndianness = false
+		d.isLittleEndianness = false
 	} else {
 		return fmt.Errorf("KTX reader: invalid endianness [%v]", buf)
 	}
 
 	buf = tmp[:12*4]
-	if _, err := io.ReadFull(r, buf); err != nil {
+	if _, err := io.ReadFull(d.r, buf); err != nil {
 		return err
 	}
 
-	glType := decodeUint32(buf[0:4], isLittleEndianness)
-	glTypeSize := decodeUint32(buf[4:8], isLittleEndianness)
-	glFormat := decodeUint32(buf[8:12], isLittleEndianness)
-	glInternalFormat := decodeUint32(buf[12:16], isLittleEndianness)
-	glBaseInternalFormat := decodeUint32(buf[16:20], isLittleEndianness)
-	log.Printf("glType : %s\n", enum.TypeString(glType))
-	log.Printf("glTypeSize : %v\n", glTypeSize)
-	log.Printf("glFormat : %s\n", enum.FormatString(glFormat))
-	log.Printf("glInternalFormat : %s\n", enum.FormatString(glInternalFormat))
-	log.Printf("glBaseInternalFormat : %s\n", enum.FormatString(glBaseInternalFormat))
-
-	d.width = int(decodeUint32(buf[20:24], isLittleEndianness))
-	d.height = int(decodeUint32(buf[24:28], isLittleEndianness))
-	log.Printf("pixelWidth : %v\n", d.width)
-	log.Printf("pixelHeight : %v\n", d.height)
-	log.Printf("pixelDepth : %v\n", decodeUint32(buf[28:32], isLittleEndianness))
-	log.Printf("numberOfArrayElements : %v\n", decodeUint32(buf[32:], isLittleEndianness))
-	log.Printf("numberOfFaces : %v\n", decodeUint32(buf[36:], isLittleEndianness))
-	log.Printf("numberOfMipmapLevels : %v\n", decodeUint32(buf[40:], isLittleEndianness))
-	log.Printf("numberOfKeyValuePairs : %v\n", decodeUint32(buf[44:], isLittleEndianness))
+	h := &d.header
+	fields := []*uint32{
+		(*uint32)(&h.GLType), &h.GLTypeSize, (*uint32)(&h.GLFormat),
+		(*uint32)(&h.GLInternalFormat), (*uint32)(&h.GLBaseInternalFormat),
+		&h.PixelWidth, &h.PixelHeight, &h.PixelDepth,
+		&h.NumberOfArrayElements, &h.NumberOfFaces, &h.NumberOfMipmapLevels,
+		&h.BytesOfKeyValueData,
+	}
+	for i, f := range fields {
+		*f = decodeUint32(buf[i*4:], d.isLittleEndianness)
+	}
 
-	buf = tmp[:4]
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return err
+	d.width, d.height = h.LevelSize(0)
+	return nil
+}
+
+// checkLayout makes sure the layers, faces and slices can be decoded.
+func (d *decoder) checkLayout() error {
+	h := &d.header
+	if h.PixelDepth > 1 {
+		return fmt.Errorf("KTX reader: 3D textures are not supported [depth=%v]", h.PixelDepth)
 	}
-	imageSize := int(decodeUint32(buf[0:4], isLittleEndianness))
-	log.Printf("imageSize : %v\n", imageSize)
-
-	var (
-		gray      *image.Gray
-		nrgba4444 *glimage.NRGBA4444
-		etc1      *glimage.ETC1
-	)
-	if glType == enum.GL_UNSIGNED_BYTE && glFormat == enum.GL_LUMINANCE {
-		gray = image.NewGray(image.Rect(0, 0, d.width, d.height))
-		if _, err := io.ReadFull(r, gray.Pix); err != nil {
-			return err
+	if h.NumberOfFaces != 1 && h.NumberOfFaces != 6 {
+		return fmt.Errorf("KTX reader: invalid number of faces [%v]", h.NumberOfFaces)
+	}
+	if full := glimage.MipLevels(int(h.PixelWidth), int(h.PixelHeight)); h.Levels() > full {
+		return fmt.Errorf("KTX reader: too many levels [%v > %v]", h.NumberOfMipmapLevels, full)
+	}
+
+	// Rows of pixels are aligned to 4 bytes, as with GL_UNPACK_ALIGNMENT
+	chain, err := glimage.MipChainLayout(d.layoutFormat, int(h.PixelWidth), int(h.PixelHeight), h.Layers(), h.Faces(), h.Levels(), 4)
+	if err != nil {
+		return fmt.Errorf("KTX reader: %v", err)
+	}
+	// The array elements of a level are counted by its 32-bit imageSize
+	if uint64(chain[0].Size) > math.MaxUint32 {
+		return fmt.Errorf("KTX reader: too many array elements [%v]", h.NumberOfArrayElements)
+	}
+	d.chain = chain
+	return nil
+}
+
+// checkFormat makes sure the type-format combination is supported
+// and decides the color model of the decoded images.
+func (d *decoder) checkFormat() error {
+	h := &d.header
+	// The pixels of uncompressed formats are laid out as the sized internal
+	// format of the type-format combination, whatever the internal format
+	if h.GLType == enum.GL_UNSIGNED_BYTE && h.GLFormat == enum.GL_LUMINANCE {
+		d.model, d.layoutFormat = color.GrayModel, enum.GL_LUMINANCE8
+	} else if h.GLType == enum.GL_UNSIGNED_BYTE && h.GLFormat == enum.GL_RGB {
+		d.model, d.layoutFormat = glcolor.RGBModel, enum.GL_RGB8
+	} else if h.GLType == enum.GL_UNSIGNED_BYTE && h.GLFormat == enum.GL_RGBA {
+		d.model, d.layoutFormat = color.NRGBAModel, enum.GL_RGBA8
+	} else if h.GLType == enum.GL_UNSIGNED_SHORT_5_6_5 && h.GLFormat == enum.GL_RGB {
+		d.model, d.layoutFormat = glcolor.RGB565Model, enum.GL_RGB565
+	} else if h.GLType == enum.GL_UNSIGNED_SHORT_4_4_4_4 && h.GLFormat == enum.GL_RGBA {
+		d.model, d.layoutFormat = glcolor.NRGBA4444Model, enum.GL_RGBA4
+	} else if h.GLType == 0 && h.GLFormat == 0 {
+		// For compressed formats
+		d.layoutFormat = h.GLInternalFormat
+		switch h.GLInternalFormat {
+		case enum.GL_ETC1_RGB8_OES, enum.GL_COMPRESSED_RGB8_ETC2, enum.GL_COMPRESSED_SRGB8_ETC2:
+			d.model = glcolor.RGBModel
+		case enum.GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, enum.GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+			enum.GL_COMPRESSED_RGBA8_ETC2_EAC, enum.GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC:
+			d.model = color.NRGBAModel
+		case enum.GL_COMPRESSED_RGB_S3TC_DXT1_EXT, enum.GL_COMPRESSED_RGBA_S3TC_DXT1_EXT,
+			enum.GL_COMPRESSED_SRGB_S3TC_DXT1_EXT, enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT,
+			enum.GL_COMPRESSED_RGBA_S3TC_DXT3_EXT, enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT,
+			enum.GL_COMPRESSED_RGBA_S3TC_DXT5_EXT, enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT:
+			d.model = color.NRGBAModel
+		case enum.GL_COMPRESSED_R11_EAC, enum.GL_COMPRESSED_RED_RGTC1:
+			d.model = glcolor.R16Model
+		case enum.GL_COMPRESSED_SIGNED_R11_EAC, enum.GL_COMPRESSED_SIGNED_RED_RGTC1:
+			d.model = glcolor.SignedR16Model
+		case enum.GL_COMPRESSED_RG11_EAC, enum.GL_COMPRESSED_RG_RGTC2:
+			d.model = glcolor.RG16Model
+		case enum.GL_COMPRESSED_SIGNED_RG11_EAC, enum.GL_COMPRESSED_SIGNED_RG_RGTC2:
+			d.model = glcolor.SignedRG16Model
+		case enum.GL_COMPRESSED_RGBA_BPTC_UNORM, enum.GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM:
+			d.model = color.NRGBAModel
+		case enum.GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT, enum.GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT:
+			d.model = glcolor.NRGBAF32Model
+		case enum.GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG, enum.GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG,
+			enum.GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG, enum.GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG:
+			d.model = color.NRGBAModel
+		default:
+			if enum.IsASTC(h.GLInternalFormat) {
+				d.model = color.NRGBAModel
+				break
+			}
+			return fmt.Errorf("KTX reader: unrecognized compressed internal format [%v]\n", h.GLInternalFormat)
+		}
+	} else {
+		return fmt.Errorf("KTX reader: unsupported type-format combination [%v %v]\n", h.GLType, h.GLFormat)
+	}
+	if info, ok := enum.InternalFormatInfo(h.GLInternalFormat); ok && !info.Compressed && !validTypeFormat(info, h.GLType, h.GLFormat) {
+		return fmt.Errorf("KTX reader: type-format combination [%v %v] invalid for internal format [%v]", h.GLType, h.GLFormat, h.GLInternalFormat)
+	}
+	return nil
+}
+
+// validTypeFormat returns whether pixels of type t and format f can be
+// uploaded to a texture of the internal format described by info.
+func validTypeFormat(info enum.FormatInfo, t enum.Type, f enum.Format) bool {
+	for _, tf := range info.TypeFormats {
+		if tf.Type == t && tf.Format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// newImage allocates an image of the texture format, and returns it with its
+// pixel buffer and its stride. The stride is 0 for compressed images.
+func (d *decoder) newImage(r image.Rectangle) (image.Image, []byte, int) {
+	h := &d.header
+	if h.GLType == enum.GL_UNSIGNED_BYTE && h.GLFormat == enum.GL_LUMINANCE {
+		gray := image.NewGray(r)
+		return gray, gray.Pix, gray.Stride
+	} else if h.GLType == enum.GL_UNSIGNED_BYTE && h.GLFormat == enum.GL_RGB {
+		rgb := glimage.NewRGB(r)
+		return rgb, rgb.Pix, rgb.Stride
+	} else if h.GLType == enum.GL_UNSIGNED_BYTE && h.GLFormat == enum.GL_RGBA {
+		nrgba := image.NewNRGBA(r)
+		return nrgba, nrgba.Pix, nrgba.Stride
+	} else if h.GLType == enum.GL_UNSIGNED_SHORT_5_6_5 && h.GLFormat == enum.GL_RGB {
+		rgb565 := glimage.NewRGB565(r)
+		return rgb565, rgb565.Pix, rgb565.Stride
+	} else if h.GLType == enum.GL_UNSIGNED_SHORT_4_4_4_4 && h.GLFormat == enum.GL_RGBA {
+		nrgba4444 := glimage.NewNRGBA4444(r)
+		return nrgba4444, nrgba4444.Pix, nrgba4444.Stride
+	}
+	switch h.GLInternalFormat {
+	case enum.GL_COMPRESSED_RGB8_ETC2, enum.GL_COMPRESSED_SRGB8_ETC2:
+		etc2 := glimage.NewETC2RGB8(r)
+		return etc2, etc2.Pix, 0
+	case enum.GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, enum.GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2:
+		etc2 := glimage.NewETC2RGB8A1(r)
+		return etc2, etc2.Pix, 0
+	case enum.GL_COMPRESSED_RGBA8_ETC2_EAC, enum.GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC:
+		etc2 := glimage.NewETC2RGBA8(r)
+		return etc2, etc2.Pix, 0
+	case enum.GL_COMPRESSED_RGB_S3TC_DXT1_EXT, enum.GL_COMPRESSED_RGBA_S3TC_DXT1_EXT,
+		enum.GL_COMPRESSED_SRGB_S3TC_DXT1_EXT, enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT:
+		bc1 := glimage.NewBC1(r)
+		return bc1, bc1.Pix, 0
+	case enum.GL_COMPRESSED_RGBA_S3TC_DXT3_EXT, enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT:
+		bc2 := glimage.NewBC2(r)
+		return bc2, bc2.Pix, 0
+	case enum.GL_COMPRESSED_RGBA_S3TC_DXT5_EXT, enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT:
+		bc3 := glimage.NewBC3(r)
+		return bc3, bc3.Pix, 0
+	case enum.GL_COMPRESSED_R11_EAC:
+		eac := glimage.NewEACR11(r)
+		return eac, eac.Pix, 0
+	case enum.GL_COMPRESSED_SIGNED_R11_EAC:
+		eac := glimage.NewEACSignedR11(r)
+		return eac, eac.Pix, 0
+	case enum.GL_COMPRESSED_RG11_EAC:
+		eac := glimage.NewEACRG11(r)
+		return eac, eac.Pix, 0
+	case enum.GL_COMPRESSED_SIGNED_RG11_EAC:
+		eac := glimage.NewEACSignedRG11(r)
+		return eac, eac.Pix, 0
+	case enum.GL_COMPRESSED_RED_RGTC1:
+		bc4 := glimage.NewBC4(r)
+		return bc4, bc4.Pix, 0
+	case enum.GL_COMPRESSED_SIGNED_RED_RGTC1:
+		bc4 := glimage.NewBC4Signed(r)
+		return bc4, bc4.Pix, 0
+	case enum.GL_COMPRESSED_RG_RGTC2:
+		bc5 := glimage.NewBC5(r)
+		return bc5, bc5.Pix, 0
+	case enum.GL_COMPRESSED_SIGNED_RG_RGTC2:
+		bc5 := glimage.NewBC5Signed(r)
+		return bc5, bc5.Pix, 0
+	case enum.GL_COMPRESSED_RGBA_BPTC_UNORM, enum.GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM:
+		bc7 := glimage.NewBC7(r)
+		return bc7, bc7.Pix, 0
+	case enum.GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT, enum.GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT:
+		bc6h := glimage.NewBC6H(r)
+		bc6h.Signed = h.GLInternalFormat == enum.GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT
+		return bc6h, bc6h.Pix, 0
+	case enum.GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG, enum.GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG:
+		pvrtc := glimage.NewPVRTC(r, 4)
+		return pvrtc, pvrtc.Pix, 0
+	case enum.GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG, enum.GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG:
+		pvrtc := glimage.NewPVRTC(r, 2)
+		return pvrtc, pvrtc.Pix, 0
+	}
+	if enum.IsASTC(h.GLInternalFormat) {
+		info, _ := enum.InternalFormatInfo(h.GLInternalFormat)
+		astc := glimage.NewASTC(r, info.BlockWidth, info.BlockHeight)
+		astc.SRGB = info.SRGB
+		return astc, astc.Pix, 0
+	}
+	etc1 := glimage.NewETC1(r)
+	return etc1, etc1.Pix, 0
+}
+
+// decodeImage decodes one face of the given dimensions from the beginning of
+// data, and returns the image with the number of bytes it occupies.
+func (d *decoder) decodeImage(data []byte, width, height int) (image.Image, int, error) {
+	im, pix, stride := d.newImage(image.Rect(0, 0, width, height))
+	if stride == 0 {
+		if len(data) < len(pix) {
+			return nil, 0, fmt.Errorf("KTX reader: not enough image data [%v < %v]", len(data), len(pix))
+		}
+		copy(pix, data)
+		return im, len(pix), nil
+	}
+
+	// Rows of uncompressed images are aligned to 4 bytes
+	rowSize := stride + padding(stride)
+	if len(data) < rowSize*height {
+		return nil, 0, fmt.Errorf("KTX reader: not enough image data [%v < %v]", len(data), rowSize*height)
+	}
+	for y := 0; y < height; y++ {
+		copy(pix[y*stride:(y+1)*stride], data[y*rowSize:])
+	}
+	// Pixels are kept in little endian in memory
+	if !d.isLittleEndianness {
+		swapBytes(pix, int(d.header.GLTypeSize))
+	}
+	return im, rowSize * height, nil
+}
+
+// swapBytes reverses the byte order of every element of the given size in buf.
+func swapBytes(buf []byte, size int) {
+	if size <= 1 {
+		return
+	}
+	for i := 0; i+size <= len(buf); i += size {
+		for j, k := i, i+size-1; j < k; j, k = j+1, k-1 {
+			buf[j], buf[k] = buf[k], buf[j]
 		}
-		d.im = gray
-		d.model = color.GrayModel
-	} else if glType == enum.GL_UNSIGNED_SHORT_4_4_4_4 && glFormat == enum.GL_RGBA {
-		nrgba4444 = glimage.NewNRGBA4444(image.Rect(0, 0, d.width, d.height))
-		if _, err := io.ReadFull(r, nrgba4444.Pix); err != nil {
+	}
+}
+
+func (d *decoder) decodeImages() error {
+	h := &d.header
+	levels, layers, faces := h.Levels(), h.Layers(), h.Faces()
+	// Only faces of non-array cubemaps are stored and padded one by one
+	isCubemap := faces == 6 && h.NumberOfArrayElements == 0
+
+	d.tex = &Texture{
+		Config: Config{Header: *h, KeyValues: d.keyValues},
+		Images: make([][][]image.Image, levels),
+	}
+	var tmp [4]byte
+	for level := 0; level < levels; level++ {
+		if _, err := io.ReadFull(d.r, tmp[:]); err != nil {
 			return err
 		}
+		imageSize := int(decodeUint32(tmp[:], d.isLittleEndianness))
+		expected := d.chain[level].Size
+		if isCubemap {
+			expected = d.chain[level].ImageSize
+		}
+		if imageSize != expected {
+			return fmt.Errorf("KTX reader: invalid imageSize of level %v [%v != %v]", level, imageSize, expected)
+		}
 
-		d.im = nrgba4444
-		d.model = glcolor.NRGBA4444Model
-	} else if glType == 0 && glFormat == 0 {
-		// For compressed formats
-		if glInternalFormat == enum.GL_ETC1_RGB8_OES {
-			etc1 = glimage.NewETC1(image.Rect(0, 0, d.width, d.height))
-			if _, err := io.ReadFull(r, etc1.Pix); err != nil {
+		// The images are allocated once the file has supplied their data
+		var (
+			data []byte
+			err  error
+		)
+		if isCubemap {
+			// imageSize is the size of one face, followed by cubePadding
+			faceSize := imageSize + padding(imageSize)
+			data, err = d.readData(int64(faceSize * faces))
+			if err != nil {
 				return err
 			}
-
-			d.im = etc1
-			d.model = glcolor.RGBModel
 		} else {
-			return fmt.Errorf("KTX reader: unrecognized compressed internal format [%v]\n", glInternalFormat)
+			data, err = d.readData(int64(imageSize))
+			if err != nil {
+				return err
+			}
+			// mipPadding
+			if _, err := io.ReadFull(d.r, tmp[:padding(imageSize)]); err != nil {
+				return err
+			}
 		}
-	} else {
-		return fmt.Errorf("KTX reader: unsupported type-format combination [%v %v]\n", glType, glFormat)
-	}
 
+		width, height := h.LevelSize(level)
+		d.tex.Images[level] = make([][]image.Image, layers)
+		for layer := 0; layer < layers; layer++ {
+			d.tex.Images[level][layer] = make([]image.Image, faces)
+			for face := 0; face < faces; face++ {
+				im, n, err := d.decodeImage(data, width, height)
+				if err != nil {
+					return err
+				}
+				if isCubemap {
+					n = imageSize + padding(imageSize)
+				}
+				data = data[n:]
+				d.tex.Images[level][layer][face] = im
+			}
+		}
+	}
 	return nil
 }
 
+// DecodeTexture reads a KTX file from r and returns every mipmap level,
+// array layer and cube face stored in it.
+func DecodeTexture(r io.Reader) (*Texture, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex, nil
+}
+
+// Decode reads a KTX file from r and returns the first face of
+// the first array layer in the base mipmap level.
 func Decode(r io.Reader) (image.Image, error) {
 	var d decoder
-	err := d.decode(r, false)
-	return d.im, err
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex.Image(0, 0, 0), nil
+}
+
+// DecodeTextureConfig reads the header and the key/value data of a KTX file
+// from r without decoding any image.
+func DecodeTextureConfig(r io.Reader) (Config, error) {
+	var d decoder
+	if err := d.decode(r, true); err != nil {
+		return Config{}, err
+	}
+	return Config{Header: d.header, KeyValues: d.keyValues}, nil
 }
 
 func DecodeConfig(r io.Reader) (image.Config, error) {
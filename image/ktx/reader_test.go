@@ -2,12 +2,14 @@ package ktx
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
 	"strings"
 	"testing"
 
+	"github.com/hantempo/glu/enum"
 	glcolor "github.com/hantempo/glu/image/color"
 )
 
@@ -94,8 +96,35 @@ var goodTestData = []struct {
 		dims:  image.Rect(0, 0, 2, 1),
 		model: glcolor.RGBModel,
 		output: []color.Color{
-			glcolor.RGB{0xFF, 0x00, 0xFF},
-			glcolor.RGB{0x00, 0xFF, 0x00},
+			glcolor.RGB{0xED, 0xA3, 0x16},
+			glcolor.RGB{0xF1, 0xA7, 0x1A},
+		},
+	},
+	{
+		input: []byte{
+			'\xAB', 'K', 'T', 'X', ' ', '1', '1', '\xBB', '\r', '\n', '\x1A', '\n',
+			1, 2, 3, 4, // litter endian
+			0x00, 0x00, 0x00, 0x00, // glType=GL_NONE
+			0x01, 0x00, 0x00, 0x00, // glTypeSize=1
+			0x00, 0x00, 0x00, 0x00, // glFormat=GL_NONE
+			0x74, 0x92, 0x00, 0x00, // glInternalFormat=GL_COMPRESSED_RGB8_ETC2
+			0x07, 0x19, 0x00, 0x00, // glBaseInternalFormat=GL_RGB
+			0x02, 0x00, 0x00, 0x00, // width=2,
+			0x01, 0x00, 0x00, 0x00, // height=1,
+			0x00, 0x00, 0x00, 0x00, // depth=0,
+			0x00, 0x00, 0x00, 0x00, // numberOfArrayElements=0
+			0x01, 0x00, 0x00, 0x00, // numberOfFaces=1
+			0x01, 0x00, 0x00, 0x00, // numberOfMipmapLevels=1
+			0x00, 0x00, 0x00, 0x00, // numberOfKeyValuePairs=0,
+			0x08, 0x00, 0x00, 0x00, // imageSize=8,
+			0x53, 0x1C, 0x9A, 0xE6, // imageData in H mode
+			0xCC, 0xCC, 0xAA, 0xAA,
+		},
+		dims:  image.Rect(0, 0, 2, 1),
+		model: glcolor.RGBModel,
+		output: []color.Color{
+			glcolor.RGB{0xCA, 0x97, 0xB9},
+			glcolor.RGB{0xCA, 0x97, 0xB9},
 		},
 	},
 }
@@ -185,6 +214,86 @@ var badTestData = []struct {
 		},
 		err: "KTX reader: unsupported type-format combination",
 	},
+	{
+		input: []byte{
+			'\xAB', 'K', 'T', 'X', ' ', '1', '1', '\xBB', '\r', '\n', '\x1A', '\n',
+			1, 2, 3, 4, // litter endian
+			0x00, 0x00, 0x00, 0x00, // glType=GL_NONE
+			0x01, 0x00, 0x00, 0x00, // glTypeSize=1
+			0x00, 0x00, 0x00, 0x00, // glFormat=GL_NONE
+			0x74, 0x92, 0x00, 0x00, // glInternalFormat=GL_COMPRESSED_RGB8_ETC2
+			0x07, 0x19, 0x00, 0x00, // glBaseInternalFormat=GL_RGB
+			0x02, 0x00, 0x00, 0x00, // width=2,
+			0x01, 0x00, 0x00, 0x00, // height=1,
+			0x00, 0x00, 0x00, 0x00, // depth=0,
+			0x00, 0x00, 0x00, 0x00, // numberOfArrayElements=0
+			0x01, 0x00, 0x00, 0x00, // numberOfFaces=1
+			0x01, 0x00, 0x00, 0x00, // numberOfMipmapLevels=1
+			0x00, 0x00, 0x00, 0x00, // numberOfKeyValuePairs=0,
+			0x04, 0x00, 0x00, 0x00, // imageSize=4, instead of one block of 8 bytes
+			0x53, 0x1C, 0x9A, 0xE6,
+		},
+		err: "KTX reader: invalid imageSize of level 0 [4 != 8]",
+	},
+	{
+		input: []byte{
+			'\xAB', 'K', 'T', 'X', ' ', '1', '1', '\xBB', '\r', '\n', '\x1A', '\n',
+			1, 2, 3, 4, // litter endian
+			0x01, 0x14, 0x00, 0x00, // glType=GL_UNSIGNED_BYTE
+			0x01, 0x00, 0x00, 0x00, // glTypeSize=1
+			0x08, 0x19, 0x00, 0x00, // glFormat=GL_RGBA
+			0x51, 0x80, 0x00, 0x00, // glInternalFormat=GL_RGB8
+			0x07, 0x19, 0x00, 0x00, // glBaseInternalFormat=GL_RGB
+			0x01, 0x00, 0x00, 0x00, // width=1,
+			0x01, 0x00, 0x00, 0x00, // height=1,
+			0x00, 0x00, 0x00, 0x00, // depth=0,
+			0x00, 0x00, 0x00, 0x00, // numberOfArrayElements=0
+			0x01, 0x00, 0x00, 0x00, // numberOfFaces=1
+			0x01, 0x00, 0x00, 0x00, // numberOfMipmapLevels=1
+			0x00, 0x00, 0x00, 0x00, // numberOfKeyValuePairs=0,
+			0x04, 0x00, 0x00, 0x00, // imageSize=4,
+			0x5A, 0xA5, 0x2B, 0xB2, // imageData
+		},
+		err: "KTX reader: type-format combination [GL_UNSIGNED_BYTE GL_RGBA] invalid for internal format [GL_RGB8]",
+	},
+	{
+		// Rows of 3 pixels not padded to 4 bytes
+		input: ktxFile(3, 2, 0, 1, nil, [][]byte{
+			{6, 0, 0, 0, 1, 2, 3, 4, 5, 6, 0, 0},
+		}),
+		err: "KTX reader: invalid imageSize of level 0 [6 != 8]",
+	},
+	{
+		input: ktxFile(3, 2, 0, 1, nil, [][]byte{
+			{8, 0, 0, 0, 1, 2, 3, 0, 4, 5, 6, 0},
+			{1, 0, 0, 0, 7, 0, 0, 0},
+		}),
+		err: "KTX reader: invalid imageSize of level 1 [1 != 4]",
+	},
+	{
+		// 4 GB of key/value data, more than the file holds
+		input: setUint32(ktxFile(1, 1, 0, 1, nil, [][]byte{{4, 0, 0, 0, 0x42, 0, 0, 0}}), 60, 0xFFFFFFFF),
+		err:   "unexpected EOF",
+	},
+	{
+		input: setUint32(ktxFile(3, 2, 0, 1, nil, nil), 56, 0x7FFFFFFF),
+		err:   "KTX reader: too many levels [2147483647 > 2]",
+	},
+	{
+		input: ktxFile(1, 1, 0x40000000, 1, nil, [][]byte{{0, 0, 0, 0}}),
+		err:   "KTX reader: too many array elements [1073741824]",
+	},
+	{
+		// 1 GB of images announced, but not in the file
+		input: ktxFile(1, 1, 0x10000000, 1, nil, [][]byte{{0, 0, 0, 0x40}}),
+		err:   "unexpected EOF",
+	},
+}
+
+// setUint32 overwrites the little endian field of data at offset with v.
+func setUint32(data []byte, offset int, v uint32) []byte {
+	binary.LittleEndian.PutUint32(data[offset:], v)
+	return data
 }
 
 func TestDecodeError(t *testing.T) {
@@ -199,3 +308,137 @@ func TestDecodeError(t *testing.T) {
 	}
 
 }
+
+// ktxFile assembles a little endian KTX file of GL_LUMINANCE pixels from
+// the header fields, the key/value data and the data of each mipmap level.
+func ktxFile(width, height, layers, faces uint32, kvData []byte, levels [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	fields := []uint32{
+		0x04030201,
+		enum.GL_UNSIGNED_BYTE, 1, enum.GL_LUMINANCE, 0x8040, enum.GL_LUMINANCE,
+		width, height, 0,
+		layers, faces, uint32(len(levels)),
+		uint32(len(kvData)),
+	}
+	for _, f := range fields {
+		binary.Write(&buf, binary.LittleEndian, f)
+	}
+	buf.Write(kvData)
+	for _, level := range levels {
+		buf.Write(level)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeTexture(t *testing.T) {
+	tests := []struct {
+		input                 []byte
+		levels, layers, faces int
+		// first pixel of each image, in level, layer, face order
+		output []uint8
+	}{
+		// Mipmaps with rows and levels padded to 4 bytes
+		{
+			input: ktxFile(3, 2, 0, 1, nil, [][]byte{
+				{8, 0, 0, 0, 1, 2, 3, 0, 4, 5, 6, 0}, // imageSize=8, 3x2
+				{4, 0, 0, 0, 7, 0, 0, 0},             // imageSize=4, 1x1
+			}),
+			levels: 2, layers: 1, faces: 1,
+			output: []uint8{1, 7},
+		},
+		// Array of two layers
+		{
+			input: ktxFile(1, 1, 2, 1, nil, [][]byte{
+				{8, 0, 0, 0, 0x10, 0, 0, 0, 0x20, 0, 0, 0},
+			}),
+			levels: 1, layers: 2, faces: 1,
+			output: []uint8{0x10, 0x20},
+		},
+		// Cubemap with faces stored one by one
+		{
+			input: ktxFile(1, 1, 0, 6, nil, [][]byte{
+				{4, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 4, 0, 0, 0, 5, 0, 0, 0, 6, 0, 0, 0},
+			}),
+			levels: 1, layers: 1, faces: 6,
+			output: []uint8{1, 2, 3, 4, 5, 6},
+		},
+	}
+
+	for _, test := range tests {
+		tex, err := DecodeTexture(bytes.NewReader(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tex.Images) != test.levels {
+			t.Fatalf("Wrong number of levels : expected %v, got %v", test.levels, len(tex.Images))
+		}
+		i := 0
+		for level := 0; level < test.levels; level++ {
+			if len(tex.Images[level]) != test.layers {
+				t.Fatalf("Wrong number of layers : expected %v, got %v", test.layers, len(tex.Images[level]))
+			}
+			for layer := 0; layer < test.layers; layer++ {
+				if len(tex.Images[level][layer]) != test.faces {
+					t.Fatalf("Wrong number of faces : expected %v, got %v", test.faces, len(tex.Images[level][layer]))
+				}
+				for face := 0; face < test.faces; face++ {
+					im := tex.Image(level, layer, face)
+					w, h := tex.LevelSize(level)
+					if dims := image.Rect(0, 0, w, h); im.Bounds() != dims {
+						t.Errorf("Wrong image size : expected(%v) got(%v)", dims, im.Bounds())
+					}
+					if c := im.At(0, 0); c != (color.Gray{test.output[i]}) {
+						t.Errorf("Wrong pixel of image [%v %v %v] : expected %v, got %v", level, layer, face, test.output[i], c)
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	// Rows of the base level are read without their padding
+	tex, _ := DecodeTexture(bytes.NewReader(tests[0].input))
+	if c := tex.Image(0, 0, 0).At(0, 1); c != (color.Gray{4}) {
+		t.Errorf("Wrong pixel at [0 1] : expected %v, got %v", color.Gray{4}, c)
+	}
+}
+
+func TestDecodeTextureKeyValues(t *testing.T) {
+	kvData := []byte{
+		23, 0, 0, 0, // keyAndValueByteSize=23
+		'K', 'T', 'X', 'o', 'r', 'i', 'e', 'n', 't', 'a', 't', 'i', 'o', 'n', 0,
+		'S', '=', 'r', ',', 'T', '=', 'd', 0,
+		0,           // valuePadding
+		14, 0, 0, 0, // keyAndValueByteSize=14
+		'K', 'T', 'X', 'w', 'r', 'i', 't', 'e', 'r', 0,
+		'g', 'l', 'u', 0,
+		0, 0, // valuePadding
+	}
+	input := ktxFile(1, 1, 0, 1, kvData, [][]byte{{4, 0, 0, 0, 0x42, 0, 0, 0}})
+
+	tex, err := DecodeTexture(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := tex.Image(0, 0, 0).At(0, 0); c != (color.Gray{0x42}) {
+		t.Errorf("Wrong pixel at [0 0] : expected %v, got %v", color.Gray{0x42}, c)
+	}
+	if len(tex.KeyValues) != 2 {
+		t.Errorf("Wrong number of key/value pairs : expected 2, got %v", len(tex.KeyValues))
+	}
+	if o, ok := tex.KeyValues.Orientation(); !ok || o != (Orientation{'r', 'd', 0}) {
+		t.Errorf("Wrong orientation : got %v", o)
+	}
+	if w, ok := tex.KeyValues.Writer(); !ok || w != "glu" {
+		t.Errorf("Wrong writer : expected glu, got %v", w)
+	}
+
+	config, err := DecodeTextureConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := config.KeyValues.String(KeyOrientation); s != "S=r,T=d" {
+		t.Errorf("Wrong orientation : expected S=r,T=d, got %v", s)
+	}
+}
@@ -0,0 +1,384 @@
+package ktx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+
+	"github.com/hantempo/glu/enum"
+	glimage "github.com/hantempo/glu/image"
+)
+
+// Options are the encoding parameters.
+type Options struct {
+	// ByteOrder of the written file, little endian if nil.
+	ByteOrder binary.ByteOrder
+	// Mipmaps holds the images of the mipmap levels following the base level.
+	// It is only used by Encode.
+	Mipmaps []image.Image
+	// KeyValues holds the key/value pairs to write. It is only used by Encode.
+	KeyValues KeyValues
+}
+
+type encoder struct {
+	w       io.Writer
+	order   binary.ByteOrder
+	header  Header
+	tex     *Texture
+	err     error
+	padding [4]byte
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(b)
+}
+
+func (e *encoder) writeUint32(v uint32) {
+	var buf [4]byte
+	e.order.PutUint32(buf[:], v)
+	e.write(buf[:])
+}
+
+// astcFormat returns the ASTC internal format of the given block footprint,
+// or 0 if there is none.
+func astcFormat(blockWidth, blockHeight int, srgb bool) enum.InternalFormat {
+	first, last := enum.InternalFormat(enum.GL_COMPRESSED_RGBA_ASTC_4x4_KHR), enum.InternalFormat(enum.GL_COMPRESSED_RGBA_ASTC_12x12_KHR)
+	if srgb {
+		first, last = enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR, enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x12_KHR
+	}
+	for f := first; f <= last; f++ {
+		if info, ok := enum.InternalFormatInfo(f); ok && info.BlockWidth == blockWidth && info.BlockHeight == blockHeight {
+			return f
+		}
+	}
+	return 0
+}
+
+// setFormat fills the type and format fields of the header
+// from the concrete type of im.
+func (e *encoder) setFormat(im image.Image) error {
+	h := &e.header
+	switch m := im.(type) {
+	case *image.Gray:
+		h.GLType, h.GLTypeSize = enum.GL_UNSIGNED_BYTE, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = enum.GL_LUMINANCE, enum.GL_LUMINANCE8, enum.GL_LUMINANCE
+	case *glimage.RGB:
+		h.GLType, h.GLTypeSize = enum.GL_UNSIGNED_BYTE, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = enum.GL_RGB, enum.GL_RGB8, enum.GL_RGB
+	case *image.RGBA, *image.NRGBA:
+		h.GLType, h.GLTypeSize = enum.GL_UNSIGNED_BYTE, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = enum.GL_RGBA, enum.GL_RGBA8, enum.GL_RGBA
+	case *glimage.RGB565:
+		h.GLType, h.GLTypeSize = enum.GL_UNSIGNED_SHORT_5_6_5, 2
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = enum.GL_RGB, enum.GL_RGB565, enum.GL_RGB
+	case *glimage.NRGBA4444:
+		h.GLType, h.GLTypeSize = enum.GL_UNSIGNED_SHORT_4_4_4_4, 2
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = enum.GL_RGBA, enum.GL_RGBA4, enum.GL_RGBA
+	case *glimage.ETC1:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_ETC1_RGB8_OES, enum.GL_RGB
+	case *glimage.ETC2RGB8:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGB8_ETC2, enum.GL_RGB
+	case *glimage.ETC2RGB8A1:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, enum.GL_RGBA
+	case *glimage.ETC2RGBA8:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGBA8_ETC2_EAC, enum.GL_RGBA
+	case *glimage.EACR11:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_R11_EAC, enum.GL_RED
+	case *glimage.EACSignedR11:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_SIGNED_R11_EAC, enum.GL_RED
+	case *glimage.EACRG11:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RG11_EAC, enum.GL_RG
+	case *glimage.EACSignedRG11:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_SIGNED_RG11_EAC, enum.GL_RG
+	case *glimage.BC1:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGBA_S3TC_DXT1_EXT, enum.GL_RGBA
+	case *glimage.BC2:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGBA_S3TC_DXT3_EXT, enum.GL_RGBA
+	case *glimage.BC3:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGBA_S3TC_DXT5_EXT, enum.GL_RGBA
+	case *glimage.BC4:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RED_RGTC1, enum.GL_RED
+	case *glimage.BC4Signed:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_SIGNED_RED_RGTC1, enum.GL_RED
+	case *glimage.BC5:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RG_RGTC2, enum.GL_RG
+	case *glimage.BC5Signed:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_SIGNED_RG_RGTC2, enum.GL_RG
+	case *glimage.BC6H:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, enum.GL_RGB
+		if m.Signed {
+			h.GLInternalFormat = enum.GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT
+		}
+	case *glimage.BC7:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGBA_BPTC_UNORM, enum.GL_RGBA
+	case *glimage.PVRTC:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, enum.GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG, enum.GL_RGBA
+		if m.BitsPerPixel == 2 {
+			h.GLInternalFormat = enum.GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG
+		}
+	case *glimage.ASTC:
+		h.GLType, h.GLTypeSize = 0, 1
+		h.GLFormat, h.GLInternalFormat, h.GLBaseInternalFormat = 0, 0, enum.GL_RGBA
+		h.GLInternalFormat = astcFormat(m.BlockWidth, m.BlockHeight, m.SRGB)
+		if h.GLInternalFormat == 0 {
+			return fmt.Errorf("KTX writer: unsupported ASTC block footprint [%vx%v]", m.BlockWidth, m.BlockHeight)
+		}
+	default:
+		return fmt.Errorf("KTX writer: unsupported image type [%T]", im)
+	}
+	return nil
+}
+
+// imageData returns the bytes of im as stored in a KTX file,
+// including the padding of rows.
+func (e *encoder) imageData(im image.Image) []byte {
+	var (
+		pix    []byte
+		stride int
+		rowLen int
+	)
+	b := im.Bounds()
+	switch m := im.(type) {
+	case *image.Gray:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()
+	case *glimage.RGB:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*3
+	case *image.NRGBA:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*4
+	case *image.RGBA:
+		// GL expects colors which are not alpha-premultiplied
+		nrgba := image.NewNRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				nrgba.Set(x, y, color.NRGBAModel.Convert(m.At(x, y)))
+			}
+		}
+		pix, stride, rowLen = nrgba.Pix, nrgba.Stride, b.Dx()*4
+	case *glimage.RGB565:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*2
+	case *glimage.NRGBA4444:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*2
+	case *glimage.ETC1:
+		return m.Pix
+	case *glimage.ETC2RGB8:
+		return m.Pix
+	case *glimage.ETC2RGB8A1:
+		return m.Pix
+	case *glimage.ETC2RGBA8:
+		return m.Pix
+	case *glimage.EACR11:
+		return m.Pix
+	case *glimage.EACSignedR11:
+		return m.Pix
+	case *glimage.EACRG11:
+		return m.Pix
+	case *glimage.EACSignedRG11:
+		return m.Pix
+	case *glimage.BC1:
+		return m.Pix
+	case *glimage.BC2:
+		return m.Pix
+	case *glimage.BC3:
+		return m.Pix
+	case *glimage.BC4:
+		return m.Pix
+	case *glimage.BC4Signed:
+		return m.Pix
+	case *glimage.BC5:
+		return m.Pix
+	case *glimage.BC5Signed:
+		return m.Pix
+	case *glimage.BC6H:
+		return m.Pix
+	case *glimage.BC7:
+		return m.Pix
+	case *glimage.PVRTC:
+		return m.Pix
+	case *glimage.ASTC:
+		return m.Pix
+	}
+
+	rowSize := rowLen + padding(rowLen)
+	data := make([]byte, rowSize*b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		copy(data[y*rowSize:y*rowSize+rowLen], pix[y*stride:])
+	}
+	if e.order == binary.BigEndian {
+		for y := 0; y < b.Dy(); y++ {
+			swapBytes(data[y*rowSize:y*rowSize+rowLen], int(e.header.GLTypeSize))
+		}
+	}
+	return data
+}
+
+func (e *encoder) writeHeader() {
+	e.write([]byte(magic))
+	e.writeUint32(0x04030201)
+	h := &e.header
+	for _, f := range []uint32{
+		uint32(h.GLType), h.GLTypeSize, uint32(h.GLFormat),
+		uint32(h.GLInternalFormat), uint32(h.GLBaseInternalFormat),
+		h.PixelWidth, h.PixelHeight, h.PixelDepth,
+		h.NumberOfArrayElements, h.NumberOfFaces, h.NumberOfMipmapLevels,
+		h.BytesOfKeyValueData,
+	} {
+		e.writeUint32(f)
+	}
+}
+
+// encodeKeyValues returns the key/value data sorted by key,
+// with the padding of every value.
+func (e *encoder) encodeKeyValues(kv KeyValues) []byte {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	var size [4]byte
+	for _, k := range keys {
+		n := len(k) + 1 + len(kv[k])
+		e.order.PutUint32(size[:], uint32(n))
+		buf.Write(size[:])
+		buf.WriteString(k)
+		buf.WriteByte(0)
+		buf.Write(kv[k])
+		buf.Write(e.padding[:padding(n)])
+	}
+	return buf.Bytes()
+}
+
+func (e *encoder) encode() error {
+	t := e.tex
+	if len(t.Images) == 0 || len(t.Images[0]) == 0 || len(t.Images[0][0]) == 0 {
+		return fmt.Errorf("KTX writer: no image to write")
+	}
+	base := t.Images[0][0][0]
+	if err := e.setFormat(base); err != nil {
+		return err
+	}
+
+	h := &e.header
+	levels, layers, faces := len(t.Images), len(t.Images[0]), len(t.Images[0][0])
+	if faces != 1 && faces != 6 {
+		return fmt.Errorf("KTX writer: invalid number of faces [%v]", faces)
+	}
+	h.PixelWidth = uint32(base.Bounds().Dx())
+	h.PixelHeight = uint32(base.Bounds().Dy())
+	h.NumberOfFaces = uint32(faces)
+	h.NumberOfMipmapLevels = uint32(levels)
+	if layers > 1 || t.NumberOfArrayElements == 1 {
+		h.NumberOfArrayElements = uint32(layers)
+	}
+	// Only faces of non-array cubemaps are stored and padded one by one
+	isCubemap := faces == 6 && h.NumberOfArrayElements == 0
+
+	// Gather the data of every level before writing anything
+	levelData := make([][][]byte, levels)
+	for level := 0; level < levels; level++ {
+		if len(t.Images[level]) != layers {
+			return fmt.Errorf("KTX writer: wrong number of layers in level %v [%v != %v]", level, len(t.Images[level]), layers)
+		}
+		width, height := h.LevelSize(level)
+		for layer := 0; layer < layers; layer++ {
+			if len(t.Images[level][layer]) != faces {
+				return fmt.Errorf("KTX writer: wrong number of faces in level %v [%v != %v]", level, len(t.Images[level][layer]), faces)
+			}
+			for _, im := range t.Images[level][layer] {
+				if b := im.Bounds(); b.Dx() != width || b.Dy() != height {
+					return fmt.Errorf("KTX writer: wrong image size in level %v [%vx%v != %vx%v]", level, b.Dx(), b.Dy(), width, height)
+				}
+				// The same type may hold several formats, such as the
+				// footprints of ASTC
+				var f encoder
+				if err := f.setFormat(im); err != nil {
+					return err
+				}
+				if f.header.GLType != h.GLType || f.header.GLFormat != h.GLFormat || f.header.GLInternalFormat != h.GLInternalFormat {
+					return fmt.Errorf("KTX writer: mixed image formats in level %v [%v != %v]", level, f.header.GLInternalFormat, h.GLInternalFormat)
+				}
+				levelData[level] = append(levelData[level], e.imageData(im))
+			}
+		}
+	}
+
+	kvData := e.encodeKeyValues(t.KeyValues)
+	h.BytesOfKeyValueData = uint32(len(kvData))
+	e.writeHeader()
+	e.write(kvData)
+
+	for _, data := range levelData {
+		if isCubemap {
+			e.writeUint32(uint32(len(data[0])))
+			for _, face := range data {
+				e.write(face)
+				e.write(e.padding[:padding(len(face))])
+			}
+		} else {
+			imageSize := 0
+			for _, d := range data {
+				imageSize += len(d)
+			}
+			e.writeUint32(uint32(imageSize))
+			for _, d := range data {
+				e.write(d)
+			}
+			e.write(e.padding[:padding(imageSize)])
+		}
+	}
+	return e.err
+}
+
+// EncodeTexture writes every image of tex to w in KTX format. The type and
+// format fields of the header are decided by the type of the images.
+func EncodeTexture(w io.Writer, tex *Texture, opts *Options) error {
+	e := &encoder{w: w, order: binary.LittleEndian, tex: tex}
+	if opts != nil && opts.ByteOrder != nil {
+		e.order = opts.ByteOrder
+	}
+	return e.encode()
+}
+
+// Encode writes the image m to w in KTX format, followed by the mipmap levels
+// and the key/value pairs in opts. The type of m must be one of *image.Gray,
+// *image.RGBA, *image.NRGBA, *glimage.RGB, *glimage.RGB565,
+// *glimage.NRGBA4444, *glimage.ETC1, *glimage.ETC2RGB8, *glimage.ETC2RGB8A1,
+// *glimage.ETC2RGBA8, *glimage.ASTC, *glimage.BC1, *glimage.BC2,
+// *glimage.BC3, *glimage.BC6H, *glimage.BC7, *glimage.PVRTC, one of the BC4
+// and BC5 images or one of the EAC R11 and RG11 images.
+func Encode(w io.Writer, m image.Image, opts *Options) error {
+	tex := &Texture{Images: [][][]image.Image{{{m}}}}
+	if opts != nil {
+		for _, mipmap := range opts.Mipmaps {
+			tex.Images = append(tex.Images, [][]image.Image{{mipmap}})
+		}
+		tex.KeyValues = opts.KeyValues
+	}
+	return EncodeTexture(w, tex, opts)
+}
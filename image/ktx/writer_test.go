@@ -0,0 +1,219 @@
+package ktx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hantempo/glu/enum"
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+	"github.com/hantempo/glu/image/internal/imagetest"
+)
+
+func TestEncode(t *testing.T) {
+	r := image.Rect(0, 0, 3, 2)
+	etc1 := glimage.NewETC1(r)
+	copy(etc1.Pix, []byte{0xE8, 0xA0, 0x18, 0x03, 0x00, 0x0F, 0x00, 0x00})
+	etc2 := glimage.NewETC2RGB8(r)
+	copy(etc2.Pix, []byte{0x1C, 0x48, 0x26, 0xA7, 0xFF, 0x00, 0xF0, 0xF0})
+	etc2A1 := glimage.NewETC2RGB8A1(r)
+	copy(etc2A1.Pix, []byte{0x1C, 0x48, 0x26, 0xA5, 0xFF, 0x00, 0xF0, 0xF0})
+	r11 := glimage.NewEACSignedR11(r)
+	copy(r11.Pix, []byte{0x80, 0x1D, 0x05, 0x39, 0x77, 0x05, 0x39, 0x77})
+	rg11 := glimage.NewEACRG11(r)
+	copy(rg11.Pix, []byte{0x80, 0x2D, 0x05, 0x39, 0x77, 0x05, 0x39, 0x77, 0xFF, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	etc2RGBA := glimage.NewETC2RGBA8(r)
+	copy(etc2RGBA.Pix, []byte{0x80, 0x2D, 0x05, 0x39, 0x77, 0x05, 0x39, 0x77, 0x1C, 0x48, 0x26, 0xA7, 0xFF, 0x00, 0xF0, 0xF0})
+	bc1 := glimage.NewBC1(r)
+	copy(bc1.Pix, []byte{0x1F, 0x00, 0x00, 0xF8, 0xE4, 0xE4, 0xE4, 0xE4})
+	bc3 := glimage.NewBC3(r)
+	copy(bc3.Pix, []byte{0xFF, 0x00, 0x88, 0xC6, 0xFA, 0x88, 0xC6, 0xFA, 0x00, 0xF8, 0x1F, 0x00, 0xE4, 0xE4, 0xE4, 0xE4})
+	bc4 := glimage.NewBC4Signed(r)
+	copy(bc4.Pix, []byte{0x7F, 0x81, 0x88, 0xC6, 0xFA, 0x88, 0xC6, 0xFA})
+	bc5 := glimage.NewBC5(r)
+	copy(bc5.Pix, []byte{0xFF, 0x00, 0x88, 0xC6, 0xFA, 0x88, 0xC6, 0xFA, 0x00, 0xFF, 0x88, 0xC6, 0xFA, 0x88, 0xC6, 0xFA})
+	// Blocks of the mode 11 of BC6H and the mode 6 of BC7
+	bc6h := glimage.NewBC6H(r)
+	bc6h.Signed = true
+	copy(bc6h.Pix, []byte{0x03, 0x00, 0x00, 0x00, 0xD0, 0xFF, 0x1F, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE4, 0xE4})
+	bc7 := glimage.NewBC7(r)
+	copy(bc7.Pix, []byte{0x40, 0x80, 0x40, 0x20, 0xF0, 0xFF, 0xFF, 0x0F, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE4, 0xE4})
+	pvrtc := glimage.NewPVRTC(r, 2)
+	copy(pvrtc.Pix, []byte{0x03, 0x00, 0x10, 0x00, 0x01, 0xFC, 0x1F, 0x80, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0xFC, 0x1F, 0x80})
+	astc := glimage.NewASTC(r, 6, 5)
+	astc.SRGB = true
+	copy(astc.Pix, []byte{0x42, 0x00, 0x15, 0x90, 0x29, 0x2C, 0x3D, 0xC8, 0x00, 0x00, 0x00, 0x00, 0x27, 0x27, 0x27, 0x27})
+	tests := []struct {
+		im            image.Image
+		model         color.Model
+		glType        enum.Type
+		glInternalFmt enum.InternalFormat
+	}{
+		{imagetest.FillImage(image.NewGray(r)), color.GrayModel, 0x1401, 0x8040},
+		{imagetest.FillImage(glimage.NewRGB(r)), glcolor.RGBModel, 0x1401, 0x8051},
+		{imagetest.FillImage(image.NewNRGBA(r)), color.NRGBAModel, 0x1401, 0x8058},
+		{imagetest.FillImage(image.NewRGBA(r)), color.NRGBAModel, 0x1401, 0x8058},
+		{imagetest.FillImage(glimage.NewRGB565(r)), glcolor.RGB565Model, 0x8363, 0x8D62},
+		{imagetest.FillImage(glimage.NewNRGBA4444(r)), glcolor.NRGBA4444Model, 0x8033, 0x8056},
+		{etc1, glcolor.RGBModel, 0, 0x8D64},
+		{etc2, glcolor.RGBModel, 0, 0x9274},
+		{etc2A1, color.NRGBAModel, 0, 0x9276},
+		{etc2RGBA, color.NRGBAModel, 0, 0x9278},
+		{r11, glcolor.SignedR16Model, 0, 0x9271},
+		{rg11, glcolor.RG16Model, 0, 0x9272},
+		{bc1, color.NRGBAModel, 0, 0x83F1},
+		{bc3, color.NRGBAModel, 0, 0x83F3},
+		{bc4, glcolor.SignedR16Model, 0, 0x8DBC},
+		{bc5, glcolor.RG16Model, 0, 0x8DBD},
+		{bc6h, glcolor.NRGBAF32Model, 0, 0x8E8E},
+		{bc7, color.NRGBAModel, 0, 0x8E8C},
+		{pvrtc, color.NRGBAModel, 0, 0x8C03},
+		{astc, color.NRGBAModel, 0, 0x93D3},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, test := range tests {
+			var buf bytes.Buffer
+			if err := Encode(&buf, test.im, &Options{ByteOrder: order}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len()%4 != 0 {
+				t.Errorf("%T: file size not aligned to 4 bytes [%v]", test.im, buf.Len())
+			}
+
+			config, err := DecodeTextureConfig(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.GLType != test.glType || config.GLInternalFormat != test.glInternalFmt {
+				t.Errorf("%T: wrong header : type 0x%X internal format 0x%X", test.im, config.GLType, config.GLInternalFormat)
+			}
+
+			im, _, err := image.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if im.ColorModel() != test.model {
+				t.Errorf("%T: wrong color model of decoded image %T", test.im, im)
+			}
+			if !imagetest.SameImage(test.im, im) {
+				t.Errorf("%T: decoded image differs in %v", test.im, order)
+			}
+		}
+	}
+}
+
+func TestEncodeMipmapsAndKeyValues(t *testing.T) {
+	kv := make(KeyValues)
+	kv.SetString(KeyWriter, "glu")
+	kv.SetOrientation(Orientation{'r', 'u', 0})
+	opts := &Options{
+		Mipmaps: []image.Image{
+			imagetest.FillImage(glimage.NewRGB(image.Rect(0, 0, 2, 1))),
+			imagetest.FillImage(glimage.NewRGB(image.Rect(0, 0, 1, 1))),
+		},
+		KeyValues: kv,
+	}
+	base := imagetest.FillImage(glimage.NewRGB(image.Rect(0, 0, 5, 3)))
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, base, opts); err != nil {
+		t.Fatal(err)
+	}
+	tex, err := DecodeTexture(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tex.KeyValues, kv) {
+		t.Errorf("Wrong key/value pairs : expected %v, got %v", kv, tex.KeyValues)
+	}
+	levels := append([]image.Image{base}, opts.Mipmaps...)
+	if len(tex.Images) != len(levels) {
+		t.Fatalf("Wrong number of levels : expected %v, got %v", len(levels), len(tex.Images))
+	}
+	for i, im := range levels {
+		if !imagetest.SameImage(im, tex.Image(i, 0, 0)) {
+			t.Errorf("Level %v differs", i)
+		}
+	}
+}
+
+func TestEncodeTexture(t *testing.T) {
+	newFace := func(v uint8) image.Image {
+		im := image.NewGray(image.Rect(0, 0, 2, 2))
+		for i := range im.Pix {
+			im.Pix[i] = v
+		}
+		return im
+	}
+	cubemap := &Texture{Images: [][][]image.Image{{{
+		newFace(1), newFace(2), newFace(3), newFace(4), newFace(5), newFace(6),
+	}}}}
+	array := &Texture{Images: [][][]image.Image{
+		{{newFace(1)}, {newFace(2)}, {newFace(3)}},
+	}}
+
+	for _, tex := range []*Texture{cubemap, array} {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			var buf bytes.Buffer
+			if err := EncodeTexture(&buf, tex, &Options{ByteOrder: order}); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeTexture(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decoded.Images[0]) != len(tex.Images[0]) || len(decoded.Images[0][0]) != len(tex.Images[0][0]) {
+				t.Fatalf("Wrong layout : expected %vx%v, got %vx%v", len(tex.Images[0]), len(tex.Images[0][0]), len(decoded.Images[0]), len(decoded.Images[0][0]))
+			}
+			for layer := range tex.Images[0] {
+				for face := range tex.Images[0][layer] {
+					if !imagetest.SameImage(tex.Image(0, layer, face), decoded.Image(0, layer, face)) {
+						t.Errorf("Image [%v %v] differs", layer, face)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	signedBC6H := glimage.NewBC6H(image.Rect(0, 0, 4, 4))
+	signedBC6H.Signed = true
+	tests := []struct {
+		tex *Texture
+		err string
+	}{
+		{&Texture{}, "KTX writer: no image to write"},
+		{&Texture{Images: [][][]image.Image{{{image.NewCMYK(image.Rect(0, 0, 1, 1))}}}}, "KTX writer: unsupported image type"},
+		{&Texture{Images: [][][]image.Image{
+			{{image.NewGray(image.Rect(0, 0, 2, 2))}},
+			{{image.NewGray(image.Rect(0, 0, 2, 2))}},
+		}}, "KTX writer: wrong image size in level 1"},
+		{&Texture{Images: [][][]image.Image{
+			{{image.NewGray(image.Rect(0, 0, 1, 1)), glimage.NewRGB(image.Rect(0, 0, 1, 1))}},
+		}}, "KTX writer: invalid number of faces"},
+		{&Texture{Images: [][][]image.Image{
+			{{glimage.NewASTC(image.Rect(0, 0, 8, 8), 4, 4)}},
+			{{glimage.NewASTC(image.Rect(0, 0, 4, 4), 6, 6)}},
+		}}, "KTX writer: mixed image formats in level 1"},
+		{&Texture{Images: [][][]image.Image{
+			{{glimage.NewBC6H(image.Rect(0, 0, 8, 8))}},
+			{{signedBC6H}},
+		}}, "KTX writer: mixed image formats in level 1"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := EncodeTexture(&buf, test.tex, nil)
+		if err == nil {
+			t.Errorf("Expected pattern of error message (%s), got no error", test.err)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%s)", test.err, err.Error())
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package ktx2
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Color models of the basic descriptor block
+const (
+	ModelUnspecified = 0
+	ModelRGBSDA      = 1
+	ModelYUVSDA      = 2
+	ModelBC1A        = 128
+	ModelBC2         = 129
+	ModelBC3         = 130
+	ModelBC4         = 131
+	ModelBC5         = 132
+	ModelBC6H        = 133
+	ModelBC7         = 134
+	ModelETC1        = 160
+	ModelETC2        = 161
+	ModelASTC        = 162
+	ModelETC1S       = 163
+	ModelPVRTC       = 164
+	ModelPVRTC2      = 165
+)
+
+// Color primaries of the basic descriptor block
+const (
+	PrimariesUnspecified = 0
+	PrimariesBT709       = 1
+)
+
+// Transfer functions of the basic descriptor block
+const (
+	TransferUnspecified = 0
+	TransferLinear      = 1
+	TransferSRGB        = 2
+)
+
+// Flags of the basic descriptor block
+const (
+	FlagAlphaStraight      = 0
+	FlagAlphaPremultiplied = 1
+)
+
+// Channel identifiers of the samples
+const (
+	ChannelRGBSDARed   = 0
+	ChannelRGBSDAGreen = 1
+	ChannelRGBSDABlue  = 2
+	ChannelRGBSDAAlpha = 15
+
+	ChannelBC1AColor        = 0
+	ChannelBC1AAlphaPresent = 1
+
+	ChannelBC2Color = 0
+	ChannelBC2Alpha = 15
+
+	ChannelBC3Color = 0
+	ChannelBC3Alpha = 15
+
+	ChannelBC4Data = 0
+
+	ChannelBC5Red   = 0
+	ChannelBC5Green = 1
+
+	ChannelBC6HColor = 0
+
+	ChannelBC7Color = 0
+
+	ChannelETC1Color = 0
+
+	ChannelETC2Red   = 0
+	ChannelETC2Green = 1
+	ChannelETC2Color = 2
+	ChannelETC2Alpha = 15
+
+	ChannelASTCData = 0
+)
+
+// Qualifiers of the channel type of the samples
+const (
+	QualifierLinear   = 0x10
+	QualifierExponent = 0x20
+	QualifierSigned   = 0x40
+	QualifierFloat    = 0x80
+)
+
+const (
+	basicBlockHeaderSize = 24
+	sampleSize           = 16
+)
+
+// Sample describes one sample of the basic descriptor block.
+type Sample struct {
+	BitOffset uint16
+	// BitLength is the number of bits of the sample, not the stored value
+	// which is one less.
+	BitLength uint8
+	// ChannelType holds the channel identifier in the low 4 bits
+	// and the qualifiers in the high 4 bits.
+	ChannelType    uint8
+	SamplePosition [4]uint8
+	SampleLower    uint32
+	SampleUpper    uint32
+}
+
+// Channel returns the channel identifier of the sample.
+func (s *Sample) Channel() uint8 {
+	return s.ChannelType & 0x0F
+}
+
+// DataFormatDescriptor is the basic descriptor block of the data format
+// descriptor of a KTX2 file. Other descriptor blocks are ignored.
+type DataFormatDescriptor struct {
+	VendorID         uint32
+	DescriptorType   uint32
+	VersionNumber    uint16
+	ColorModel       uint8
+	ColorPrimaries   uint8
+	TransferFunction uint8
+	Flags            uint8
+	// TexelBlockDimension holds the dimensions of a texel block,
+	// not the stored values which are one less.
+	TexelBlockDimension [4]uint8
+	BytesPlane          [8]uint8
+	Samples             []Sample
+}
+
+// decodeDFD parses the data format descriptor, which starts with its total size.
+func decodeDFD(data []byte) (*DataFormatDescriptor, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("KTX2 reader: truncated data format descriptor")
+	}
+	totalSize := int(binary.LittleEndian.Uint32(data))
+	if totalSize > len(data) {
+		return nil, fmt.Errorf("KTX2 reader: invalid data format descriptor size [%v > %v]", totalSize, len(data))
+	}
+	data = data[4:totalSize]
+
+	for len(data) >= basicBlockHeaderSize {
+		word0 := binary.LittleEndian.Uint32(data[0:])
+		word1 := binary.LittleEndian.Uint32(data[4:])
+		blockSize := int(word1 >> 16)
+		if blockSize < 8 || blockSize > len(data) {
+			return nil, fmt.Errorf("KTX2 reader: invalid descriptor block size [%v]", blockSize)
+		}
+		vendorID, descriptorType := word0&0x1FFFF, word0>>17
+		if vendorID != 0 || descriptorType != 0 {
+			data = data[blockSize:]
+			continue
+		}
+		if blockSize < basicBlockHeaderSize || (blockSize-basicBlockHeaderSize)%sampleSize != 0 {
+			return nil, fmt.Errorf("KTX2 reader: invalid basic descriptor block size [%v]", blockSize)
+		}
+
+		dfd := &DataFormatDescriptor{
+			VendorID:         vendorID,
+			DescriptorType:   descriptorType,
+			VersionNumber:    uint16(word1),
+			ColorModel:       data[8],
+			ColorPrimaries:   data[9],
+			TransferFunction: data[10],
+			Flags:            data[11],
+		}
+		for i := range dfd.TexelBlockDimension {
+			dfd.TexelBlockDimension[i] = data[12+i] + 1
+		}
+		copy(dfd.BytesPlane[:], data[16:24])
+		for s := data[basicBlockHeaderSize:blockSize]; len(s) > 0; s = s[sampleSize:] {
+			sample := Sample{
+				BitOffset:   binary.LittleEndian.Uint16(s[0:]),
+				BitLength:   s[2] + 1,
+				ChannelType: s[3],
+				SampleLower: binary.LittleEndian.Uint32(s[8:]),
+				SampleUpper: binary.LittleEndian.Uint32(s[12:]),
+			}
+			copy(sample.SamplePosition[:], s[4:8])
+			dfd.Samples = append(dfd.Samples, sample)
+		}
+		return dfd, nil
+	}
+	return nil, fmt.Errorf("KTX2 reader: no basic descriptor block")
+}
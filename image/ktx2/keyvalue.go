@@ -0,0 +1,79 @@
+package ktx2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Standard keys of the key/value data defined by the KTX2 specification.
+const (
+	KeyOrientation = "KTXorientation"
+	KeyWriter      = "KTXwriter"
+)
+
+// KeyValues holds the key/value pairs stored in a KTX2 file. Values are kept
+// as they are in the file, including the NUL terminator of string values.
+type KeyValues map[string][]byte
+
+// String returns the value of key as a string, without its NUL terminator.
+func (kv KeyValues) String(key string) (string, bool) {
+	v, ok := kv[key]
+	if !ok {
+		return "", false
+	}
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		v = v[:i]
+	}
+	return string(v), true
+}
+
+// SetString sets the value of key to s followed by a NUL terminator.
+func (kv KeyValues) SetString(key, s string) {
+	kv[key] = append([]byte(s), 0)
+}
+
+// Writer returns the name of the tool which wrote the file.
+func (kv KeyValues) Writer() (string, bool) {
+	return kv.String(KeyWriter)
+}
+
+// Orientation returns the logical orientation of the texture, like "rd",
+// one letter per dimension.
+func (kv KeyValues) Orientation() (string, bool) {
+	return kv.String(KeyOrientation)
+}
+
+// padding returns how many bytes are needed to align n to 4 bytes.
+func padding(n int) int {
+	return 3 - ((n + 3) % 4)
+}
+
+// decodeKeyValues parses the key/value data of a KTX2 file.
+func decodeKeyValues(data []byte) (KeyValues, error) {
+	kv := make(KeyValues)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("KTX2 reader: truncated key/value data")
+		}
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if size > len(data) {
+			return nil, fmt.Errorf("KTX2 reader: key/value pair too large [%v > %v]", size, len(data))
+		}
+		pair := data[:size]
+		i := bytes.IndexByte(pair, 0)
+		if i < 0 {
+			return nil, fmt.Errorf("KTX2 reader: key without NUL terminator [%q]", pair)
+		}
+		kv[string(pair[:i])] = append([]byte(nil), pair[i+1:]...)
+
+		// valuePadding
+		size += padding(size)
+		if size > len(data) {
+			size = len(data)
+		}
+		data = data[size:]
+	}
+	return kv, nil
+}
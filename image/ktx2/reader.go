@@ -0,0 +1,587 @@
+package ktx2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/hantempo/glu/enum"
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+	"github.com/hantempo/glu/internal/zstd"
+)
+
+const magic = "\xABKTX 20\xBB\r\n\x1A\n"
+
+// Supercompression schemes
+const (
+	SupercompressionNone    = 0
+	SupercompressionBasisLZ = 1
+	SupercompressionZstd    = 2
+	SupercompressionZLIB    = 3
+)
+
+const (
+	// Size of the identifier, the header and the index
+	headerSize = 80
+	// Size of one entry of the level index
+	levelIndexSize = 24
+	// Enough levels for textures of 2^31 pixels wide
+	maxLevels = 32
+)
+
+// Header holds the fields of a KTX2 file header which follow the identifier.
+type Header struct {
+	VkFormat               uint32
+	TypeSize               uint32
+	PixelWidth             uint32
+	PixelHeight            uint32
+	PixelDepth             uint32
+	LayerCount             uint32
+	FaceCount              uint32
+	LevelCount             uint32
+	SupercompressionScheme uint32
+}
+
+// Levels returns the number of mipmap levels stored in the file.
+func (h *Header) Levels() int {
+	if h.LevelCount == 0 {
+		return 1
+	}
+	return int(h.LevelCount)
+}
+
+// Layers returns the number of array layers stored in the file,
+// which is 1 for non-array textures.
+func (h *Header) Layers() int {
+	if h.LayerCount == 0 {
+		return 1
+	}
+	return int(h.LayerCount)
+}
+
+// Faces returns the number of faces stored in the file,
+// which is 6 for cubemaps and 1 otherwise.
+func (h *Header) Faces() int {
+	return int(h.FaceCount)
+}
+
+// LevelSize returns the width and height of the given mipmap level.
+func (h *Header) LevelSize(level int) (width, height int) {
+	width = int(h.PixelWidth) >> uint(level)
+	height = int(h.PixelHeight) >> uint(level)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return
+}
+
+// Config holds the header, the data format descriptor and
+// the key/value data of a KTX2 file.
+type Config struct {
+	Header
+	DFD       *DataFormatDescriptor
+	KeyValues KeyValues
+}
+
+// GLFormat returns the GL internal format, format and type of the texture.
+// Format and type are GL_NONE for compressed formats.
+func (c *Config) GLFormat() (internalFormat enum.InternalFormat, format enum.Format, typ enum.Type, ok bool) {
+	if c.isETC1() {
+		return enum.GL_ETC1_RGB8_OES, 0, 0, true
+	}
+	return GLFormat(c.VkFormat)
+}
+
+// ASTCProfile returns the profile which decodes the ASTC blocks of
+// the texture, or false if it is not in an ASTC format.
+func (c *Config) ASTCProfile() (glimage.ASTCProfile, bool) {
+	if _, ok := astcHDRFormats[c.VkFormat]; ok {
+		return glimage.ASTCProfileHDR, true
+	}
+	if c.VkFormat >= VK_FORMAT_ASTC_4x4_UNORM_BLOCK && c.VkFormat <= VK_FORMAT_ASTC_12x12_SRGB_BLOCK {
+		return glimage.ASTCProfileLDR, true
+	}
+	return glimage.ASTCProfileLDR, false
+}
+
+// isETC1 tells whether the texture holds ETC1 data, which is stored as
+// its superset ETC2 with the ETC1 color model.
+func (c *Config) isETC1() bool {
+	return c.VkFormat == VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK && c.DFD != nil && c.DFD.ColorModel == ModelETC1
+}
+
+// Texture holds every image stored in a KTX2 file.
+type Texture struct {
+	Config
+	// Images is indexed by mipmap level, array layer and cube face,
+	// in this order.
+	Images [][][]image.Image
+}
+
+// Image returns the image of the given mipmap level, array layer and cube face.
+func (t *Texture) Image(level, layer, face int) image.Image {
+	return t.Images[level][layer][face]
+}
+
+// format describes how the images of a Vulkan format are decoded.
+type format struct {
+	model color.Model
+	// newImage allocates an image and returns it with its pixel buffer,
+	// which is laid out as in a KTX2 file.
+	newImage func(r image.Rectangle) (image.Image, []byte)
+}
+
+var formats = map[uint32]format{
+	VK_FORMAT_R8_UNORM: {color.GrayModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := image.NewGray(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_R4G4B4A4_UNORM_PACK16: {glcolor.NRGBA4444Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewNRGBA4444(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_R5G6B5_UNORM_PACK16: {glcolor.RGB565Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewRGB565(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_R8G8B8_UNORM: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewRGB(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_R8G8B8_SRGB: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewRGB(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_R8G8B8A8_UNORM: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := image.NewNRGBA(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_R8G8B8A8_SRGB: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := image.NewNRGBA(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC1_RGB_UNORM_BLOCK:  {color.NRGBAModel, newBC1},
+	VK_FORMAT_BC1_RGB_SRGB_BLOCK:   {color.NRGBAModel, newBC1},
+	VK_FORMAT_BC1_RGBA_UNORM_BLOCK: {color.NRGBAModel, newBC1},
+	VK_FORMAT_BC1_RGBA_SRGB_BLOCK:  {color.NRGBAModel, newBC1},
+	VK_FORMAT_BC2_UNORM_BLOCK:      {color.NRGBAModel, newBC2},
+	VK_FORMAT_BC2_SRGB_BLOCK:       {color.NRGBAModel, newBC2},
+	VK_FORMAT_BC3_UNORM_BLOCK:      {color.NRGBAModel, newBC3},
+	VK_FORMAT_BC3_SRGB_BLOCK:       {color.NRGBAModel, newBC3},
+	VK_FORMAT_BC4_UNORM_BLOCK: {glcolor.R16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC4(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC4_SNORM_BLOCK: {glcolor.SignedR16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC4Signed(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC5_UNORM_BLOCK: {glcolor.RG16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC5(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC5_SNORM_BLOCK: {glcolor.SignedRG16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC5Signed(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC6H_UFLOAT_BLOCK: {glcolor.NRGBAF32Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC6H(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC6H_SFLOAT_BLOCK: {glcolor.NRGBAF32Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC6H(r)
+		m.Signed = true
+		return m, m.Pix
+	}},
+	VK_FORMAT_BC7_UNORM_BLOCK: {color.NRGBAModel, newBC7},
+	VK_FORMAT_BC7_SRGB_BLOCK:  {color.NRGBAModel, newBC7},
+	VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGB8(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGB8(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGB8A1(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_ETC2_R8G8B8A1_SRGB_BLOCK: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGB8A1(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGBA8(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGBA8(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_EAC_R11_UNORM_BLOCK: {glcolor.R16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACR11(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_EAC_R11_SNORM_BLOCK: {glcolor.SignedR16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACSignedR11(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_EAC_R11G11_UNORM_BLOCK: {glcolor.RG16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACRG11(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_EAC_R11G11_SNORM_BLOCK: {glcolor.SignedRG16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACSignedRG11(r)
+		return m, m.Pix
+	}},
+	VK_FORMAT_ASTC_4x4_UNORM_BLOCK:    astcFormat(4, 4, false),
+	VK_FORMAT_ASTC_4x4_SRGB_BLOCK:     astcFormat(4, 4, true),
+	VK_FORMAT_ASTC_5x4_UNORM_BLOCK:    astcFormat(5, 4, false),
+	VK_FORMAT_ASTC_5x4_SRGB_BLOCK:     astcFormat(5, 4, true),
+	VK_FORMAT_ASTC_5x5_UNORM_BLOCK:    astcFormat(5, 5, false),
+	VK_FORMAT_ASTC_5x5_SRGB_BLOCK:     astcFormat(5, 5, true),
+	VK_FORMAT_ASTC_6x5_UNORM_BLOCK:    astcFormat(6, 5, false),
+	VK_FORMAT_ASTC_6x5_SRGB_BLOCK:     astcFormat(6, 5, true),
+	VK_FORMAT_ASTC_6x6_UNORM_BLOCK:    astcFormat(6, 6, false),
+	VK_FORMAT_ASTC_6x6_SRGB_BLOCK:     astcFormat(6, 6, true),
+	VK_FORMAT_ASTC_8x5_UNORM_BLOCK:    astcFormat(8, 5, false),
+	VK_FORMAT_ASTC_8x5_SRGB_BLOCK:     astcFormat(8, 5, true),
+	VK_FORMAT_ASTC_8x6_UNORM_BLOCK:    astcFormat(8, 6, false),
+	VK_FORMAT_ASTC_8x6_SRGB_BLOCK:     astcFormat(8, 6, true),
+	VK_FORMAT_ASTC_8x8_UNORM_BLOCK:    astcFormat(8, 8, false),
+	VK_FORMAT_ASTC_8x8_SRGB_BLOCK:     astcFormat(8, 8, true),
+	VK_FORMAT_ASTC_10x5_UNORM_BLOCK:   astcFormat(10, 5, false),
+	VK_FORMAT_ASTC_10x5_SRGB_BLOCK:    astcFormat(10, 5, true),
+	VK_FORMAT_ASTC_10x6_UNORM_BLOCK:   astcFormat(10, 6, false),
+	VK_FORMAT_ASTC_10x6_SRGB_BLOCK:    astcFormat(10, 6, true),
+	VK_FORMAT_ASTC_10x8_UNORM_BLOCK:   astcFormat(10, 8, false),
+	VK_FORMAT_ASTC_10x8_SRGB_BLOCK:    astcFormat(10, 8, true),
+	VK_FORMAT_ASTC_10x10_UNORM_BLOCK:  astcFormat(10, 10, false),
+	VK_FORMAT_ASTC_10x10_SRGB_BLOCK:   astcFormat(10, 10, true),
+	VK_FORMAT_ASTC_12x10_UNORM_BLOCK:  astcFormat(12, 10, false),
+	VK_FORMAT_ASTC_12x10_SRGB_BLOCK:   astcFormat(12, 10, true),
+	VK_FORMAT_ASTC_12x12_UNORM_BLOCK:  astcFormat(12, 12, false),
+	VK_FORMAT_ASTC_12x12_SRGB_BLOCK:   astcFormat(12, 12, true),
+	VK_FORMAT_ASTC_4x4_SFLOAT_BLOCK:   astcHDRFormat(4, 4),
+	VK_FORMAT_ASTC_5x4_SFLOAT_BLOCK:   astcHDRFormat(5, 4),
+	VK_FORMAT_ASTC_5x5_SFLOAT_BLOCK:   astcHDRFormat(5, 5),
+	VK_FORMAT_ASTC_6x5_SFLOAT_BLOCK:   astcHDRFormat(6, 5),
+	VK_FORMAT_ASTC_6x6_SFLOAT_BLOCK:   astcHDRFormat(6, 6),
+	VK_FORMAT_ASTC_8x5_SFLOAT_BLOCK:   astcHDRFormat(8, 5),
+	VK_FORMAT_ASTC_8x6_SFLOAT_BLOCK:   astcHDRFormat(8, 6),
+	VK_FORMAT_ASTC_8x8_SFLOAT_BLOCK:   astcHDRFormat(8, 8),
+	VK_FORMAT_ASTC_10x5_SFLOAT_BLOCK:  astcHDRFormat(10, 5),
+	VK_FORMAT_ASTC_10x6_SFLOAT_BLOCK:  astcHDRFormat(10, 6),
+	VK_FORMAT_ASTC_10x8_SFLOAT_BLOCK:  astcHDRFormat(10, 8),
+	VK_FORMAT_ASTC_10x10_SFLOAT_BLOCK: astcHDRFormat(10, 10),
+	VK_FORMAT_ASTC_12x10_SFLOAT_BLOCK: astcHDRFormat(12, 10),
+	VK_FORMAT_ASTC_12x12_SFLOAT_BLOCK: astcHDRFormat(12, 12),
+}
+
+// astcFormat returns the format of the ASTC blocks of the given footprint.
+func astcFormat(blockWidth, blockHeight int, srgb bool) format {
+	return format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewASTC(r, blockWidth, blockHeight)
+		m.SRGB = srgb
+		return m, m.Pix
+	}}
+}
+
+// astcHDRFormat returns the format of the ASTC blocks of the given footprint
+// decoded with the HDR profile.
+func astcHDRFormat(blockWidth, blockHeight int) format {
+	return format{glcolor.NRGBAF32Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewASTC(r, blockWidth, blockHeight)
+		m.Profile = glimage.ASTCProfileHDR
+		return m, m.Pix
+	}}
+}
+
+func newBC1(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC1(r)
+	return m, m.Pix
+}
+
+func newBC2(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC2(r)
+	return m, m.Pix
+}
+
+func newBC3(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC3(r)
+	return m, m.Pix
+}
+
+func newBC7(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC7(r)
+	return m, m.Pix
+}
+
+var etc1Format = format{glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewETC1(r)
+	return m, m.Pix
+}}
+
+type levelIndex struct {
+	byteOffset             uint64
+	byteLength             uint64
+	uncompressedByteLength uint64
+}
+
+type decoder struct {
+	r      io.Reader
+	buf    []byte
+	config Config
+	levels []levelIndex
+	format format
+	tex    *Texture
+}
+
+// readTo makes sure the first n bytes of the file are in d.buf.
+func (d *decoder) readTo(n uint64) error {
+	if n <= uint64(len(d.buf)) {
+		return nil
+	}
+	if n > 1<<32 {
+		return fmt.Errorf("KTX2 reader: invalid offset [%v]", n)
+	}
+	buf := make([]byte, n)
+	copy(buf, d.buf)
+	if _, err := io.ReadFull(d.r, buf[len(d.buf):]); err != nil {
+		return err
+	}
+	d.buf = buf
+	return nil
+}
+
+func (d *decoder) decode(r io.Reader, configOnly bool) error {
+	d.r = r
+	if err := d.decodeHeader(); err != nil {
+		return err
+	}
+	if err := d.checkFormat(); err != nil {
+		return err
+	}
+	if configOnly {
+		return nil
+	}
+	return d.decodeImages()
+}
+
+func (d *decoder) decodeHeader() error {
+	if err := d.readTo(uint64(len(magic))); err != nil {
+		return err
+	}
+	if magic != string(d.buf) {
+		return fmt.Errorf("KTX2 reader: invalid identifier [%v]", d.buf)
+	}
+	if err := d.readTo(headerSize); err != nil {
+		return err
+	}
+
+	h := &d.config.Header
+	fields := []*uint32{
+		&h.VkFormat, &h.TypeSize,
+		&h.PixelWidth, &h.PixelHeight, &h.PixelDepth,
+		&h.LayerCount, &h.FaceCount, &h.LevelCount,
+		&h.SupercompressionScheme,
+	}
+	for i, f := range fields {
+		*f = binary.LittleEndian.Uint32(d.buf[12+i*4:])
+	}
+	dfdByteOffset := binary.LittleEndian.Uint32(d.buf[48:])
+	dfdByteLength := binary.LittleEndian.Uint32(d.buf[52:])
+	kvdByteOffset := binary.LittleEndian.Uint32(d.buf[56:])
+	kvdByteLength := binary.LittleEndian.Uint32(d.buf[60:])
+
+	if h.PixelWidth == 0 {
+		return fmt.Errorf("KTX2 reader: invalid width [%v]", h.PixelWidth)
+	}
+	if h.PixelDepth > 1 {
+		return fmt.Errorf("KTX2 reader: 3D textures are not supported [depth=%v]", h.PixelDepth)
+	}
+	if h.FaceCount != 1 && h.FaceCount != 6 {
+		return fmt.Errorf("KTX2 reader: invalid number of faces [%v]", h.FaceCount)
+	}
+	if h.Levels() > maxLevels {
+		return fmt.Errorf("KTX2 reader: too many levels [%v]", h.LevelCount)
+	}
+
+	// Level index
+	levels := h.Levels()
+	if err := d.readTo(headerSize + uint64(levels)*levelIndexSize); err != nil {
+		return err
+	}
+	d.levels = make([]levelIndex, levels)
+	for i := range d.levels {
+		b := d.buf[headerSize+i*levelIndexSize:]
+		d.levels[i] = levelIndex{
+			byteOffset:             binary.LittleEndian.Uint64(b[0:]),
+			byteLength:             binary.LittleEndian.Uint64(b[8:]),
+			uncompressedByteLength: binary.LittleEndian.Uint64(b[16:]),
+		}
+	}
+
+	// Data format descriptor
+	if dfdByteLength > 0 {
+		end := uint64(dfdByteOffset) + uint64(dfdByteLength)
+		if err := d.readTo(end); err != nil {
+			return err
+		}
+		dfd, err := decodeDFD(d.buf[dfdByteOffset:end])
+		if err != nil {
+			return err
+		}
+		d.config.DFD = dfd
+	}
+
+	// Key/value data
+	d.config.KeyValues = make(KeyValues)
+	if kvdByteLength > 0 {
+		end := uint64(kvdByteOffset) + uint64(kvdByteLength)
+		if err := d.readTo(end); err != nil {
+			return err
+		}
+		kv, err := decodeKeyValues(d.buf[kvdByteOffset:end])
+		if err != nil {
+			return err
+		}
+		d.config.KeyValues = kv
+	}
+	return nil
+}
+
+// checkFormat makes sure the images can be decoded and decides their format.
+func (d *decoder) checkFormat() error {
+	c := &d.config
+	if c.isETC1() {
+		d.format = etc1Format
+	} else if f, ok := formats[c.VkFormat]; ok {
+		d.format = f
+	} else {
+		return fmt.Errorf("KTX2 reader: unsupported format [%v]", c.VkFormat)
+	}
+	switch c.SupercompressionScheme {
+	case SupercompressionNone, SupercompressionZstd, SupercompressionZLIB:
+	default:
+		return fmt.Errorf("KTX2 reader: unsupported supercompression scheme [%v]", c.SupercompressionScheme)
+	}
+	return nil
+}
+
+// levelData returns the data of the given mipmap level.
+func (d *decoder) levelData(level int) ([]byte, error) {
+	index := d.levels[level]
+	end := index.byteOffset + index.byteLength
+	if end < index.byteOffset {
+		return nil, fmt.Errorf("KTX2 reader: invalid level index [%v]", level)
+	}
+	if err := d.readTo(end); err != nil {
+		return nil, err
+	}
+	data := d.buf[index.byteOffset:end]
+
+	var err error
+	switch d.config.SupercompressionScheme {
+	case SupercompressionNone:
+		return data, nil
+	case SupercompressionZstd:
+		data, err = zstd.Decompress(data)
+	case SupercompressionZLIB:
+		var r io.ReadCloser
+		if r, err = zlib.NewReader(bytes.NewReader(data)); err == nil {
+			data, err = io.ReadAll(r)
+			r.Close()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("KTX2 reader: invalid supercompressed data in level %v [%v]", level, err)
+	}
+	if uint64(len(data)) != index.uncompressedByteLength {
+		return nil, fmt.Errorf("KTX2 reader: wrong uncompressed size of level %v [%v != %v]", level, len(data), index.uncompressedByteLength)
+	}
+	return data, nil
+}
+
+func (d *decoder) decodeImages() error {
+	h := &d.config.Header
+	levels, layers, faces := h.Levels(), h.Layers(), h.Faces()
+
+	d.tex = &Texture{Config: d.config, Images: make([][][]image.Image, levels)}
+	for level := 0; level < levels; level++ {
+		data, err := d.levelData(level)
+		if err != nil {
+			return err
+		}
+
+		width, height := h.LevelSize(level)
+		d.tex.Images[level] = make([][]image.Image, layers)
+		for layer := 0; layer < layers; layer++ {
+			d.tex.Images[level][layer] = make([]image.Image, faces)
+			for face := 0; face < faces; face++ {
+				im, pix := d.format.newImage(image.Rect(0, 0, width, height))
+				if len(data) < len(pix) {
+					return fmt.Errorf("KTX2 reader: not enough image data in level %v [%v < %v]", level, len(data), len(pix))
+				}
+				copy(pix, data)
+				data = data[len(pix):]
+				d.tex.Images[level][layer][face] = im
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeTexture reads a KTX2 file from r and returns every mipmap level,
+// array layer and cube face stored in it.
+func DecodeTexture(r io.Reader) (*Texture, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex, nil
+}
+
+// DecodeTextureConfig reads the header, the data format descriptor and
+// the key/value data of a KTX2 file from r without decoding any image.
+func DecodeTextureConfig(r io.Reader) (Config, error) {
+	var d decoder
+	if err := d.decode(r, true); err != nil {
+		return Config{}, err
+	}
+	return d.config, nil
+}
+
+// Decode reads a KTX2 file from r and returns the first face of
+// the first array layer in the base mipmap level.
+func Decode(r io.Reader) (image.Image, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex.Image(0, 0, 0), nil
+}
+
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	var d decoder
+	err := d.decode(r, true)
+	width, height := d.config.LevelSize(0)
+	return image.Config{
+		ColorModel: d.format.model,
+		Width:      width,
+		Height:     height,
+	}, err
+}
+
+func init() {
+	image.RegisterFormat("ktx2", magic, Decode, DecodeConfig)
+}
@@ -0,0 +1,257 @@
+package ktx2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/hantempo/glu/enum"
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// basicDFD builds a data format descriptor with one sample per channel,
+// each of the given number of bits.
+func basicDFD(model, transfer uint8, blockDim [4]uint8, bytesPlane0 uint8, bits uint8, channels []uint8) []byte {
+	blockSize := basicBlockHeaderSize + sampleSize*len(channels)
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(4+blockSize))
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	binary.Write(&buf, binary.LittleEndian, uint32(blockSize<<16|2))
+	buf.Write([]byte{model, PrimariesBT709, transfer, 0})
+	for _, d := range blockDim {
+		buf.WriteByte(d - 1)
+	}
+	buf.Write([]byte{bytesPlane0, 0, 0, 0, 0, 0, 0, 0})
+	for i, c := range channels {
+		binary.Write(&buf, binary.LittleEndian, uint16(i*int(bits)))
+		buf.Write([]byte{bits - 1, c, 0, 0, 0, 0})
+		binary.Write(&buf, binary.LittleEndian, uint32(0))
+		binary.Write(&buf, binary.LittleEndian, uint32(1)<<bits-1)
+	}
+	return buf.Bytes()
+}
+
+// ktx2File assembles a KTX2 file, storing the levels from the smallest one
+// as the specification requires.
+func ktx2File(vkFormat, typeSize, width, height, layers, faces uint32, dfd, kvd []byte, levels [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	for _, f := range []uint32{vkFormat, typeSize, width, height, 0, layers, faces, uint32(len(levels)), 0} {
+		binary.Write(&buf, binary.LittleEndian, f)
+	}
+	dfdOffset := headerSize + levelIndexSize*len(levels)
+	kvdOffset := dfdOffset + len(dfd)
+	levelOffset := kvdOffset + len(kvd)
+	levelOffset += padding(levelOffset)
+	for _, f := range []uint32{uint32(dfdOffset), uint32(len(dfd)), uint32(kvdOffset), uint32(len(kvd))} {
+		binary.Write(&buf, binary.LittleEndian, f)
+	}
+	binary.Write(&buf, binary.LittleEndian, [2]uint64{0, 0})
+
+	offsets := make([]int, len(levels))
+	for i := len(levels) - 1; i >= 0; i-- {
+		offsets[i] = levelOffset
+		levelOffset += len(levels[i])
+		levelOffset += padding(levelOffset)
+	}
+	for i, level := range levels {
+		binary.Write(&buf, binary.LittleEndian, [3]uint64{uint64(offsets[i]), uint64(len(level)), uint64(len(level))})
+	}
+	buf.Write(dfd)
+	buf.Write(kvd)
+	for i := len(levels) - 1; i >= 0; i-- {
+		buf.Write(make([]byte, offsets[i]-buf.Len()))
+		buf.Write(levels[i])
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeTexture(t *testing.T) {
+	rgbaDFD := basicDFD(ModelRGBSDA, TransferSRGB, [4]uint8{1, 1, 1, 1}, 4, 8, []uint8{0, 1, 2, 15})
+	kvd := []byte{
+		18, 0, 0, 0,
+		'K', 'T', 'X', 'o', 'r', 'i', 'e', 'n', 't', 'a', 't', 'i', 'o', 'n', 0, 'r', 'd', 0,
+		0, 0,
+	}
+	input := ktx2File(VK_FORMAT_R8G8B8A8_SRGB, 1, 2, 2, 2, 1, rgbaDFD, kvd, [][]byte{
+		{
+			// layer 0
+			0x10, 0x20, 0x30, 0xFF, 0x11, 0x21, 0x31, 0xFF,
+			0x12, 0x22, 0x32, 0xFF, 0x13, 0x23, 0x33, 0x80,
+			// layer 1
+			0x40, 0x50, 0x60, 0xFF, 0x41, 0x51, 0x61, 0xFF,
+			0x42, 0x52, 0x62, 0xFF, 0x43, 0x53, 0x63, 0x80,
+		},
+		{
+			0x01, 0x02, 0x03, 0x04,
+			0x05, 0x06, 0x07, 0x08,
+		},
+	})
+
+	tex, err := DecodeTexture(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tex.Images) != 2 || len(tex.Images[0]) != 2 || len(tex.Images[0][0]) != 1 {
+		t.Fatalf("Wrong layout : got %vx%vx%v", len(tex.Images), len(tex.Images[0]), len(tex.Images[0][0]))
+	}
+	tests := []struct {
+		level, layer, x, y int
+		c                  color.Color
+	}{
+		{0, 0, 0, 0, color.NRGBA{0x10, 0x20, 0x30, 0xFF}},
+		{0, 0, 1, 1, color.NRGBA{0x13, 0x23, 0x33, 0x80}},
+		{0, 1, 1, 0, color.NRGBA{0x41, 0x51, 0x61, 0xFF}},
+		{1, 0, 0, 0, color.NRGBA{0x01, 0x02, 0x03, 0x04}},
+		{1, 1, 0, 0, color.NRGBA{0x05, 0x06, 0x07, 0x08}},
+	}
+	for _, test := range tests {
+		if c := tex.Image(test.level, test.layer, 0).At(test.x, test.y); c != test.c {
+			t.Errorf("Wrong pixel at [%v %v] of image [%v %v] : expected %v, got %v", test.x, test.y, test.level, test.layer, test.c, c)
+		}
+	}
+	if dims := tex.Image(1, 0, 0).Bounds(); dims != image.Rect(0, 0, 1, 1) {
+		t.Errorf("Wrong size of level 1 : got %v", dims)
+	}
+
+	if tex.DFD == nil || tex.DFD.ColorModel != ModelRGBSDA || tex.DFD.TransferFunction != TransferSRGB || len(tex.DFD.Samples) != 4 {
+		t.Fatalf("Wrong data format descriptor : got %+v", tex.DFD)
+	}
+	if s := tex.DFD.Samples[3]; s.Channel() != ChannelRGBSDAAlpha || s.BitOffset != 24 || s.BitLength != 8 || s.SampleUpper != 0xFF {
+		t.Errorf("Wrong alpha sample : got %+v", s)
+	}
+	if o, ok := tex.KeyValues.Orientation(); !ok || o != "rd" {
+		t.Errorf("Wrong orientation : expected rd, got %v", o)
+	}
+	if internalFormat, _, _, ok := tex.GLFormat(); !ok || internalFormat != enum.GL_SRGB8_ALPHA8 {
+		t.Errorf("Wrong GL internal format : got %v", internalFormat)
+	}
+}
+
+func TestDecodeETC1(t *testing.T) {
+	dfd := basicDFD(ModelETC1, TransferLinear, [4]uint8{4, 4, 1, 1}, 8, 64, []uint8{ChannelETC1Color})
+	block := []byte{0xE8, 0xA0, 0x18, 0x03, 0x00, 0x0F, 0x00, 0x00}
+	input := ktx2File(VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK, 1, 4, 4, 0, 1, dfd, nil, [][]byte{block})
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ColorModel != glcolor.RGBModel || config.Width != 4 || config.Height != 4 {
+		t.Errorf("Wrong config : got %+v", config)
+	}
+
+	tex, err := DecodeTexture(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internalFormat, _, _, _ := tex.GLFormat(); internalFormat != enum.GL_ETC1_RGB8_OES {
+		t.Errorf("Wrong GL internal format : got %v", internalFormat)
+	}
+	if etc1, ok := tex.Image(0, 0, 0).(interface{ BlockDimensions() (int, int) }); !ok {
+		t.Errorf("Wrong image type : got %T", tex.Image(0, 0, 0))
+	} else if x, y := etc1.BlockDimensions(); x != 1 || y != 1 {
+		t.Errorf("Wrong block dimensions : got %vx%v", x, y)
+	}
+}
+
+func TestDecodeASTC(t *testing.T) {
+	// Blocks of 8x5 pixels, the RGB endpoints (10, 20, 30) and (200, 150, 100)
+	// interpolated by a grid of 4x4 weights from 0 to 3 in every row
+	block := []byte{0x42, 0x00, 0x15, 0x90, 0x29, 0x2C, 0x3D, 0xC8, 0x00, 0x00, 0x00, 0x00, 0x27, 0x27, 0x27, 0x27}
+	input := ktx2File(VK_FORMAT_ASTC_8x5_UNORM_BLOCK, 1, 10, 5, 0, 1, nil, nil, [][]byte{append(block, block...)})
+
+	m, err := Decode(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ColorModel() != color.NRGBAModel || m.Bounds() != image.Rect(0, 0, 10, 5) {
+		t.Errorf("Wrong color model or bounds : got %v", m.Bounds())
+	}
+	if bd, ok := m.(interface{ BlockDimensions() (int, int) }); !ok {
+		t.Errorf("Wrong image type : got %T", m)
+	} else if x, y := bd.BlockDimensions(); x != 2 || y != 1 {
+		t.Errorf("Wrong block dimensions : got %vx%v", x, y)
+	}
+	tests := []struct {
+		x, y int
+		c    color.Color
+	}{
+		{0, 0, color.NRGBA{10, 20, 30, 255}},
+		{7, 4, color.NRGBA{200, 150, 100, 255}},
+		{8, 2, color.NRGBA{10, 20, 30, 255}},
+	}
+	for _, test := range tests {
+		if c := m.At(test.x, test.y); c != test.c {
+			t.Errorf("Wrong pixel at [%v %v] : expected %v, got %v", test.x, test.y, test.c, c)
+		}
+	}
+}
+
+func TestDecodeASTCHDR(t *testing.T) {
+	// An HDR void extent of (1, 2, 0.5, 1) in half floats
+	block := []byte{0xFC, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x3C, 0x00, 0x40, 0x00, 0x38, 0x00, 0x3C}
+	input := ktx2File(VK_FORMAT_ASTC_4x4_SFLOAT_BLOCK, 1, 4, 4, 0, 1, nil, nil, [][]byte{block})
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ColorModel != glcolor.NRGBAF32Model {
+		t.Errorf("Wrong color model : got %v", config.ColorModel)
+	}
+	texConfig, err := DecodeTextureConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile, ok := texConfig.ASTCProfile(); !ok || profile != glimage.ASTCProfileHDR {
+		t.Errorf("Wrong ASTC profile : got %v %v", profile, ok)
+	}
+	if internalFormat, _, _, _ := texConfig.GLFormat(); internalFormat != enum.GL_COMPRESSED_RGBA_ASTC_4x4_KHR {
+		t.Errorf("Wrong GL internal format : got %v", internalFormat)
+	}
+
+	m, err := Decode(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, expected := m.At(3, 3), (glcolor.NRGBAF32{1, 2, 0.5, 1}); c != expected {
+		t.Errorf("Wrong pixel : expected %v, got %v", expected, c)
+	}
+
+	// The UNORM formats have the LDR profile
+	texConfig.VkFormat = VK_FORMAT_ASTC_4x4_SRGB_BLOCK
+	if profile, ok := texConfig.ASTCProfile(); !ok || profile != glimage.ASTCProfileLDR {
+		t.Errorf("Wrong ASTC profile of the UNORM format : got %v %v", profile, ok)
+	}
+	texConfig.VkFormat = VK_FORMAT_R8G8B8A8_UNORM
+	if _, ok := texConfig.ASTCProfile(); ok {
+		t.Error("Expected no ASTC profile for VK_FORMAT_R8G8B8A8_UNORM")
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	rgbDFD := basicDFD(ModelRGBSDA, TransferLinear, [4]uint8{1, 1, 1, 1}, 3, 8, []uint8{0, 1, 2})
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{[]byte("\xABKTX 11\xBB\r\n\x1A\n"), "KTX2 reader: invalid identifier"},
+		{ktx2File(VK_FORMAT_UNDEFINED, 1, 4, 4, 0, 1, nil, nil, [][]byte{make([]byte, 16)}), "KTX2 reader: unsupported format"},
+		{ktx2File(VK_FORMAT_R8G8B8_UNORM, 1, 4, 4, 0, 2, rgbDFD, nil, [][]byte{make([]byte, 48)}), "KTX2 reader: invalid number of faces"},
+		{ktx2File(VK_FORMAT_R8G8B8_UNORM, 1, 4, 4, 0, 1, rgbDFD, nil, [][]byte{make([]byte, 47)}), "KTX2 reader: not enough image data"},
+		{ktx2File(VK_FORMAT_R8G8B8_UNORM, 1, 4, 4, 0, 1, rgbDFD[:20], nil, [][]byte{make([]byte, 48)}), "KTX2 reader: invalid data format descriptor size"},
+	}
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader(test.input))
+		if err == nil {
+			t.Errorf("Expected pattern of error message (%s), got no error", test.err)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%s)", test.err, err.Error())
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package ktx2
+
+import "github.com/hantempo/glu/enum"
+
+// Vulkan formats which have a GL equivalent
+const (
+	VK_FORMAT_UNDEFINED                 = 0
+	VK_FORMAT_R4G4B4A4_UNORM_PACK16     = 2
+	VK_FORMAT_R5G6B5_UNORM_PACK16       = 4
+	VK_FORMAT_R8_UNORM                  = 9
+	VK_FORMAT_R8G8B8_UNORM              = 23
+	VK_FORMAT_R8G8B8_SRGB               = 29
+	VK_FORMAT_R8G8B8A8_UNORM            = 37
+	VK_FORMAT_R8G8B8A8_SRGB             = 43
+	VK_FORMAT_BC1_RGB_UNORM_BLOCK       = 131
+	VK_FORMAT_BC1_RGB_SRGB_BLOCK        = 132
+	VK_FORMAT_BC1_RGBA_UNORM_BLOCK      = 133
+	VK_FORMAT_BC1_RGBA_SRGB_BLOCK       = 134
+	VK_FORMAT_BC2_UNORM_BLOCK           = 135
+	VK_FORMAT_BC2_SRGB_BLOCK            = 136
+	VK_FORMAT_BC3_UNORM_BLOCK           = 137
+	VK_FORMAT_BC3_SRGB_BLOCK            = 138
+	VK_FORMAT_BC4_UNORM_BLOCK           = 139
+	VK_FORMAT_BC4_SNORM_BLOCK           = 140
+	VK_FORMAT_BC5_UNORM_BLOCK           = 141
+	VK_FORMAT_BC5_SNORM_BLOCK           = 142
+	VK_FORMAT_BC6H_UFLOAT_BLOCK         = 143
+	VK_FORMAT_BC6H_SFLOAT_BLOCK         = 144
+	VK_FORMAT_BC7_UNORM_BLOCK           = 145
+	VK_FORMAT_BC7_SRGB_BLOCK            = 146
+	VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK   = 147
+	VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK    = 148
+	VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK = 149
+	VK_FORMAT_ETC2_R8G8B8A1_SRGB_BLOCK  = 150
+	VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK = 151
+	VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK  = 152
+	VK_FORMAT_EAC_R11_UNORM_BLOCK       = 153
+	VK_FORMAT_EAC_R11_SNORM_BLOCK       = 154
+	VK_FORMAT_EAC_R11G11_UNORM_BLOCK    = 155
+	VK_FORMAT_EAC_R11G11_SNORM_BLOCK    = 156
+	VK_FORMAT_ASTC_4x4_UNORM_BLOCK      = 157
+	VK_FORMAT_ASTC_4x4_SRGB_BLOCK       = 158
+	VK_FORMAT_ASTC_5x4_UNORM_BLOCK      = 159
+	VK_FORMAT_ASTC_5x4_SRGB_BLOCK       = 160
+	VK_FORMAT_ASTC_5x5_UNORM_BLOCK      = 161
+	VK_FORMAT_ASTC_5x5_SRGB_BLOCK       = 162
+	VK_FORMAT_ASTC_6x5_UNORM_BLOCK      = 163
+	VK_FORMAT_ASTC_6x5_SRGB_BLOCK       = 164
+	VK_FORMAT_ASTC_6x6_UNORM_BLOCK      = 165
+	VK_FORMAT_ASTC_6x6_SRGB_BLOCK       = 166
+	VK_FORMAT_ASTC_8x5_UNORM_BLOCK      = 167
+	VK_FORMAT_ASTC_8x5_SRGB_BLOCK       = 168
+	VK_FORMAT_ASTC_8x6_UNORM_BLOCK      = 169
+	VK_FORMAT_ASTC_8x6_SRGB_BLOCK       = 170
+	VK_FORMAT_ASTC_8x8_UNORM_BLOCK      = 171
+	VK_FORMAT_ASTC_8x8_SRGB_BLOCK       = 172
+	VK_FORMAT_ASTC_10x5_UNORM_BLOCK     = 173
+	VK_FORMAT_ASTC_10x5_SRGB_BLOCK      = 174
+	VK_FORMAT_ASTC_10x6_UNORM_BLOCK     = 175
+	VK_FORMAT_ASTC_10x6_SRGB_BLOCK      = 176
+	VK_FORMAT_ASTC_10x8_UNORM_BLOCK     = 177
+	VK_FORMAT_ASTC_10x8_SRGB_BLOCK      = 178
+	VK_FORMAT_ASTC_10x10_UNORM_BLOCK    = 179
+	VK_FORMAT_ASTC_10x10_SRGB_BLOCK     = 180
+	VK_FORMAT_ASTC_12x10_UNORM_BLOCK    = 181
+	VK_FORMAT_ASTC_12x10_SRGB_BLOCK     = 182
+	VK_FORMAT_ASTC_12x12_UNORM_BLOCK    = 183
+	VK_FORMAT_ASTC_12x12_SRGB_BLOCK     = 184
+)
+
+// Vulkan formats of the ASTC blocks decoded with the HDR profile,
+// which share the GL internal formats of the UNORM ones
+const (
+	VK_FORMAT_ASTC_4x4_SFLOAT_BLOCK   = 1000066000
+	VK_FORMAT_ASTC_5x4_SFLOAT_BLOCK   = 1000066001
+	VK_FORMAT_ASTC_5x5_SFLOAT_BLOCK   = 1000066002
+	VK_FORMAT_ASTC_6x5_SFLOAT_BLOCK   = 1000066003
+	VK_FORMAT_ASTC_6x6_SFLOAT_BLOCK   = 1000066004
+	VK_FORMAT_ASTC_8x5_SFLOAT_BLOCK   = 1000066005
+	VK_FORMAT_ASTC_8x6_SFLOAT_BLOCK   = 1000066006
+	VK_FORMAT_ASTC_8x8_SFLOAT_BLOCK   = 1000066007
+	VK_FORMAT_ASTC_10x5_SFLOAT_BLOCK  = 1000066008
+	VK_FORMAT_ASTC_10x6_SFLOAT_BLOCK  = 1000066009
+	VK_FORMAT_ASTC_10x8_SFLOAT_BLOCK  = 1000066010
+	VK_FORMAT_ASTC_10x10_SFLOAT_BLOCK = 1000066011
+	VK_FORMAT_ASTC_12x10_SFLOAT_BLOCK = 1000066012
+	VK_FORMAT_ASTC_12x12_SFLOAT_BLOCK = 1000066013
+)
+
+// astcHDRFormats maps the Vulkan formats of the ASTC HDR profile to
+// the UNORM formats of the same footprint
+var astcHDRFormats = map[uint32]uint32{
+	VK_FORMAT_ASTC_4x4_SFLOAT_BLOCK:   VK_FORMAT_ASTC_4x4_UNORM_BLOCK,
+	VK_FORMAT_ASTC_5x4_SFLOAT_BLOCK:   VK_FORMAT_ASTC_5x4_UNORM_BLOCK,
+	VK_FORMAT_ASTC_5x5_SFLOAT_BLOCK:   VK_FORMAT_ASTC_5x5_UNORM_BLOCK,
+	VK_FORMAT_ASTC_6x5_SFLOAT_BLOCK:   VK_FORMAT_ASTC_6x5_UNORM_BLOCK,
+	VK_FORMAT_ASTC_6x6_SFLOAT_BLOCK:   VK_FORMAT_ASTC_6x6_UNORM_BLOCK,
+	VK_FORMAT_ASTC_8x5_SFLOAT_BLOCK:   VK_FORMAT_ASTC_8x5_UNORM_BLOCK,
+	VK_FORMAT_ASTC_8x6_SFLOAT_BLOCK:   VK_FORMAT_ASTC_8x6_UNORM_BLOCK,
+	VK_FORMAT_ASTC_8x8_SFLOAT_BLOCK:   VK_FORMAT_ASTC_8x8_UNORM_BLOCK,
+	VK_FORMAT_ASTC_10x5_SFLOAT_BLOCK:  VK_FORMAT_ASTC_10x5_UNORM_BLOCK,
+	VK_FORMAT_ASTC_10x6_SFLOAT_BLOCK:  VK_FORMAT_ASTC_10x6_UNORM_BLOCK,
+	VK_FORMAT_ASTC_10x8_SFLOAT_BLOCK:  VK_FORMAT_ASTC_10x8_UNORM_BLOCK,
+	VK_FORMAT_ASTC_10x10_SFLOAT_BLOCK: VK_FORMAT_ASTC_10x10_UNORM_BLOCK,
+	VK_FORMAT_ASTC_12x10_SFLOAT_BLOCK: VK_FORMAT_ASTC_12x10_UNORM_BLOCK,
+	VK_FORMAT_ASTC_12x12_SFLOAT_BLOCK: VK_FORMAT_ASTC_12x12_UNORM_BLOCK,
+}
+
+// astcFootprints holds the block footprints of the ASTC formats,
+// in the order of their Vulkan formats
+var astcFootprints = [][2]int{
+	{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6},
+	{8, 8}, {10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+}
+
+// astcVkFormat returns the Vulkan format of the ASTC blocks of the given
+// footprint, whose SFLOAT variant is decoded with the HDR profile.
+func astcVkFormat(blockWidth, blockHeight int, srgb, hdr bool) (uint32, bool) {
+	for i, f := range astcFootprints {
+		if f != [2]int{blockWidth, blockHeight} {
+			continue
+		}
+		switch {
+		case hdr:
+			return VK_FORMAT_ASTC_4x4_SFLOAT_BLOCK + uint32(i), true
+		case srgb:
+			return VK_FORMAT_ASTC_4x4_SRGB_BLOCK + 2*uint32(i), true
+		}
+		return VK_FORMAT_ASTC_4x4_UNORM_BLOCK + 2*uint32(i), true
+	}
+	return VK_FORMAT_UNDEFINED, false
+}
+
+// glFormat is the GL equivalent of a Vulkan format. Type and format are
+// GL_NONE for compressed formats.
+type glFormat struct {
+	internalFormat enum.InternalFormat
+	format         enum.Format
+	typ            enum.Type
+}
+
+var glFormats = map[uint32]glFormat{
+	VK_FORMAT_R4G4B4A4_UNORM_PACK16:     {enum.GL_RGBA4, enum.GL_RGBA, enum.GL_UNSIGNED_SHORT_4_4_4_4},
+	VK_FORMAT_R5G6B5_UNORM_PACK16:       {enum.GL_RGB565, enum.GL_RGB, enum.GL_UNSIGNED_SHORT_5_6_5},
+	VK_FORMAT_R8_UNORM:                  {enum.GL_R8, enum.GL_RED, enum.GL_UNSIGNED_BYTE},
+	VK_FORMAT_R8G8B8_UNORM:              {enum.GL_RGB8, enum.GL_RGB, enum.GL_UNSIGNED_BYTE},
+	VK_FORMAT_R8G8B8_SRGB:               {enum.GL_SRGB8, enum.GL_RGB, enum.GL_UNSIGNED_BYTE},
+	VK_FORMAT_R8G8B8A8_UNORM:            {enum.GL_RGBA8, enum.GL_RGBA, enum.GL_UNSIGNED_BYTE},
+	VK_FORMAT_R8G8B8A8_SRGB:             {enum.GL_SRGB8_ALPHA8, enum.GL_RGBA, enum.GL_UNSIGNED_BYTE},
+	VK_FORMAT_BC1_RGB_UNORM_BLOCK:       {enum.GL_COMPRESSED_RGB_S3TC_DXT1_EXT, 0, 0},
+	VK_FORMAT_BC1_RGB_SRGB_BLOCK:        {enum.GL_COMPRESSED_SRGB_S3TC_DXT1_EXT, 0, 0},
+	VK_FORMAT_BC1_RGBA_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_S3TC_DXT1_EXT, 0, 0},
+	VK_FORMAT_BC1_RGBA_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT, 0, 0},
+	VK_FORMAT_BC2_UNORM_BLOCK:           {enum.GL_COMPRESSED_RGBA_S3TC_DXT3_EXT, 0, 0},
+	VK_FORMAT_BC2_SRGB_BLOCK:            {enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT, 0, 0},
+	VK_FORMAT_BC3_UNORM_BLOCK:           {enum.GL_COMPRESSED_RGBA_S3TC_DXT5_EXT, 0, 0},
+	VK_FORMAT_BC3_SRGB_BLOCK:            {enum.GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, 0, 0},
+	VK_FORMAT_BC4_UNORM_BLOCK:           {enum.GL_COMPRESSED_RED_RGTC1, 0, 0},
+	VK_FORMAT_BC4_SNORM_BLOCK:           {enum.GL_COMPRESSED_SIGNED_RED_RGTC1, 0, 0},
+	VK_FORMAT_BC5_UNORM_BLOCK:           {enum.GL_COMPRESSED_RG_RGTC2, 0, 0},
+	VK_FORMAT_BC5_SNORM_BLOCK:           {enum.GL_COMPRESSED_SIGNED_RG_RGTC2, 0, 0},
+	VK_FORMAT_BC6H_UFLOAT_BLOCK:         {enum.GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, 0, 0},
+	VK_FORMAT_BC6H_SFLOAT_BLOCK:         {enum.GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT, 0, 0},
+	VK_FORMAT_BC7_UNORM_BLOCK:           {enum.GL_COMPRESSED_RGBA_BPTC_UNORM, 0, 0},
+	VK_FORMAT_BC7_SRGB_BLOCK:            {enum.GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM, 0, 0},
+	VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK:   {enum.GL_COMPRESSED_RGB8_ETC2, 0, 0},
+	VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK:    {enum.GL_COMPRESSED_SRGB8_ETC2, 0, 0},
+	VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK: {enum.GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, 0, 0},
+	VK_FORMAT_ETC2_R8G8B8A1_SRGB_BLOCK:  {enum.GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2, 0, 0},
+	VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK: {enum.GL_COMPRESSED_RGBA8_ETC2_EAC, 0, 0},
+	VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK:  {enum.GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC, 0, 0},
+	VK_FORMAT_EAC_R11_UNORM_BLOCK:       {enum.GL_COMPRESSED_R11_EAC, 0, 0},
+	VK_FORMAT_EAC_R11_SNORM_BLOCK:       {enum.GL_COMPRESSED_SIGNED_R11_EAC, 0, 0},
+	VK_FORMAT_EAC_R11G11_UNORM_BLOCK:    {enum.GL_COMPRESSED_RG11_EAC, 0, 0},
+	VK_FORMAT_EAC_R11G11_SNORM_BLOCK:    {enum.GL_COMPRESSED_SIGNED_RG11_EAC, 0, 0},
+	VK_FORMAT_ASTC_4x4_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_4x4_KHR, 0, 0},
+	VK_FORMAT_ASTC_4x4_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR, 0, 0},
+	VK_FORMAT_ASTC_5x4_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_5x4_KHR, 0, 0},
+	VK_FORMAT_ASTC_5x4_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x4_KHR, 0, 0},
+	VK_FORMAT_ASTC_5x5_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_5x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_5x5_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_5x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_6x5_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_6x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_6x5_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_6x6_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_6x6_KHR, 0, 0},
+	VK_FORMAT_ASTC_6x6_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x6_KHR, 0, 0},
+	VK_FORMAT_ASTC_8x5_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_8x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_8x5_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_8x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_8x6_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_8x6_KHR, 0, 0},
+	VK_FORMAT_ASTC_8x6_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_8x6_KHR, 0, 0},
+	VK_FORMAT_ASTC_8x8_UNORM_BLOCK:      {enum.GL_COMPRESSED_RGBA_ASTC_8x8_KHR, 0, 0},
+	VK_FORMAT_ASTC_8x8_SRGB_BLOCK:       {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_8x8_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x5_UNORM_BLOCK:     {enum.GL_COMPRESSED_RGBA_ASTC_10x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x5_SRGB_BLOCK:      {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x5_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x6_UNORM_BLOCK:     {enum.GL_COMPRESSED_RGBA_ASTC_10x6_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x6_SRGB_BLOCK:      {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x6_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x8_UNORM_BLOCK:     {enum.GL_COMPRESSED_RGBA_ASTC_10x8_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x8_SRGB_BLOCK:      {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x8_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x10_UNORM_BLOCK:    {enum.GL_COMPRESSED_RGBA_ASTC_10x10_KHR, 0, 0},
+	VK_FORMAT_ASTC_10x10_SRGB_BLOCK:     {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x10_KHR, 0, 0},
+	VK_FORMAT_ASTC_12x10_UNORM_BLOCK:    {enum.GL_COMPRESSED_RGBA_ASTC_12x10_KHR, 0, 0},
+	VK_FORMAT_ASTC_12x10_SRGB_BLOCK:     {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x10_KHR, 0, 0},
+	VK_FORMAT_ASTC_12x12_UNORM_BLOCK:    {enum.GL_COMPRESSED_RGBA_ASTC_12x12_KHR, 0, 0},
+	VK_FORMAT_ASTC_12x12_SRGB_BLOCK:     {enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x12_KHR, 0, 0},
+}
+
+// GLFormat returns the GL internal format, format and type equivalent to
+// the given Vulkan format. Format and type are GL_NONE for compressed formats.
+func GLFormat(vkFormat uint32) (internalFormat enum.InternalFormat, format enum.Format, typ enum.Type, ok bool) {
+	if unorm, ok := astcHDRFormats[vkFormat]; ok {
+		vkFormat = unorm
+	}
+	f, ok := glFormats[vkFormat]
+	return f.internalFormat, f.format, f.typ, ok
+}
+
+// VkFormat returns the Vulkan format equivalent to the given GL internal format.
+func VkFormat(internalFormat enum.InternalFormat) (uint32, bool) {
+	if internalFormat == enum.GL_ETC1_RGB8_OES {
+		// ETC1 is stored as its superset ETC2, see ModelETC1
+		return VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK, true
+	}
+	for vkFormat, f := range glFormats {
+		if f.internalFormat == internalFormat {
+			return vkFormat, true
+		}
+	}
+	return VK_FORMAT_UNDEFINED, false
+}
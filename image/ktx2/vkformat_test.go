@@ -0,0 +1,40 @@
+package ktx2
+
+import (
+	"testing"
+
+	"github.com/hantempo/glu/enum"
+)
+
+func TestGLFormat(t *testing.T) {
+	tests := []struct {
+		vkFormat       uint32
+		internalFormat enum.InternalFormat
+		format         enum.Format
+		typ            enum.Type
+	}{
+		{VK_FORMAT_R5G6B5_UNORM_PACK16, enum.GL_RGB565, enum.GL_RGB, enum.GL_UNSIGNED_SHORT_5_6_5},
+		{VK_FORMAT_R8G8B8A8_UNORM, enum.GL_RGBA8, enum.GL_RGBA, enum.GL_UNSIGNED_BYTE},
+		{VK_FORMAT_EAC_R11G11_SNORM_BLOCK, enum.GL_COMPRESSED_SIGNED_RG11_EAC, 0, 0},
+		{VK_FORMAT_ASTC_6x6_SRGB_BLOCK, enum.GL_COMPRESSED_SRGB8_ALPHA8_ASTC_6x6_KHR, 0, 0},
+		{VK_FORMAT_BC1_RGB_SRGB_BLOCK, enum.GL_COMPRESSED_SRGB_S3TC_DXT1_EXT, 0, 0},
+		{VK_FORMAT_BC5_SNORM_BLOCK, enum.GL_COMPRESSED_SIGNED_RG_RGTC2, 0, 0},
+		{VK_FORMAT_BC6H_UFLOAT_BLOCK, enum.GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, 0, 0},
+	}
+	for _, test := range tests {
+		internalFormat, format, typ, ok := GLFormat(test.vkFormat)
+		if !ok || internalFormat != test.internalFormat || format != test.format || typ != test.typ {
+			t.Errorf("Wrong GL format of %v : got %v %v %v", test.vkFormat, internalFormat, format, typ)
+		}
+		if vkFormat, ok := VkFormat(test.internalFormat); !ok || vkFormat != test.vkFormat {
+			t.Errorf("Wrong Vulkan format of %v : got %v", test.internalFormat, vkFormat)
+		}
+	}
+
+	if _, _, _, ok := GLFormat(VK_FORMAT_UNDEFINED); ok {
+		t.Error("Expected no GL format for VK_FORMAT_UNDEFINED")
+	}
+	if vkFormat, ok := VkFormat(enum.GL_ETC1_RGB8_OES); !ok || vkFormat != VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK {
+		t.Errorf("Wrong Vulkan format of GL_ETC1_RGB8_OES : got %v", vkFormat)
+	}
+}
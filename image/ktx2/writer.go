@@ -0,0 +1,642 @@
+package ktx2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"reflect"
+	"sort"
+
+	glimage "github.com/hantempo/glu/image"
+	"github.com/hantempo/glu/internal/zstd"
+)
+
+// Options are the encoding parameters.
+type Options struct {
+	// Supercompression is the scheme applied to every mipmap level, one of
+	// SupercompressionNone, SupercompressionZstd or SupercompressionZLIB.
+	Supercompression uint32
+	// SRGB selects the sRGB variant of the Vulkan format,
+	// for the image types which have one.
+	SRGB bool
+	// Mipmaps holds the images of the mipmap levels following the base level.
+	// It is only used by Encode.
+	Mipmaps []image.Image
+	// KeyValues holds the key/value pairs to write. It is only used by Encode.
+	KeyValues KeyValues
+}
+
+type encoder struct {
+	w       io.Writer
+	opts    Options
+	header  Header
+	dfd     *DataFormatDescriptor
+	tex     *Texture
+	err     error
+	padding [16]byte
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(b)
+}
+
+func (e *encoder) writeUint32(v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	e.write(buf[:])
+}
+
+func (e *encoder) writeUint64(v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	e.write(buf[:])
+}
+
+// rgbsdaSample returns the sample of an unsigned normalized channel.
+func rgbsdaSample(bitOffset uint16, bitLength uint8, channel uint8) Sample {
+	return Sample{
+		BitOffset:   bitOffset,
+		BitLength:   bitLength,
+		ChannelType: channel,
+		SampleUpper: 1<<bitLength - 1,
+	}
+}
+
+// blockSample returns the sample of the compressed data of a texel block.
+func blockSample(bitOffset uint16, bitLength uint8, channel uint8) Sample {
+	return Sample{
+		BitOffset:   bitOffset,
+		BitLength:   bitLength,
+		ChannelType: channel,
+		SampleUpper: 0xFFFFFFFF,
+	}
+}
+
+// signedBlockSample returns the sample of the compressed data of a texel
+// block holding signed normalized values.
+func signedBlockSample(bitOffset uint16, bitLength uint8, channel uint8) Sample {
+	return Sample{
+		BitOffset:   bitOffset,
+		BitLength:   bitLength,
+		ChannelType: channel | QualifierSigned,
+		SampleLower: 0x80000000,
+		SampleUpper: 0x7FFFFFFF,
+	}
+}
+
+// floatBlockSample returns the sample of the compressed data of a texel
+// block holding floating-point values, which range from -1.0 or 0.0 to 1.0.
+func floatBlockSample(bitOffset uint16, bitLength uint8, channel uint8, signed bool) Sample {
+	s := Sample{
+		BitOffset:   bitOffset,
+		BitLength:   bitLength,
+		ChannelType: channel | QualifierFloat,
+		SampleUpper: 0x3F800000,
+	}
+	if signed {
+		s.ChannelType |= QualifierSigned
+		s.SampleLower = 0xBF800000
+	}
+	return s
+}
+
+// setFormat fills the format fields of the header and the data format
+// descriptor from the concrete type of im.
+func (e *encoder) setFormat(im image.Image) error {
+	h := &e.header
+	transfer := uint8(TransferLinear)
+	if e.opts.SRGB {
+		transfer = TransferSRGB
+	}
+	dfd := &DataFormatDescriptor{
+		VersionNumber:       2,
+		ColorModel:          ModelRGBSDA,
+		ColorPrimaries:      PrimariesBT709,
+		TransferFunction:    transfer,
+		TexelBlockDimension: [4]uint8{1, 1, 1, 1},
+	}
+	h.TypeSize = 1
+	// Alpha is never encoded with the sRGB transfer function
+	alphaQualifier := uint8(0)
+	if e.opts.SRGB {
+		alphaQualifier = QualifierLinear
+	}
+
+	switch m := im.(type) {
+	case *image.Gray:
+		h.VkFormat = VK_FORMAT_R8_UNORM
+		dfd.TransferFunction = TransferLinear
+		dfd.Samples = []Sample{rgbsdaSample(0, 8, ChannelRGBSDARed)}
+	case *glimage.RGB:
+		h.VkFormat = VK_FORMAT_R8G8B8_UNORM
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_R8G8B8_SRGB
+		}
+		dfd.Samples = []Sample{
+			rgbsdaSample(0, 8, ChannelRGBSDARed),
+			rgbsdaSample(8, 8, ChannelRGBSDAGreen),
+			rgbsdaSample(16, 8, ChannelRGBSDABlue),
+		}
+	case *image.RGBA, *image.NRGBA:
+		h.VkFormat = VK_FORMAT_R8G8B8A8_UNORM
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_R8G8B8A8_SRGB
+		}
+		dfd.Samples = []Sample{
+			rgbsdaSample(0, 8, ChannelRGBSDARed),
+			rgbsdaSample(8, 8, ChannelRGBSDAGreen),
+			rgbsdaSample(16, 8, ChannelRGBSDABlue),
+			rgbsdaSample(24, 8, ChannelRGBSDAAlpha|alphaQualifier),
+		}
+	case *glimage.RGB565:
+		h.VkFormat, h.TypeSize = VK_FORMAT_R5G6B5_UNORM_PACK16, 2
+		dfd.TransferFunction = TransferLinear
+		dfd.Samples = []Sample{
+			rgbsdaSample(0, 5, ChannelRGBSDABlue),
+			rgbsdaSample(5, 6, ChannelRGBSDAGreen),
+			rgbsdaSample(11, 5, ChannelRGBSDARed),
+		}
+	case *glimage.NRGBA4444:
+		h.VkFormat, h.TypeSize = VK_FORMAT_R4G4B4A4_UNORM_PACK16, 2
+		dfd.TransferFunction = TransferLinear
+		dfd.Samples = []Sample{
+			rgbsdaSample(0, 4, ChannelRGBSDAAlpha),
+			rgbsdaSample(4, 4, ChannelRGBSDABlue),
+			rgbsdaSample(8, 4, ChannelRGBSDAGreen),
+			rgbsdaSample(12, 4, ChannelRGBSDARed),
+		}
+	case *glimage.ETC1:
+		// ETC1 is stored as its superset ETC2 with the ETC1 color model
+		h.VkFormat = VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK
+		dfd.ColorModel = ModelETC1
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 64, ChannelETC1Color)}
+	case *glimage.ETC2RGB8:
+		h.VkFormat = VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK
+		}
+		dfd.ColorModel = ModelETC2
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 64, ChannelETC2Color)}
+	case *glimage.ETC2RGB8A1:
+		h.VkFormat = VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_ETC2_R8G8B8A1_SRGB_BLOCK
+		}
+		// The punch-through alpha is part of the color data
+		dfd.ColorModel = ModelETC2
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 64, ChannelETC2Color)}
+	case *glimage.ETC2RGBA8:
+		h.VkFormat = VK_FORMAT_ETC2_R8G8B8A8_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK
+		}
+		dfd.ColorModel = ModelETC2
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			blockSample(0, 64, ChannelETC2Alpha|alphaQualifier),
+			blockSample(64, 64, ChannelETC2Color),
+		}
+	case *glimage.EACR11:
+		h.VkFormat = VK_FORMAT_EAC_R11_UNORM_BLOCK
+		dfd.ColorModel = ModelETC2
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 64, ChannelETC2Red)}
+	case *glimage.EACSignedR11:
+		h.VkFormat = VK_FORMAT_EAC_R11_SNORM_BLOCK
+		dfd.ColorModel = ModelETC2
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{signedBlockSample(0, 64, ChannelETC2Red)}
+	case *glimage.EACRG11:
+		h.VkFormat = VK_FORMAT_EAC_R11G11_UNORM_BLOCK
+		dfd.ColorModel = ModelETC2
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			blockSample(0, 64, ChannelETC2Red),
+			blockSample(64, 64, ChannelETC2Green),
+		}
+	case *glimage.EACSignedRG11:
+		h.VkFormat = VK_FORMAT_EAC_R11G11_SNORM_BLOCK
+		dfd.ColorModel = ModelETC2
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			signedBlockSample(0, 64, ChannelETC2Red),
+			signedBlockSample(64, 64, ChannelETC2Green),
+		}
+	case *glimage.ASTC:
+		hdr := m.Profile == glimage.ASTCProfileHDR
+		vkFormat, ok := astcVkFormat(m.BlockWidth, m.BlockHeight, m.SRGB, hdr)
+		if !ok {
+			return fmt.Errorf("KTX2 writer: unsupported ASTC block footprint [%vx%v]", m.BlockWidth, m.BlockHeight)
+		}
+		h.VkFormat = vkFormat
+		dfd.ColorModel = ModelASTC
+		dfd.TransferFunction = TransferLinear
+		if m.SRGB && !hdr {
+			dfd.TransferFunction = TransferSRGB
+		}
+		dfd.TexelBlockDimension = [4]uint8{uint8(m.BlockWidth), uint8(m.BlockHeight), 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 128, ChannelASTCData)}
+		if hdr {
+			dfd.Samples = []Sample{floatBlockSample(0, 128, ChannelASTCData, true)}
+		}
+	case *glimage.BC1:
+		// The blocks may hold punch-through alpha
+		h.VkFormat = VK_FORMAT_BC1_RGBA_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_BC1_RGBA_SRGB_BLOCK
+		}
+		dfd.ColorModel = ModelBC1A
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 64, ChannelBC1AAlphaPresent)}
+	case *glimage.BC2:
+		h.VkFormat = VK_FORMAT_BC2_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_BC2_SRGB_BLOCK
+		}
+		dfd.ColorModel = ModelBC2
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			blockSample(0, 64, ChannelBC2Alpha|alphaQualifier),
+			blockSample(64, 64, ChannelBC2Color),
+		}
+	case *glimage.BC3:
+		h.VkFormat = VK_FORMAT_BC3_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_BC3_SRGB_BLOCK
+		}
+		dfd.ColorModel = ModelBC3
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			blockSample(0, 64, ChannelBC3Alpha|alphaQualifier),
+			blockSample(64, 64, ChannelBC3Color),
+		}
+	case *glimage.BC4:
+		h.VkFormat = VK_FORMAT_BC4_UNORM_BLOCK
+		dfd.ColorModel = ModelBC4
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 64, ChannelBC4Data)}
+	case *glimage.BC4Signed:
+		h.VkFormat = VK_FORMAT_BC4_SNORM_BLOCK
+		dfd.ColorModel = ModelBC4
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{signedBlockSample(0, 64, ChannelBC4Data)}
+	case *glimage.BC5:
+		h.VkFormat = VK_FORMAT_BC5_UNORM_BLOCK
+		dfd.ColorModel = ModelBC5
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			blockSample(0, 64, ChannelBC5Red),
+			blockSample(64, 64, ChannelBC5Green),
+		}
+	case *glimage.BC5Signed:
+		h.VkFormat = VK_FORMAT_BC5_SNORM_BLOCK
+		dfd.ColorModel = ModelBC5
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{
+			signedBlockSample(0, 64, ChannelBC5Red),
+			signedBlockSample(64, 64, ChannelBC5Green),
+		}
+	case *glimage.BC6H:
+		h.VkFormat = VK_FORMAT_BC6H_UFLOAT_BLOCK
+		if m.Signed {
+			h.VkFormat = VK_FORMAT_BC6H_SFLOAT_BLOCK
+		}
+		dfd.ColorModel = ModelBC6H
+		dfd.TransferFunction = TransferLinear
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{floatBlockSample(0, 128, ChannelBC6HColor, m.Signed)}
+	case *glimage.BC7:
+		h.VkFormat = VK_FORMAT_BC7_UNORM_BLOCK
+		if e.opts.SRGB {
+			h.VkFormat = VK_FORMAT_BC7_SRGB_BLOCK
+		}
+		dfd.ColorModel = ModelBC7
+		dfd.TexelBlockDimension = [4]uint8{4, 4, 1, 1}
+		dfd.Samples = []Sample{blockSample(0, 128, ChannelBC7Color)}
+	default:
+		return fmt.Errorf("KTX2 writer: unsupported image type [%T]", im)
+	}
+
+	bits := 0
+	for _, s := range dfd.Samples {
+		bits += int(s.BitLength)
+	}
+	dfd.BytesPlane[0] = uint8(bits / 8)
+	e.dfd = dfd
+	return nil
+}
+
+// encodeDFD returns the data format descriptor as stored in a KTX2 file,
+// starting with its total size.
+func encodeDFD(dfd *DataFormatDescriptor) []byte {
+	blockSize := basicBlockHeaderSize + sampleSize*len(dfd.Samples)
+	buf := make([]byte, 4+blockSize)
+	binary.LittleEndian.PutUint32(buf[0:], uint32(len(buf)))
+	b := buf[4:]
+	binary.LittleEndian.PutUint32(b[0:], dfd.VendorID|dfd.DescriptorType<<17)
+	binary.LittleEndian.PutUint32(b[4:], uint32(dfd.VersionNumber)|uint32(blockSize)<<16)
+	b[8], b[9], b[10], b[11] = dfd.ColorModel, dfd.ColorPrimaries, dfd.TransferFunction, dfd.Flags
+	for i, d := range dfd.TexelBlockDimension {
+		b[12+i] = d - 1
+	}
+	copy(b[16:24], dfd.BytesPlane[:])
+	for i, sample := range dfd.Samples {
+		s := b[basicBlockHeaderSize+i*sampleSize:]
+		binary.LittleEndian.PutUint16(s[0:], sample.BitOffset)
+		s[2], s[3] = sample.BitLength-1, sample.ChannelType
+		copy(s[4:8], sample.SamplePosition[:])
+		binary.LittleEndian.PutUint32(s[8:], sample.SampleLower)
+		binary.LittleEndian.PutUint32(s[12:], sample.SampleUpper)
+	}
+	return buf
+}
+
+// encodeKeyValues returns the key/value data sorted by key,
+// with the padding of every value.
+func (e *encoder) encodeKeyValues(kv KeyValues) []byte {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	var size [4]byte
+	for _, k := range keys {
+		n := len(k) + 1 + len(kv[k])
+		binary.LittleEndian.PutUint32(size[:], uint32(n))
+		buf.Write(size[:])
+		buf.WriteString(k)
+		buf.WriteByte(0)
+		buf.Write(kv[k])
+		buf.Write(e.padding[:padding(n)])
+	}
+	return buf.Bytes()
+}
+
+// imageData returns the bytes of im as stored in a KTX2 file,
+// where rows are not padded.
+func imageData(im image.Image) []byte {
+	var (
+		pix    []byte
+		stride int
+		rowLen int
+	)
+	b := im.Bounds()
+	switch m := im.(type) {
+	case *image.Gray:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()
+	case *glimage.RGB:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*3
+	case *image.NRGBA:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*4
+	case *image.RGBA:
+		// Vulkan expects colors which are not alpha-premultiplied
+		nrgba := image.NewNRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				nrgba.Set(x, y, color.NRGBAModel.Convert(m.At(x, y)))
+			}
+		}
+		pix, stride, rowLen = nrgba.Pix, nrgba.Stride, b.Dx()*4
+	case *glimage.RGB565:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*2
+	case *glimage.NRGBA4444:
+		pix, stride, rowLen = m.Pix, m.Stride, b.Dx()*2
+	// Blocks are stored as is
+	case *glimage.ETC1:
+		return m.Pix
+	case *glimage.ETC2RGB8:
+		return m.Pix
+	case *glimage.ETC2RGB8A1:
+		return m.Pix
+	case *glimage.ETC2RGBA8:
+		return m.Pix
+	case *glimage.EACR11:
+		return m.Pix
+	case *glimage.EACSignedR11:
+		return m.Pix
+	case *glimage.EACRG11:
+		return m.Pix
+	case *glimage.EACSignedRG11:
+		return m.Pix
+	case *glimage.ASTC:
+		return m.Pix
+	case *glimage.BC1:
+		return m.Pix
+	case *glimage.BC2:
+		return m.Pix
+	case *glimage.BC3:
+		return m.Pix
+	case *glimage.BC4:
+		return m.Pix
+	case *glimage.BC4Signed:
+		return m.Pix
+	case *glimage.BC5:
+		return m.Pix
+	case *glimage.BC5Signed:
+		return m.Pix
+	case *glimage.BC6H:
+		return m.Pix
+	case *glimage.BC7:
+		return m.Pix
+	}
+
+	data := make([]byte, rowLen*b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		copy(data[y*rowLen:(y+1)*rowLen], pix[y*stride:])
+	}
+	return data
+}
+
+// supercompress returns the data of a mipmap level compressed
+// with the scheme of the options.
+func (e *encoder) supercompress(data []byte) ([]byte, error) {
+	switch e.opts.Supercompression {
+	case SupercompressionNone:
+		return data, nil
+	case SupercompressionZstd:
+		return zstd.Compress(data), nil
+	case SupercompressionZLIB:
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("KTX2 writer: unsupported supercompression scheme [%v]", e.opts.Supercompression)
+}
+
+// levelAlignment returns the alignment of the mipmap levels in the file.
+func (e *encoder) levelAlignment() int {
+	if e.opts.Supercompression != SupercompressionNone {
+		return 1
+	}
+	// Least common multiple of the texel block size and 4
+	size := int(e.dfd.BytesPlane[0])
+	for a := size; ; a += size {
+		if a%4 == 0 {
+			return a
+		}
+	}
+}
+
+func (e *encoder) encode() error {
+	t := e.tex
+	if len(t.Images) == 0 || len(t.Images[0]) == 0 || len(t.Images[0][0]) == 0 {
+		return fmt.Errorf("KTX2 writer: no image to write")
+	}
+	base := t.Images[0][0][0]
+	if err := e.setFormat(base); err != nil {
+		return err
+	}
+
+	h := &e.header
+	levels, layers, faces := len(t.Images), len(t.Images[0]), len(t.Images[0][0])
+	if faces != 1 && faces != 6 {
+		return fmt.Errorf("KTX2 writer: invalid number of faces [%v]", faces)
+	}
+	if levels > maxLevels {
+		return fmt.Errorf("KTX2 writer: too many levels [%v]", levels)
+	}
+	h.PixelWidth = uint32(base.Bounds().Dx())
+	h.PixelHeight = uint32(base.Bounds().Dy())
+	h.FaceCount = uint32(faces)
+	h.LevelCount = uint32(levels)
+	h.SupercompressionScheme = e.opts.Supercompression
+	if layers > 1 || t.LayerCount == 1 {
+		h.LayerCount = uint32(layers)
+	}
+
+	// Gather the data of every level before writing anything
+	levelData := make([][]byte, levels)
+	uncompressedLengths := make([]int, levels)
+	for level := 0; level < levels; level++ {
+		if len(t.Images[level]) != layers {
+			return fmt.Errorf("KTX2 writer: wrong number of layers in level %v [%v != %v]", level, len(t.Images[level]), layers)
+		}
+		width, height := h.LevelSize(level)
+		var data []byte
+		for layer := 0; layer < layers; layer++ {
+			if len(t.Images[level][layer]) != faces {
+				return fmt.Errorf("KTX2 writer: wrong number of faces in level %v [%v != %v]", level, len(t.Images[level][layer]), faces)
+			}
+			for _, im := range t.Images[level][layer] {
+				if b := im.Bounds(); b.Dx() != width || b.Dy() != height {
+					return fmt.Errorf("KTX2 writer: wrong image size in level %v [%vx%v != %vx%v]", level, b.Dx(), b.Dy(), width, height)
+				}
+				if reflect.TypeOf(im) != reflect.TypeOf(base) {
+					return fmt.Errorf("KTX2 writer: mixed image types [%T %T]", im, base)
+				}
+				data = append(data, imageData(im)...)
+			}
+		}
+		uncompressedLengths[level] = len(data)
+		compressed, err := e.supercompress(data)
+		if err != nil {
+			return err
+		}
+		levelData[level] = compressed
+	}
+
+	dfd := encodeDFD(e.dfd)
+	kvd := e.encodeKeyValues(t.KeyValues)
+	dfdOffset := headerSize + levelIndexSize*levels
+	kvdOffset := dfdOffset + len(dfd)
+	if len(kvd) == 0 {
+		kvdOffset = 0
+	}
+
+	// Levels are stored from the smallest one
+	alignment := e.levelAlignment()
+	offsets := make([]int, levels)
+	offset := dfdOffset + len(dfd) + len(kvd)
+	for level := levels - 1; level >= 0; level-- {
+		offset += (alignment - offset%alignment) % alignment
+		offsets[level] = offset
+		offset += len(levelData[level])
+	}
+
+	e.write([]byte(magic))
+	for _, f := range []uint32{
+		h.VkFormat, h.TypeSize,
+		h.PixelWidth, h.PixelHeight, h.PixelDepth,
+		h.LayerCount, h.FaceCount, h.LevelCount,
+		h.SupercompressionScheme,
+		uint32(dfdOffset), uint32(len(dfd)),
+		uint32(kvdOffset), uint32(len(kvd)),
+	} {
+		e.writeUint32(f)
+	}
+	// No supercompression global data
+	e.writeUint64(0)
+	e.writeUint64(0)
+	for level := 0; level < levels; level++ {
+		e.writeUint64(uint64(offsets[level]))
+		e.writeUint64(uint64(len(levelData[level])))
+		e.writeUint64(uint64(uncompressedLengths[level]))
+	}
+	e.write(dfd)
+	e.write(kvd)
+
+	written := dfdOffset + len(dfd) + len(kvd)
+	for level := levels - 1; level >= 0; level-- {
+		e.write(e.padding[:offsets[level]-written])
+		e.write(levelData[level])
+		written = offsets[level] + len(levelData[level])
+	}
+	return e.err
+}
+
+// EncodeTexture writes every image of tex to w in KTX2 format. The Vulkan
+// format and the data format descriptor are decided by the type of the images.
+func EncodeTexture(w io.Writer, tex *Texture, opts *Options) error {
+	e := &encoder{w: w, tex: tex}
+	if opts != nil {
+		e.opts = *opts
+	}
+	return e.encode()
+}
+
+// Encode writes the image m to w in KTX2 format, followed by the mipmap levels
+// and the key/value pairs in opts. The type of m must be one of *image.Gray,
+// *image.RGBA, *image.NRGBA, *glimage.RGB, *glimage.RGB565,
+// *glimage.NRGBA4444, or one of the block-compressed images *glimage.ETC1,
+// *glimage.ETC2RGB8, *glimage.ETC2RGB8A1, *glimage.ETC2RGBA8, the EAC images
+// (*glimage.EACR11, *glimage.EACRG11 and their signed variants),
+// *glimage.ASTC, *glimage.BC1 to *glimage.BC7 and the signed BC4 and BC5.
+// Block-compressed images are written as is, and their Vulkan format and data
+// format descriptor follow their block footprint and variant.
+func Encode(w io.Writer, m image.Image, opts *Options) error {
+	tex := &Texture{Images: [][][]image.Image{{{m}}}}
+	if opts != nil {
+		for _, mipmap := range opts.Mipmaps {
+			tex.Images = append(tex.Images, [][]image.Image{{mipmap}})
+		}
+		tex.KeyValues = opts.KeyValues
+	}
+	return EncodeTexture(w, tex, opts)
+}
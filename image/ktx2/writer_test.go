@@ -0,0 +1,262 @@
+package ktx2
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+	"github.com/hantempo/glu/image/internal/imagetest"
+)
+
+var supercompressions = []uint32{SupercompressionNone, SupercompressionZstd, SupercompressionZLIB}
+
+func TestEncode(t *testing.T) {
+	r := image.Rect(0, 0, 5, 3)
+	tests := []struct {
+		im        image.Image
+		srgb      bool
+		vkFormat  uint32
+		typeSize  uint32
+		texelSize uint8
+		samples   int
+	}{
+		{imagetest.FillImage(image.NewGray(r)), false, VK_FORMAT_R8_UNORM, 1, 1, 1},
+		{imagetest.FillImage(glimage.NewRGB(r)), false, VK_FORMAT_R8G8B8_UNORM, 1, 3, 3},
+		{imagetest.FillImage(glimage.NewRGB(r)), true, VK_FORMAT_R8G8B8_SRGB, 1, 3, 3},
+		{imagetest.FillImage(image.NewNRGBA(r)), false, VK_FORMAT_R8G8B8A8_UNORM, 1, 4, 4},
+		{imagetest.FillImage(image.NewRGBA(r)), true, VK_FORMAT_R8G8B8A8_SRGB, 1, 4, 4},
+		{imagetest.FillImage(glimage.NewRGB565(r)), false, VK_FORMAT_R5G6B5_UNORM_PACK16, 2, 2, 3},
+		{imagetest.FillImage(glimage.NewNRGBA4444(r)), false, VK_FORMAT_R4G4B4A4_UNORM_PACK16, 2, 2, 4},
+	}
+	for _, test := range tests {
+		for _, scheme := range supercompressions {
+			var buf bytes.Buffer
+			if err := Encode(&buf, test.im, &Options{Supercompression: scheme, SRGB: test.srgb}); err != nil {
+				t.Errorf("Unexpected error with %T and scheme %v: %v", test.im, scheme, err)
+				continue
+			}
+			tex, err := DecodeTexture(&buf)
+			if err != nil {
+				t.Errorf("Unexpected error when decoding %T with scheme %v: %v", test.im, scheme, err)
+				continue
+			}
+			if tex.VkFormat != test.vkFormat || tex.TypeSize != test.typeSize || tex.SupercompressionScheme != scheme {
+				t.Errorf("Wrong header of %T : got %+v", test.im, tex.Header)
+			}
+			if len(tex.DFD.Samples) != test.samples || tex.DFD.BytesPlane[0] != test.texelSize {
+				t.Errorf("Wrong data format descriptor of %T : got %+v", test.im, tex.DFD)
+			}
+			if !imagetest.SameImage(test.im, tex.Image(0, 0, 0)) {
+				t.Errorf("Wrong round trip of %T with scheme %v", test.im, scheme)
+			}
+		}
+	}
+}
+
+func TestEncodeDFD(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 1, 1)), &Options{SRGB: true}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := DecodeTextureConfig(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfd := config.DFD
+	if dfd.ColorModel != ModelRGBSDA || dfd.ColorPrimaries != PrimariesBT709 || dfd.TransferFunction != TransferSRGB ||
+		dfd.BytesPlane[0] != 4 || dfd.TexelBlockDimension != [4]uint8{1, 1, 1, 1} {
+		t.Errorf("Wrong data format descriptor : got %+v", dfd)
+	}
+	expected := []Sample{
+		{BitOffset: 0, BitLength: 8, ChannelType: ChannelRGBSDARed, SampleUpper: 0xFF},
+		{BitOffset: 8, BitLength: 8, ChannelType: ChannelRGBSDAGreen, SampleUpper: 0xFF},
+		{BitOffset: 16, BitLength: 8, ChannelType: ChannelRGBSDABlue, SampleUpper: 0xFF},
+		{BitOffset: 24, BitLength: 8, ChannelType: ChannelRGBSDAAlpha | QualifierLinear, SampleUpper: 0xFF},
+	}
+	for i, s := range dfd.Samples {
+		if s != expected[i] {
+			t.Errorf("Wrong sample %v : expected %+v, got %+v", i, expected[i], s)
+		}
+	}
+}
+
+func TestEncodeETC1(t *testing.T) {
+	block := []byte{0xE8, 0xA0, 0x18, 0x03, 0x00, 0x0F, 0x00, 0x00}
+	for _, scheme := range supercompressions {
+		m := glimage.NewETC1(image.Rect(0, 0, 8, 4))
+		copy(m.Pix, block)
+		copy(m.Pix[8:], block)
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, m, &Options{Supercompression: scheme}); err != nil {
+			t.Fatal(err)
+		}
+		tex, err := DecodeTexture(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dfd := tex.DFD
+		if tex.VkFormat != VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK || dfd.ColorModel != ModelETC1 ||
+			dfd.TexelBlockDimension != [4]uint8{4, 4, 1, 1} || dfd.BytesPlane[0] != 8 ||
+			len(dfd.Samples) != 1 || dfd.Samples[0].BitLength != 64 {
+			t.Errorf("Wrong ETC1 format : got %v %+v", tex.VkFormat, dfd)
+		}
+		if decoded, ok := tex.Image(0, 0, 0).(*glimage.ETC1); !ok || !bytes.Equal(decoded.Pix, m.Pix) {
+			t.Errorf("Wrong round trip of ETC1 data with scheme %v", scheme)
+		}
+	}
+}
+
+// blockPix returns the pixel buffer of a block-compressed image.
+func blockPix(m image.Image) []byte {
+	return reflect.ValueOf(m).Elem().FieldByName("Pix").Bytes()
+}
+
+func TestEncodeBlocks(t *testing.T) {
+	r := image.Rect(0, 0, 8, 4)
+	astcSRGB := glimage.NewASTC(r, 6, 5)
+	astcSRGB.SRGB = true
+	astcHDR := glimage.NewASTC(r, 12, 10)
+	astcHDR.Profile = glimage.ASTCProfileHDR
+	bc6hSigned := glimage.NewBC6H(r)
+	bc6hSigned.Signed = true
+	tests := []struct {
+		im        image.Image
+		srgb      bool
+		vkFormat  uint32
+		model     uint8
+		blockSize [4]uint8
+		texelSize uint8
+		samples   int
+	}{
+		{glimage.NewETC2RGB8(r), false, VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewETC2RGB8(r), true, VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewETC2RGB8A1(r), false, VK_FORMAT_ETC2_R8G8B8A1_UNORM_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewETC2RGBA8(r), true, VK_FORMAT_ETC2_R8G8B8A8_SRGB_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewEACR11(r), false, VK_FORMAT_EAC_R11_UNORM_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewEACSignedR11(r), false, VK_FORMAT_EAC_R11_SNORM_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewEACRG11(r), false, VK_FORMAT_EAC_R11G11_UNORM_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewEACSignedRG11(r), false, VK_FORMAT_EAC_R11G11_SNORM_BLOCK, ModelETC2, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewASTC(r, 8, 5), false, VK_FORMAT_ASTC_8x5_UNORM_BLOCK, ModelASTC, [4]uint8{8, 5, 1, 1}, 16, 1},
+		{astcSRGB, false, VK_FORMAT_ASTC_6x5_SRGB_BLOCK, ModelASTC, [4]uint8{6, 5, 1, 1}, 16, 1},
+		{astcHDR, false, VK_FORMAT_ASTC_12x10_SFLOAT_BLOCK, ModelASTC, [4]uint8{12, 10, 1, 1}, 16, 1},
+		{glimage.NewBC1(r), false, VK_FORMAT_BC1_RGBA_UNORM_BLOCK, ModelBC1A, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewBC1(r), true, VK_FORMAT_BC1_RGBA_SRGB_BLOCK, ModelBC1A, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewBC2(r), false, VK_FORMAT_BC2_UNORM_BLOCK, ModelBC2, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewBC3(r), true, VK_FORMAT_BC3_SRGB_BLOCK, ModelBC3, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewBC4(r), false, VK_FORMAT_BC4_UNORM_BLOCK, ModelBC4, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewBC4Signed(r), false, VK_FORMAT_BC4_SNORM_BLOCK, ModelBC4, [4]uint8{4, 4, 1, 1}, 8, 1},
+		{glimage.NewBC5(r), false, VK_FORMAT_BC5_UNORM_BLOCK, ModelBC5, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewBC5Signed(r), false, VK_FORMAT_BC5_SNORM_BLOCK, ModelBC5, [4]uint8{4, 4, 1, 1}, 16, 2},
+		{glimage.NewBC6H(r), false, VK_FORMAT_BC6H_UFLOAT_BLOCK, ModelBC6H, [4]uint8{4, 4, 1, 1}, 16, 1},
+		{bc6hSigned, false, VK_FORMAT_BC6H_SFLOAT_BLOCK, ModelBC6H, [4]uint8{4, 4, 1, 1}, 16, 1},
+		{glimage.NewBC7(r), true, VK_FORMAT_BC7_SRGB_BLOCK, ModelBC7, [4]uint8{4, 4, 1, 1}, 16, 1},
+	}
+	for _, test := range tests {
+		pix := blockPix(test.im)
+		for i := range pix {
+			pix[i] = uint8(i * 37)
+		}
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, test.im, &Options{SRGB: test.srgb}); err != nil {
+			t.Errorf("Unexpected error with %T : %v", test.im, err)
+			continue
+		}
+		tex, err := DecodeTexture(&buf)
+		if err != nil {
+			t.Errorf("Unexpected error when decoding %T : %v", test.im, err)
+			continue
+		}
+		dfd := tex.DFD
+		if tex.VkFormat != test.vkFormat || dfd.ColorModel != test.model || dfd.TexelBlockDimension != test.blockSize ||
+			dfd.BytesPlane[0] != test.texelSize || len(dfd.Samples) != test.samples {
+			t.Errorf("Wrong format of %T : got %v %+v", test.im, tex.VkFormat, dfd)
+		}
+		decoded := tex.Image(0, 0, 0)
+		if !reflect.DeepEqual(decoded, test.im) {
+			t.Errorf("Wrong round trip of %T : got %+v", test.im, decoded)
+		}
+		if _, _, _, ok := tex.GLFormat(); !ok {
+			t.Errorf("No GL format of %v", tex.VkFormat)
+		}
+	}
+}
+
+func TestEncodeTexture(t *testing.T) {
+	kv := make(KeyValues)
+	kv.SetString(KeyOrientation, "rd")
+	kv.SetString(KeyWriter, "glu")
+
+	for _, scheme := range supercompressions {
+		// Cubemap array of 2 layers with 3 levels
+		tex := &Texture{Images: make([][][]image.Image, 3)}
+		tex.KeyValues = kv
+		for level := range tex.Images {
+			size := 4 >> uint(level)
+			tex.Images[level] = make([][]image.Image, 2)
+			for layer := range tex.Images[level] {
+				for face := 0; face < 6; face++ {
+					m := glimage.NewRGB(image.Rect(0, 0, size, size))
+					m.Set(0, 0, color.RGBA{uint8(level), uint8(layer), uint8(face), 0xFF})
+					tex.Images[level][layer] = append(tex.Images[level][layer], m)
+				}
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := EncodeTexture(&buf, tex, &Options{Supercompression: scheme}); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeTexture(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.LevelCount != 3 || decoded.LayerCount != 2 || decoded.FaceCount != 6 {
+			t.Fatalf("Wrong header : got %+v", decoded.Header)
+		}
+		for level := range tex.Images {
+			for layer := range tex.Images[level] {
+				for face := range tex.Images[level][layer] {
+					if !imagetest.SameImage(tex.Image(level, layer, face), decoded.Image(level, layer, face)) {
+						t.Errorf("Wrong image [%v %v %v] with scheme %v", level, layer, face, scheme)
+					}
+				}
+			}
+		}
+		if o, ok := decoded.KeyValues.Orientation(); !ok || o != "rd" {
+			t.Errorf("Wrong orientation : got %v", o)
+		}
+		if w, ok := decoded.KeyValues.Writer(); !ok || w != "glu" {
+			t.Errorf("Wrong writer : got %v", w)
+		}
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	r := image.Rect(0, 0, 4, 4)
+	tests := []struct {
+		tex  *Texture
+		opts *Options
+		err  string
+	}{
+		{&Texture{}, nil, "KTX2 writer: no image to write"},
+		{&Texture{Images: [][][]image.Image{{{image.NewPaletted(r, nil)}}}}, nil, "KTX2 writer: unsupported image type"},
+		{&Texture{Images: [][][]image.Image{{{image.NewNRGBA(r)}}}}, &Options{Supercompression: SupercompressionBasisLZ}, "KTX2 writer: unsupported supercompression scheme"},
+		{&Texture{Images: [][][]image.Image{{{image.NewNRGBA(r), image.NewNRGBA(r)}}}}, nil, "KTX2 writer: invalid number of faces"},
+		{&Texture{Images: [][][]image.Image{{{image.NewNRGBA(r)}}, {{image.NewNRGBA(r)}}}}, nil, "KTX2 writer: wrong image size in level 1"},
+		{&Texture{Images: [][][]image.Image{{{image.NewNRGBA(r)}}, {{image.NewGray(image.Rect(0, 0, 2, 2))}}}}, nil, "KTX2 writer: mixed image types"},
+	}
+	for _, test := range tests {
+		err := EncodeTexture(&bytes.Buffer{}, test.tex, test.opts)
+		if err == nil {
+			t.Errorf("Expected pattern of error message (%s), got no error", test.err)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%s)", test.err, err.Error())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package image
+
+import (
+	"fmt"
+
+	"github.com/hantempo/glu/enum"
+)
+
+// MipLevel is where a mipmap level is stored in a mipmap chain.
+type MipLevel struct {
+	Width, Height int
+	// Offset of the level from the beginning of the chain
+	Offset int
+	// ImageSize is the size of the image of one layer and face, and Size the
+	// size of the whole level.
+	ImageSize, Size int
+}
+
+// isPVRTC returns whether f is one of the PVRTC1 formats, whose textures
+// have at least 2x2 blocks.
+func isPVRTC(f enum.InternalFormat) bool {
+	return f >= enum.GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG && f <= enum.GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG
+}
+
+// alignedImageSize returns the size of an image of width x height x depth
+// pixels of the internal format f, whose rows of pixels are aligned to
+// alignment bytes. Rows of blocks of compressed formats are not aligned.
+func alignedImageSize(f enum.InternalFormat, width, height, depth, alignment int) (int, error) {
+	info, ok := enum.InternalFormatInfo(f)
+	if !ok {
+		return 0, fmt.Errorf("image layout: unknown internal format [%v]", f)
+	}
+	if info.BytesPerBlock == 0 {
+		return 0, fmt.Errorf("image layout: unsized internal format [%v]", f)
+	}
+	if width < 0 || height < 0 || depth < 0 {
+		return 0, fmt.Errorf("image layout: invalid dimensions [%vx%vx%v]", width, height, depth)
+	}
+	if !info.Compressed {
+		rowSize := width * info.BytesPerBlock
+		rowSize = (rowSize + alignment - 1) / alignment * alignment
+		return rowSize * height * depth, nil
+	}
+
+	xBlocks := (width + info.BlockWidth - 1) / info.BlockWidth
+	yBlocks := (height + info.BlockHeight - 1) / info.BlockHeight
+	zBlocks := (depth + info.BlockDepth - 1) / info.BlockDepth
+	if isPVRTC(f) {
+		xBlocks, yBlocks = pvrtcBlocks(width, info.BlockWidth), pvrtcBlocks(height, info.BlockHeight)
+	}
+	return xBlocks * yBlocks * zBlocks * info.BytesPerBlock, nil
+}
+
+// ImageSize returns how many bytes hold an image of width x height x depth
+// pixels of the sized or compressed internal format f, with rows of pixels
+// packed without padding.
+func ImageSize(f enum.InternalFormat, width, height, depth int) (int, error) {
+	return alignedImageSize(f, width, height, depth, 1)
+}
+
+// MipLevels returns the number of mipmap levels of a full chain whose base
+// level is width x height pixels, down to 1x1: floor(log2(max(width, height)))+1.
+func MipLevels(width, height int) int {
+	levels := 1
+	for size := width | height; size > 1; size >>= 1 {
+		levels++
+	}
+	return levels
+}
+
+// MipChainLayout returns the dimensions, offset and size of the given number
+// of mipmap levels of a 2D texture of the internal format f. A levels count
+// of 0 means the full chain down to 1x1, and counts past the 1x1 level, as
+// given by MipLevels, are rejected. Every level holds its array layers of
+// cube faces one after the other, and rows of pixels of uncompressed formats
+// are aligned to alignment bytes, as with GL_UNPACK_ALIGNMENT.
+func MipChainLayout(f enum.InternalFormat, width, height, layers, faces, levels, alignment int) ([]MipLevel, error) {
+	if alignment != 1 && alignment != 2 && alignment != 4 && alignment != 8 {
+		return nil, fmt.Errorf("image layout: invalid alignment [%v]", alignment)
+	}
+	if layers < 1 || faces < 1 || levels < 0 {
+		return nil, fmt.Errorf("image layout: invalid number of layers, faces or levels [%v %v %v]", layers, faces, levels)
+	}
+	if full := MipLevels(width, height); levels > full {
+		return nil, fmt.Errorf("image layout: too many levels for %vx%v [%v > %v]", width, height, levels, full)
+	} else if levels == 0 {
+		levels = full
+	}
+
+	chain := make([]MipLevel, levels)
+	offset := 0
+	for level := range chain {
+		w, h := width>>uint(level), height>>uint(level)
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		size, err := alignedImageSize(f, w, h, 1, alignment)
+		if err != nil {
+			return nil, err
+		}
+		chain[level] = MipLevel{w, h, offset, size, size * layers * faces}
+		offset += chain[level].Size
+	}
+	return chain, nil
+}
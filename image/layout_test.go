@@ -0,0 +1,116 @@
+package image
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hantempo/glu/enum"
+)
+
+func TestImageSize(t *testing.T) {
+	tests := []struct {
+		f                    enum.InternalFormat
+		width, height, depth int
+		size                 int
+	}{
+		{enum.GL_ETC1_RGB8_OES, 5, 3, 1, 2 * 1 * 8},
+		{enum.GL_COMPRESSED_RGBA8_ETC2_EAC, 4, 4, 1, 16},
+		{enum.GL_COMPRESSED_RGBA_ASTC_8x5_KHR, 17, 10, 1, 3 * 2 * 16},
+		{enum.GL_COMPRESSED_RGBA_ASTC_4x4x3_OES, 5, 4, 7, 2 * 1 * 3 * 16},
+		{enum.GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG, 4, 4, 1, 2 * 2 * 8},
+		{enum.GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG, 32, 8, 1, 4 * 2 * 8},
+		{enum.GL_RGB8, 3, 2, 1, 18},
+		{enum.GL_RGBA16F, 2, 2, 3, 2 * 2 * 3 * 8},
+		{enum.GL_RGB565, 0, 0, 1, 0},
+	}
+	for _, test := range tests {
+		size, err := ImageSize(test.f, test.width, test.height, test.depth)
+		if err != nil {
+			t.Errorf("Unexpected error of %v : %v", test.f, err)
+		} else if size != test.size {
+			t.Errorf("Wrong size of %v %vx%vx%v : expected %v, got %v", test.f, test.width, test.height, test.depth, test.size, size)
+		}
+	}
+}
+
+func TestMipChainLayout(t *testing.T) {
+	tests := []struct {
+		f                                    enum.InternalFormat
+		width, height, layers, faces, levels int
+		alignment                            int
+		chain                                []MipLevel
+	}{
+		// Rows of 9 and 3 bytes padded to 12 and 4
+		{enum.GL_RGB8, 3, 2, 1, 1, 0, 4, []MipLevel{
+			{3, 2, 0, 24, 24},
+			{1, 1, 24, 4, 4},
+		}},
+		{enum.GL_RGB8, 3, 2, 1, 1, 0, 1, []MipLevel{
+			{3, 2, 0, 18, 18},
+			{1, 1, 18, 3, 3},
+		}},
+		// Rows of blocks are not padded
+		{enum.GL_ETC1_RGB8_OES, 8, 4, 2, 6, 3, 8, []MipLevel{
+			{8, 4, 0, 16, 192},
+			{4, 2, 192, 8, 96},
+			{2, 1, 288, 8, 96},
+		}},
+		{enum.GL_COMPRESSED_RGBA_ASTC_6x6_KHR, 16, 4, 1, 1, 0, 4, []MipLevel{
+			{16, 4, 0, 48, 48},
+			{8, 2, 48, 32, 32},
+			{4, 1, 80, 16, 16},
+			{2, 1, 96, 16, 16},
+			{1, 1, 112, 16, 16},
+		}},
+	}
+	for _, test := range tests {
+		chain, err := MipChainLayout(test.f, test.width, test.height, test.layers, test.faces, test.levels, test.alignment)
+		if err != nil {
+			t.Errorf("Unexpected error of %v : %v", test.f, err)
+		} else if !reflect.DeepEqual(chain, test.chain) {
+			t.Errorf("Wrong layout of %v %vx%v : expected %v, got %v", test.f, test.width, test.height, test.chain, chain)
+		}
+	}
+}
+
+func TestMipLevels(t *testing.T) {
+	tests := []struct {
+		width, height, levels int
+	}{
+		{1, 1, 1},
+		{2, 1, 2},
+		{5, 3, 3},
+		{16, 4, 5},
+		{1, 1024, 11},
+	}
+	for _, test := range tests {
+		if levels := MipLevels(test.width, test.height); levels != test.levels {
+			t.Errorf("Wrong number of levels of %vx%v : expected %v, got %v", test.width, test.height, test.levels, levels)
+		}
+	}
+}
+
+func TestMipChainLayoutError(t *testing.T) {
+	tests := []struct {
+		f                                    enum.InternalFormat
+		width, height, layers, faces, levels int
+		alignment                            int
+		err                                  string
+	}{
+		{enum.GL_RGBA8, 4, 4, 1, 1, 1, 3, "image layout: invalid alignment [3]"},
+		{enum.GL_RGBA8, 4, 4, 0, 1, 1, 4, "image layout: invalid number of layers, faces or levels"},
+		{enum.GL_RGBA8, 5, 3, 1, 1, 4, 4, "image layout: too many levels for 5x3 [4 > 3]"},
+		{enum.GL_RGBA8, 1, 1, 1, 1, 0x7FFFFFFF, 4, "image layout: too many levels for 1x1"},
+		{enum.GL_RGBA, 4, 4, 1, 1, 1, 4, "image layout: unsized internal format [GL_RGBA]"},
+		{enum.GL_UNSIGNED_BYTE, 4, 4, 1, 1, 1, 4, "image layout: unknown internal format"},
+	}
+	for _, test := range tests {
+		_, err := MipChainLayout(test.f, test.width, test.height, test.layers, test.faces, test.levels, test.alignment)
+		if err == nil {
+			t.Errorf("Expected pattern of error message (%s), got no error", test.err)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
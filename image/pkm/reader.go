@@ -0,0 +1,208 @@
+// Package pkm reads and writes the PKM files of the ETC tools, which store
+// the ETC1 or ETC2 blocks of a single image after a 16-byte header.
+package pkm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+const magic = "PKM "
+
+// Versions of the format, PKM 10 only stores ETC1 blocks
+const (
+	Version10 = "10"
+	Version20 = "20"
+)
+
+const headerSize = 16
+
+// Data types of the blocks
+const (
+	TypeETC1RGB      = 0
+	TypeETC2RGB      = 1
+	TypeETC2RGBAOld  = 2
+	TypeETC2RGBA     = 3
+	TypeETC2RGBA1    = 4
+	TypeETC2R        = 5
+	TypeETC2RG       = 6
+	TypeETC2SignedR  = 7
+	TypeETC2SignedRG = 8
+	TypeETC2SRGB     = 9
+	TypeETC2SRGBA    = 10
+	TypeETC2SRGBA1   = 11
+)
+
+// Header holds the fields of a PKM file header which follow the magic.
+// Fields are stored in big endian.
+type Header struct {
+	Version  string
+	DataType uint16
+	// Size of the image rounded up to whole blocks
+	ExtendedWidth, ExtendedHeight uint16
+	Width, Height                 uint16
+}
+
+// format describes how the images of a data type are decoded.
+type format struct {
+	model color.Model
+	// newImage allocates an image and returns it with its blocks.
+	newImage func(r image.Rectangle) (image.Image, []byte)
+}
+
+var etc1Format = format{glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewETC1(r)
+	return m, m.Pix
+}}
+
+var etc2RGBFormat = format{glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewETC2RGB8(r)
+	return m, m.Pix
+}}
+
+var etc2RGBAFormat = format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewETC2RGBA8(r)
+	return m, m.Pix
+}}
+
+var etc2RGBA1Format = format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewETC2RGB8A1(r)
+	return m, m.Pix
+}}
+
+var formats = map[uint16]format{
+	TypeETC1RGB:     etc1Format,
+	TypeETC2RGB:     etc2RGBFormat,
+	TypeETC2RGBAOld: etc2RGBAFormat,
+	TypeETC2RGBA:    etc2RGBAFormat,
+	TypeETC2RGBA1:   etc2RGBA1Format,
+	TypeETC2R: {glcolor.R16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACR11(r)
+		return m, m.Pix
+	}},
+	TypeETC2RG: {glcolor.RG16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACRG11(r)
+		return m, m.Pix
+	}},
+	TypeETC2SignedR: {glcolor.SignedR16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACSignedR11(r)
+		return m, m.Pix
+	}},
+	TypeETC2SignedRG: {glcolor.SignedRG16Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewEACSignedRG11(r)
+		return m, m.Pix
+	}},
+	TypeETC2SRGB:   etc2RGBFormat,
+	TypeETC2SRGBA:  etc2RGBAFormat,
+	TypeETC2SRGBA1: etc2RGBA1Format,
+}
+
+type decoder struct {
+	r      io.Reader
+	header Header
+	format format
+	im     image.Image
+}
+
+func (d *decoder) decode(r io.Reader, configOnly bool) error {
+	d.r = r
+	if err := d.decodeHeader(); err != nil {
+		return err
+	}
+	if configOnly {
+		return nil
+	}
+	return d.decodeImage()
+}
+
+func (d *decoder) decodeHeader() error {
+	var buf [headerSize]byte
+	if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+		return err
+	}
+	if magic != string(buf[:4]) {
+		return fmt.Errorf("PKM reader: invalid identifier [%v]", buf[:4])
+	}
+
+	h := &d.header
+	h.Version = string(buf[4:6])
+	h.DataType = binary.BigEndian.Uint16(buf[6:])
+	h.ExtendedWidth = binary.BigEndian.Uint16(buf[8:])
+	h.ExtendedHeight = binary.BigEndian.Uint16(buf[10:])
+	h.Width = binary.BigEndian.Uint16(buf[12:])
+	h.Height = binary.BigEndian.Uint16(buf[14:])
+
+	switch {
+	case h.Version == Version10 && h.DataType == TypeETC1RGB:
+	case h.Version == Version20:
+	case h.Version == Version10:
+		return fmt.Errorf("PKM reader: unsupported data type of version 10 [%v]", h.DataType)
+	default:
+		return fmt.Errorf("PKM reader: unsupported version [%q]", h.Version)
+	}
+	f, ok := formats[h.DataType]
+	if !ok {
+		return fmt.Errorf("PKM reader: unsupported data type [%v]", h.DataType)
+	}
+	d.format = f
+	// Blocks are laid out by the rounded size
+	if h.ExtendedWidth != roundUp(h.Width) || h.ExtendedHeight != roundUp(h.Height) {
+		return fmt.Errorf("PKM reader: inconsistent extended size [%vx%v for %vx%v]", h.ExtendedWidth, h.ExtendedHeight, h.Width, h.Height)
+	}
+	return nil
+}
+
+// roundUp returns v rounded up to whole blocks of 4 pixels.
+func roundUp(v uint16) uint16 {
+	return (v + 3) &^ 3
+}
+
+func (d *decoder) decodeImage() error {
+	h := &d.header
+	im, pix := d.format.newImage(image.Rect(0, 0, int(h.Width), int(h.Height)))
+	if _, err := io.ReadFull(d.r, pix); err != nil {
+		return fmt.Errorf("PKM reader: not enough image data [%v]", err)
+	}
+	d.im = im
+	return nil
+}
+
+// Decode reads a PKM file from r and returns the image as a *glimage.ETC1,
+// one of the ETC2 images or one of the EAC R11 and RG11 images.
+func Decode(r io.Reader) (image.Image, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.im, nil
+}
+
+// DecodeHeader reads the header of a PKM file from r without decoding
+// the blocks.
+func DecodeHeader(r io.Reader) (Header, error) {
+	var d decoder
+	if err := d.decode(r, true); err != nil {
+		return Header{}, err
+	}
+	return d.header, nil
+}
+
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	var d decoder
+	err := d.decode(r, true)
+	return image.Config{
+		ColorModel: d.format.model,
+		Width:      int(d.header.Width),
+		Height:     int(d.header.Height),
+	}, err
+}
+
+func init() {
+	image.RegisterFormat("pkm", magic, Decode, DecodeConfig)
+}
@@ -0,0 +1,89 @@
+package pkm
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+var etc1Block = []byte{0xE8, 0xA0, 0x18, 0x03, 0x00, 0x0F, 0x00, 0x00}
+
+// pkmFile builds a PKM file with the given header fields and blocks.
+func pkmFile(version string, dataType, extendedWidth, extendedHeight, width, height uint16, blocks ...[]byte) []byte {
+	buf := []byte(magic + version)
+	for _, v := range []uint16{dataType, extendedWidth, extendedHeight, width, height} {
+		buf = append(buf, byte(v>>8), byte(v))
+	}
+	for _, b := range blocks {
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+func TestDecode(t *testing.T) {
+	// Two ETC1 blocks cropped to 6x3
+	input := pkmFile(Version10, TypeETC1RGB, 8, 4, 6, 3, etc1Block, etc1Block)
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "pkm" || config.ColorModel != glcolor.RGBModel || config.Width != 6 || config.Height != 3 {
+		t.Errorf("Wrong config : got %v %+v", format, config)
+	}
+	header, err := DecodeHeader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Header{Version10, TypeETC1RGB, 8, 4, 6, 3}); header != expected {
+		t.Errorf("Wrong header : expected %+v, got %+v", expected, header)
+	}
+
+	m, _, err := image.Decode(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etc1, ok := m.(*glimage.ETC1)
+	if !ok {
+		t.Fatalf("Wrong image type : got %T", m)
+	}
+	expected := glimage.NewETC1(image.Rect(0, 0, 6, 3))
+	copy(expected.Pix, input[headerSize:])
+	if etc1.Bounds() != expected.Bounds() || !bytes.Equal(etc1.Pix, expected.Pix) {
+		t.Errorf("Wrong image : expected %v %v, got %v %v", expected.Bounds(), expected.Pix, etc1.Bounds(), etc1.Pix)
+	}
+
+	// The sRGB data types share the images of the linear ones
+	input = pkmFile(Version20, TypeETC2SRGBA, 4, 4, 4, 4, etc1Block, etc1Block)
+	if m, err = Decode(bytes.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(*glimage.ETC2RGBA8); !ok {
+		t.Errorf("Wrong image type of sRGBA data : got %T", m)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{[]byte("KTX 10\x00\x00\x00\x04\x00\x04\x00\x04\x00\x04"), "PKM reader: invalid identifier"},
+		{pkmFile("30", TypeETC1RGB, 4, 4, 4, 4, etc1Block), "PKM reader: unsupported version"},
+		{pkmFile(Version10, TypeETC2RGB, 4, 4, 4, 4, etc1Block), "PKM reader: unsupported data type of version 10"},
+		{pkmFile(Version20, 12, 4, 4, 4, 4, etc1Block), "PKM reader: unsupported data type"},
+		{pkmFile(Version20, TypeETC2RGB, 8, 4, 4, 4, etc1Block), "PKM reader: inconsistent extended size"},
+		{pkmFile(Version20, TypeETC2RGBA, 4, 4, 4, 4, etc1Block), "PKM reader: not enough image data"},
+		{[]byte(magic + Version10), "unexpected EOF"},
+	}
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
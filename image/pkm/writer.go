@@ -0,0 +1,69 @@
+package pkm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+// Largest width and height which fit in the header
+const maxSize = 0xFFFC
+
+// Encode writes the image m to w in PKM format. The type of m must be one of
+// *glimage.ETC1, *glimage.ETC2RGB8, *glimage.ETC2RGB8A1, *glimage.ETC2RGBA8
+// or one of the EAC R11 and RG11 images. ETC1 images are written in the
+// version 10 of the format, the others in the version 20.
+func Encode(w io.Writer, m image.Image) error {
+	version := Version20
+	var dataType uint16
+	var pix []byte
+	// How many bytes in a block of 4x4 pixels
+	blockSize := 8
+	switch m := m.(type) {
+	case *glimage.ETC1:
+		version, dataType, pix = Version10, TypeETC1RGB, m.Pix
+	case *glimage.ETC2RGB8:
+		dataType, pix = TypeETC2RGB, m.Pix
+	case *glimage.ETC2RGB8A1:
+		dataType, pix = TypeETC2RGBA1, m.Pix
+	case *glimage.ETC2RGBA8:
+		dataType, pix, blockSize = TypeETC2RGBA, m.Pix, 16
+	case *glimage.EACR11:
+		dataType, pix = TypeETC2R, m.Pix
+	case *glimage.EACRG11:
+		dataType, pix, blockSize = TypeETC2RG, m.Pix, 16
+	case *glimage.EACSignedR11:
+		dataType, pix = TypeETC2SignedR, m.Pix
+	case *glimage.EACSignedRG11:
+		dataType, pix, blockSize = TypeETC2SignedRG, m.Pix, 16
+	default:
+		return fmt.Errorf("PKM writer: unsupported image type [%T]", m)
+	}
+
+	b := m.Bounds()
+	if b.Dx() > maxSize || b.Dy() > maxSize {
+		return fmt.Errorf("PKM writer: image too large [%vx%v]", b.Dx(), b.Dy())
+	}
+	width, height := uint16(b.Dx()), uint16(b.Dy())
+	size := int(roundUp(width)/4) * int(roundUp(height)/4) * blockSize
+	if len(pix) < size {
+		return fmt.Errorf("PKM writer: not enough image data [%v < %v]", len(pix), size)
+	}
+
+	var header [headerSize]byte
+	copy(header[:], magic)
+	copy(header[4:], version)
+	binary.BigEndian.PutUint16(header[6:], dataType)
+	binary.BigEndian.PutUint16(header[8:], roundUp(width))
+	binary.BigEndian.PutUint16(header[10:], roundUp(height))
+	binary.BigEndian.PutUint16(header[12:], width)
+	binary.BigEndian.PutUint16(header[14:], height)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(pix[:size])
+	return err
+}
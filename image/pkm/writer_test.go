@@ -0,0 +1,70 @@
+package pkm
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+)
+
+func TestEncode(t *testing.T) {
+	r := image.Rect(0, 0, 6, 3)
+	etc1 := glimage.NewETC1(r)
+	etc2 := glimage.NewETC2RGB8(r)
+	etc2A1 := glimage.NewETC2RGB8A1(r)
+	etc2RGBA := glimage.NewETC2RGBA8(r)
+	r11 := glimage.NewEACSignedR11(r)
+	rg11 := glimage.NewEACRG11(r)
+	tests := []struct {
+		m        image.Image
+		pix      []byte
+		version  string
+		dataType uint16
+	}{
+		{etc1, etc1.Pix, Version10, TypeETC1RGB},
+		{etc2, etc2.Pix, Version20, TypeETC2RGB},
+		{etc2A1, etc2A1.Pix, Version20, TypeETC2RGBA1},
+		{etc2RGBA, etc2RGBA.Pix, Version20, TypeETC2RGBA},
+		{r11, r11.Pix, Version20, TypeETC2SignedR},
+		{rg11, rg11.Pix, Version20, TypeETC2RG},
+	}
+	for _, test := range tests {
+		for i := range test.pix {
+			test.pix[i] = byte(i * 7)
+		}
+		var buf bytes.Buffer
+		if err := Encode(&buf, test.m); err != nil {
+			t.Fatal(err)
+		}
+		if expected := pkmFile(test.version, test.dataType, 8, 4, 6, 3, test.pix); !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("Wrong file of %T : expected %v, got %v", test.m, expected, buf.Bytes())
+		}
+		m, err := Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(m, test.m) {
+			t.Errorf("Wrong decoded image of %T : got %T", test.m, m)
+		}
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	tests := []struct {
+		m   image.Image
+		err string
+	}{
+		{image.NewNRGBA(image.Rect(0, 0, 4, 4)), "PKM writer: unsupported image type"},
+		{glimage.NewETC1(image.Rect(0, 0, 0x10000, 4)), "PKM writer: image too large"},
+		{&glimage.ETC2RGBA8{Pix: make([]byte, 8), Rect: image.Rect(0, 0, 4, 4)}, "PKM writer: not enough image data"},
+	}
+	for _, test := range tests {
+		err := Encode(&bytes.Buffer{}, test.m)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
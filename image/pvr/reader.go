@@ -0,0 +1,505 @@
+// Package pvr reads the version 3 PVR files of the PowerVR tools, which
+// store the mipmap levels, surfaces and cube faces of a texture after
+// a header and its metadata.
+package pvr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// The version field of the header is "PVR\x03" in the byte order of the file
+const (
+	magic        = "PVR\x03"
+	magicSwapped = "\x03RVP"
+)
+
+const headerSize = 52
+
+// Flags of the header
+const (
+	FlagPremultiplied = 0x2
+)
+
+// Compressed pixel formats, which have no channel names
+const (
+	PixelFormatPVRTC2RGB  = 0
+	PixelFormatPVRTC2RGBA = 1
+	PixelFormatPVRTC4RGB  = 2
+	PixelFormatPVRTC4RGBA = 3
+	PixelFormatETC1       = 6
+	PixelFormatDXT1       = 7
+	PixelFormatDXT2       = 8
+	PixelFormatDXT3       = 9
+	PixelFormatDXT4       = 10
+	PixelFormatDXT5       = 11
+	PixelFormatBC4        = 12
+	PixelFormatBC5        = 13
+	PixelFormatBC6        = 14
+	PixelFormatBC7        = 15
+	PixelFormatETC2RGB    = 22
+	PixelFormatETC2RGBA   = 23
+	PixelFormatETC2RGBA1  = 24
+	PixelFormatEACR11     = 25
+	PixelFormatEACRG11    = 26
+	PixelFormatASTC4x4    = 27
+	PixelFormatASTC12x12  = 40
+)
+
+// Uncompressed pixel formats, whose low 32 bits name the channels in memory
+// order and whose high 32 bits hold their bits
+const (
+	PixelFormatRGBA8888 = 0x0808080861626772
+	PixelFormatBGRA8888 = 0x0808080861726762
+	PixelFormatRGB888   = 0x0008080800626772
+	PixelFormatL8       = 0x000000080000006C
+)
+
+// Color spaces
+const (
+	ColorSpaceLinear = 0
+	ColorSpaceSRGB   = 1
+)
+
+// Channel types
+const (
+	ChannelTypeUnsignedByteNorm    = 0
+	ChannelTypeSignedByteNorm      = 1
+	ChannelTypeUnsignedByte        = 2
+	ChannelTypeSignedByte          = 3
+	ChannelTypeUnsignedShortNorm   = 4
+	ChannelTypeSignedShortNorm     = 5
+	ChannelTypeUnsignedShort       = 6
+	ChannelTypeSignedShort         = 7
+	ChannelTypeUnsignedIntegerNorm = 8
+	ChannelTypeSignedIntegerNorm   = 9
+	ChannelTypeUnsignedInteger     = 10
+	ChannelTypeSignedInteger       = 11
+	ChannelTypeSignedFloat         = 12
+	ChannelTypeUnsignedFloat       = 13
+)
+
+// FourCC and keys of the metadata defined by the PVR specification
+const (
+	MetaDataFourCC = 0x03525650
+
+	MetaDataTextureAtlas = 0
+	MetaDataNormalMap    = 1
+	MetaDataCubeMapOrder = 2
+	MetaDataOrientation  = 3
+	MetaDataBorder       = 4
+	MetaDataPadding      = 5
+)
+
+// Header holds the fields of a PVR file header.
+type Header struct {
+	Version      uint32
+	Flags        uint32
+	PixelFormat  uint64
+	ColorSpace   uint32
+	ChannelType  uint32
+	Height       uint32
+	Width        uint32
+	Depth        uint32
+	NumSurfaces  uint32
+	NumFaces     uint32
+	MipMapCount  uint32
+	MetaDataSize uint32
+}
+
+// MetaData is a block of metadata, whose key is defined by the creator
+// identified by FourCC.
+type MetaData struct {
+	FourCC uint32
+	Key    uint32
+	Data   []byte
+}
+
+// Config holds the header and the metadata of a PVR file.
+type Config struct {
+	Header
+	MetaData []MetaData
+}
+
+// Lookup returns the data of the first metadata block of fourCC and key.
+func (c *Config) Lookup(fourCC, key uint32) ([]byte, bool) {
+	for _, m := range c.MetaData {
+		if m.FourCC == fourCC && m.Key == key {
+			return m.Data, true
+		}
+	}
+	return nil, false
+}
+
+// Levels returns the number of mipmap levels stored in the file.
+func (c *Config) Levels() int {
+	if c.MipMapCount == 0 {
+		return 1
+	}
+	return int(c.MipMapCount)
+}
+
+// Surfaces returns the number of array surfaces stored in the file,
+// which is 1 for non-array textures.
+func (c *Config) Surfaces() int {
+	if c.NumSurfaces == 0 {
+		return 1
+	}
+	return int(c.NumSurfaces)
+}
+
+// Faces returns the number of faces stored in the file,
+// which is 6 for cubemaps and 1 otherwise.
+func (c *Config) Faces() int {
+	if c.NumFaces == 0 {
+		return 1
+	}
+	return int(c.NumFaces)
+}
+
+// LevelSize returns the width and height of the given mipmap level.
+func (c *Config) LevelSize(level int) (width, height int) {
+	width = int(c.Width) >> uint(level)
+	height = int(c.Height) >> uint(level)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return
+}
+
+// Texture holds every image stored in a PVR file.
+type Texture struct {
+	Config
+	// Images is indexed by mipmap level, surface and cube face,
+	// in this order.
+	Images [][][]image.Image
+}
+
+// Image returns the image of the given mipmap level, surface and cube face.
+func (t *Texture) Image(level, surface, face int) image.Image {
+	return t.Images[level][surface][face]
+}
+
+type format struct {
+	model color.Model
+	// newImage allocates an image and returns it with its pixel buffer.
+	newImage func(r image.Rectangle) (image.Image, []byte)
+}
+
+var pvrtc2Format = format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewPVRTC(r, 2)
+	return m, m.Pix
+}}
+
+var pvrtc4Format = format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewPVRTC(r, 4)
+	return m, m.Pix
+}}
+
+var bc2Format = format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC2(r)
+	return m, m.Pix
+}}
+
+var bc3Format = format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+	m := glimage.NewBC3(r)
+	return m, m.Pix
+}}
+
+// Formats whose decoding does not depend on the channel type
+var formats = map[uint64]format{
+	PixelFormatPVRTC2RGB:  pvrtc2Format,
+	PixelFormatPVRTC2RGBA: pvrtc2Format,
+	PixelFormatPVRTC4RGB:  pvrtc4Format,
+	PixelFormatPVRTC4RGBA: pvrtc4Format,
+	PixelFormatETC1: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC1(r)
+		return m, m.Pix
+	}},
+	PixelFormatDXT1: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC1(r)
+		return m, m.Pix
+	}},
+	PixelFormatDXT2: bc2Format,
+	PixelFormatDXT3: bc2Format,
+	PixelFormatDXT4: bc3Format,
+	PixelFormatDXT5: bc3Format,
+	PixelFormatBC7: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewBC7(r)
+		return m, m.Pix
+	}},
+	PixelFormatETC2RGB: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGB8(r)
+		return m, m.Pix
+	}},
+	PixelFormatETC2RGBA: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGBA8(r)
+		return m, m.Pix
+	}},
+	PixelFormatETC2RGBA1: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewETC2RGB8A1(r)
+		return m, m.Pix
+	}},
+	PixelFormatRGBA8888: {color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := image.NewNRGBA(r)
+		return m, m.Pix
+	}},
+	PixelFormatBGRA8888: {glcolor.NBGRA8888Model, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewNBGRA8888(r)
+		return m, m.Pix
+	}},
+	PixelFormatRGB888: {glcolor.RGBModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := glimage.NewRGB(r)
+		return m, m.Pix
+	}},
+	PixelFormatL8: {color.GrayModel, func(r image.Rectangle) (image.Image, []byte) {
+		m := image.NewGray(r)
+		return m, m.Pix
+	}},
+}
+
+// Footprints of the ASTC pixel formats, in their order
+var astcFootprints = [][2]int{
+	{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6},
+	{8, 8}, {10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+}
+
+// isSigned tells whether the channels of the channel type are signed.
+func isSigned(channelType uint32) bool {
+	switch channelType {
+	case ChannelTypeSignedByteNorm, ChannelTypeSignedByte, ChannelTypeSignedShortNorm, ChannelTypeSignedShort,
+		ChannelTypeSignedIntegerNorm, ChannelTypeSignedInteger, ChannelTypeSignedFloat:
+		return true
+	}
+	return false
+}
+
+// formatOf returns how the images of a file of header h are decoded.
+func formatOf(h *Header) (format, bool) {
+	signed := isSigned(h.ChannelType)
+	switch pf := h.PixelFormat; {
+	case pf == PixelFormatBC4 && signed:
+		return format{glcolor.SignedR16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewBC4Signed(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatBC4:
+		return format{glcolor.R16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewBC4(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatBC5 && signed:
+		return format{glcolor.SignedRG16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewBC5Signed(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatBC5:
+		return format{glcolor.RG16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewBC5(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatBC6:
+		return format{glcolor.NRGBAF32Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewBC6H(r)
+			m.Signed = signed
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatEACR11 && signed:
+		return format{glcolor.SignedR16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewEACSignedR11(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatEACR11:
+		return format{glcolor.R16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewEACR11(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatEACRG11 && signed:
+		return format{glcolor.SignedRG16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewEACSignedRG11(r)
+			return m, m.Pix
+		}}, true
+	case pf == PixelFormatEACRG11:
+		return format{glcolor.RG16Model, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewEACRG11(r)
+			return m, m.Pix
+		}}, true
+	case pf >= PixelFormatASTC4x4 && pf <= PixelFormatASTC12x12:
+		footprint := astcFootprints[pf-PixelFormatASTC4x4]
+		srgb := h.ColorSpace == ColorSpaceSRGB
+		return format{color.NRGBAModel, func(r image.Rectangle) (image.Image, []byte) {
+			m := glimage.NewASTC(r, footprint[0], footprint[1])
+			m.SRGB = srgb
+			return m, m.Pix
+		}}, true
+	}
+	f, ok := formats[h.PixelFormat]
+	return f, ok
+}
+
+type decoder struct {
+	r      io.Reader
+	order  binary.ByteOrder
+	config Config
+	format format
+	tex    *Texture
+}
+
+func (d *decoder) decode(r io.Reader, configOnly bool) error {
+	d.r = r
+	if err := d.decodeHeader(); err != nil {
+		return err
+	}
+	if err := d.decodeMetaData(); err != nil {
+		return err
+	}
+	if configOnly {
+		return nil
+	}
+	return d.decodeImages()
+}
+
+func (d *decoder) decodeHeader() error {
+	var buf [headerSize]byte
+	if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+		return err
+	}
+	switch string(buf[:4]) {
+	case magic:
+		d.order = binary.LittleEndian
+	case magicSwapped:
+		d.order = binary.BigEndian
+	default:
+		return fmt.Errorf("PVR reader: invalid identifier [%v]", buf[:4])
+	}
+
+	h := &d.config.Header
+	if err := binary.Read(bytes.NewReader(buf[:]), d.order, h); err != nil {
+		return err
+	}
+	f, ok := formatOf(h)
+	if !ok {
+		return fmt.Errorf("PVR reader: unsupported pixel format [%#x]", h.PixelFormat)
+	}
+	d.format = f
+	if h.Depth > 1 {
+		return fmt.Errorf("PVR reader: 3D textures are not supported [depth=%v]", h.Depth)
+	}
+	if h.NumFaces > 1 && h.NumFaces != 6 {
+		return fmt.Errorf("PVR reader: invalid number of faces [%v]", h.NumFaces)
+	}
+	if full := glimage.MipLevels(int(h.Width), int(h.Height)); uint64(h.MipMapCount) > uint64(full) {
+		return fmt.Errorf("PVR reader: too many levels [%v > %v]", h.MipMapCount, full)
+	}
+	return nil
+}
+
+// decodeMetaData reads the metadata blocks which follow the header.
+func (d *decoder) decodeMetaData() error {
+	size := d.config.MetaDataSize
+	// The buffer grows as the data arrives, so that a crafted size cannot
+	// allocate more than the file holds
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(size)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("PVR reader: not enough metadata [%v]", err)
+	}
+	data := buf.Bytes()
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return fmt.Errorf("PVR reader: invalid metadata size [%v]", size)
+		}
+		m := MetaData{FourCC: d.order.Uint32(data), Key: d.order.Uint32(data[4:])}
+		n := d.order.Uint32(data[8:])
+		data = data[12:]
+		if uint32(len(data)) < n {
+			return fmt.Errorf("PVR reader: invalid metadata size [%v]", size)
+		}
+		m.Data = data[:n:n]
+		data = data[n:]
+		d.config.MetaData = append(d.config.MetaData, m)
+	}
+	return nil
+}
+
+func (d *decoder) decodeImages() error {
+	c := &d.config
+	levels, surfaces, faces := c.Levels(), c.Surfaces(), c.Faces()
+
+	// Every level stores all the faces of its surfaces in turn. The images
+	// of a surface are only allocated once the file has supplied the previous
+	// surfaces, whatever the number of surfaces in the header.
+	d.tex = &Texture{Config: *c, Images: make([][][]image.Image, levels)}
+	for level := range d.tex.Images {
+		width, height := c.LevelSize(level)
+		for surface := 0; surface < surfaces; surface++ {
+			d.tex.Images[level] = append(d.tex.Images[level], make([]image.Image, faces))
+			for face := range d.tex.Images[level][surface] {
+				im, pix := d.format.newImage(image.Rect(0, 0, width, height))
+				if _, err := io.ReadFull(d.r, pix); err != nil {
+					return fmt.Errorf("PVR reader: not enough image data [%v]", err)
+				}
+				d.tex.Images[level][surface][face] = im
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeTexture reads a PVR file from r and returns every mipmap level,
+// surface and cube face stored in it, with its metadata.
+func DecodeTexture(r io.Reader) (*Texture, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex, nil
+}
+
+// Decode reads a PVR file from r and returns the first face of
+// the first surface in the base mipmap level.
+func Decode(r io.Reader) (image.Image, error) {
+	var d decoder
+	if err := d.decode(r, false); err != nil {
+		return nil, err
+	}
+	return d.tex.Image(0, 0, 0), nil
+}
+
+// DecodeTextureConfig reads the header and the metadata of a PVR file
+// from r without decoding any image.
+func DecodeTextureConfig(r io.Reader) (Config, error) {
+	var d decoder
+	if err := d.decode(r, true); err != nil {
+		return Config{}, err
+	}
+	return d.config, nil
+}
+
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	var d decoder
+	err := d.decode(r, true)
+	width, height := d.config.LevelSize(0)
+	return image.Config{
+		ColorModel: d.format.model,
+		Width:      width,
+		Height:     height,
+	}, err
+}
+
+func init() {
+	image.RegisterFormat("pvr", magic, Decode, DecodeConfig)
+	image.RegisterFormat("pvr", magicSwapped, Decode, DecodeConfig)
+}
@@ -0,0 +1,223 @@
+package pvr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	glimage "github.com/hantempo/glu/image"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// pvrFile builds a PVR file in the given byte order with the header h, the
+// metadata and the data of the images in file order.
+func pvrFile(order binary.ByteOrder, h Header, metaData []MetaData, data ...[]byte) []byte {
+	var meta bytes.Buffer
+	for _, m := range metaData {
+		binary.Write(&meta, order, []uint32{m.FourCC, m.Key, uint32(len(m.Data))})
+		meta.Write(m.Data)
+	}
+	h.Version = MetaDataFourCC
+	h.MetaDataSize = uint32(meta.Len())
+
+	var buf bytes.Buffer
+	binary.Write(&buf, order, h)
+	buf.Write(meta.Bytes())
+	for _, d := range data {
+		buf.Write(d)
+	}
+	return buf.Bytes()
+}
+
+// header returns the header of a 2D texture of a single level.
+func header(pixelFormat uint64, channelType uint32, width, height uint32) Header {
+	return Header{PixelFormat: pixelFormat, ChannelType: channelType, Width: width, Height: height, Depth: 1, NumSurfaces: 1, NumFaces: 1, MipMapCount: 1}
+}
+
+func TestDecode(t *testing.T) {
+	input := pvrFile(binary.LittleEndian, header(PixelFormatRGBA8888, ChannelTypeUnsignedByteNorm, 2, 1), nil, []byte{0x11, 0x22, 0x33, 0xFF, 0x44, 0x55, 0x66, 0x80})
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "pvr" || config.ColorModel != color.NRGBAModel || config.Width != 2 || config.Height != 1 {
+		t.Errorf("Wrong config : got %v %+v", format, config)
+	}
+
+	m, format, err := image.Decode(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "pvr" {
+		t.Errorf("Wrong format : got %v", format)
+	}
+	if c := m.At(0, 0); c != (color.NRGBA{0x11, 0x22, 0x33, 0xFF}) {
+		t.Errorf("Wrong color at (0, 0) : got %v", c)
+	}
+	if c := m.At(1, 0); c != (color.NRGBA{0x44, 0x55, 0x66, 0x80}) {
+		t.Errorf("Wrong color at (1, 0) : got %v", c)
+	}
+}
+
+func TestDecodeFormats(t *testing.T) {
+	// Opaque red A and blue B with every pixel using A
+	pvrtc := bytes.Repeat([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0xFC, 0x1F, 0x80}, 4)
+	tests := []struct {
+		pixelFormat uint64
+		channelType uint32
+		colorSpace  uint32
+		data        []byte
+		expected    image.Image
+	}{
+		{PixelFormatL8, ChannelTypeUnsignedByteNorm, ColorSpaceLinear, []byte{0x80}, image.NewGray(image.Rect(0, 0, 1, 1))},
+		{PixelFormatRGB888, ChannelTypeUnsignedByteNorm, ColorSpaceLinear, []byte{1, 2, 3}, glimage.NewRGB(image.Rect(0, 0, 1, 1))},
+		{PixelFormatBGRA8888, ChannelTypeUnsignedByte, ColorSpaceLinear, []byte{1, 2, 3, 4}, glimage.NewNBGRA8888(image.Rect(0, 0, 1, 1))},
+		{PixelFormatPVRTC4RGB, ChannelTypeUnsignedByteNorm, ColorSpaceLinear, pvrtc, glimage.NewPVRTC(image.Rect(0, 0, 1, 1), 4)},
+		{PixelFormatPVRTC2RGBA, ChannelTypeUnsignedByteNorm, ColorSpaceSRGB, pvrtc, glimage.NewPVRTC(image.Rect(0, 0, 1, 1), 2)},
+		{PixelFormatDXT5, ChannelTypeUnsignedByteNorm, ColorSpaceLinear, make([]byte, 16), glimage.NewBC3(image.Rect(0, 0, 1, 1))},
+		{PixelFormatBC4, ChannelTypeSignedByteNorm, ColorSpaceLinear, make([]byte, 8), glimage.NewBC4Signed(image.Rect(0, 0, 1, 1))},
+		{PixelFormatBC5, ChannelTypeUnsignedByteNorm, ColorSpaceLinear, make([]byte, 16), glimage.NewBC5(image.Rect(0, 0, 1, 1))},
+		{PixelFormatEACRG11, ChannelTypeSignedByteNorm, ColorSpaceLinear, make([]byte, 16), glimage.NewEACSignedRG11(image.Rect(0, 0, 1, 1))},
+		{PixelFormatETC2RGBA1, ChannelTypeUnsignedByteNorm, ColorSpaceLinear, make([]byte, 8), glimage.NewETC2RGB8A1(image.Rect(0, 0, 1, 1))},
+	}
+	for _, test := range tests {
+		h := header(test.pixelFormat, test.channelType, 1, 1)
+		h.ColorSpace = test.colorSpace
+		m, err := Decode(bytes.NewReader(pvrFile(binary.LittleEndian, h, nil, test.data)))
+		if err != nil {
+			t.Errorf("Pixel format %#x : %v", test.pixelFormat, err)
+			continue
+		}
+		if reflect.TypeOf(m) != reflect.TypeOf(test.expected) || m.ColorModel() != test.expected.ColorModel() {
+			t.Errorf("Pixel format %#x : wrong image %T", test.pixelFormat, m)
+		}
+	}
+
+	// The PVRTC blocks are decoded, and the ASTC and BC6H images follow
+	// the color space and the channel type
+	m, err := Decode(bytes.NewReader(pvrFile(binary.LittleEndian, header(PixelFormatPVRTC4RGBA, 0, 8, 8), nil, pvrtc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := m.At(5, 3); c != (color.NRGBA{0xFF, 0, 0, 0xFF}) {
+		t.Errorf("Wrong PVRTC color : got %v", c)
+	}
+	h := header(PixelFormatASTC4x4+3, ChannelTypeUnsignedByteNorm, 6, 5)
+	h.ColorSpace = ColorSpaceSRGB
+	if m, err = Decode(bytes.NewReader(pvrFile(binary.LittleEndian, h, nil, make([]byte, 16)))); err != nil {
+		t.Fatal(err)
+	}
+	if astc, ok := m.(*glimage.ASTC); !ok || astc.BlockWidth != 6 || astc.BlockHeight != 5 || !astc.SRGB {
+		t.Errorf("Wrong ASTC image : got %T %+v", m, m)
+	}
+	if m, err = Decode(bytes.NewReader(pvrFile(binary.LittleEndian, header(PixelFormatBC6, ChannelTypeSignedFloat, 4, 4), nil, make([]byte, 16)))); err != nil {
+		t.Fatal(err)
+	}
+	if bc6h, ok := m.(*glimage.BC6H); !ok || !bc6h.Signed || m.ColorModel() != glcolor.NRGBAF32Model {
+		t.Errorf("Wrong BC6H image : got %T", m)
+	}
+}
+
+func TestDecodeTexture(t *testing.T) {
+	// Array of 2 cubemaps of 2 levels, every level storing all the faces
+	// of the first surface, then of the second one
+	h := header(PixelFormatL8, ChannelTypeUnsignedByteNorm, 2, 2)
+	h.NumSurfaces, h.NumFaces, h.MipMapCount = 2, 6, 2
+	var data [][]byte
+	for level := 0; level < 2; level++ {
+		for i := 0; i < 12; i++ {
+			v := uint8(level*12 + i)
+			if level == 0 {
+				data = append(data, []byte{v, v, v, v})
+			} else {
+				data = append(data, []byte{v})
+			}
+		}
+	}
+	metaData := []MetaData{
+		{MetaDataFourCC, MetaDataOrientation, []byte{0, 1, 0}},
+		{0x12345678, 7, []byte("glu")},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		input := pvrFile(order, h, metaData, data...)
+		config, err := DecodeTextureConfig(bytes.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Levels() != 2 || config.Surfaces() != 2 || config.Faces() != 6 {
+			t.Errorf("Wrong config layout in %v : got %v %v %v", order, config.Levels(), config.Surfaces(), config.Faces())
+		}
+		if !reflect.DeepEqual(config.MetaData, metaData) {
+			t.Errorf("Wrong metadata in %v : got %v", order, config.MetaData)
+		}
+		if v, ok := config.Lookup(MetaDataFourCC, MetaDataOrientation); !ok || !bytes.Equal(v, []byte{0, 1, 0}) {
+			t.Errorf("Wrong orientation in %v : got %v", order, v)
+		}
+		if _, ok := config.Lookup(MetaDataFourCC, MetaDataBorder); ok {
+			t.Errorf("Unexpected border in %v", order)
+		}
+
+		tex, err := DecodeTexture(bytes.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tex.Images) != 2 || len(tex.Images[0]) != 2 || len(tex.Images[0][0]) != 6 {
+			t.Fatalf("Wrong layout : got %vx%vx%v", len(tex.Images), len(tex.Images[0]), len(tex.Images[0][0]))
+		}
+		for level := 0; level < 2; level++ {
+			for surface := 0; surface < 2; surface++ {
+				for face := 0; face < 6; face++ {
+					m := tex.Image(level, surface, face).(*image.Gray)
+					if expected := uint8(level*12 + surface*6 + face); m.Pix[0] != expected || m.Rect.Dx() != 2>>uint(level) {
+						t.Errorf("Level %v of face %v of surface %v in %v : expected %v, got %v %v", level, face, surface, order, expected, m.Pix[0], m.Rect)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	l8 := header(PixelFormatL8, ChannelTypeUnsignedByteNorm, 2, 2)
+	volume := l8
+	volume.Depth = 2
+	invalidMetaData := pvrFile(binary.LittleEndian, l8, []MetaData{{MetaDataFourCC, MetaDataBorder, make([]byte, 12)}}, make([]byte, 4))
+	binary.LittleEndian.PutUint32(invalidMetaData[headerSize+8:], 13)
+	cubemapFaces := l8
+	cubemapFaces.NumFaces = 3
+	tooManyLevels := l8
+	tooManyLevels.MipMapCount = 0x7FFFFFFF
+	manySurfaces := l8
+	manySurfaces.NumSurfaces = 0x7FFFFFFF
+	hugeMetaData := pvrFile(binary.LittleEndian, l8, nil)
+	binary.LittleEndian.PutUint32(hugeMetaData[headerSize-4:], 0xFFFFFFFF)
+
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{[]byte("PVR\x03"), "unexpected EOF"},
+		{append([]byte("PVR\x02"), pvrFile(binary.LittleEndian, l8, nil)[4:]...), "PVR reader: invalid identifier"},
+		{pvrFile(binary.LittleEndian, header(PixelFormatPVRTC4RGB+2, 0, 2, 2), nil), "PVR reader: unsupported pixel format"},
+		{pvrFile(binary.LittleEndian, volume, nil), "PVR reader: 3D textures are not supported"},
+		{invalidMetaData, "PVR reader: invalid metadata size"},
+		{hugeMetaData, "PVR reader: not enough metadata [unexpected EOF]"},
+		{pvrFile(binary.LittleEndian, cubemapFaces, nil), "PVR reader: invalid number of faces [3]"},
+		{pvrFile(binary.LittleEndian, tooManyLevels, nil), "PVR reader: too many levels [2147483647 > 2]"},
+		// Only the data of the first surface
+		{pvrFile(binary.LittleEndian, manySurfaces, nil, make([]byte, 4)), "PVR reader: not enough image data"},
+		{pvrFile(binary.LittleEndian, l8, nil, make([]byte, 3)), "PVR reader: not enough image data"},
+	}
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package image
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hantempo/glu/enum"
+)
+
+const (
+	blockSizePVRTC = 8
+	// The textures have at least 2x2 blocks
+	minBlocksPVRTC = 2
+)
+
+// Modulation weights, out of 8, of the 2-bit values of the standard mode.
+var pvrtcWeights = [4]int{0, 3, 5, 8}
+
+// pvrtcFootprint returns the size in pixels of the blocks of PVRTC1 at the
+// given bits per pixel.
+func pvrtcFootprint(bitsPerPixel int) (width, height int) {
+	if bitsPerPixel == 2 {
+		return 8, 4
+	}
+	return 4, 4
+}
+
+// pvrtcFormat returns the internal format of PVRTC at 2 or 4 bits per pixel.
+func pvrtcFormat(bitsPerPixel int) enum.InternalFormat {
+	if bitsPerPixel == 2 {
+		return enum.GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG
+	}
+	return enum.GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG
+}
+
+// pvrtcBlocks returns the number of blocks covering size pixels in blocks
+// of the given size, rounded up to a power of two.
+func pvrtcBlocks(size, blockSize int) int {
+	n := minBlocksPVRTC
+	for n*blockSize < size {
+		n *= 2
+	}
+	return n
+}
+
+// pvrtcBlockIndex returns the position of the block at x, y in the twiddled
+// order of a grid of xBlocks x yBlocks, both powers of two. The bits of y and
+// x are interleaved up to the smaller dimension, y in the lowest bit, and
+// the remaining bits of the larger dimension come above them.
+func pvrtcBlockIndex(x, y, xBlocks, yBlocks int) int {
+	index, shift := 0, uint(0)
+	for bit := 1; bit < xBlocks && bit < yBlocks; bit <<= 1 {
+		if y&bit != 0 {
+			index |= 1 << (2 * shift)
+		}
+		if x&bit != 0 {
+			index |= 1 << (2*shift + 1)
+		}
+		shift++
+	}
+	if xBlocks > yBlocks {
+		return index | x>>shift<<(2*shift)
+	}
+	return index | y>>shift<<(2*shift)
+}
+
+// pvrtcColors returns the colors A and B of a block, with 5 bits per color
+// component and 4 bits of alpha. Opaque colors are RGB554 for A and RGB555
+// for B, and translucent ones ARGB3443 for A and ARGB3444 for B.
+func pvrtcColors(colorData uint32) (a, b [4]int) {
+	v := int(colorData & 0xFFFF)
+	if v&0x8000 != 0 {
+		a = [4]int{v >> 10 & 0x1F, v >> 5 & 0x1F, v&0x1E | v>>4&0x1, 0xF}
+	} else {
+		a = [4]int{v>>7&0x1E | v>>11&0x1, v>>3&0x1E | v>>7&0x1, v<<1&0x1C | v>>2&0x3, v >> 11 & 0xE}
+	}
+	v = int(colorData >> 16)
+	if v&0x8000 != 0 {
+		b = [4]int{v >> 10 & 0x1F, v >> 5 & 0x1F, v & 0x1F, 0xF}
+	} else {
+		b = [4]int{v>>7&0x1E | v>>11&0x1, v>>3&0x1E | v>>7&0x1, v<<1&0x1E | v>>3&0x1, v >> 11 & 0xE}
+	}
+	return
+}
+
+// PVRTC is an in-memory image of blocks in the PVRTC1 formats, of 4x4 pixels
+// at 4 bits per pixel or 8x4 pixels at 2 bits per pixel. The blocks cover
+// a texture whose numbers of blocks in width and height are powers of two,
+// and are stored in twiddled order.
+//
+// The colors A and B of a pixel are upscaled bilinearly from the blocks
+// around it, and mixed by its modulation weight.
+type PVRTC struct {
+	Pix  []uint8
+	Rect image.Rectangle
+	// BitsPerPixel is 2 or 4
+	BitsPerPixel int
+}
+
+func (p *PVRTC) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *PVRTC) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *PVRTC) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.NRGBA{}
+	}
+	return p.pixel(x-p.Rect.Min.X, y-p.Rect.Min.Y)
+}
+
+// BlockDimensions returns how many blocks the texture of p has in width and
+// height, which are powers of two no smaller than 2.
+func (p *PVRTC) BlockDimensions() (x, y int) {
+	width, height := pvrtcFootprint(p.BitsPerPixel)
+	return pvrtcBlocks(p.Rect.Dx(), width), pvrtcBlocks(p.Rect.Dy(), height)
+}
+
+// Compress is not supported yet.
+func (p *PVRTC) Compress(im image.Image) error {
+	return fmt.Errorf("PVRTC compress: not supported")
+}
+
+// Uncompress decodes every pixel of p, and returns them in a *image.NRGBA.
+func (p *PVRTC) Uncompress() (image.Image, error) {
+	xBlockDim, yBlockDim := p.BlockDimensions()
+	if len(p.Pix) < xBlockDim*yBlockDim*blockSizePVRTC {
+		return nil, fmt.Errorf("PVRTC uncompress: not enough data [%v < %v]", len(p.Pix), xBlockDim*yBlockDim*blockSizePVRTC)
+	}
+
+	m := image.NewNRGBA(p.Rect)
+	for y := 0; y < p.Rect.Dy(); y++ {
+		for x := 0; x < p.Rect.Dx(); x++ {
+			c := p.pixel(x, y)
+			i := m.PixOffset(p.Rect.Min.X+x, p.Rect.Min.Y+y)
+			m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+	}
+	return m, nil
+}
+
+// block returns the modulation and color data of the block at x, y, which
+// wrap around the texture.
+func (p *PVRTC) block(x, y int) (modulation, colors uint32) {
+	xBlockDim, yBlockDim := p.BlockDimensions()
+	x = (x%xBlockDim + xBlockDim) % xBlockDim
+	y = (y%yBlockDim + yBlockDim) % yBlockDim
+	offset := pvrtcBlockIndex(x, y, xBlockDim, yBlockDim) * blockSizePVRTC
+	return binary.LittleEndian.Uint32(p.Pix[offset:]), binary.LittleEndian.Uint32(p.Pix[offset+4:])
+}
+
+// pixel decodes the pixel at x, y of the texture.
+func (p *PVRTC) pixel(x, y int) color.NRGBA {
+	width, height := pvrtcFootprint(p.BitsPerPixel)
+
+	// The colors are defined at the centers of the blocks, and the pixel
+	// lies between the ones of 4 blocks whose top left one is x0, y0
+	fx, fy := x-width/2, y-height/2
+	x0, y0 := fx/width, fy/height
+	if fx < 0 {
+		x0 = -1
+	}
+	if fy < 0 {
+		y0 = -1
+	}
+	dx, dy := fx-x0*width, fy-y0*height
+	var a, b [4]int
+	for j := 0; j < 2; j++ {
+		for i := 0; i < 2; i++ {
+			wx, wy := width-dx, height-dy
+			if i == 1 {
+				wx = dx
+			}
+			if j == 1 {
+				wy = dy
+			}
+			_, colors := p.block(x0+i, y0+j)
+			ca, cb := pvrtcColors(colors)
+			for k := range a {
+				a[k] += wx * wy * ca[k]
+				b[k] += wx * wy * cb[k]
+			}
+		}
+	}
+
+	weight, punchThrough := p.modulation(x, y)
+	var c [4]uint8
+	for k := range c {
+		va, vb := a[k], b[k]
+		// The weights add up to 16 in 4x4 blocks and 32 in 8x4 ones
+		if width == 8 {
+			va, vb = va>>1, vb>>1
+		}
+		// Components are extended to 8 bits from their 5 or 4 bits
+		if k == 3 {
+			va, vb = va>>4+va, vb>>4+vb
+		} else {
+			va, vb = va>>6+va>>1, vb>>6+vb>>1
+		}
+		c[k] = uint8((va*(8-weight) + vb*weight) / 8)
+	}
+	if punchThrough {
+		c[3] = 0
+	}
+	return color.NRGBA{c[0], c[1], c[2], c[3]}
+}
+
+// modulation returns the modulation weight of the pixel at x, y of the
+// texture, out of 8, and whether the pixel is punched through.
+func (p *PVRTC) modulation(x, y int) (int, bool) {
+	width, height := pvrtcFootprint(p.BitsPerPixel)
+	modulation, colors := p.block(x/width, y/height)
+	i := (y%height)*width + x%width
+	if p.BitsPerPixel != 2 {
+		v := int(modulation >> uint(2*i) & 0x3)
+		if colors&0x1 == 0 {
+			return pvrtcWeights[v], false
+		}
+		// The punch-through mode halves the values 1 and 2, and the
+		// pixels of 2 are transparent
+		return [4]int{0, 4, 4, 8}[v], v == 2
+	}
+
+	if colors&0x1 == 0 {
+		// 1 bit per pixel for either color
+		return int(modulation>>uint(i)&0x1) * 8, false
+	}
+	// Only the pixels of a checkerboard store 2-bit values, and the others
+	// average their neighbors horizontally and vertically, or either way
+	// depending on the first and the central values
+	if (x^y)&1 == 0 {
+		return pvrtcWeights[p.storedModulation(x, y)], false
+	}
+	left, right := pvrtcWeights[p.storedModulation(x-1, y)], pvrtcWeights[p.storedModulation(x+1, y)]
+	up, down := pvrtcWeights[p.storedModulation(x, y-1)], pvrtcWeights[p.storedModulation(x, y+1)]
+	switch {
+	case modulation&0x1 == 0:
+		return (left + right + up + down + 2) / 4, false
+	case modulation&(1<<20) == 0:
+		return (left + right + 1) / 2, false
+	default:
+		return (up + down + 1) / 2, false
+	}
+}
+
+// storedModulation returns the 2-bit modulation value of the pixel at x, y
+// of a 2bpp texture, from a block in either mode. The pixels of the blocks in
+// the direct mode have 0 or 3.
+func (p *PVRTC) storedModulation(x, y int) int {
+	width, height := pvrtcFootprint(p.BitsPerPixel)
+	bx, by := x/width, y/height
+	if x < 0 {
+		bx = -1
+	}
+	if y < 0 {
+		by = -1
+	}
+	modulation, colors := p.block(bx, by)
+	x, y = x-bx*width, y-by*height
+	if colors&0x1 == 0 {
+		return int(modulation>>uint(y*width+x)&0x1) * 3
+	}
+
+	// The lowest bits of the first and the central values select the
+	// interpolation, and are copies of their other bits
+	if modulation&0x1 != 0 {
+		modulation = modulation&^(1<<20) | modulation>>1&(1<<20)
+	}
+	modulation = modulation&^0x1 | modulation>>1&0x1
+	i := (y*width + x) / 2
+	return int(modulation >> uint(2*i) & 0x3)
+}
+
+// NewPVRTC returns a PVRTC image of bounds r at 2 or 4 bits per pixel.
+func NewPVRTC(r image.Rectangle, bitsPerPixel int) *PVRTC {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(pvrtcFormat(bitsPerPixel), w, h))
+	return &PVRTC{buf, r, bitsPerPixel}
+}
@@ -0,0 +1,152 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPVRTCBlockIndex(t *testing.T) {
+	tests := []struct {
+		x, y, xBlocks, yBlocks int
+		expected               int
+	}{
+		{0, 1, 4, 4, 1},
+		{1, 0, 4, 4, 2},
+		{2, 1, 4, 4, 9},
+		{3, 3, 4, 4, 15},
+		// The remaining bits of the larger dimension
+		{5, 1, 8, 2, 11},
+		{1, 5, 2, 8, 11},
+	}
+	for _, test := range tests {
+		if index := pvrtcBlockIndex(test.x, test.y, test.xBlocks, test.yBlocks); index != test.expected {
+			t.Errorf("Wrong index of the block [%v %v] of %vx%v : expected %v, got %v", test.x, test.y, test.xBlocks, test.yBlocks, test.expected, index)
+		}
+	}
+}
+
+func TestPVRTCColors(t *testing.T) {
+	tests := []struct {
+		colors uint32
+		a, b   [4]int
+	}{
+		// Opaque red A and blue B
+		{0x801FFC00, [4]int{31, 0, 0, 15}, [4]int{0, 0, 31, 15}},
+		// Translucent A of alpha 0x7 and red 0xF, and B of alpha 0x5,
+		// red 0x5 and blue 0xF
+		{0x550F7F00, [4]int{31, 0, 0, 14}, [4]int{10, 0, 31, 10}},
+	}
+	for _, test := range tests {
+		if a, b := pvrtcColors(test.colors); a != test.a || b != test.b {
+			t.Errorf("Wrong colors of %#x : expected %v %v, got %v %v", test.colors, test.a, test.b, a, b)
+		}
+	}
+}
+
+// pvrtcImage returns a PVRTC image of bounds r whose blocks are all block.
+func pvrtcImage(r image.Rectangle, bitsPerPixel int, block []byte) *PVRTC {
+	p := NewPVRTC(r, bitsPerPixel)
+	copy(p.Pix, bytes.Repeat(block, len(p.Pix)/blockSizePVRTC))
+	return p
+}
+
+func TestDecodePVRTC(t *testing.T) {
+	// Opaque red A and blue B, every pixel having the value of its position
+	// modulo 4, in the standard and the punch-through modes
+	standard := pvrtcImage(image.Rect(0, 0, 8, 8), 4, []byte{0xE4, 0xE4, 0xE4, 0xE4, 0x00, 0xFC, 0x1F, 0x80})
+	punchThrough := pvrtcImage(image.Rect(0, 0, 8, 8), 4, []byte{0xE4, 0xE4, 0xE4, 0xE4, 0x01, 0xFC, 0x1F, 0x80})
+	// Black A except for the red top left block, upscaled between the
+	// centers of the blocks
+	upscaled := NewPVRTC(image.Rect(0, 0, 8, 8), 4)
+	copy(upscaled.Pix, bytes.Repeat([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0x00, 0x80}, 4))
+	upscaled.Pix[5] = 0xFC
+	// 2bpp with the pixels of the first 2 rows using B
+	direct := pvrtcImage(image.Rect(0, 0, 16, 8), 2, []byte{0xFF, 0xFF, 0x00, 0x00, 0x00, 0xFC, 0x1F, 0x80})
+	// 2bpp interpolating between stored values, all 0 but the first one
+	// of 3, in the H&V, H-only and V-only modes
+	hv := pvrtcImage(image.Rect(0, 0, 16, 8), 2, []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0xFC, 0x1F, 0x80})
+	h := pvrtcImage(image.Rect(0, 0, 16, 8), 2, []byte{0x03, 0x00, 0x00, 0x00, 0x01, 0xFC, 0x1F, 0x80})
+	v := pvrtcImage(image.Rect(0, 0, 16, 8), 2, []byte{0x03, 0x00, 0x10, 0x00, 0x01, 0xFC, 0x1F, 0x80})
+
+	tests := []struct {
+		name   string
+		p      *PVRTC
+		pixels map[image.Point]color.NRGBA
+	}{
+		{"standard", standard, map[image.Point]color.NRGBA{
+			{0, 0}: {255, 0, 0, 255}, {1, 0}: {159, 0, 95, 255}, {2, 0}: {95, 0, 159, 255}, {3, 0}: {0, 0, 255, 255},
+			{5, 7}: {159, 0, 95, 255},
+		}},
+		{"punch-through", punchThrough, map[image.Point]color.NRGBA{
+			{0, 0}: {255, 0, 0, 255}, {1, 0}: {127, 0, 127, 255}, {2, 0}: {127, 0, 127, 0}, {3, 0}: {0, 0, 255, 255},
+		}},
+		{"upscaled", upscaled, map[image.Point]color.NRGBA{
+			{2, 2}: {255, 0, 0, 255}, {3, 2}: {191, 0, 0, 255}, {4, 2}: {127, 0, 0, 255},
+			{6, 2}: {0, 0, 0, 255}, {7, 2}: {63, 0, 0, 255}, {0, 0}: {63, 0, 0, 255},
+		}},
+		{"2bpp direct", direct, map[image.Point]color.NRGBA{
+			{0, 0}: {0, 0, 255, 255}, {7, 1}: {0, 0, 255, 255}, {0, 2}: {255, 0, 0, 255}, {12, 7}: {255, 0, 0, 255},
+		}},
+		{"2bpp H&V", hv, map[image.Point]color.NRGBA{
+			{0, 0}: {0, 0, 255, 255}, {1, 0}: {191, 0, 63, 255}, {7, 0}: {191, 0, 63, 255}, {2, 0}: {255, 0, 0, 255},
+		}},
+		{"2bpp H", h, map[image.Point]color.NRGBA{
+			{1, 0}: {127, 0, 127, 255}, {0, 1}: {255, 0, 0, 255},
+		}},
+		{"2bpp V", v, map[image.Point]color.NRGBA{
+			{1, 0}: {255, 0, 0, 255}, {0, 1}: {127, 0, 127, 255}, {4, 2}: {255, 0, 0, 255},
+		}},
+	}
+	for _, test := range tests {
+		uncompressed, err := test.p.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := uncompressed.(*image.NRGBA); !ok {
+			t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+		}
+		for pt, expected := range test.pixels {
+			if c := test.p.At(pt.X, pt.Y); c != expected {
+				t.Errorf("Wrong pixel at %v of the %s image : expected %v, got %v", pt, test.name, expected, c)
+			}
+			if c := uncompressed.At(pt.X, pt.Y); c != expected {
+				t.Errorf("Wrong uncompressed pixel at %v of the %s image : expected %v, got %v", pt, test.name, expected, c)
+			}
+		}
+	}
+}
+
+func TestNewPVRTC(t *testing.T) {
+	tests := []struct {
+		r            image.Rectangle
+		bitsPerPixel int
+		xBlocks      int
+		yBlocks      int
+	}{
+		// At least 2x2 blocks, rounded up to powers of two
+		{image.Rect(0, 0, 1, 1), 4, 2, 2},
+		{image.Rect(0, 0, 5, 3), 4, 2, 2},
+		{image.Rect(0, 0, 32, 12), 4, 8, 4},
+		{image.Rect(0, 0, 17, 4), 2, 4, 2},
+		{image.Rect(2, 2, 66, 34), 2, 8, 8},
+	}
+	for _, test := range tests {
+		p := NewPVRTC(test.r, test.bitsPerPixel)
+		if x, y := p.BlockDimensions(); x != test.xBlocks || y != test.yBlocks {
+			t.Errorf("Wrong block dimensions of %v at %vbpp : expected %vx%v, got %vx%v", test.r, test.bitsPerPixel, test.xBlocks, test.yBlocks, x, y)
+		}
+		if len(p.Pix) != test.xBlocks*test.yBlocks*blockSizePVRTC {
+			t.Errorf("Wrong size of pixel data of %v at %vbpp : got %v", test.r, test.bitsPerPixel, len(p.Pix))
+		}
+	}
+
+	p := NewPVRTC(image.Rect(0, 0, 8, 8), 4)
+	if err := p.Compress(image.NewNRGBA(p.Rect)); err == nil {
+		t.Error("Expected an error for the compression")
+	}
+	if _, err := (&PVRTC{make([]byte, 8), p.Rect, 4}).Uncompress(); err == nil {
+		t.Error("Expected an error for missing data")
+	}
+}
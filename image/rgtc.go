@@ -0,0 +1,300 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hantempo/glu/enum"
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// rgtcFormat describes the images of one or two BC4 channels, whose blocks
+// of every channel follow each other. BC5 holds the blocks of red and green.
+type rgtcFormat struct {
+	name     string
+	channels int
+	signed   bool
+}
+
+var (
+	rgtcBC4       = rgtcFormat{"BC4", 1, false}
+	rgtcBC4Signed = rgtcFormat{"BC4 signed", 1, true}
+	rgtcBC5       = rgtcFormat{"BC5", 2, false}
+	rgtcBC5Signed = rgtcFormat{"BC5 signed", 2, true}
+)
+
+func (f rgtcFormat) blockSize() int {
+	return f.channels * blockSize
+}
+
+// limits returns the range of the endpoints, and the scale which extends
+// them to 16 bits.
+func (f rgtcFormat) limits() (min, max int, scale float64) {
+	if f.signed {
+		return -0x7F, 0x7F, 0x7FFF / 127.0
+	}
+	return 0, 0xFF, 0xFFFF / 255.0
+}
+
+// endpoints returns the two endpoints of a BC4 block. The signed value -128
+// is the same as -127.
+func (f rgtcFormat) endpoints(block []byte) (e0, e1 int) {
+	if !f.signed {
+		return int(block[0]), int(block[1])
+	}
+	e0, e1 = int(int8(block[0])), int(int8(block[1]))
+	if e0 < -0x7F {
+		e0 = -0x7F
+	}
+	if e1 < -0x7F {
+		e1 = -0x7F
+	}
+	return
+}
+
+// palette returns the 8 values of a BC4 block of endpoints e0 and e1,
+// extended to 16 bits. Like a BC3 alpha block, the block interpolates
+// 6 values between its endpoints when the first one is greater, and 4 values
+// followed by the lowest and highest ones otherwise.
+func (f rgtcFormat) palette(e0, e1 int) (palette [8]int32) {
+	min, max, scale := f.limits()
+	extend := func(v float64) int32 {
+		return int32(math.Floor(v*scale + 0.5))
+	}
+	palette[0], palette[1] = extend(float64(e0)), extend(float64(e1))
+	if e0 > e1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = extend(float64((7-i)*e0+i*e1) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = extend(float64((5-i)*e0+i*e1) / 5)
+		}
+		palette[6], palette[7] = extend(float64(min)), extend(float64(max))
+	}
+	return
+}
+
+// decodeChannel decodes the 16-bit values of a BC4 block into dst, row by row.
+func (f rgtcFormat) decodeChannel(block []byte, dst *[blockWidth * blockWidth]int32) {
+	palette := f.palette(f.endpoints(block))
+	indices := bc3AlphaIndices(block)
+	for i := range dst {
+		dst[i] = palette[indices>>uint(3*i)&0x07]
+	}
+}
+
+func (f rgtcFormat) model() color.Model {
+	switch {
+	case f.channels == 1 && f.signed:
+		return glcolor.SignedR16Model
+	case f.channels == 1:
+		return glcolor.R16Model
+	case f.signed:
+		return glcolor.SignedRG16Model
+	}
+	return glcolor.RG16Model
+}
+
+// color returns the color of the 16-bit values of every channel.
+func (f rgtcFormat) color(v [2]int32) color.Color {
+	switch {
+	case f.channels == 1 && f.signed:
+		return glcolor.SignedR16{int16(v[0])}
+	case f.channels == 1:
+		return glcolor.R16{uint16(v[0])}
+	case f.signed:
+		return glcolor.SignedRG16{int16(v[0]), int16(v[1])}
+	}
+	return glcolor.RG16{uint16(v[0]), uint16(v[1])}
+}
+
+// decodeBlock decodes the pixels of a block into dst, row by row.
+func (f rgtcFormat) decodeBlock(block []byte, dst *[blockWidth * blockWidth]color.Color) {
+	var values [2][blockWidth * blockWidth]int32
+	for c := 0; c < f.channels; c++ {
+		f.decodeChannel(block[c*blockSize:], &values[c])
+	}
+	for i := range dst {
+		dst[i] = f.color([2]int32{values[0][i], values[1][i]})
+	}
+}
+
+// at returns the pixel at x, y of an image of bounds r whose blocks
+// are stored row by row in pix.
+func (f rgtcFormat) at(r image.Rectangle, pix []byte, x, y int) color.Color {
+	if !(image.Point{x, y}.In(r)) {
+		return f.color([2]int32{})
+	}
+	x, y = x-r.Min.X, y-r.Min.Y
+
+	xBlockDim, _ := blockDimensions(r)
+	offset := ((y/blockWidth)*xBlockDim + x/blockWidth) * f.blockSize()
+	var pixels [blockWidth * blockWidth]color.Color
+	f.decodeBlock(pix[offset:offset+f.blockSize()], &pixels)
+	return pixels[(y%blockWidth)*blockWidth+x%blockWidth]
+}
+
+// uncompress decodes every block of an image of bounds r whose blocks
+// are stored row by row in pix, keeping 16 bits per channel.
+func (f rgtcFormat) uncompress(r image.Rectangle, pix []byte) (*image.RGBA64, error) {
+	xBlockDim, yBlockDim := blockDimensions(r)
+	if len(pix) < xBlockDim*yBlockDim*f.blockSize() {
+		return nil, fmt.Errorf("%s uncompress: not enough data [%v < %v]", f.name, len(pix), xBlockDim*yBlockDim*f.blockSize())
+	}
+
+	m := image.NewRGBA64(r)
+	w, h := r.Dx(), r.Dy()
+	var pixels [blockWidth * blockWidth]color.Color
+	for yBlock := 0; yBlock < yBlockDim; yBlock++ {
+		for xBlock := 0; xBlock < xBlockDim; xBlock++ {
+			offset := (yBlock*xBlockDim + xBlock) * f.blockSize()
+			f.decodeBlock(pix[offset:offset+f.blockSize()], &pixels)
+			// Pixels of edge blocks outside of the image are dropped
+			for y := 0; y < blockWidth && yBlock*blockWidth+y < h; y++ {
+				for x := 0; x < blockWidth && xBlock*blockWidth+x < w; x++ {
+					m.Set(r.Min.X+xBlock*blockWidth+x, r.Min.Y+yBlock*blockWidth+y, pixels[y*blockWidth+x])
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// BC4 is an in-memory image of blocks in the BC4 format, also known as RGTC1,
+// with an unsigned red channel.
+type BC4 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC4) ColorModel() color.Model {
+	return rgtcBC4.model()
+}
+
+func (p *BC4) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC4) At(x, y int) color.Color {
+	return rgtcBC4.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *BC4) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *BC4) Uncompress() (image.Image, error) {
+	return rgtcBC4.uncompress(p.Rect, p.Pix)
+}
+
+func NewBC4(r image.Rectangle) *BC4 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RED_RGTC1, w, h))
+	return &BC4{buf, r}
+}
+
+// BC4Signed is an in-memory image of blocks in the signed BC4 format,
+// with a signed red channel.
+type BC4Signed struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC4Signed) ColorModel() color.Model {
+	return rgtcBC4Signed.model()
+}
+
+func (p *BC4Signed) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC4Signed) At(x, y int) color.Color {
+	return rgtcBC4Signed.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *BC4Signed) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *BC4Signed) Uncompress() (image.Image, error) {
+	return rgtcBC4Signed.uncompress(p.Rect, p.Pix)
+}
+
+func NewBC4Signed(r image.Rectangle) *BC4Signed {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_SIGNED_RED_RGTC1, w, h))
+	return &BC4Signed{buf, r}
+}
+
+// BC5 is an in-memory image of blocks in the BC5 format, also known as RGTC2,
+// with unsigned red and green channels.
+type BC5 struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC5) ColorModel() color.Model {
+	return rgtcBC5.model()
+}
+
+func (p *BC5) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC5) At(x, y int) color.Color {
+	return rgtcBC5.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *BC5) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *BC5) Uncompress() (image.Image, error) {
+	return rgtcBC5.uncompress(p.Rect, p.Pix)
+}
+
+func NewBC5(r image.Rectangle) *BC5 {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_RG_RGTC2, w, h))
+	return &BC5{buf, r}
+}
+
+// BC5Signed is an in-memory image of blocks in the signed BC5 format,
+// with signed red and green channels.
+type BC5Signed struct {
+	Pix  []uint8
+	Rect image.Rectangle
+}
+
+func (p *BC5Signed) ColorModel() color.Model {
+	return rgtcBC5Signed.model()
+}
+
+func (p *BC5Signed) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *BC5Signed) At(x, y int) color.Color {
+	return rgtcBC5Signed.at(p.Rect, p.Pix, x, y)
+}
+
+func (p *BC5Signed) BlockDimensions() (x, y int) {
+	return blockDimensions(p.Rect)
+}
+
+// Uncompress decodes every block of p, and returns the pixels in a *image.RGBA64.
+func (p *BC5Signed) Uncompress() (image.Image, error) {
+	return rgtcBC5Signed.uncompress(p.Rect, p.Pix)
+}
+
+func NewBC5Signed(r image.Rectangle) *BC5Signed {
+	w, h := r.Dx(), r.Dy()
+	buf := make([]byte, calculateSize(enum.GL_COMPRESSED_SIGNED_RG_RGTC2, w, h))
+	return &BC5Signed{buf, r}
+}
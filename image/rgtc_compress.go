@@ -0,0 +1,132 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// quantize returns the endpoint nearest to the 16-bit value v.
+func (f rgtcFormat) quantize(v int) int {
+	min, max, scale := f.limits()
+	e := int(math.Floor(float64(v)/scale + 0.5))
+	if e < min {
+		return min
+	}
+	if e > max {
+		return max
+	}
+	return e
+}
+
+// candidate writes the BC4 block of the endpoints e0 and e1 into dst,
+// and returns its squared error over the given channel of the texels.
+func (f rgtcFormat) candidate(dst []byte, e0, e1 int, texels []astcTexel, channel int) int64 {
+	palette := f.palette(e0, e1)
+	var indices uint64
+	var total int64
+	for _, t := range texels {
+		best, bestErr := 0, int64(-1)
+		for j, v := range palette {
+			d := int64(v) - int64(t.c[channel])
+			if bestErr < 0 || d*d < bestErr {
+				best, bestErr = j, d*d
+			}
+		}
+		total += bestErr
+		indices |= uint64(best) << uint(3*(t.y*blockWidth+t.x))
+	}
+	dst[0], dst[1] = uint8(e0), uint8(e1)
+	for i := 2; i < 8; i++ {
+		dst[i] = uint8(indices >> uint(8*(i-2)))
+	}
+	return total
+}
+
+// encodeChannel encodes the given channel of the texels, whose values have
+// 16 bits, into the BC4 block dst. Like encodeAlphaBC3, the block interpolates
+// either between the extreme values, or between the extreme values other than
+// the lowest and highest ones which the block holds exactly.
+func (f rgtcFormat) encodeChannel(dst []byte, texels []astcTexel, channel int) {
+	min, max, _ := f.limits()
+	lo, hi := max, min
+	innerLo, innerHi := max, min
+	for _, t := range texels {
+		e := f.quantize(t.c[channel])
+		if e < lo {
+			lo = e
+		}
+		if e > hi {
+			hi = e
+		}
+		if e != min && e != max {
+			if e < innerLo {
+				innerLo = e
+			}
+			if e > innerHi {
+				innerHi = e
+			}
+		}
+	}
+	if lo > hi {
+		lo, hi = min, min
+	}
+	if innerLo > innerHi {
+		innerLo, innerHi = lo, lo
+	}
+
+	var candidate [blockSize]byte
+	err := f.candidate(dst, hi, lo, texels, channel)
+	if err == 0 {
+		return
+	}
+	if f.candidate(candidate[:], innerLo, innerHi, texels, channel) < err {
+		copy(dst, candidate[:])
+	}
+}
+
+// compress encodes every block of im into pix, which holds the blocks
+// of an image of bounds r.
+func (f rgtcFormat) compress(r image.Rectangle, pix []byte, im image.Image) error {
+	convert := func(c color.Color) [4]int {
+		if f.signed {
+			s := glcolor.SignedRG16Model.Convert(c).(glcolor.SignedRG16)
+			return [4]int{int(s.R), int(s.G), 0, 0}
+		}
+		u := glcolor.RG16Model.Convert(c).(glcolor.RG16)
+		return [4]int{int(u.R), int(u.G), 0, 0}
+	}
+	return compressBlocks(f.name, r, pix, f.blockSize(), im, convert, func(dst []byte, texels []astcTexel) {
+		for c := 0; c < f.channels; c++ {
+			f.encodeChannel(dst[c*blockSize:], texels, c)
+		}
+	})
+}
+
+// Compress encodes the red channel of im into p. Both images must have
+// the same size, which does not need to be a multiple of 4.
+func (p *BC4) Compress(im image.Image) error {
+	return rgtcBC4.compress(p.Rect, p.Pix, im)
+}
+
+// Compress encodes the red channel of im into p, as signed normalized values
+// like glcolor.SignedR16. Both images must have the same size, which does not
+// need to be a multiple of 4.
+func (p *BC4Signed) Compress(im image.Image) error {
+	return rgtcBC4Signed.compress(p.Rect, p.Pix, im)
+}
+
+// Compress encodes the red and green channels of im into p. Both images must
+// have the same size, which does not need to be a multiple of 4.
+func (p *BC5) Compress(im image.Image) error {
+	return rgtcBC5.compress(p.Rect, p.Pix, im)
+}
+
+// Compress encodes the red and green channels of im into p, as signed
+// normalized values like glcolor.SignedRG16. Both images must have the same
+// size, which does not need to be a multiple of 4.
+func (p *BC5Signed) Compress(im image.Image) error {
+	return rgtcBC5Signed.compress(p.Rect, p.Pix, im)
+}
@@ -0,0 +1,97 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// rgtcError returns the largest error of a 16-bit channel between the values
+// of im and the uncompressed p, in the color model of p.
+func rgtcError(t *testing.T, im image.Image, p BlockCompressedImage) int {
+	uncompressed, err := p.Uncompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels := func(c color.Color) []int {
+		switch c := p.ColorModel().Convert(c).(type) {
+		case glcolor.R16:
+			return []int{int(c.R)}
+		case glcolor.SignedR16:
+			return []int{int(c.R)}
+		case glcolor.RG16:
+			return []int{int(c.R), int(c.G)}
+		case glcolor.SignedRG16:
+			return []int{int(c.R), int(c.G)}
+		}
+		t.Fatalf("Unexpected color model of %T", p)
+		return nil
+	}
+	max := 0
+	b := im.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c0 := channels(im.At(b.Min.X+x, b.Min.Y+y))
+			c1 := channels(uncompressed.At(p.Bounds().Min.X+x, p.Bounds().Min.Y+y))
+			for i := range c0 {
+				d := c1[i] - c0[i]
+				if d < 0 {
+					d = -d
+				}
+				if d > max {
+					max = d
+				}
+			}
+		}
+	}
+	return max
+}
+
+func TestCompressRGTC(t *testing.T) {
+	// 6x5 has partial blocks, with a smooth height in red and a steeper one
+	// in green
+	r := image.Rect(0, 0, 6, 5)
+	im := image.NewRGBA64(r)
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 6; x++ {
+			im.Set(x, y, color.RGBA64{uint16(x * 2000), uint16(0xFFFF - y*12000), 0, 0xFFFF})
+		}
+	}
+	// 0x8080 is the endpoint 0x80, and 0xFFFF the highest value
+	solid := image.NewRGBA64(r)
+	draw.Draw(solid, r, image.NewUniform(color.RGBA64{0x8080, 0xFFFF, 0, 0xFFFF}), image.ZP, draw.Src)
+	tests := []struct {
+		p        BlockCompressedImage
+		im       image.Image
+		maxError int
+	}{
+		// The palette splits the range of the values in a block in 5 or 7
+		// steps, the largest range being 36000 in green
+		{NewBC4(r), im, 6000 / 10},
+		{NewBC5(r), im, 36000 / 10},
+		{NewBC4Signed(r), im, 6000 / 10},
+		{NewBC5Signed(r), im, 36000 / 10},
+		{NewBC5(r), solid, 0},
+		{NewBC5(r), image.NewRGBA64(r), 0},
+	}
+	for _, test := range tests {
+		if err := test.p.Compress(test.im); err != nil {
+			t.Fatal(err)
+		}
+		if max := rgtcError(t, test.im, test.p); max > test.maxError {
+			t.Errorf("Wrong %T encoding : largest error %v > %v", test.p, max, test.maxError)
+		}
+	}
+}
+
+func TestCompressRGTCError(t *testing.T) {
+	p := NewBC4Signed(image.Rect(0, 0, 4, 4))
+	err := p.Compress(image.NewNRGBA(image.Rect(0, 0, 4, 5)))
+	if err == nil || !strings.Contains(err.Error(), "BC4 signed compress: wrong image size") {
+		t.Errorf("Expected pattern of error message (BC4 signed compress: wrong image size), got (%v)", err)
+	}
+}
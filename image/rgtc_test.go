@@ -0,0 +1,102 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	glcolor "github.com/hantempo/glu/image/color"
+)
+
+// BC4 blocks whose pixel i has the index i%8, and the 16-bit values
+// of their palettes
+var rgtcGoldenBlocks = []struct {
+	block  []byte
+	values [8]int32
+}{
+	// Unsigned with 8 values
+	{bc3AlphaBlock(0xFF, 0x00), [8]int32{65535, 0, 56173, 46811, 37449, 28086, 18724, 9362}},
+	// Unsigned with 6 values, 0 and 65535
+	{bc3AlphaBlock(0x00, 0xFF), [8]int32{0, 65535, 13107, 26214, 39321, 52428, 0, 65535}},
+	// Signed 127 and -127 with 8 values
+	{bc3AlphaBlock(0x7F, 0x81), [8]int32{32767, -32767, 23405, 14043, 4681, -4681, -14043, -23405}},
+	// Signed -128, which is -127, and 127 with 6 values, -32767 and 32767
+	{bc3AlphaBlock(0x80, 0x7F), [8]int32{-32767, 32767, -19660, -6553, 6553, 19660, -32767, 32767}},
+}
+
+func rgtcValue(values [8]int32, x, y int) int32 {
+	return values[(y*4+x)%8]
+}
+
+func TestDecodeRGTCImages(t *testing.T) {
+	unsigned8, unsigned6 := rgtcGoldenBlocks[0], rgtcGoldenBlocks[1]
+	signed8, signed6 := rgtcGoldenBlocks[2], rgtcGoldenBlocks[3]
+	tests := []struct {
+		im    BlockCompressedImage
+		pix   []byte
+		model color.Model
+		color func(x, y int) color.Color
+	}{
+		{NewBC4(image.Rect(0, 0, 4, 4)), unsigned8.block, glcolor.R16Model, func(x, y int) color.Color {
+			return glcolor.R16{uint16(rgtcValue(unsigned8.values, x, y))}
+		}},
+		{NewBC4(image.Rect(0, 0, 4, 4)), unsigned6.block, glcolor.R16Model, func(x, y int) color.Color {
+			return glcolor.R16{uint16(rgtcValue(unsigned6.values, x, y))}
+		}},
+		{NewBC4Signed(image.Rect(0, 0, 4, 4)), signed8.block, glcolor.SignedR16Model, func(x, y int) color.Color {
+			return glcolor.SignedR16{int16(rgtcValue(signed8.values, x, y))}
+		}},
+		{NewBC4Signed(image.Rect(0, 0, 4, 4)), signed6.block, glcolor.SignedR16Model, func(x, y int) color.Color {
+			return glcolor.SignedR16{int16(rgtcValue(signed6.values, x, y))}
+		}},
+		{NewBC5(image.Rect(0, 0, 4, 4)), append(unsigned8.block, unsigned6.block...), glcolor.RG16Model, func(x, y int) color.Color {
+			return glcolor.RG16{uint16(rgtcValue(unsigned8.values, x, y)), uint16(rgtcValue(unsigned6.values, x, y))}
+		}},
+		{NewBC5Signed(image.Rect(0, 0, 4, 4)), append(signed6.block, signed8.block...), glcolor.SignedRG16Model, func(x, y int) color.Color {
+			return glcolor.SignedRG16{int16(rgtcValue(signed6.values, x, y)), int16(rgtcValue(signed8.values, x, y))}
+		}},
+	}
+	for _, test := range tests {
+		var pix []byte
+		switch m := test.im.(type) {
+		case *BC4:
+			pix = m.Pix
+		case *BC4Signed:
+			pix = m.Pix
+		case *BC5:
+			pix = m.Pix
+		case *BC5Signed:
+			pix = m.Pix
+		}
+		if len(pix) != len(test.pix) {
+			t.Fatalf("Wrong size of pixel data of %T : expected %v, got %v", test.im, len(test.pix), len(pix))
+		}
+		copy(pix, test.pix)
+		if test.im.ColorModel() != test.model {
+			t.Errorf("Wrong color model of %T", test.im)
+		}
+
+		uncompressed, err := test.im.Uncompress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := uncompressed.(*image.RGBA64); !ok {
+			t.Fatalf("Wrong type of uncompressed image : got %T", uncompressed)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				expected := test.color(x, y)
+				if c := test.im.At(x, y); c != expected {
+					t.Errorf("Wrong pixel at [%v %v] of %T : expected %v, got %v", x, y, test.im, expected, c)
+				}
+				if c := uncompressed.At(x, y); c != color.RGBA64Model.Convert(expected) {
+					t.Errorf("Wrong uncompressed pixel at [%v %v] of %T : expected %v, got %v", x, y, test.im, expected, c)
+				}
+			}
+		}
+	}
+
+	if _, err := (&BC5{make([]byte, 8), image.Rect(0, 0, 4, 4)}).Uncompress(); err == nil {
+		t.Error("Expected an error for missing data")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
@@ -10,11 +11,47 @@ import (
 	"path/filepath"
 	"strings"
 
-	_ "github.com/hantempo/glu/image/ktx"
+	"github.com/hantempo/glu/enum"
+	"github.com/hantempo/glu/image/astcfile"
+	"github.com/hantempo/glu/image/dds"
+	"github.com/hantempo/glu/image/ktx"
+	"github.com/hantempo/glu/image/ktx2"
+	"github.com/hantempo/glu/image/pkm"
 )
 
+var format = flag.String("format", "", "print the storage of an internal format, such as RGBA_ASTC_8x5, and exit")
+
+// printFormat prints how the pixels of the internal format named s are stored.
+func printFormat(s string) {
+	e, err := enum.Parse(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f := enum.InternalFormat(e)
+	info, ok := enum.InternalFormatInfo(f)
+	if !ok {
+		log.Fatalf("Unknown storage of internal format : %v\n", f)
+	}
+	fmt.Printf("internal format      : %v\n", f)
+	fmt.Printf("base internal format : %v\n", info.BaseInternalFormat)
+	fmt.Printf("block                : %vx%vx%v, %v bytes\n", info.BlockWidth, info.BlockHeight, info.BlockDepth, info.BytesPerBlock)
+	fmt.Printf("channels             : %v, bits %v\n", info.Channels, info.Bits[:info.Channels])
+	fmt.Printf("compressed           : %v\n", info.Compressed)
+	fmt.Printf("sRGB                 : %v\n", info.SRGB)
+	fmt.Printf("signed               : %v\n", info.Signed)
+	fmt.Printf("float                : %v\n", info.Float)
+	fmt.Printf("integer              : %v\n", info.Integer)
+	for _, tf := range info.TypeFormats {
+		fmt.Printf("type-format          : %v %v\n", tf.Type, tf.Format)
+	}
+}
+
 func main() {
 	flag.Parse()
+	if *format != "" {
+		printFormat(*format)
+		return
+	}
 	if len(flag.Args()) < 2 {
 		return
 	}
@@ -43,6 +80,27 @@ func main() {
 		png.Encode(writer, im)
 	} else if outputExt == ".JPEG" || outputExt == ".JPG" {
 		jpeg.Encode(writer, im, nil)
+	} else if outputExt == ".ASTC" {
+		if err := astcfile.Encode(writer, im, nil); err != nil {
+			log.Fatal(err)
+		}
+	} else if outputExt == ".DDS" {
+		if err := dds.Encode(writer, im, nil); err != nil {
+			log.Fatal(err)
+		}
+	} else if outputExt == ".PKM" {
+		if err := pkm.Encode(writer, im); err != nil {
+			log.Fatal(err)
+		}
+	} else if outputExt == ".KTX" {
+		if err := ktx.Encode(writer, im, nil); err != nil {
+			log.Fatal(err)
+		}
+	} else if outputExt == ".KTX2" {
+		opts := &ktx2.Options{Supercompression: ktx2.SupercompressionZstd}
+		if err := ktx2.Encode(writer, im, opts); err != nil {
+			log.Fatal(err)
+		}
 	} else {
 		log.Fatalf("Unknown output format : %s\n", outputExt)
 	}
@@ -0,0 +1,103 @@
+package zstd
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// load64 returns up to 8 bytes of data starting at i, in little endian.
+func load64(data []byte, i int) uint64 {
+	var v uint64
+	for j := 0; j < 8 && i+j < len(data); j++ {
+		v |= uint64(data[i+j]) << (8 * uint(j))
+	}
+	return v
+}
+
+// forwardReader reads a bitstream from its beginning, starting from the lowest
+// bit of every byte. It is used by the FSE table descriptions.
+type forwardReader struct {
+	data []byte
+	pos  int
+}
+
+// read returns the next n bits, with n no more than 32.
+func (r *forwardReader) read(n uint) (uint32, error) {
+	if r.pos+int(n) > len(r.data)*8 {
+		return 0, errors.New("zstd: truncated bitstream")
+	}
+	v := load64(r.data, r.pos>>3) >> uint(r.pos&7)
+	r.pos += int(n)
+	return uint32(v & (1<<n - 1)), nil
+}
+
+// bytesRead returns how many bytes have been touched by the read bits.
+func (r *forwardReader) bytesRead() int {
+	return (r.pos + 7) / 8
+}
+
+// backwardReader reads a bitstream from its end, starting from the highest bit
+// of the last byte below the end mark. It is used by the FSE and the Huffman
+// coded parts. Bits before the beginning of the stream read as zeros.
+type backwardReader struct {
+	data []byte
+	// pos is the number of bits left to read
+	pos int
+}
+
+func newBackwardReader(data []byte) (backwardReader, error) {
+	if len(data) == 0 {
+		return backwardReader{}, errors.New("zstd: empty bitstream")
+	}
+	last := data[len(data)-1]
+	if last == 0 {
+		return backwardReader{}, errors.New("zstd: missing end mark of bitstream")
+	}
+	return backwardReader{data, (len(data)-1)*8 + bits.Len8(last) - 1}, nil
+}
+
+// peek returns the next n bits without consuming them, with n no more than 56.
+func (r *backwardReader) peek(n uint) uint64 {
+	start := r.pos - int(n)
+	var v uint64
+	if start >= 0 {
+		v = load64(r.data, start>>3) >> uint(start&7)
+	} else {
+		v = load64(r.data, 0) << uint(-start)
+	}
+	return v & (1<<n - 1)
+}
+
+// read returns the next n bits, with n no more than 56.
+func (r *backwardReader) read(n uint) uint32 {
+	v := r.peek(n)
+	r.pos -= int(n)
+	return uint32(v)
+}
+
+// bitWriter writes a bitstream which is read back by backwardReader.
+type bitWriter struct {
+	out []byte
+	acc uint64
+	n   uint
+}
+
+// write appends the low n bits of v, with n no more than 32.
+func (w *bitWriter) write(v uint64, n uint) {
+	w.acc |= (v & (1<<n - 1)) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+// close appends the end mark and returns the stream.
+func (w *bitWriter) close() []byte {
+	w.write(1, 1)
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
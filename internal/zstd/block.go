@@ -0,0 +1,322 @@
+package zstd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Baselines and numbers of extra bits of the literals length codes
+var (
+	llBase = [36]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	llBits = [36]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+)
+
+// Baselines and numbers of extra bits of the match length codes
+var (
+	mlBase = [53]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	mlBits = [53]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+)
+
+// Predefined distributions of the literals length, offset and match length codes
+var (
+	llDefault = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}
+	ofDefault = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}
+	mlDefault = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}
+
+	llDefaultLog, ofDefaultLog, mlDefaultLog uint = 6, 5, 6
+
+	llDefaultTable = buildTable(llDefault, llDefaultLog)
+	ofDefaultTable = buildTable(ofDefault, ofDefaultLog)
+	mlDefaultTable = buildTable(mlDefault, mlDefaultLog)
+)
+
+const (
+	llMaxSymbol, ofMaxSymbol, mlMaxSymbol = 35, 31, 52
+	llMaxLog, ofMaxLog, mlMaxLog          = 9, 8, 9
+)
+
+// decodeBlock decodes a compressed block and appends its content to d.out.
+func (d *decoder) decodeBlock(data []byte) error {
+	literals, n, err := d.decodeLiterals(data)
+	if err != nil {
+		return err
+	}
+	return d.decodeSequences(data[n:], literals)
+}
+
+// decodeLiterals decodes the literals section, and returns the literals
+// with the number of bytes read.
+func (d *decoder) decodeLiterals(data []byte) ([]byte, int, error) {
+	if len(data) < 1 {
+		return nil, 0, errors.New("zstd: missing literals section")
+	}
+	blockType := data[0] & 3
+	sizeFormat := (data[0] >> 2) & 3
+
+	if blockType == 0 || blockType == 1 {
+		// Raw or RLE literals
+		var size, headerSize int
+		switch sizeFormat {
+		case 0, 2:
+			size, headerSize = int(data[0]>>3), 1
+		case 1:
+			headerSize = 2
+			if len(data) < headerSize {
+				return nil, 0, errors.New("zstd: truncated literals header")
+			}
+			size = int(data[0]>>4) | int(data[1])<<4
+		case 3:
+			headerSize = 3
+			if len(data) < headerSize {
+				return nil, 0, errors.New("zstd: truncated literals header")
+			}
+			size = int(data[0]>>4) | int(data[1])<<4 | int(data[2])<<12
+		}
+		if size > maxBlockSize {
+			return nil, 0, fmt.Errorf("zstd: too many literals [%v]", size)
+		}
+		if blockType == 0 {
+			if len(data) < headerSize+size {
+				return nil, 0, errors.New("zstd: truncated raw literals")
+			}
+			return data[headerSize : headerSize+size], headerSize + size, nil
+		}
+		if len(data) < headerSize+1 {
+			return nil, 0, errors.New("zstd: truncated RLE literals")
+		}
+		literals := make([]byte, size)
+		for i := range literals {
+			literals[i] = data[headerSize]
+		}
+		return literals, headerSize + 1, nil
+	}
+
+	// Huffman coded literals, with a new or the previous tree
+	var headerSize, streams int
+	var bitsPerSize uint
+	switch sizeFormat {
+	case 0:
+		headerSize, streams, bitsPerSize = 3, 1, 10
+	case 1:
+		headerSize, streams, bitsPerSize = 3, 4, 10
+	case 2:
+		headerSize, streams, bitsPerSize = 4, 4, 14
+	case 3:
+		headerSize, streams, bitsPerSize = 5, 4, 18
+	}
+	if len(data) < headerSize {
+		return nil, 0, errors.New("zstd: truncated literals header")
+	}
+	header := load64(data[:headerSize], 0) >> 4
+	mask := uint64(1)<<bitsPerSize - 1
+	size := int(header & mask)
+	compressedSize := int((header >> bitsPerSize) & mask)
+	if size > maxBlockSize {
+		return nil, 0, fmt.Errorf("zstd: too many literals [%v]", size)
+	}
+	if len(data) < headerSize+compressedSize {
+		return nil, 0, errors.New("zstd: truncated compressed literals")
+	}
+	src := data[headerSize : headerSize+compressedSize]
+	if blockType == 2 {
+		t, n, err := readHuffmanTable(src)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.huffman = t
+		src = src[n:]
+	} else if d.huffman == nil {
+		return nil, 0, errors.New("zstd: missing Huffman tree of treeless literals")
+	}
+	literals := make([]byte, size)
+	if err := d.huffman.decode(literals, src, streams); err != nil {
+		return nil, 0, err
+	}
+	return literals, headerSize + compressedSize, nil
+}
+
+// readTable decodes the table of one kind of codes in the given mode,
+// and returns the table with the number of bytes read.
+func readTable(data []byte, mode uint8, previous, predefined *fseTable, maxSymbol int, maxLog uint) (*fseTable, int, error) {
+	switch mode {
+	case 0:
+		return predefined, 0, nil
+	case 1:
+		if len(data) < 1 {
+			return nil, 0, errors.New("zstd: truncated RLE table")
+		}
+		if int(data[0]) > maxSymbol {
+			return nil, 0, fmt.Errorf("zstd: invalid RLE symbol [%v]", data[0])
+		}
+		return rleTable(data[0]), 1, nil
+	case 2:
+		probs, accLog, n, err := readDistribution(data, maxSymbol, maxLog)
+		if err != nil {
+			return nil, 0, err
+		}
+		return buildTable(probs, accLog), n, nil
+	default:
+		if previous == nil {
+			return nil, 0, errors.New("zstd: missing table to repeat")
+		}
+		return previous, 0, nil
+	}
+}
+
+// decodeSequences decodes the sequences section and executes the sequences
+// with the literals.
+func (d *decoder) decodeSequences(data []byte, literals []byte) error {
+	if len(data) < 1 {
+		return errors.New("zstd: missing sequences section")
+	}
+	count := int(data[0])
+	pos := 1
+	if count >= 128 {
+		if count < 255 {
+			if len(data) < 2 {
+				return errors.New("zstd: truncated sequences header")
+			}
+			count = (count-128)<<8 + int(data[1])
+			pos = 2
+		} else {
+			if len(data) < 3 {
+				return errors.New("zstd: truncated sequences header")
+			}
+			count = int(data[1]) + int(data[2])<<8 + 0x7F00
+			pos = 3
+		}
+	}
+	if count == 0 {
+		d.out = append(d.out, literals...)
+		return nil
+	}
+
+	if len(data) < pos+1 {
+		return errors.New("zstd: truncated sequences header")
+	}
+	modes := data[pos]
+	pos++
+	if modes&3 != 0 {
+		return errors.New("zstd: reserved bits set in symbol compression modes")
+	}
+	var err error
+	var n int
+	if d.llTable, n, err = readTable(data[pos:], modes>>6, d.llTable, llDefaultTable, llMaxSymbol, llMaxLog); err != nil {
+		return err
+	}
+	pos += n
+	if d.ofTable, n, err = readTable(data[pos:], (modes>>4)&3, d.ofTable, ofDefaultTable, ofMaxSymbol, ofMaxLog); err != nil {
+		return err
+	}
+	pos += n
+	if d.mlTable, n, err = readTable(data[pos:], (modes>>2)&3, d.mlTable, mlDefaultTable, mlMaxSymbol, mlMaxLog); err != nil {
+		return err
+	}
+	pos += n
+
+	r, err := newBackwardReader(data[pos:])
+	if err != nil {
+		return err
+	}
+	llState := r.read(d.llTable.accLog)
+	ofState := r.read(d.ofTable.accLog)
+	mlState := r.read(d.mlTable.accLog)
+
+	for i := 0; i < count; i++ {
+		llCode := d.llTable.entries[llState].symbol
+		ofCode := d.ofTable.entries[ofState].symbol
+		mlCode := d.mlTable.entries[mlState].symbol
+		if llCode > llMaxSymbol || ofCode > ofMaxSymbol || mlCode > mlMaxSymbol {
+			return errors.New("zstd: invalid sequence code")
+		}
+
+		offsetValue := uint32(1)<<ofCode + r.read(uint(ofCode))
+		matchLength := mlBase[mlCode] + r.read(uint(mlBits[mlCode]))
+		literalsLength := llBase[llCode] + r.read(uint(llBits[llCode]))
+
+		var offset uint32
+		if offsetValue > 3 {
+			offset = offsetValue - 3
+			d.reps = [3]uint32{offset, d.reps[0], d.reps[1]}
+		} else {
+			// Repeated offsets, shifted by one without literals
+			index := offsetValue - 1
+			if literalsLength == 0 {
+				index++
+			}
+			switch index {
+			case 0:
+				offset = d.reps[0]
+			case 1:
+				offset = d.reps[1]
+				d.reps[0], d.reps[1] = d.reps[1], d.reps[0]
+			case 2:
+				offset = d.reps[2]
+				d.reps = [3]uint32{offset, d.reps[0], d.reps[1]}
+			default:
+				offset = d.reps[0] - 1
+				if offset == 0 {
+					return errors.New("zstd: invalid repeated offset")
+				}
+				d.reps = [3]uint32{offset, d.reps[0], d.reps[1]}
+			}
+		}
+
+		if i != count-1 {
+			llState = d.llTable.next(llState, &r)
+			mlState = d.mlTable.next(mlState, &r)
+			ofState = d.ofTable.next(ofState, &r)
+		}
+		if r.pos < 0 {
+			return errors.New("zstd: corrupt sequences bitstream")
+		}
+
+		if int(literalsLength) > len(literals) {
+			return errors.New("zstd: not enough literals")
+		}
+		d.out = append(d.out, literals[:literalsLength]...)
+		literals = literals[literalsLength:]
+		if int(offset) > len(d.out) {
+			return fmt.Errorf("zstd: offset beyond content [%v > %v]", offset, len(d.out))
+		}
+		start := len(d.out) - int(offset)
+		for j := 0; j < int(matchLength); j++ {
+			d.out = append(d.out, d.out[start+j])
+		}
+	}
+	if r.pos != 0 {
+		return errors.New("zstd: corrupt sequences bitstream")
+	}
+	d.out = append(d.out, literals...)
+	return nil
+}
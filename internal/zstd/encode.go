@@ -0,0 +1,214 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+const (
+	minMatch  = 4
+	maxOffset = 1 << 27
+	hashLog   = 16
+)
+
+var (
+	llEncTable = buildEncTable(llDefault, llDefaultLog)
+	ofEncTable = buildEncTable(ofDefault, ofDefaultLog)
+	mlEncTable = buildEncTable(mlDefault, mlDefaultLog)
+)
+
+// sequence is a run of literals followed by a match.
+type sequence struct {
+	literals uint32
+	offset   uint32
+	match    uint32
+}
+
+// Compress returns src compressed in a single Zstandard frame.
+func Compress(src []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, frameMagic)
+
+	// Single segment frame with the content size
+	size := uint64(len(src))
+	switch {
+	case size < 256:
+		out = append(out, 0x20, byte(size))
+	case size < 65536+256:
+		out = append(out, 0x60)
+		out = binary.LittleEndian.AppendUint16(out, uint16(size-256))
+	case size <= 0xFFFFFFFF:
+		out = append(out, 0xA0)
+		out = binary.LittleEndian.AppendUint32(out, uint32(size))
+	default:
+		out = append(out, 0xE0)
+		out = binary.LittleEndian.AppendUint64(out, size)
+	}
+
+	if len(src) == 0 {
+		return appendBlockHeader(out, true, 0, 0)
+	}
+	e := encoder{src: src}
+	for i := range e.table {
+		e.table[i] = -1
+	}
+	for start := 0; start < len(src); start += maxBlockSize {
+		end := start + maxBlockSize
+		if end > len(src) {
+			end = len(src)
+		}
+		out = e.encodeBlock(out, start, end, end == len(src))
+	}
+	return out
+}
+
+// appendBlockHeader appends the 3-byte header of a block.
+func appendBlockHeader(out []byte, last bool, blockType, size int) []byte {
+	header := uint32(blockType<<1 | size<<3)
+	if last {
+		header |= 1
+	}
+	return append(out, byte(header), byte(header>>8), byte(header>>16))
+}
+
+// encoder holds the match finder, whose history spans the whole input.
+type encoder struct {
+	src   []byte
+	table [1 << hashLog]int32
+}
+
+func hash4(v uint32) uint32 {
+	return (v * 2654435761) >> (32 - hashLog)
+}
+
+// encodeBlock appends the block of src[start:end], as an RLE, raw or
+// compressed block, whichever is the smallest.
+func (e *encoder) encodeBlock(out []byte, start, end int, last bool) []byte {
+	block := e.src[start:end]
+	rle := true
+	for _, b := range block[1:] {
+		if b != block[0] {
+			rle = false
+			break
+		}
+	}
+	if rle {
+		out = appendBlockHeader(out, last, 1, len(block))
+		return append(out, block[0])
+	}
+
+	seqs, literals := e.findMatches(start, end)
+	if len(seqs) > 0 {
+		body := encodeSequences(encodeLiterals(nil, literals), seqs)
+		if len(body) < len(block) {
+			out = appendBlockHeader(out, last, 2, len(body))
+			return append(out, body...)
+		}
+	}
+	out = appendBlockHeader(out, last, 0, len(block))
+	return append(out, block...)
+}
+
+// findMatches greedily splits src[start:end] into sequences, and returns
+// them with the literals they need, trailing literals included.
+func (e *encoder) findMatches(start, end int) ([]sequence, []byte) {
+	var seqs []sequence
+	var literals []byte
+	src := e.src
+	anchor := start
+	for i := start; i+minMatch <= end; {
+		v := binary.LittleEndian.Uint32(src[i:])
+		h := hash4(v)
+		candidate := int(e.table[h])
+		e.table[h] = int32(i)
+		if candidate < 0 || i-candidate > maxOffset ||
+			binary.LittleEndian.Uint32(src[candidate:]) != v {
+			i++
+			continue
+		}
+		length := minMatch
+		for i+length < end && src[candidate+length] == src[i+length] {
+			length++
+		}
+		literals = append(literals, src[anchor:i]...)
+		seqs = append(seqs, sequence{
+			literals: uint32(i - anchor),
+			offset:   uint32(i - candidate),
+			match:    uint32(length),
+		})
+		i += length
+		anchor = i
+	}
+	literals = append(literals, src[anchor:end]...)
+	return seqs, literals
+}
+
+// encodeLiterals appends a raw literals section.
+func encodeLiterals(out, literals []byte) []byte {
+	size := len(literals)
+	switch {
+	case size < 32:
+		out = append(out, byte(size<<3))
+	case size < 4096:
+		out = append(out, byte(size<<4)|0x04, byte(size>>4))
+	default:
+		out = append(out, byte(size<<4)|0x0C, byte(size>>4), byte(size>>12))
+	}
+	return append(out, literals...)
+}
+
+// code returns the code of value in a table of baselines.
+func code(base []uint32, value uint32) uint8 {
+	return uint8(sort.Search(len(base), func(i int) bool { return base[i] > value }) - 1)
+}
+
+// encodeSequences appends a sequences section, coded with the predefined
+// distributions.
+func encodeSequences(out []byte, seqs []sequence) []byte {
+	count := len(seqs)
+	switch {
+	case count < 128:
+		out = append(out, byte(count))
+	case count < 0x7F00:
+		out = append(out, byte(count>>8)+128, byte(count))
+	default:
+		out = append(out, 255, byte(count-0x7F00), byte((count-0x7F00)>>8))
+	}
+	out = append(out, 0)
+
+	n := len(seqs)
+	llCodes := make([]uint8, n)
+	ofCodes := make([]uint8, n)
+	mlCodes := make([]uint8, n)
+	offsets := make([]uint32, n)
+	for i, s := range seqs {
+		llCodes[i] = code(llBase[:], s.literals)
+		mlCodes[i] = code(mlBase[:], s.match)
+		// Offsets are shifted past the repeated offset codes
+		offsets[i] = s.offset + 3
+		ofCodes[i] = uint8(bits.Len32(offsets[i]) - 1)
+	}
+	writeExtra := func(w *bitWriter, i int) {
+		w.write(uint64(seqs[i].literals-llBase[llCodes[i]]), uint(llBits[llCodes[i]]))
+		w.write(uint64(seqs[i].match-mlBase[mlCodes[i]]), uint(mlBits[mlCodes[i]]))
+		w.write(uint64(offsets[i]), uint(ofCodes[i]))
+	}
+
+	// Sequences are coded backwards so that the decoder reads them forwards
+	w := bitWriter{out: out}
+	var ll, of, ml fseEncoder
+	ml.init(mlEncTable, mlCodes[n-1])
+	of.init(ofEncTable, ofCodes[n-1])
+	ll.init(llEncTable, llCodes[n-1])
+	writeExtra(&w, n-1)
+	for i := n - 2; i >= 0; i-- {
+		of.encode(&w, ofCodes[i])
+		ml.encode(&w, mlCodes[i])
+		ll.encode(&w, llCodes[i])
+		writeExtra(&w, i)
+	}
+	ml.flush(&w)
+	of.flush(&w)
+	ll.flush(&w)
+	return w.close()
+}
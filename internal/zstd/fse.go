@@ -0,0 +1,242 @@
+package zstd
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// fseEntry is one state of an FSE decoding table.
+type fseEntry struct {
+	symbol uint8
+	nbBits uint8
+	base   uint16
+}
+
+// fseTable is an FSE decoding table.
+type fseTable struct {
+	accLog  uint
+	entries []fseEntry
+}
+
+// next returns the state following state, reading its bits from r.
+func (t *fseTable) next(state uint32, r *backwardReader) uint32 {
+	e := t.entries[state]
+	return uint32(e.base) + r.read(uint(e.nbBits))
+}
+
+// readDistribution parses an FSE table description, and returns the
+// normalized probabilities with the accuracy log and the number of bytes read.
+func readDistribution(data []byte, maxSymbol int, maxAccLog uint) ([]int16, uint, int, error) {
+	r := forwardReader{data: data}
+	v, err := r.read(4)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	accLog := uint(v) + 5
+	if accLog > maxAccLog {
+		return nil, 0, 0, errors.New("zstd: FSE accuracy log too large")
+	}
+
+	probs := make([]int16, 0, maxSymbol+1)
+	remaining := int32(1<<accLog) + 1
+	threshold := int32(1 << accLog)
+	nbBits := accLog + 1
+	previous0 := false
+	for remaining > 1 {
+		if previous0 {
+			// Repeat flags tell how many more symbols have a zero probability
+			for {
+				repeat, err := r.read(2)
+				if err != nil {
+					return nil, 0, 0, err
+				}
+				for i := uint32(0); i < repeat; i++ {
+					probs = append(probs, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+		}
+		if len(probs) > maxSymbol {
+			return nil, 0, 0, errors.New("zstd: too many symbols in FSE table")
+		}
+
+		max := (2*threshold - 1) - remaining
+		low, err := r.read(nbBits - 1)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		var count int32
+		if int32(low) < max {
+			count = int32(low)
+		} else {
+			r.pos -= int(nbBits - 1)
+			v, err := r.read(nbBits)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			count = int32(v)
+			if count >= threshold {
+				count -= max
+			}
+		}
+		// Probabilities are stored plus one, -1 means "less than 1"
+		count--
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+		probs = append(probs, int16(count))
+		previous0 = count == 0
+		for remaining < threshold && nbBits > 1 {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	if remaining != 1 || len(probs) > maxSymbol+1 {
+		return nil, 0, 0, errors.New("zstd: invalid FSE table description")
+	}
+	return probs, accLog, r.bytesRead(), nil
+}
+
+// spreadSymbols returns the symbol of every state of a table built from
+// the normalized probabilities.
+func spreadSymbols(probs []int16, accLog uint) []uint8 {
+	size := 1 << accLog
+	symbols := make([]uint8, size)
+	high := size - 1
+	for s, p := range probs {
+		if p == -1 {
+			symbols[high] = uint8(s)
+			high--
+		}
+	}
+	mask := size - 1
+	step := (size >> 1) + (size >> 3) + 3
+	pos := 0
+	for s, p := range probs {
+		for i := 0; i < int(p); i++ {
+			symbols[pos] = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+	return symbols
+}
+
+// buildTable builds the FSE decoding table from the normalized probabilities.
+func buildTable(probs []int16, accLog uint) *fseTable {
+	symbols := spreadSymbols(probs, accLog)
+	size := uint32(1) << accLog
+	next := make([]uint32, len(probs))
+	for s, p := range probs {
+		if p == -1 {
+			next[s] = 1
+		} else {
+			next[s] = uint32(p)
+		}
+	}
+	t := &fseTable{accLog: accLog, entries: make([]fseEntry, size)}
+	for i, s := range symbols {
+		n := next[s]
+		next[s]++
+		nbBits := accLog + 1 - uint(bits.Len32(n))
+		t.entries[i] = fseEntry{
+			symbol: s,
+			nbBits: uint8(nbBits),
+			base:   uint16((n << nbBits) - size),
+		}
+	}
+	return t
+}
+
+// rleTable returns the table which always decodes symbol without reading any bit.
+func rleTable(symbol uint8) *fseTable {
+	return &fseTable{entries: []fseEntry{{symbol: symbol}}}
+}
+
+// fseEncTable is an FSE encoding table.
+type fseEncTable struct {
+	accLog     uint
+	stateTable []uint16
+	transforms []symbolTransform
+}
+
+type symbolTransform struct {
+	deltaNbBits    uint32
+	deltaFindState int32
+}
+
+// buildEncTable builds the FSE encoding table from the normalized
+// probabilities, matching the decoding table of buildTable.
+func buildEncTable(probs []int16, accLog uint) *fseEncTable {
+	size := 1 << accLog
+	symbols := spreadSymbols(probs, accLog)
+
+	cumul := make([]int, len(probs)+1)
+	for s, p := range probs {
+		if p == -1 {
+			cumul[s+1] = cumul[s] + 1
+		} else {
+			cumul[s+1] = cumul[s] + int(p)
+		}
+	}
+	t := &fseEncTable{
+		accLog:     accLog,
+		stateTable: make([]uint16, size),
+		transforms: make([]symbolTransform, len(probs)),
+	}
+	for u, s := range symbols {
+		t.stateTable[cumul[s]] = uint16(size + u)
+		cumul[s]++
+	}
+
+	total := int32(0)
+	for s, p := range probs {
+		tt := &t.transforms[s]
+		switch p {
+		case 0:
+			tt.deltaNbBits = uint32((accLog+1)<<16) - uint32(size)
+		case -1, 1:
+			tt.deltaNbBits = uint32(accLog<<16) - uint32(size)
+			tt.deltaFindState = total - 1
+			total++
+		default:
+			maxBitsOut := accLog - uint(bits.Len32(uint32(p-1))-1)
+			minStatePlus := uint32(p) << maxBitsOut
+			tt.deltaNbBits = uint32(maxBitsOut<<16) - minStatePlus
+			tt.deltaFindState = total - int32(p)
+			total += int32(p)
+		}
+	}
+	return t
+}
+
+// fseEncoder encodes symbols with an FSE encoding table.
+type fseEncoder struct {
+	t     *fseEncTable
+	state uint32
+}
+
+func (e *fseEncoder) init(t *fseEncTable, symbol uint8) {
+	e.t = t
+	tt := t.transforms[symbol]
+	nbBitsOut := (tt.deltaNbBits + (1 << 15)) >> 16
+	value := (nbBitsOut << 16) - tt.deltaNbBits
+	e.state = uint32(t.stateTable[int32(value>>nbBitsOut)+tt.deltaFindState])
+}
+
+func (e *fseEncoder) encode(w *bitWriter, symbol uint8) {
+	tt := e.t.transforms[symbol]
+	nbBitsOut := (e.state + tt.deltaNbBits) >> 16
+	w.write(uint64(e.state), uint(nbBitsOut))
+	e.state = uint32(e.t.stateTable[int32(e.state>>nbBitsOut)+tt.deltaFindState])
+}
+
+func (e *fseEncoder) flush(w *bitWriter) {
+	w.write(uint64(e.state), e.t.accLog)
+}
@@ -0,0 +1,190 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+const maxHuffmanBits = 11
+
+type huffmanEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+// huffmanTable is a Huffman decoding table indexed by the next maxBits bits.
+type huffmanTable struct {
+	maxBits uint
+	entries []huffmanEntry
+}
+
+// readHuffmanTable parses a Huffman tree description, and returns the
+// decoding table with the number of bytes read.
+func readHuffmanTable(data []byte) (*huffmanTable, int, error) {
+	if len(data) == 0 {
+		return nil, 0, errors.New("zstd: missing Huffman tree description")
+	}
+	var weights [256]uint8
+	nw := 0
+	n := 0
+
+	if header := int(data[0]); header < 128 {
+		// Weights are FSE compressed in header bytes
+		n = 1 + header
+		if n > len(data) {
+			return nil, 0, errors.New("zstd: truncated Huffman tree description")
+		}
+		src := data[1:n]
+		probs, accLog, m, err := readDistribution(src, 255, 6)
+		if err != nil {
+			return nil, 0, err
+		}
+		t := buildTable(probs, accLog)
+		r, err := newBackwardReader(src[m:])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Two interleaved states share the bitstream
+		state1 := r.read(accLog)
+		state2 := r.read(accLog)
+		for {
+			if nw > 253 {
+				return nil, 0, errors.New("zstd: too many Huffman weights")
+			}
+			weights[nw] = t.entries[state1].symbol
+			nw++
+			state1 = t.next(state1, &r)
+			if r.pos < 0 {
+				weights[nw] = t.entries[state2].symbol
+				nw++
+				break
+			}
+			weights[nw] = t.entries[state2].symbol
+			nw++
+			state2 = t.next(state2, &r)
+			if r.pos < 0 {
+				weights[nw] = t.entries[state1].symbol
+				nw++
+				break
+			}
+		}
+	} else {
+		// Weights are stored directly in 4 bits
+		nw = header - 127
+		n = 1 + (nw+1)/2
+		if n > len(data) {
+			return nil, 0, errors.New("zstd: truncated Huffman tree description")
+		}
+		for i := 0; i < nw; i++ {
+			b := data[1+i/2]
+			if i%2 == 0 {
+				weights[i] = b >> 4
+			} else {
+				weights[i] = b & 0x0F
+			}
+		}
+	}
+
+	// The weight of the last symbol is implied by the others
+	total := 0
+	for _, w := range weights[:nw] {
+		if w > maxHuffmanBits {
+			return nil, 0, errors.New("zstd: invalid Huffman weight")
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, 0, errors.New("zstd: invalid Huffman weights")
+	}
+	maxBits := uint(bits.Len(uint(total)))
+	left := 1<<maxBits - total
+	if maxBits > maxHuffmanBits || left&(left-1) != 0 {
+		return nil, 0, errors.New("zstd: invalid Huffman weights")
+	}
+	weights[nw] = uint8(bits.Len(uint(left)))
+	nw++
+
+	// Symbols of each weight take consecutive entries, from the lowest weight
+	var rankStart [maxHuffmanBits + 2]int
+	for _, w := range weights[:nw] {
+		if w > 0 {
+			rankStart[w] += 1 << (w - 1)
+		}
+	}
+	next := 0
+	for w := 1; w <= int(maxBits); w++ {
+		count := rankStart[w]
+		rankStart[w] = next
+		next += count
+	}
+	t := &huffmanTable{maxBits: maxBits, entries: make([]huffmanEntry, 1<<maxBits)}
+	for s, w := range weights[:nw] {
+		if w == 0 {
+			continue
+		}
+		e := huffmanEntry{symbol: uint8(s), nbBits: uint8(maxBits + 1 - uint(w))}
+		length := 1 << (w - 1)
+		for i := rankStart[w]; i < rankStart[w]+length; i++ {
+			t.entries[i] = e
+		}
+		rankStart[w] += length
+	}
+	return t, n, nil
+}
+
+// decodeStream fills dst with the symbols of one Huffman coded stream.
+func (t *huffmanTable) decodeStream(dst, src []byte) error {
+	r, err := newBackwardReader(src)
+	if err != nil {
+		return err
+	}
+	for i := range dst {
+		e := t.entries[r.peek(t.maxBits)]
+		dst[i] = e.symbol
+		r.pos -= int(e.nbBits)
+	}
+	if r.pos != 0 {
+		return errors.New("zstd: corrupt Huffman stream")
+	}
+	return nil
+}
+
+// decode fills dst with the symbols of one or four Huffman coded streams.
+func (t *huffmanTable) decode(dst, src []byte, streams int) error {
+	if streams == 1 {
+		return t.decodeStream(dst, src)
+	}
+	if len(src) < 6 {
+		return errors.New("zstd: truncated jump table")
+	}
+	var sizes [4]int
+	sizes[3] = len(src) - 6
+	for i := 0; i < 3; i++ {
+		sizes[i] = int(binary.LittleEndian.Uint16(src[i*2:]))
+		sizes[3] -= sizes[i]
+	}
+	if sizes[3] < 0 {
+		return errors.New("zstd: invalid jump table")
+	}
+	src = src[6:]
+	segment := (len(dst) + 3) / 4
+	last := len(dst) - 3*segment
+	for i := 0; i < 4; i++ {
+		n := segment
+		if i == 3 {
+			n = last
+		}
+		if n < 0 {
+			return errors.New("zstd: invalid literals size")
+		}
+		if err := t.decodeStream(dst[:n], src[:sizes[i]]); err != nil {
+			return err
+		}
+		dst, src = dst[n:], src[sizes[i]:]
+	}
+	return nil
+}
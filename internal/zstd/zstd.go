@@ -0,0 +1,170 @@
+// Package zstd implements the Zstandard compressed data format of RFC 8878.
+//
+// The decoder handles every block type and entropy mode, except frames which
+// need a dictionary. The encoder emits valid frames with raw literals and
+// sequences coded with the predefined distributions.
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	frameMagic         = 0xFD2FB528
+	skippableMagicMask = 0xFFFFFFF0
+	skippableMagic     = 0x184D2A50
+	maxBlockSize       = 128 << 10
+	// Upper limit of memory reserved from the frame content size
+	maxReservedSize = 1 << 28
+)
+
+// Decompress returns the content of every frame in src, one after another.
+func Decompress(src []byte) ([]byte, error) {
+	var out []byte
+	for len(src) > 0 {
+		if len(src) < 4 {
+			return nil, errors.New("zstd: truncated frame")
+		}
+		magic := binary.LittleEndian.Uint32(src)
+		if magic&skippableMagicMask == skippableMagic {
+			if len(src) < 8 {
+				return nil, errors.New("zstd: truncated skippable frame")
+			}
+			size := uint64(binary.LittleEndian.Uint32(src[4:]))
+			if size > uint64(len(src)-8) {
+				return nil, errors.New("zstd: truncated skippable frame")
+			}
+			src = src[8+size:]
+			continue
+		}
+		if magic != frameMagic {
+			return nil, fmt.Errorf("zstd: invalid magic number [0x%X]", magic)
+		}
+
+		var d decoder
+		n, err := d.decodeFrame(src[4:])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d.out...)
+		src = src[4+n:]
+	}
+	return out, nil
+}
+
+// decoder holds the state shared by the blocks of a frame.
+type decoder struct {
+	out     []byte
+	reps    [3]uint32
+	huffman *huffmanTable
+	llTable *fseTable
+	ofTable *fseTable
+	mlTable *fseTable
+}
+
+// decodeFrame decodes the frame following the magic number in data,
+// and returns the number of bytes read.
+func (d *decoder) decodeFrame(data []byte) (int, error) {
+	if len(data) < 1 {
+		return 0, errors.New("zstd: truncated frame header")
+	}
+	descriptor := data[0]
+	fcsFlag := descriptor >> 6
+	singleSegment := descriptor&0x20 != 0
+	hasChecksum := descriptor&0x04 != 0
+	dictIDFlag := descriptor & 0x03
+	if descriptor&0x08 != 0 {
+		return 0, errors.New("zstd: reserved bit set in frame header")
+	}
+
+	pos := 1
+	if !singleSegment {
+		// Window descriptor, the whole content is kept in memory anyway
+		pos++
+	}
+	dictIDSize := [4]int{0, 1, 2, 4}[dictIDFlag]
+	fcsSize := [4]int{0, 2, 4, 8}[fcsFlag]
+	if fcsFlag == 0 && singleSegment {
+		fcsSize = 1
+	}
+	if len(data) < pos+dictIDSize+fcsSize {
+		return 0, errors.New("zstd: truncated frame header")
+	}
+	if dictID := load64(data[pos:pos+dictIDSize], 0); dictID != 0 {
+		return 0, fmt.Errorf("zstd: dictionaries are not supported [%v]", dictID)
+	}
+	pos += dictIDSize
+	contentSize := load64(data[pos:pos+fcsSize], 0)
+	if fcsSize == 2 {
+		contentSize += 256
+	}
+	pos += fcsSize
+	if fcsSize > 0 {
+		reserved := contentSize
+		if reserved > maxReservedSize {
+			reserved = maxReservedSize
+		}
+		d.out = make([]byte, 0, reserved)
+	}
+
+	d.reps = [3]uint32{1, 4, 8}
+	for {
+		if len(data) < pos+3 {
+			return 0, errors.New("zstd: truncated block header")
+		}
+		header := uint32(data[pos]) | uint32(data[pos+1])<<8 | uint32(data[pos+2])<<16
+		pos += 3
+		last := header&1 != 0
+		blockType := (header >> 1) & 3
+		size := int(header >> 3)
+
+		switch blockType {
+		case 0:
+			// Raw block
+			if len(data) < pos+size {
+				return 0, errors.New("zstd: truncated raw block")
+			}
+			d.out = append(d.out, data[pos:pos+size]...)
+			pos += size
+		case 1:
+			// RLE block, size is the number of repeated bytes
+			if len(data) < pos+1 {
+				return 0, errors.New("zstd: truncated RLE block")
+			}
+			for i := 0; i < size; i++ {
+				d.out = append(d.out, data[pos])
+			}
+			pos++
+		case 2:
+			if size > maxBlockSize {
+				return 0, fmt.Errorf("zstd: block too large [%v]", size)
+			}
+			if len(data) < pos+size {
+				return 0, errors.New("zstd: truncated compressed block")
+			}
+			if err := d.decodeBlock(data[pos : pos+size]); err != nil {
+				return 0, err
+			}
+			pos += size
+		default:
+			return 0, errors.New("zstd: reserved block type")
+		}
+		if last {
+			break
+		}
+	}
+
+	if fcsSize > 0 && uint64(len(d.out)) != contentSize {
+		return 0, fmt.Errorf("zstd: wrong content size [%v != %v]", len(d.out), contentSize)
+	}
+	if hasChecksum {
+		// The checksum is not verified
+		if len(data) < pos+4 {
+			return 0, errors.New("zstd: truncated checksum")
+		}
+		pos += 4
+	}
+	return pos, nil
+}
@@ -0,0 +1,117 @@
+package zstd
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const testText = "KTX is a format for storing textures for OpenGL and OpenGL ES " +
+	"applications. It is distinguished by the simplicity of the loader " +
+	"required to instantiate a GL texture object from the file contents. " +
+	"KTX 2.0 adds support for supercompression, so that the texture data of" +
+	" every mip level may be compressed with Zstandard or zlib, and a data " +
+	"format descriptor which describes the texel layout of the texture " +
+	"data."
+
+// testText compressed by the reference implementation at level 19, with
+// Huffman coded literals in four streams and FSE coded match lengths
+var testFrame = []byte{
+	0x28, 0xB5, 0x2F, 0xFD, 0x60, 0x97, 0x00, 0xDD, 0x07, 0x00, 0x56, 0x91,
+	0x30, 0x1B, 0x50, 0x6D, 0xDB, 0x1E, 0xC3, 0x76, 0xCB, 0x2D, 0x94, 0xD1,
+	0x35, 0x9B, 0x74, 0x53, 0x03, 0x06, 0x4F, 0xA1, 0x53, 0x55, 0xE2, 0x26,
+	0x56, 0x63, 0x66, 0xC6, 0x84, 0x29, 0x00, 0x28, 0x00, 0x28, 0x00, 0xD9,
+	0x9C, 0xE9, 0x9C, 0xEF, 0xC2, 0x15, 0x66, 0x9A, 0x7D, 0x28, 0x57, 0x6B,
+	0xF7, 0xC4, 0x20, 0xB1, 0xFB, 0x81, 0xC0, 0xB0, 0x21, 0x18, 0xD7, 0x69,
+	0x09, 0xF6, 0x1B, 0x2C, 0x57, 0x9B, 0xDF, 0xD7, 0xAC, 0x32, 0xBF, 0x73,
+	0x7E, 0xA1, 0x30, 0x04, 0xCB, 0xBC, 0x11, 0x3A, 0x16, 0xFD, 0x81, 0xF0,
+	0x3B, 0xF6, 0x65, 0xB3, 0x6B, 0x66, 0x79, 0xED, 0x27, 0xDC, 0x74, 0x1E,
+	0xB8, 0x5F, 0x4C, 0xA7, 0x8B, 0xD1, 0x3F, 0x9D, 0x4D, 0xF2, 0x51, 0xF5,
+	0x9F, 0xE5, 0x09, 0x15, 0xA7, 0xD3, 0x60, 0x01, 0xD9, 0xFC, 0x59, 0x84,
+	0xE0, 0x19, 0xFD, 0xF5, 0xA5, 0x3E, 0xD5, 0x57, 0x3E, 0x80, 0x69, 0x32,
+	0xE3, 0xB6, 0x46, 0xD1, 0xE2, 0x6A, 0x6B, 0x0E, 0x9F, 0x53, 0xBA, 0x07,
+	0x14, 0xC1, 0x15, 0x6E, 0x86, 0xCD, 0x14, 0x1D, 0x57, 0x32, 0x8D, 0x06,
+	0x4A, 0x3B, 0xFA, 0xDC, 0xFA, 0x42, 0xC6, 0x45, 0xA1, 0x54, 0x91, 0xAF,
+	0x76, 0xCB, 0x45, 0x8C, 0xE9, 0x00, 0x4B, 0xAE, 0xC9, 0x57, 0x9F, 0xF6,
+	0x12, 0xE3, 0xAA, 0x33, 0xF2, 0x84, 0xC7, 0xE5, 0xCF, 0x35, 0x5F, 0x08,
+	0xC1, 0xF5, 0x16, 0x15, 0x08, 0x10, 0x50, 0x2B, 0x98, 0xEE, 0x19, 0x12,
+	0x13, 0x80, 0xC7, 0x0F, 0x3B, 0xD0, 0xB4, 0x4A, 0x14, 0x2C, 0xF8, 0xC2,
+	0x20, 0x3F, 0xEA, 0x3F, 0x0F, 0x91, 0x87, 0x11, 0x90, 0x04, 0xFB, 0x05,
+	0x60, 0x1A, 0xF5, 0xD1, 0x58, 0xBF, 0x4A, 0x47, 0x30, 0x49, 0xCD, 0xE0,
+	0x9B, 0x5A, 0xBB, 0xD4, 0xE3, 0x74, 0x11, 0x28, 0x75,
+}
+
+func TestDecompress(t *testing.T) {
+	out, err := Decompress(testFrame)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(out) != testText {
+		t.Errorf("Wrong content : got %q", out)
+	}
+
+	// Skippable frames are ignored, consecutive frames are concatenated
+	var src []byte
+	src = append(src, 0x50, 0x2A, 0x4D, 0x18, 0x02, 0x00, 0x00, 0x00, 0xAB, 0xCD)
+	src = append(src, testFrame...)
+	src = append(src, testFrame...)
+	out, err = Decompress(src)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(out) != testText+testText {
+		t.Errorf("Wrong content of concatenated frames : got %q", out)
+	}
+}
+
+func TestDecompressError(t *testing.T) {
+	tests := []struct {
+		src     []byte
+		message string
+	}{
+		{[]byte{0x28, 0xB5, 0x2F}, "truncated frame"},
+		{[]byte{0x00, 0x00, 0x00, 0x00}, "invalid magic number"},
+		{[]byte{0x28, 0xB5, 0x2F, 0xFD, 0x20, 0x04}, "truncated block header"},
+		{[]byte{0x28, 0xB5, 0x2F, 0xFD, 0x21, 0x01, 0x04}, "dictionaries are not supported"},
+		{[]byte{0x28, 0xB5, 0x2F, 0xFD, 0x20, 0x04, 0x19, 0x00, 0x00, 0x61, 0x62, 0x63}, "wrong content size"},
+		{[]byte{0x28, 0xB5, 0x2F, 0xFD, 0x20, 0x01, 0x07, 0x00, 0x00}, "reserved block type"},
+		{testFrame[:len(testFrame)-1], "truncated compressed block"},
+	}
+	for _, test := range tests {
+		_, err := Decompress(test.src)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Expected pattern of error message (%s), got (%v)", test.message, err)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	random := make([]byte, 200000)
+	rand.New(rand.NewSource(1)).Read(random)
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{"empty", nil},
+		{"short", []byte("glu")},
+		{"text", []byte(testText)},
+		{"repeated text", bytes.Repeat([]byte(testText), 1000)},
+		{"zeros", make([]byte, 300000)},
+		{"random", random},
+	}
+	for _, test := range tests {
+		compressed := Compress(test.src)
+		out, err := Decompress(compressed)
+		if err != nil {
+			t.Errorf("Unexpected error of %s: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(out, test.src) {
+			t.Errorf("Wrong round trip of %s", test.name)
+		}
+		if len(test.src) > 1000 && test.name != "random" && len(compressed) > len(test.src)/10 {
+			t.Errorf("Poor compression of %s : %v bytes into %v", test.name, len(test.src), len(compressed))
+		}
+	}
+}